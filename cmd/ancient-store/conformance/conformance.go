@@ -0,0 +1,480 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package conformance implements a test suite for remote freezer servers,
+// ie. servers implementing the freezer_* RPC methods used by rawdb.FreezerRemoteClient.
+//
+// Cases covering protocol version 2 (batch) methods are skipped against servers
+// which don't report support for them with freezer_capabilities.
+//
+// The suite is destructive: it truncates the store before running each case.
+// Binary blobs are encoded as JSON strings following Go's encoding/json
+// (standard base64) convention for byte slices.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Kinds are the ancient data kinds (tables) which a server must support.
+var Kinds = []string{
+	rawdb.FreezerRemoteHashTable,
+	rawdb.FreezerRemoteHeaderTable,
+	rawdb.FreezerRemoteBodiesTable,
+	rawdb.FreezerRemoteReceiptTable,
+	rawdb.FreezerRemoteDifficultyTable,
+}
+
+// Case is a single conformance check run against a remote freezer.
+type Case struct {
+	Name string
+	Run  func(c *rpc.Client) error
+}
+
+// Cases is the set of checks which make up the conformance suite.
+var Cases = []Case{
+	{"empty", testEmpty},
+	{"append", testAppend},
+	{"append-out-of-order", testAppendOutOfOrder},
+	{"truncate", testTruncate},
+	{"truncate-above-head", testTruncateAboveHead},
+	{"append-after-truncate", testAppendAfterTruncate},
+	{"ancient-size", testAncientSize},
+	{"unknown-kind", testUnknownKind},
+	{"sync-close", testSyncClose},
+	{"capabilities", testCapabilities},
+	{"append-batch", testAppendBatch},
+	{"append-batch-out-of-order", testAppendBatchOutOfOrder},
+	{"ancient-range", testAncientRange},
+}
+
+// ErrUnsupported is returned by cases covering methods the server doesn't support.
+var ErrUnsupported = errors.New("unsupported by server")
+
+// Test runs the conformance suite as subtests of t.
+func Test(t *testing.T, c *rpc.Client) {
+	for _, tc := range Cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Run(c)
+			if err == ErrUnsupported {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// blob returns a deterministic, distinct value for an ancient kind and number.
+// Blobs of items numbered below 10 are the same size for all kinds.
+func blob(kind string, number uint64) []byte {
+	return []byte(fmt.Sprintf("%-8s-%d", kind, number))
+}
+
+func reset(c *rpc.Client) error {
+	if err := c.Call(nil, rawdb.FreezerMethodTruncateAncients, 0); err != nil {
+		return fmt.Errorf("truncate to 0: %v", err)
+	}
+	return expectAncients(c, 0)
+}
+
+func appendN(c *rpc.Client, from, to uint64) error {
+	for n := from; n < to; n++ {
+		err := c.Call(nil, rawdb.FreezerMethodAppendAncient, n,
+			blob(Kinds[0], n), blob(Kinds[1], n), blob(Kinds[2], n), blob(Kinds[3], n), blob(Kinds[4], n))
+		if err != nil {
+			return fmt.Errorf("append %d: %v", n, err)
+		}
+	}
+	return nil
+}
+
+func expectAncients(c *rpc.Client, want uint64) error {
+	var got uint64
+	if err := c.Call(&got, rawdb.FreezerMethodAncients); err != nil {
+		return fmt.Errorf("ancients: %v", err)
+	}
+	if got != want {
+		return fmt.Errorf("ancients: want %d, got %d", want, got)
+	}
+	return nil
+}
+
+func expectHas(c *rpc.Client, kind string, number uint64, want bool) error {
+	var got bool
+	if err := c.Call(&got, rawdb.FreezerMethodHasAncient, kind, number); err != nil {
+		return fmt.Errorf("has ancient %s %d: %v", kind, number, err)
+	}
+	if got != want {
+		return fmt.Errorf("has ancient %s %d: want %v, got %v", kind, number, want, got)
+	}
+	return nil
+}
+
+// expectItems checks that items [0, n) hold their expected values, and that item n is absent.
+func expectItems(c *rpc.Client, n uint64) error {
+	if err := expectAncients(c, n); err != nil {
+		return err
+	}
+	for _, kind := range Kinds {
+		for i := uint64(0); i < n; i++ {
+			if err := expectHas(c, kind, i, true); err != nil {
+				return err
+			}
+			var got []byte
+			if err := c.Call(&got, rawdb.FreezerMethodAncient, kind, i); err != nil {
+				return fmt.Errorf("ancient %s %d: %v", kind, i, err)
+			}
+			if want := blob(kind, i); !bytes.Equal(got, want) {
+				return fmt.Errorf("ancient %s %d: want %x, got %x", kind, i, want, got)
+			}
+		}
+		if err := expectHas(c, kind, n, false); err != nil {
+			return err
+		}
+		var got []byte
+		if err := c.Call(&got, rawdb.FreezerMethodAncient, kind, n); err == nil {
+			return fmt.Errorf("ancient %s %d: want error for missing item", kind, n)
+		}
+	}
+	return nil
+}
+
+func testEmpty(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	return expectItems(c, 0)
+}
+
+func testAppend(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 10); err != nil {
+		return err
+	}
+	return expectItems(c, 10)
+}
+
+func testAppendOutOfOrder(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 3); err != nil {
+		return err
+	}
+	for _, n := range []uint64{2, 4} {
+		if err := appendN(c, n, n+1); err == nil {
+			return fmt.Errorf("append %d at head 3: want error", n)
+		}
+	}
+	return expectItems(c, 3)
+}
+
+func testTruncate(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 10); err != nil {
+		return err
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodTruncateAncients, 4); err != nil {
+		return fmt.Errorf("truncate: %v", err)
+	}
+	return expectItems(c, 4)
+}
+
+func testTruncateAboveHead(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 5); err != nil {
+		return err
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodTruncateAncients, 8); err != nil {
+		return fmt.Errorf("truncate: %v", err)
+	}
+	return expectItems(c, 5)
+}
+
+func testAppendAfterTruncate(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 10); err != nil {
+		return err
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodTruncateAncients, 5); err != nil {
+		return fmt.Errorf("truncate: %v", err)
+	}
+	if err := appendN(c, 5, 12); err != nil {
+		return err
+	}
+	return expectItems(c, 12)
+}
+
+func testAncientSize(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	sizes := func() (map[string]uint64, error) {
+		m := make(map[string]uint64)
+		for _, kind := range Kinds {
+			var size uint64
+			if err := c.Call(&size, rawdb.FreezerMethodAncientSize, kind); err != nil {
+				return nil, fmt.Errorf("ancient size %s: %v", kind, err)
+			}
+			m[kind] = size
+		}
+		return m, nil
+	}
+	if err := appendN(c, 0, 5); err != nil {
+		return err
+	}
+	small, err := sizes()
+	if err != nil {
+		return err
+	}
+	if err := appendN(c, 5, 10); err != nil {
+		return err
+	}
+	large, err := sizes()
+	if err != nil {
+		return err
+	}
+	for _, kind := range Kinds {
+		if large[kind] <= small[kind] {
+			return fmt.Errorf("ancient size %s: did not grow on append (%d -> %d)", kind, small[kind], large[kind])
+		}
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodTruncateAncients, 5); err != nil {
+		return fmt.Errorf("truncate: %v", err)
+	}
+	truncated, err := sizes()
+	if err != nil {
+		return err
+	}
+	for _, kind := range Kinds {
+		if truncated[kind] >= large[kind] {
+			return fmt.Errorf("ancient size %s: did not shrink on truncate (%d -> %d)", kind, large[kind], truncated[kind])
+		}
+	}
+	return nil
+}
+
+func testUnknownKind(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 1); err != nil {
+		return err
+	}
+	if err := expectHas(c, "unknown", 0, false); err != nil {
+		return err
+	}
+	var got []byte
+	if err := c.Call(&got, rawdb.FreezerMethodAncient, "unknown", 0); err == nil {
+		return fmt.Errorf("ancient of unknown kind: want error")
+	}
+	return nil
+}
+
+// testSyncClose checks that the store can be synced, and that it keeps serving
+// after a client closes it, since the server outlives the clients using it.
+func testSyncClose(c *rpc.Client) error {
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 3); err != nil {
+		return err
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodSync); err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodClose); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+	return expectItems(c, 3)
+}
+
+// capabilities returns the server capabilities, or ErrUnsupported for servers
+// implementing only protocol version 1.
+func capabilities(c *rpc.Client) (rawdb.FreezerRemoteCapabilities, error) {
+	var caps rawdb.FreezerRemoteCapabilities
+	if err := c.Call(&caps, rawdb.FreezerMethodCapabilities); err != nil {
+		if rpcErr, ok := err.(rpc.Error); ok && rpcErr.ErrorCode() == -32601 {
+			return caps, ErrUnsupported
+		}
+		return caps, fmt.Errorf("capabilities: %v", err)
+	}
+	if caps.Version < 2 {
+		return caps, ErrUnsupported
+	}
+	return caps, nil
+}
+
+// compressions returns the blob compressions to test the batch methods with.
+func compressions(caps rawdb.FreezerRemoteCapabilities) []string {
+	return append([]string{rawdb.FreezerRemoteCompressionNone}, caps.Compression...)
+}
+
+func batch(compression string, from, to uint64) ([]rawdb.FreezerRemoteBlock, error) {
+	var blocks []rawdb.FreezerRemoteBlock
+	for n := from; n < to; n++ {
+		b := rawdb.FreezerRemoteBlock{
+			Hash: blob(Kinds[0], n), Header: blob(Kinds[1], n), Body: blob(Kinds[2], n),
+			Receipts: blob(Kinds[3], n), Td: blob(Kinds[4], n),
+		}
+		enc, err := b.Encode(compression)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, enc)
+	}
+	return blocks, nil
+}
+
+func appendBatch(c *rpc.Client, compression string, from, to uint64) error {
+	blocks, err := batch(compression, from, to)
+	if err != nil {
+		return err
+	}
+	if err := c.Call(nil, rawdb.FreezerMethodAppendAncients, from, blocks, compression); err != nil {
+		return fmt.Errorf("append batch %d-%d (compression %q): %v", from, to, compression, err)
+	}
+	return nil
+}
+
+func testCapabilities(c *rpc.Client) error {
+	caps, err := capabilities(c)
+	if err != nil {
+		return err
+	}
+	for _, compression := range caps.Compression {
+		if _, err := rawdb.EncodeFreezerRemoteBlob(compression, nil); err != nil {
+			return fmt.Errorf("capabilities: %v", err)
+		}
+	}
+	return nil
+}
+
+func testAppendBatch(c *rpc.Client) error {
+	caps, err := capabilities(c)
+	if err != nil {
+		return err
+	}
+	for _, compression := range compressions(caps) {
+		if err := reset(c); err != nil {
+			return err
+		}
+		if err := appendBatch(c, compression, 0, 10); err != nil {
+			return err
+		}
+		if err := appendN(c, 10, 12); err != nil {
+			return err
+		}
+		if err := appendBatch(c, compression, 12, 20); err != nil {
+			return err
+		}
+		if err := expectItems(c, 20); err != nil {
+			return fmt.Errorf("compression %q: %v", compression, err)
+		}
+	}
+	return nil
+}
+
+func testAppendBatchOutOfOrder(c *rpc.Client) error {
+	if _, err := capabilities(c); err != nil {
+		return err
+	}
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 3); err != nil {
+		return err
+	}
+	for _, n := range []uint64{2, 4} {
+		if err := appendBatch(c, rawdb.FreezerRemoteCompressionNone, n, n+3); err == nil {
+			return fmt.Errorf("append batch from %d at head 3: want error", n)
+		}
+	}
+	return expectItems(c, 3)
+}
+
+func testAncientRange(c *rpc.Client) error {
+	caps, err := capabilities(c)
+	if err != nil {
+		return err
+	}
+	if err := reset(c); err != nil {
+		return err
+	}
+	if err := appendN(c, 0, 10); err != nil {
+		return err
+	}
+	ancientRange := func(compression, kind string, start, count, maxBytes uint64) ([][]byte, error) {
+		var res [][]byte
+		if err := c.Call(&res, rawdb.FreezerMethodAncientRange, kind, start, count, maxBytes, compression); err != nil {
+			return nil, err
+		}
+		for i := range res {
+			if res[i], err = rawdb.DecodeFreezerRemoteBlob(compression, res[i]); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	for _, compression := range compressions(caps) {
+		for _, tt := range []struct {
+			start, count, maxBytes uint64
+			want                   uint64 // Number of items returned
+		}{
+			{0, 10, 1 << 20, 10},
+			{2, 5, 1 << 20, 5},
+			{7, 10, 1 << 20, 3}, // Range past head is cut short
+			{0, 10, 1, 1},       // Byte limit returns at least one item
+			{0, 10, uint64(3*len(blob("", 0)) - 1), 3},
+		} {
+			for _, kind := range Kinds {
+				res, err := ancientRange(compression, kind, tt.start, tt.count, tt.maxBytes)
+				if err != nil {
+					return fmt.Errorf("ancient range %s %d+%d (compression %q): %v", kind, tt.start, tt.count, compression, err)
+				}
+				if uint64(len(res)) != tt.want {
+					return fmt.Errorf("ancient range %s %d+%d limit %d: want %d items, got %d", kind, tt.start, tt.count, tt.maxBytes, tt.want, len(res))
+				}
+				for i, got := range res {
+					if want := blob(kind, tt.start+uint64(i)); !bytes.Equal(got, want) {
+						return fmt.Errorf("ancient range %s %d: want %x, got %x", kind, tt.start+uint64(i), want, got)
+					}
+				}
+			}
+		}
+		if _, err := ancientRange(compression, Kinds[0], 10, 1, 1<<20); err == nil {
+			return fmt.Errorf("ancient range starting at head: want error")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+var (
+	errOutOfBounds = errors.New("out of bounds")
+	errOutOfOrder  = errors.New("out of order")
+	errUnknownKind = errors.New("unknown kind")
+)
+
+// s3Kinds are the ancient data kinds, in the order they are passed to AppendAncient.
+var s3Kinds = []string{
+	rawdb.FreezerRemoteHashTable,
+	rawdb.FreezerRemoteHeaderTable,
+	rawdb.FreezerRemoteBodiesTable,
+	rawdb.FreezerRemoteReceiptTable,
+	rawdb.FreezerRemoteDifficultyTable,
+}
+
+// s3Meta is the committed state of an S3 ancient store.
+type s3Meta struct {
+	Items uint64            `json:"items"`
+	Sizes map[string]uint64 `json:"sizes"`
+}
+
+// S3AncientStore is an ancient store backed by an S3-compatible object store.
+//
+// Each item is stored as an object at <prefix>/<kind>/<number>. The number of
+// items, along with the table sizes, is kept in a metadata object which serves
+// as the commit point:
+//
+// - Appended items become durable when the metadata is written on Sync. Items
+//   appended but not synced before a crash are ignored, and overwritten by the
+//   next appends.
+// - Truncations write the metadata before the truncated objects are deleted.
+//   Objects left behind by a crash are ignored in the same way.
+type S3AncientStore struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+
+	meta s3Meta // Current, possibly not yet committed, state
+	mu   sync.RWMutex
+}
+
+// NewS3AncientStore opens an ancient store in the given bucket, with object keys
+// prefixed by prefix.
+func NewS3AncientStore(client s3iface.S3API, bucket, prefix string) (*S3AncientStore, error) {
+	s := &S3AncientStore{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+	b, err := s.get(s.metaKey())
+	switch {
+	case isNotFound(err):
+		s.meta = s3Meta{Sizes: make(map[string]uint64)}
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(b, &s.meta); err != nil {
+			return nil, fmt.Errorf("invalid store metadata: %v", err)
+		}
+		if s.meta.Sizes == nil {
+			s.meta.Sizes = make(map[string]uint64)
+		}
+	}
+	return s, nil
+}
+
+func (s *S3AncientStore) metaKey() string {
+	return path.Join(s.prefix, "meta.json")
+}
+
+func (s *S3AncientStore) itemKey(kind string, number uint64) string {
+	return path.Join(s.prefix, kind, fmt.Sprintf("%d", number))
+}
+
+func isNotFound(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownKind(kind string) bool {
+	for _, k := range s3Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *S3AncientStore) get(key string) ([]byte, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return ioutil.ReadAll(out.Body)
+}
+
+func (s *S3AncientStore) put(key string, data []byte) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// commit writes the current state as the store metadata.
+func (s *S3AncientStore) commit() error {
+	b, err := json.Marshal(s.meta)
+	if err != nil {
+		return err
+	}
+	return s.put(s.metaKey(), b)
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists.
+func (s *S3AncientStore) HasAncient(kind string, number uint64) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return isKnownKind(kind) && number < s.meta.Items, nil
+}
+
+// Ancient retrieves an ancient binary blob.
+func (s *S3AncientStore) Ancient(kind string, number uint64) ([]byte, error) {
+	if !isKnownKind(kind) {
+		return nil, errUnknownKind
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if number >= s.meta.Items {
+		return nil, errOutOfBounds
+	}
+	return s.get(s.itemKey(kind, number))
+}
+
+// Ancients returns the number of items in the store.
+func (s *S3AncientStore) Ancients() (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Items, nil
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (s *S3AncientStore) AncientSize(kind string) (uint64, error) {
+	if !isKnownKind(kind) {
+		return 0, errUnknownKind
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Sizes[kind], nil
+}
+
+// AppendAncient stores all binary blobs belonging to a block.
+// The item is only counted once all objects are written.
+func (s *S3AncientStore) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if number != s.meta.Items {
+		return errOutOfOrder
+	}
+	blobs := [][]byte{hash, header, body, receipt, td}
+	for i, kind := range s3Kinds {
+		if err := s.put(s.itemKey(kind, number), blobs[i]); err != nil {
+			return err
+		}
+	}
+	for i, kind := range s3Kinds {
+		s.meta.Sizes[kind] += uint64(len(blobs[i]))
+	}
+	s.meta.Items++
+	return nil
+}
+
+// TruncateAncients discards all but the first n ancient data from the store.
+func (s *S3AncientStore) TruncateAncients(n uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n >= s.meta.Items {
+		return nil
+	}
+	truncated := s3Meta{Items: n, Sizes: make(map[string]uint64)}
+	for _, kind := range s3Kinds {
+		var removed uint64
+		for i := n; i < s.meta.Items; i++ {
+			out, err := s.client.HeadObject(&s3.HeadObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(s.itemKey(kind, i)),
+			})
+			if err != nil {
+				return err
+			}
+			removed += uint64(aws.Int64Value(out.ContentLength))
+		}
+		truncated.Sizes[kind] = s.meta.Sizes[kind] - removed
+	}
+	// Commit the truncation before removing any data.
+	previous := s.meta
+	s.meta = truncated
+	if err := s.commit(); err != nil {
+		s.meta = previous
+		return err
+	}
+	// Removal is best effort: objects beyond the committed items are ignored,
+	// and overwritten by later appends.
+	for _, kind := range s3Kinds {
+		for i := n; i < previous.Items; i++ {
+			s.client.DeleteObject(&s3.DeleteObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(s.itemKey(kind, i)),
+			})
+		}
+	}
+	return nil
+}
+
+// Sync commits all appended items.
+func (s *S3AncientStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit()
+}
+
+// Close commits all appended items.
+func (s *S3AncientStore) Close() error {
+	return s.Sync()
+}
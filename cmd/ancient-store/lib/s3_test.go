@@ -0,0 +1,179 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+package lib
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ethereum/go-ethereum/cmd/ancient-store/conformance"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible object store, serving
+// path-style object requests for a single bucket.
+type fakeS3 struct {
+	bucket  string
+	objects map[string][]byte
+
+	// writes is the number of PUT requests left to succeed, or -1 for no limit.
+	writes int
+	mu     sync.Mutex
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string][]byte), writes: -1}
+}
+
+func (f *fakeS3) failWritesAfter(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = n
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if key == r.URL.Path {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if f.writes == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("<Error><Code>InternalError</Code><Message>injected failure</Message></Error>"))
+			return
+		}
+		if f.writes > 0 {
+			f.writes--
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				w.Write([]byte("<Error><Code>NoSuchKey</Code><Message>no such key</Message></Error>"))
+			}
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3Store(t *testing.T, url string) *S3AncientStore {
+	t.Helper()
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("test", "test", "")).
+		WithRegion("us-east-1").
+		WithEndpoint(url).
+		WithS3ForcePathStyle(true).
+		WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewS3AncientStore(s3.New(sess), "ancients", "chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3Conformance(t *testing.T) {
+	server := httptest.NewServer(newFakeS3("ancients"))
+	defer server.Close()
+
+	client := serve(t, newTestS3Store(t, server.URL))
+	defer client.Close()
+
+	conformance.Test(t, client)
+}
+
+// TestS3Crash checks that an S3 store interrupted while writing is reopened at
+// its last committed state.
+func TestS3Crash(t *testing.T) {
+	fake := newFakeS3("ancients")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := newTestS3Store(t, server.URL)
+	appendItems(t, store, 0, 3)
+	if err := store.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Items appended without a sync are lost on a crash, as is an item
+	// interrupted part way through being written.
+	appendItems(t, store, 3, 5)
+	fake.failWritesAfter(2)
+	if err := store.AppendAncient(5, []byte{5}, []byte{5}, []byte{5}, []byte{5}, []byte{5}); err == nil {
+		t.Fatal("append with failing writes: want error")
+	}
+	checkItems(t, store, 5)
+	fake.failWritesAfter(-1)
+
+	store = newTestS3Store(t, server.URL)
+	checkItems(t, store, 3)
+	appendItems(t, store, 3, 8)
+	if err := store.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A truncation which fails to commit leaves the store unchanged.
+	fake.failWritesAfter(0)
+	if err := store.TruncateAncients(4); err == nil {
+		t.Fatal("truncate with failing writes: want error")
+	}
+	checkItems(t, store, 8)
+	fake.failWritesAfter(-1)
+
+	if err := store.TruncateAncients(4); err != nil {
+		t.Fatal(err)
+	}
+	store = newTestS3Store(t, server.URL)
+	checkItems(t, store, 4)
+	size, err := store.AncientSize(conformance.Kinds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(4 * len(testBlob(conformance.Kinds[0], 0))); size != want {
+		t.Fatalf("ancient size: want %d, got %d", want, size)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var errRangeStart = errors.New("range start out of bounds")
+
+// FreezerRemoteServerAPI serves an ancient store backend over the freezer_* RPC methods.
+type FreezerRemoteServerAPI struct {
+	store ethdb.AncientStore
+	mu    sync.Mutex // Serializes writes, which the backends expect to be ordered.
+}
+
+// NewFreezerRemoteServerAPI creates a freezer RPC API for the given backend.
+func NewFreezerRemoteServerAPI(store ethdb.AncientStore) *FreezerRemoteServerAPI {
+	return &FreezerRemoteServerAPI{store: store}
+}
+
+// Capabilities returns the protocol features supported by the server.
+func (api *FreezerRemoteServerAPI) Capabilities() rawdb.FreezerRemoteCapabilities {
+	return rawdb.FreezerRemoteCapabilities{
+		Version:     rawdb.FreezerRemoteProtocolVersion,
+		Compression: []string{rawdb.FreezerRemoteCompressionSnappy},
+	}
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists.
+func (api *FreezerRemoteServerAPI) HasAncient(kind string, number uint64) (bool, error) {
+	return api.store.HasAncient(kind, number)
+}
+
+// Ancient retrieves an ancient binary blob.
+func (api *FreezerRemoteServerAPI) Ancient(kind string, number uint64) ([]byte, error) {
+	return api.store.Ancient(kind, number)
+}
+
+// Ancients returns the number of items in the store.
+func (api *FreezerRemoteServerAPI) Ancients() (uint64, error) {
+	return api.store.Ancients()
+}
+
+// AncientRange retrieves up to count consecutive ancient binary blobs of a kind,
+// stopping early at the last frozen item, or once the blobs total maxBytes.
+func (api *FreezerRemoteServerAPI) AncientRange(kind string, start, count, maxBytes uint64, compression string) ([][]byte, error) {
+	frozen, err := api.store.Ancients()
+	if err != nil {
+		return nil, err
+	}
+	if start >= frozen {
+		return nil, errRangeStart
+	}
+	var (
+		res  [][]byte
+		size uint64
+	)
+	for n := start; n-start < count && n < frozen && (len(res) == 0 || size < maxBytes); n++ {
+		blob, err := api.store.Ancient(kind, n)
+		if err != nil {
+			return nil, err
+		}
+		size += uint64(len(blob))
+		if blob, err = rawdb.EncodeFreezerRemoteBlob(compression, blob); err != nil {
+			return nil, err
+		}
+		res = append(res, blob)
+	}
+	return res, nil
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (api *FreezerRemoteServerAPI) AncientSize(kind string) (uint64, error) {
+	return api.store.AncientSize(kind)
+}
+
+// AppendAncient appends all binary blobs belonging to a block.
+func (api *FreezerRemoteServerAPI) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.store.AppendAncient(number, hash, header, body, receipt, td)
+}
+
+// AppendAncients appends a batch of consecutive blocks, the first being numbered start.
+// Blocks are appended in order until one fails, so a failed batch may be partially applied.
+func (api *FreezerRemoteServerAPI) AppendAncients(start uint64, blocks []rawdb.FreezerRemoteBlock, compression string) error {
+	// Decode everything up front, so a malformed batch is rejected as a whole.
+	dec := make([]rawdb.FreezerRemoteBlock, len(blocks))
+	for i, b := range blocks {
+		var err error
+		if dec[i], err = b.Decode(compression); err != nil {
+			return err
+		}
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for i, b := range dec {
+		if err := api.store.AppendAncient(start+uint64(i), b.Hash, b.Header, b.Body, b.Receipts, b.Td); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TruncateAncients discards all but the first n ancient data from the store.
+func (api *FreezerRemoteServerAPI) TruncateAncients(n uint64) error {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.store.TruncateAncients(n)
+}
+
+// Sync flushes the backend to persistent storage.
+func (api *FreezerRemoteServerAPI) Sync() error {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.store.Sync()
+}
+
+// Close is called by clients when they shut down. Since the server outlives
+// its clients, the backend is only flushed, not closed.
+func (api *FreezerRemoteServerAPI) Close() error {
+	return api.Sync()
+}
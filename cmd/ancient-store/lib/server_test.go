@@ -0,0 +1,268 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/cmd/ancient-store/conformance"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func serve(t *testing.T, store ethdb.AncientStore) *rpc.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("freezer", NewFreezerRemoteServerAPI(store)); err != nil {
+		t.Fatal(err)
+	}
+	return rpc.DialInProc(server)
+}
+
+// testBlob mirrors the blobs written by the conformance suite.
+func testBlob(kind string, number uint64) []byte {
+	return []byte(fmt.Sprintf("%-8s-%d", kind, number))
+}
+
+func appendItems(t *testing.T, store ethdb.AncientStore, from, to uint64) {
+	t.Helper()
+	for n := from; n < to; n++ {
+		var blobs [][]byte
+		for _, kind := range conformance.Kinds {
+			blobs = append(blobs, testBlob(kind, n))
+		}
+		if err := store.AppendAncient(n, blobs[0], blobs[1], blobs[2], blobs[3], blobs[4]); err != nil {
+			t.Fatalf("append %d: %v", n, err)
+		}
+	}
+}
+
+func checkItems(t *testing.T, store ethdb.AncientStore, want uint64) {
+	t.Helper()
+	if n, err := store.Ancients(); err != nil || n != want {
+		t.Fatalf("ancients: want %d, got %d (err %v)", want, n, err)
+	}
+	for _, kind := range conformance.Kinds {
+		for n := uint64(0); n < want; n++ {
+			got, err := store.Ancient(kind, n)
+			if err != nil {
+				t.Fatalf("ancient %s %d: %v", kind, n, err)
+			}
+			if !bytes.Equal(got, testBlob(kind, n)) {
+				t.Fatalf("ancient %s %d: want %x, got %x", kind, n, testBlob(kind, n), got)
+			}
+		}
+	}
+}
+
+func TestFlatFileConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := rawdb.NewFreezer(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	client := serve(t, store)
+	defer client.Close()
+
+	conformance.Test(t, client)
+}
+
+// TestFlatFileCrash checks that a flat file store interrupted while writing is
+// repaired to its last complete item when reopened.
+func TestFlatFileCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := rawdb.NewFreezer(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendItems(t, store, 0, 5)
+	if err := store.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an append interrupted after writing part of an index entry to one
+	// table, and dropping the last entry of another.
+	indexes, err := filepath.Glob(filepath.Join(dir, "*idx"))
+	if err != nil || len(indexes) != len(conformance.Kinds) {
+		t.Fatalf("index files: %v (err %v)", indexes, err)
+	}
+	f, err := os.OpenFile(indexes[0], os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0x00, 0x01, 0x02})
+	f.Close()
+	fi, err := os.Stat(indexes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(indexes[1], fi.Size()-6); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = rawdb.NewFreezer(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	checkItems(t, store, 4)
+	appendItems(t, store, 4, 6)
+	checkItems(t, store, 6)
+}
+
+// TestRemoteClientBatch checks the remote freezer client negotiates and uses
+// the batch methods.
+func TestRemoteClientBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := rawdb.NewFreezer(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	client, err := rawdb.NewFreezerRemoteClient(serve(t, store))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []rawdb.FreezerRemoteBlock
+	for n := uint64(0); n < 8; n++ {
+		blocks = append(blocks, rawdb.FreezerRemoteBlock{
+			Hash: testBlob(conformance.Kinds[0], n), Header: testBlob(conformance.Kinds[1], n),
+			Body: testBlob(conformance.Kinds[2], n), Receipts: testBlob(conformance.Kinds[3], n),
+			Td: testBlob(conformance.Kinds[4], n),
+		})
+	}
+	if err := client.AppendAncients(0, blocks); err != nil {
+		t.Fatal(err)
+	}
+	checkItems(t, store, 8)
+
+	res, err := client.AncientRange(conformance.Kinds[1], 2, 10, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 6 {
+		t.Fatalf("ancient range: want 6 items, got %d", len(res))
+	}
+	for i, got := range res {
+		if want := testBlob(conformance.Kinds[1], uint64(2+i)); !bytes.Equal(got, want) {
+			t.Fatalf("ancient range item %d: want %x, got %x", 2+i, want, got)
+		}
+	}
+	// A count overflowing the range end must not wrap around
+	res, err = client.AncientRange(conformance.Kinds[1], 2, math.MaxUint64, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 6 {
+		t.Fatalf("ancient range with huge count: want 6 items, got %d", len(res))
+	}
+}
+
+// TestRemoteDatabaseRangeReads checks the database reads consecutive items from
+// the remote freezer with range reads, rather than one round trip per item.
+func TestRemoteDatabaseRangeReads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := rawdb.NewFreezer(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := rlp.EncodeToBytes(&types.Body{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const frozen = 300
+	for n := uint64(0); n < frozen; n++ {
+		hash := common.BytesToHash(testBlob(conformance.Kinds[0], n))
+		if err := store.AppendAncient(n, hash[:], testBlob(conformance.Kinds[1], n), body, testBlob(conformance.Kinds[3], n), testBlob(conformance.Kinds[4], n)); err != nil {
+			t.Fatalf("append %d: %v", n, err)
+		}
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("freezer", NewFreezerRemoteServerAPI(store)); err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	db, err := rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// One round trip for the number of items, and one for all the hashes
+	atomic.StoreInt32(&calls, 0)
+	rawdb.InitDatabaseFromFreezer(db)
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("init from freezer: want 2 round trips, got %d", n)
+	}
+	for _, n := range []uint64{0, 150, frozen - 1} {
+		hash := common.BytesToHash(testBlob(conformance.Kinds[0], n))
+		if number := rawdb.ReadHeaderNumber(db, hash); number == nil || *number != n {
+			t.Errorf("header number %d: got %v", n, number)
+		}
+	}
+	// One round trip for the number of items, and one per batch of 128 bodies
+	atomic.StoreInt32(&calls, 0)
+	rawdb.IndexTransactions(db, 0, frozen)
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Errorf("index transactions: want 4 round trips, got %d", n)
+	}
+	if tail := rawdb.ReadTxIndexTail(db); tail == nil || *tail != 0 {
+		t.Errorf("transaction index tail: want 0, got %v", tail)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 The chipprgeth Authors
+// This file is part of the chipprgeth library.
+//
+// The chipprgeth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The chipprgeth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the chipprgeth library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ethereum/go-ethereum/cmd/ancient-store/conformance"
+	"github.com/ethereum/go-ethereum/cmd/ancient-store/lib"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backend    string
+	datadir    string
+	s3Endpoint string
+	s3Region   string
+	s3Bucket   string
+	s3Prefix   string
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "ancient-store [flags] <ipc-path>",
+	Short: "Persistent remote ancient store application",
+	Long: `Serves ancient data over the freezer_* RPC methods, persisted to a pluggable backend.
+
+Backends:
+  fs  Flat files in --datadir, using the same table format as the built-in freezer.
+  s3  Objects in an S3-compatible object store bucket.
+
+Expects first and only argument to an IPC path, or, the directory
+in which a default 'freezer.ipc' path should be created.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := openStore()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		ipcPath := args[0]
+		fi, err := os.Stat(ipcPath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalln(err)
+		}
+		if fi != nil && fi.IsDir() {
+			ipcPath = filepath.Join(ipcPath, "freezer.ipc")
+		}
+		listener, server, err := rpc.StartIPCEndpoint(ipcPath, nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer os.Remove(ipcPath)
+		err = server.RegisterName("freezer", lib.NewFreezerRemoteServerAPI(store))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		go func() {
+			log.Println("Serving", listener.Addr())
+			log.Fatalln(server.ServeListener(listener))
+		}()
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		log.Println("Shutting down")
+		listener.Close()
+		server.Stop()
+		if err := store.Close(); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+var conformanceCmd = &cobra.Command{
+	Use:   "conformance <endpoint>",
+	Short: "Run the conformance suite against a remote freezer server",
+	Long: `Runs the freezer_* conformance suite against the server at the given RPC endpoint.
+
+WARNING: The suite is destructive; all ancient data in the server is removed.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := rpc.Dial(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer client.Close()
+		failed := 0
+		for _, c := range conformance.Cases {
+			err := c.Run(client)
+			if err == conformance.ErrUnsupported {
+				fmt.Printf("SKIP %s: %v\n", c.Name, err)
+				continue
+			}
+			if err != nil {
+				fmt.Printf("FAIL %s: %v\n", c.Name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("PASS %s\n", c.Name)
+		}
+		if failed > 0 {
+			fmt.Printf("%d/%d cases failed\n", failed, len(conformance.Cases))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&backend, "backend", "fs", "Storage backend [fs|s3]")
+	rootCmd.Flags().StringVar(&datadir, "datadir", "", "Directory for the fs backend")
+	rootCmd.Flags().StringVar(&s3Endpoint, "s3.endpoint", "", "S3 endpoint URL (default: AWS)")
+	rootCmd.Flags().StringVar(&s3Region, "s3.region", "us-east-1", "S3 region")
+	rootCmd.Flags().StringVar(&s3Bucket, "s3.bucket", "", "S3 bucket")
+	rootCmd.Flags().StringVar(&s3Prefix, "s3.prefix", "", "S3 object key prefix")
+	rootCmd.AddCommand(conformanceCmd)
+}
+
+func openStore() (ethdb.AncientStore, error) {
+	switch backend {
+	case "fs":
+		if datadir == "" {
+			return nil, fmt.Errorf("--datadir is required for the fs backend")
+		}
+		return rawdb.NewFreezer(datadir, "")
+	case "s3":
+		if s3Bucket == "" {
+			return nil, fmt.Errorf("--s3.bucket is required for the s3 backend")
+		}
+		config := aws.NewConfig().WithRegion(s3Region)
+		if s3Endpoint != "" {
+			// Stand-ins like MinIO are generally only addressable by path.
+			config = config.WithEndpoint(s3Endpoint).WithS3ForcePathStyle(true)
+		}
+		sess, err := session.NewSession(config)
+		if err != nil {
+			return nil, err
+		}
+		return lib.NewS3AncientStore(s3.New(sess), s3Bucket, s3Prefix)
+	}
+	return nil, fmt.Errorf("unknown backend: %s", backend)
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
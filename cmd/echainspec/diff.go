@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	diffAFlag = cli.StringFlag{
+		Name:  "a",
+		Usage: fmt.Sprintf("Stored (current) configuration; a default name [%s] or a path to a JSON chain configuration file", strings.Join(defaultChainspecNames, "|")),
+	}
+	diffBFlag = cli.StringFlag{
+		Name:  "b",
+		Usage: "New configuration; a default name or a path to a JSON chain configuration file",
+	}
+	diffAFormatFlag = cli.StringFlag{
+		Name:  "aformat",
+		Usage: "Format type of the --a configuration file (default: --inputf)",
+	}
+	diffBFormatFlag = cli.StringFlag{
+		Name:  "bformat",
+		Usage: "Format type of the --b configuration file (default: --inputf)",
+	}
+	diffHeadFlag = cli.StringFlag{
+		Name:  "head",
+		Usage: "Current chain head block number [0x042|42]",
+	}
+)
+
+var errDiffMissingConfig = errors.New("both --a and --b configurations are required")
+
+var diffCommand = cli.Command{
+	Name:  "diff",
+	Usage: "Show transition differences between two configurations",
+	Description: `Lists the activation block of each transition for both configurations, marking those which differ.
+   Configuration A is treated as the one stored in the database, and B as the one being upgraded to.
+   If --head is given, differences at or below the head block are marked, since applying
+   them requires rewinding the chain. In this case the block the chain would be rewound to
+   is reported, and the command fails.`,
+	Flags: []cli.Flag{
+		diffAFlag,
+		diffBFlag,
+		diffAFormatFlag,
+		diffBFormatFlag,
+		diffHeadFlag,
+	},
+	Action: diff,
+}
+
+// readDiffConfig reads a configuration from a default chainspec name or a file path.
+func readDiffConfig(ctx *cli.Context, value, formatFlag string) (ctypes.Configurator, error) {
+	if v, ok := defaultChainspecValues[value]; ok {
+		return v, nil
+	}
+	format := ctx.String(formatFlag)
+	if format == "" {
+		format = ctx.GlobalString(formatInFlag.Name)
+	}
+	data, err := ioutil.ReadFile(value)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalChainSpec(format, data)
+}
+
+func diff(ctx *cli.Context) error {
+	if !ctx.IsSet(diffAFlag.Name) || !ctx.IsSet(diffBFlag.Name) {
+		return errDiffMissingConfig
+	}
+	a, err := readDiffConfig(ctx, ctx.String(diffAFlag.Name), diffAFormatFlag.Name)
+	if err != nil {
+		return fmt.Errorf("a: %v", err)
+	}
+	b, err := readDiffConfig(ctx, ctx.String(diffBFlag.Name), diffBFormatFlag.Name)
+	if err != nil {
+		return fmt.Errorf("b: %v", err)
+	}
+	var head *uint64
+	if ctx.IsSet(diffHeadFlag.Name) {
+		var h math.HexOrDecimal64
+		if err := h.UnmarshalText([]byte(ctx.String(diffHeadFlag.Name))); err != nil {
+			return err
+		}
+		hh := uint64(h)
+		head = &hh
+	}
+
+	printv := func(v *uint64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%d", *v)
+	}
+	pastHead := func(v *uint64) bool {
+		return v != nil && head != nil && *v <= *head
+	}
+
+	w := tabwriter.NewWriter(ctx.App.Writer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSITION\tA\tB\t")
+
+	aFns, names := confp.Transitions(a)
+	bFns, bNames := confp.Transitions(b)
+	bFnsByName := make(map[string]func() *uint64, len(bFns))
+	for i, fn := range bFns {
+		bFnsByName[bNames[i]] = fn
+	}
+	for i, fn := range aFns {
+		bfn, ok := bFnsByName[names[i]]
+		if !ok {
+			continue
+		}
+		av, bv := fn(), bfn()
+		if av == nil && bv == nil {
+			continue
+		}
+		var note string
+		if printv(av) != printv(bv) {
+			note = "differs"
+			if pastHead(av) || pastHead(bv) {
+				note += ", past head"
+			}
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(names[i], "Get"), "Transition")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, printv(av), printv(bv), note)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if a.GetConsensusEngineType() != b.GetConsensusEngineType() {
+		fmt.Fprintf(ctx.App.Writer, "\nConsensus engine differs: A: %s, B: %s\n", a.GetConsensusEngineType(), b.GetConsensusEngineType())
+	}
+	if ac, bc := a.GetChainID(), b.GetChainID(); (ac == nil) != (bc == nil) || (ac != nil && ac.Cmp(bc) != 0) {
+		fmt.Fprintf(ctx.App.Writer, "\nChain ID differs: A: %v, B: %v\n", ac, bc)
+	}
+	if head == nil {
+		return nil
+	}
+	compatErr := confp.Compatible(head, a, b)
+	if compatErr == nil {
+		fmt.Fprintf(ctx.App.Writer, "\nCompatible at head %d\n", *head)
+		return nil
+	}
+	return fmt.Errorf("incompatible at head %d: %v, rewind to block %d", *head, compatErr, compatErr.RewindTo)
+}
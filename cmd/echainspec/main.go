@@ -9,12 +9,15 @@ import (
 
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/aleth"
 	"github.com/ethereum/go-ethereum/params/types/chipprgeth"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/ethereum/go-ethereum/params/types/goethereum"
 	"github.com/ethereum/go-ethereum/params/types/multigeth"
 	"github.com/ethereum/go-ethereum/params/types/parity"
+	"github.com/ethereum/go-ethereum/params/types/pyethereum"
+	"github.com/ethereum/go-ethereum/params/types/retesteth"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -32,10 +35,10 @@ var (
 		"geth": &genesisT.Genesis{
 			Config: &goethereum.ChainConfig{},
 		},
-		"parity": &parity.ParityChainSpec{},
-		// TODO
-		// "aleth"
-		// "retesteth"
+		"parity":     &parity.ParityChainSpec{},
+		"aleth":      &aleth.AlethGenesisSpec{},
+		"retesteth":  &retesteth.RetestethGenesisSpec{},
+		"pyethereum": &pyethereum.PyEthereumGenesisSpec{},
 	}
 )
 
@@ -108,6 +111,10 @@ func mustGetChainspecValue(ctx *cli.Context) error {
 		if strings.Contains(ctx.Args().First(), "help") {
 			return nil
 		}
+		// The diff command reads its own configurations.
+		if ctx.Args().First() == diffCommand.Name {
+			return nil
+		}
 	}
 	if ctx.GlobalIsSet(defaultValueFlag.Name) {
 		if ctx.GlobalString(defaultValueFlag.Name) == "" {
@@ -133,18 +140,19 @@ func mustGetChainspecValue(ctx *cli.Context) error {
 }
 
 func convertf(ctx *cli.Context) error {
-	c, ok := chainspecFormatTypes[ctx.String(outputFormatFlag.Name)]
-	if !ok && ctx.String(outputFormatFlag.Name) == "" {
+	if ctx.String(outputFormatFlag.Name) == "" {
 		b, err := jsonMarshalPretty(globalChainspecValue)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(b))
+		fmt.Fprintln(ctx.App.Writer, string(b))
 		return nil
-	} else if !ok {
+	}
+	c, err := newChainspecValue(ctx.String(outputFormatFlag.Name))
+	if err != nil {
 		return errInvalidOutputFlag
 	}
-	err := confp.Convert(globalChainspecValue, c)
+	err = confp.Convert(globalChainspecValue, c)
 	if err != nil {
 		return err
 	}
@@ -152,7 +160,7 @@ func convertf(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(b))
+	fmt.Fprintln(ctx.App.Writer, string(b))
 	return nil
 }
 
@@ -211,6 +219,14 @@ EXAMPLES:
 	
 		> {{.Name}} --default kotti validate 3000000
 
+	Show differences between a default and an external configuration for a chain at block #10000000:
+
+		> {{.Name}} --inputf chipprgeth diff --a classic --b my-classic.json --head 10000000
+
+	Project the block rewards of a default Ethereum Classic network configuration at the start of each ECIP-1017 era:
+
+		> {{.Name}} --default classic project --from 1 --to 25000001 --step 5000000
+
 VERSION:
    {{.Version}}
 
@@ -234,6 +250,8 @@ GLOBAL OPTIONS:
 		validateCommand,
 		forksCommand,
 		ipsCommand,
+		diffCommand,
+		projectCommand,
 	}
 	app.Before = mustGetChainspecValue
 	app.Action = convertf
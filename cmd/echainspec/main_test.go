@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/aleth"
+	"github.com/ethereum/go-ethereum/params/types/chipprgeth"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/types/parity"
+)
+
+// runApp runs the tool with the given arguments, returning its output.
+func runApp(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	app.Writer = &out
+	defer func() { app.Writer = nil }()
+
+	err := app.Run(append([]string{"echainspec"}, args...))
+	return out.String(), err
+}
+
+func TestConvert(t *testing.T) {
+	blob, err := ioutil.ReadFile("../../params/parity.json.d/classic.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec parity.ParityChainSpec
+	if err := json.Unmarshal(blob, &spec); err != nil {
+		t.Fatal(err)
+	}
+	// Convert the Parity chainspec into the chipprgeth format
+	out, err := runApp(t, "--inputf", "parity", "--file", "../../params/parity.json.d/classic.json", "--outputf", "chipprgeth")
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	gen := &genesisT.Genesis{Config: &chipprgeth.ChipprGethChainConfig{}}
+	if err := json.Unmarshal([]byte(out), gen); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if err := confp.Equivalent(&spec, gen); err != nil {
+		t.Errorf("converted configuration differs: %v", err)
+	}
+	// Convert a default configuration into the Aleth format
+	out, err = runApp(t, "--default", "classic", "--outputf", "aleth")
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	var alethSpec aleth.AlethGenesisSpec
+	if err := json.Unmarshal([]byte(out), &alethSpec); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if err := confp.Equivalent(defaultChainspecValues["classic"], &alethSpec); err != nil {
+		t.Errorf("converted configuration differs: %v", err)
+	}
+	// Unknown formats are rejected
+	if _, err := runApp(t, "--default", "classic", "--outputf", "unknown"); err != errInvalidOutputFlag {
+		t.Errorf("error mismatch: have %v, want %v", err, errInvalidOutputFlag)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	// The bundled Parity chainspec predates the Phoenix fork, differing from the
+	// default configuration only beyond the given head
+	out, err := runApp(t, "diff", "--a", "classic", "--b", "../../params/parity.json.d/classic.json", "--bformat", "parity", "--head", "10000000")
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if !regexp.MustCompile(`EIP2200\s+10500839\s+-\s+differs\n`).MatchString(out) || strings.Contains(out, "past head") {
+		t.Errorf("differences mismatch:\n%s", out)
+	}
+	if !strings.Contains(out, "Compatible at head 10000000") {
+		t.Errorf("missing compatibility:\n%s", out)
+	}
+	// Differences are reported, failing if they are past the head
+	out, err = runApp(t, "diff", "--a", "classic", "--b", "foundation")
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if !strings.Contains(out, "differs") || !strings.Contains(out, "Chain ID differs") {
+		t.Errorf("missing differences:\n%s", out)
+	}
+	out, err = runApp(t, "diff", "--a", "classic", "--b", "foundation", "--head", "10000000")
+	if err == nil || !strings.Contains(err.Error(), "rewind to block") {
+		t.Errorf("incompatibility error mismatch: have %v", err)
+	}
+	if !strings.Contains(out, "past head") {
+		t.Errorf("missing differences past head:\n%s", out)
+	}
+	if _, err := runApp(t, "diff", "--a", "classic"); err != errDiffMissingConfig {
+		t.Errorf("error mismatch: have %v, want %v", err, errDiffMissingConfig)
+	}
+}
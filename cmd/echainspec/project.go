@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	projectFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "First block number to project [0x042|42]",
+		Value: "1",
+	}
+	projectToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Last block number to project [0x042|42] (default: --from)",
+	}
+	projectStepFlag = cli.Uint64Flag{
+		Name:  "step",
+		Usage: "Report every n-th block of the range",
+		Value: 1,
+	}
+	projectDifficultyFlag = cli.StringFlag{
+		Name:  "difficulty",
+		Usage: "Difficulty of the parent of the first block (default: genesis difficulty)",
+	}
+	projectBlockTimeFlag = cli.Uint64Flag{
+		Name:  "blocktime",
+		Usage: "Number of seconds between projected blocks",
+		Value: ethash.DefaultProjectionBlockTime,
+	}
+	projectFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format [csv|json]",
+		Value: "csv",
+	}
+)
+
+var errInvalidProjectFormat = errors.New("invalid projection output format")
+
+var projectCommand = cli.Command{
+	Name:  "project",
+	Usage: "Project block rewards, uncle rewards, bomb delay and difficulty over a block range",
+	Description: `Computes the Ethash block reward (including ECIP-1017 eras), the rewards for
+   uncle inclusion and uncle miners, the difficulty bomb delay and the difficulty
+   of each block in the range, as defined by the configuration.
+   Difficulties are projected from --difficulty, assuming the blocks are mined
+   --blocktime seconds apart and include no uncles.`,
+	Flags: []cli.Flag{
+		projectFromFlag,
+		projectToFlag,
+		projectStepFlag,
+		projectDifficultyFlag,
+		projectBlockTimeFlag,
+		projectFormatFlag,
+	},
+	Action: project,
+}
+
+func project(ctx *cli.Context) error {
+	var from, to math.HexOrDecimal64
+	if err := from.UnmarshalText([]byte(ctx.String(projectFromFlag.Name))); err != nil {
+		return err
+	}
+	to = from
+	if ctx.IsSet(projectToFlag.Name) {
+		if err := to.UnmarshalText([]byte(ctx.String(projectToFlag.Name))); err != nil {
+			return err
+		}
+	}
+	difficulty := globalChainspecValue.GetGenesisDifficulty()
+	if ctx.IsSet(projectDifficultyFlag.Name) {
+		d, ok := math.ParseBig256(ctx.String(projectDifficultyFlag.Name))
+		if !ok {
+			return fmt.Errorf("invalid difficulty: %s", ctx.String(projectDifficultyFlag.Name))
+		}
+		difficulty = d
+	}
+	if difficulty == nil {
+		difficulty = new(big.Int)
+	}
+	projections, err := ethash.ProjectBlocks(globalChainspecValue, uint64(from), uint64(to), ctx.Uint64(projectStepFlag.Name), difficulty, ctx.Uint64(projectBlockTimeFlag.Name))
+	if err != nil {
+		return err
+	}
+	switch ctx.String(projectFormatFlag.Name) {
+	case "json":
+		b, err := jsonMarshalPretty(projections)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	case "csv":
+		return writeProjectionsCSV(projections)
+	default:
+		return errInvalidProjectFormat
+	}
+}
+
+// writeProjectionsCSV prints the projections to stdout as CSV with decimal values.
+func writeProjectionsCSV(projections []*ethash.BlockProjection) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{"number", "era", "blockReward", "uncleInclusionReward", "uncleReward", "bombDelay", "bomb", "difficulty"}); err != nil {
+		return err
+	}
+	decimal := func(v *hexutil.Big) string {
+		if v == nil {
+			return ""
+		}
+		return v.ToInt().String()
+	}
+	for _, p := range projections {
+		record := []string{
+			strconv.FormatUint(uint64(p.Number), 10),
+			decimal(p.Era),
+			decimal(p.BlockReward),
+			decimal(p.UncleInclusionReward),
+			decimal(p.UncleReward),
+			decimal(p.BombDelay),
+			decimal(p.Bomb),
+			decimal(p.Difficulty),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
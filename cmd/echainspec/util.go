@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
@@ -20,11 +21,25 @@ func readInputData(ctx *cli.Context) ([]byte, error) {
 	return ioutil.ReadFile(ctx.GlobalString(fileInFlag.Name))
 }
 
-func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, err error) {
-	conf, ok := chainspecFormatTypes[format]
+// newChainspecValue returns a new, empty value of the data type used for a chainspec format.
+func newChainspecValue(format string) (ctypes.Configurator, error) {
+	v, ok := chainspecFormatTypes[format]
 	if !ok {
 		return nil, errInvalidChainspecValue
 	}
+	if g, ok := v.(*genesisT.Genesis); ok {
+		return &genesisT.Genesis{
+			Config: reflect.New(reflect.TypeOf(g.Config).Elem()).Interface().(ctypes.ChainConfigurator),
+		}, nil
+	}
+	return reflect.New(reflect.TypeOf(v).Elem()).Interface().(ctypes.Configurator), nil
+}
+
+func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, err error) {
+	conf, err = newChainspecValue(format)
+	if err != nil {
+		return nil, err
+	}
 	err = json.Unmarshal(data, conf)
 	if err != nil {
 		return conf, err
@@ -41,11 +56,7 @@ func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, e
 		Config ctypes.ChainConfigurator `json:"config"`
 	}
 	var d dec
-	configurator, ok := chainspecFormatTypes[format]
-	if !ok {
-		return nil, fmt.Errorf("unknown chainspec format type: %v", format)
-	}
-	switch t := configurator.(type) {
+	switch t := conf.(type) {
 	case *genesisT.Genesis:
 		d.Config = t.Config
 	case *parity.ParityChainSpec:
@@ -53,7 +64,7 @@ func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, e
 	default:
 		return nil, fmt.Errorf("unhandled chainspec type: %v %v", format, t)
 	}
-	t := chainspecFormatTypes[format].(*genesisT.Genesis)
+	t := conf.(*genesisT.Genesis)
 	err = json.Unmarshal(data, &d)
 	if err != nil {
 		return conf, err
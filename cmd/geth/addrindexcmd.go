@@ -0,0 +1,151 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	addrindexCommand = cli.Command{
+		Name:        "addrindex",
+		Usage:       "A set of commands to manage the address transaction index",
+		Category:    "BLOCKCHAIN COMMANDS",
+		Description: "",
+		Subcommands: []cli.Command{
+			{
+				Name:     "build",
+				Usage:    "Build the address transaction index up to the current head",
+				Action:   utils.MigrateFlags(buildAddressIndex),
+				Category: "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.ClassicFlag,
+					utils.MordorFlag,
+					utils.KottiFlag,
+					utils.SocialFlag,
+					utils.EthersocialFlag,
+					utils.LegacyTestnetFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.YoloV1Flag,
+					utils.GCModeFlag,
+					utils.AddressIndexInternalFlag,
+				},
+				Description: `
+geth addrindex build
+indexes the transactions of each address for the blocks of an existing database,
+as the node does in the background when run with --addrindex. Internal calls are
+only indexed with --addrindex.internal, which requires the state of the blocks,
+thus an archive node.
+`,
+			},
+			{
+				Name:     "drop",
+				Usage:    "Delete the address transaction index",
+				Action:   utils.MigrateFlags(dropAddressIndex),
+				Category: "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.ClassicFlag,
+					utils.MordorFlag,
+					utils.KottiFlag,
+					utils.SocialFlag,
+					utils.EthersocialFlag,
+					utils.LegacyTestnetFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.YoloV1Flag,
+				},
+				Description: `
+geth addrindex drop
+deletes all the entries of the address transaction index and its progress.
+`,
+			},
+		},
+	}
+)
+
+func buildAddressIndex(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, chainDb := utils.MakeChain(ctx, stack, false)
+	defer chainDb.Close()
+	defer chain.Stop()
+
+	indexer, backend := eth.NewAddressIndexer(chainDb, chain, eth.AddressIndexSection, eth.AddressIndexConfirms, ctx.GlobalBool(utils.AddressIndexInternalFlag.Name))
+	indexer.Start(chain)
+	defer indexer.Close()
+
+	// Wait for the indexer to process all the confirmed sections
+	var (
+		head   = chain.CurrentBlock().NumberU64()
+		target uint64
+		start  = time.Now()
+		logged = time.Now()
+	)
+	if head >= eth.AddressIndexConfirms {
+		target = (head + 1 - eth.AddressIndexConfirms) / eth.AddressIndexSection
+	}
+	for {
+		sections, _, _ := indexer.Sections()
+		if sections >= target {
+			break
+		}
+		// Failed sections are only retried on new chain heads, which never come
+		if err := backend.Err(); err != nil {
+			log.Error("Failed to index address transactions", "sections", sections, "err", err)
+			return fmt.Errorf("failed to index section %d: %v", sections, err)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing address transactions", "sections", sections, "target", target, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Info("Indexed address transactions", "sections", target, "blocks", target*eth.AddressIndexSection, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func dropAddressIndex(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := rawdb.DeleteAddressTxIndex(chainDb); err != nil {
+		log.Error("Failed to delete address transaction index", "err", err)
+		return err
+	}
+	log.Info("Deleted address transaction index", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
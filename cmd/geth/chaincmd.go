@@ -39,7 +39,9 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/confp/tconvert"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/types/pyethereum"
 	"github.com/ethereum/go-ethereum/trie"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -59,7 +61,8 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument. Pyethereum chain specifications are
+also accepted.`,
 	}
 	dumpGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(dumpGenesis),
@@ -251,7 +254,15 @@ func initGenesis(ctx *cli.Context) error {
 		utils.Fatalf("Failed to read genesis file: %v", err)
 	}
 
-	err = genesis.UnmarshalJSON(bs)
+	if pyethereum.IsPyEthereumGenesisSpec(bs) {
+		spec := &pyethereum.PyEthereumGenesisSpec{}
+		if err := json.Unmarshal(bs, spec); err != nil {
+			utils.Fatalf("invalid genesis file: %v", err)
+		}
+		genesis, err = tconvert.PyEthereumConfigTochipprgethGenesis(spec)
+	} else {
+		err = genesis.UnmarshalJSON(bs)
+	}
 	if err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
@@ -73,6 +73,7 @@ var (
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.ConsensusEngineFlag,
 		utils.EthashCacheDirFlag,
 		utils.EthashCachesInMemoryFlag,
 		utils.EthashCachesOnDiskFlag,
@@ -97,6 +98,8 @@ var (
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.AddressIndexFlag,
+		utils.AddressIndexInternalFlag,
 		utils.LightServeFlag,
 		utils.LegacyLightServFlag,
 		utils.LightIngressFlag,
@@ -109,6 +112,8 @@ var (
 		utils.UltraLightFractionFlag,
 		utils.UltraLightOnlyAnnounceFlag,
 		utils.WhitelistFlag,
+		utils.ECBP1100Flag,
+		utils.ECBP1100NoDisableFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
@@ -124,6 +129,8 @@ var (
 		utils.MinerThreadsFlag,
 		utils.LegacyMinerThreadsFlag,
 		utils.MinerNotifyFlag,
+		utils.MinerStratumFlag,
+		utils.MinerStratumDifficultyFlag,
 		utils.MinerGasTargetFlag,
 		utils.LegacyMinerGasTargetFlag,
 		utils.MinerGasLimitFlag,
@@ -166,7 +173,6 @@ var (
 		utils.LegacyGpoPercentileFlag,
 		utils.EWASMInterpreterFlag,
 		utils.EVMInterpreterFlag,
-		utils.PirlGuardFlag,
 		configFileFlag,
 	}
 
@@ -184,8 +190,14 @@ var (
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
+		utils.GraphQLDebugFlag,
 		utils.HTTPApiFlag,
 		utils.LegacyRPCApiFlag,
+		utils.HTTPJWTSecretFlag,
+		utils.HTTPAPIKeysFlag,
+		utils.HTTPAllowMethodsFlag,
+		utils.HTTPDenyMethodsFlag,
+		utils.HTTPRateLimitFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.LegacyWSListenAddrFlag,
@@ -195,11 +207,21 @@ var (
 		utils.LegacyWSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.LegacyWSAllowedOriginsFlag,
+		utils.WSJWTSecretFlag,
+		utils.WSAPIKeysFlag,
+		utils.WSAllowMethodsFlag,
+		utils.WSDenyMethodsFlag,
+		utils.WSRateLimitFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCap,
 		utils.RPCGlobalTxFeeCap,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCCallTimeoutFlag,
+		utils.RPCMethodTimeoutsFlag,
+		utils.RPCSlowCallFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -254,6 +276,10 @@ func init() {
 		licenseCommand,
 		// See config.go
 		dumpConfigCommand,
+		// See snapshot.go
+		snapshotCommand,
+		// See addrindexcmd.go
+		addrindexCommand,
 		// See retesteth.go
 		retestethCommand,
 		// See cmd/utils/flags_legacy.go
@@ -39,6 +39,7 @@ var (
 		Category:  "MISCELLANEOUS COMMANDS",
 		Description: `
 The makecache command generates an ethash cache in <outputDir>.
+The chain flags (e.g. --classic) select the epoch length of the block (ECIP-1099).
 
 This command exists to support the system testing project.
 Regular users do not need to execute it.
@@ -52,6 +53,7 @@ Regular users do not need to execute it.
 		Category:  "MISCELLANEOUS COMMANDS",
 		Description: `
 The makedag command generates an ethash DAG in <outputDir>.
+The chain flags (e.g. --classic) select the epoch length of the block (ECIP-1099).
 
 This command exists to support the system testing project.
 Regular users do not need to execute it.
@@ -86,7 +88,7 @@ func makecache(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Invalid block number: %v", err)
 	}
-	ethash.MakeCache(block, args[1])
+	ethash.MakeCache(block, ecip1099Transition(ctx), args[1])
 
 	return nil
 }
@@ -101,11 +103,21 @@ func makedag(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Invalid block number: %v", err)
 	}
-	ethash.MakeDataset(block, args[1])
+	ethash.MakeDataset(block, ecip1099Transition(ctx), args[1])
 
 	return nil
 }
 
+// ecip1099Transition returns the ECIP-1099 transition block of the chain
+// selected by the command line flags, if any.
+func ecip1099Transition(ctx *cli.Context) *uint64 {
+	genesis := utils.MakeGenesis(ctx)
+	if genesis == nil {
+		return nil
+	}
+	return genesis.Config.GetEthashECIP1099Transition()
+}
+
 func version(ctx *cli.Context) error {
 	versionClientIdentifier := clientIdentifier
 	if params.VersionName != "" {
@@ -56,10 +56,14 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
 			utils.TxLookupLimitFlag,
+			utils.AddressIndexFlag,
+			utils.AddressIndexInternalFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.ECBP1100Flag,
+			utils.ECBP1100NoDisableFlag,
 		},
 	},
 	{
@@ -82,6 +86,12 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.DeveloperPeriodFlag,
 		},
 	},
+	{
+		Name: "CONSENSUS",
+		Flags: []cli.Flag{
+			utils.ConsensusEngineFlag,
+		},
+	},
 	{
 		Name: "ETHASH",
 		Flags: []cli.Flag{
@@ -144,16 +154,32 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.HTTPApiFlag,
 			utils.HTTPCORSDomainFlag,
 			utils.HTTPVirtualHostsFlag,
+			utils.HTTPJWTSecretFlag,
+			utils.HTTPAPIKeysFlag,
+			utils.HTTPAllowMethodsFlag,
+			utils.HTTPDenyMethodsFlag,
+			utils.HTTPRateLimitFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.WSJWTSecretFlag,
+			utils.WSAPIKeysFlag,
+			utils.WSAllowMethodsFlag,
+			utils.WSDenyMethodsFlag,
+			utils.WSRateLimitFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
+			utils.GraphQLDebugFlag,
 			utils.RPCGlobalGasCap,
 			utils.RPCGlobalTxFeeCap,
+			utils.RPCBatchLimitFlag,
+			utils.RPCResponseLimitFlag,
+			utils.RPCCallTimeoutFlag,
+			utils.RPCMethodTimeoutsFlag,
+			utils.RPCSlowCallFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -183,6 +209,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MiningEnabledFlag,
 			utils.MinerThreadsFlag,
 			utils.MinerNotifyFlag,
+			utils.MinerStratumFlag,
+			utils.MinerStratumDifficultyFlag,
 			utils.MinerGasPriceFlag,
 			utils.MinerGasTargetFlag,
 			utils.MinerGasLimitFlag,
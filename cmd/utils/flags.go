@@ -18,6 +18,7 @@
 package utils
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"io"
@@ -36,7 +37,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
 	"github.com/ethereum/go-ethereum/consensus"
-	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -217,13 +217,9 @@ var (
 		Usage: "Exclude contract code (save db lookups)",
 	}
 	defaultSyncMode = eth.DefaultConfig.SyncMode
-	PirlGuardFlag   = cli.BoolFlag{
-		Name:  "PirlGuard",
-		Usage: "*experimental - yolo* When enabled, Pirlguard rules will be enforced to control re-org limits. Chipprgeth uses pirlgaurd with a block setting of 250 blocks by default. If a majority of nodes are run with this setting, they will follow the same chain and defend against possible malicious reorgs ",
-	}
-	SyncModeFlag = TextMarshalerFlag{
+	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -235,11 +231,24 @@ var (
 		Name:  "snapshot",
 		Usage: `Enables snapshot-database mode -- experimental work in progress feature`,
 	}
+	BloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to bloom-filter for pruning",
+		Value: 2048,
+	}
 	TxLookupLimitFlag = cli.Int64Flag{
 		Name:  "txlookuplimit",
 		Usage: "Number of recent blocks to maintain transactions index by-hash for (default = index all blocks)",
 		Value: 0,
 	}
+	AddressIndexFlag = cli.BoolFlag{
+		Name:  "addrindex",
+		Usage: "Index the transactions of each address (eth_getTransactionsByAddress)",
+	}
+	AddressIndexInternalFlag = cli.BoolFlag{
+		Name:  "addrindex.internal",
+		Usage: "Index the addresses touched by internal calls too (requires --gcmode=archive)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -248,6 +257,14 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	ECBP1100Flag = cli.Uint64Flag{
+		Name:  "ecbp1100",
+		Usage: "Block number activating ECBP1100 (MESS) artificial finality, overriding the chain configuration",
+	}
+	ECBP1100NoDisableFlag = cli.BoolFlag{
+		Name:  "ecbp1100.nodisable",
+		Usage: "Keep ECBP1100 (MESS) artificial finality enabled once synced, even with low peers or a stale head",
+	}
 	// Light server and client settings
 	LightServeFlag = cli.IntFlag{
 		Name:  "light.serve",
@@ -287,6 +304,11 @@ var (
 		Name:  "light.nopruning",
 		Usage: "Disable ancient light chain data pruning",
 	}
+	// Consensus settings
+	ConsensusEngineFlag = cli.StringFlag{
+		Name:  "consensus.engine",
+		Usage: `Consensus engine to run the chain with, overriding the chain configuration ("ethash" or "clique")`,
+	}
 	// Ethash settings
 	EthashCacheDirFlag = DirectoryFlag{
 		Name:  "ethash.cachedir",
@@ -433,6 +455,14 @@ var (
 		Name:  "miner.notify",
 		Usage: "Comma separated HTTP URL list to notify of new work packages",
 	}
+	MinerStratumFlag = cli.StringFlag{
+		Name:  "miner.stratum",
+		Usage: "TCP listening address of the stratum server pushing work packages to miners (e.g. 127.0.0.1:8008)",
+	}
+	MinerStratumDifficultyFlag = cli.Float64Flag{
+		Name:  "miner.stratum.difficulty",
+		Usage: "Share difficulty of the NiceHash stratum miners, in units of 2^32 hashes (0 = block difficulty)",
+	}
 	MinerGasTargetFlag = cli.Uint64Flag{
 		Name:  "miner.gastarget",
 		Usage: "Target gas floor for mined blocks",
@@ -500,6 +530,26 @@ var (
 		Usage: "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
 		Value: eth.DefaultConfig.RPCTxFeeCap,
 	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in a JSON-RPC batch (0 = no limit)",
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.responselimit",
+		Usage: "Maximum size in bytes of the results of a JSON-RPC call or batch (0 = no limit)",
+	}
+	RPCCallTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.calltimeout",
+		Usage: "Execution timeout of JSON-RPC method calls (0 = no timeout)",
+	}
+	RPCMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpc.methodtimeouts",
+		Usage: "Comma separated execution timeouts of individual methods, overriding --rpc.calltimeout (e.g. eth_getLogs=1m,eth_call=5s)",
+	}
+	RPCSlowCallFlag = cli.DurationFlag{
+		Name:  "rpc.slowcall",
+		Usage: "Execution time beyond which JSON-RPC calls are logged as slow (0 = disabled)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -551,6 +601,26 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	HTTPJWTSecretFlag = cli.StringFlag{
+		Name:  "http.jwtsecret",
+		Usage: "File holding the hex encoded secret of the HS256 JSON web tokens authenticating HTTP-RPC requests",
+	}
+	HTTPAPIKeysFlag = cli.StringFlag{
+		Name:  "http.apikeys",
+		Usage: "File holding the API keys authenticating HTTP-RPC requests, one per line",
+	}
+	HTTPAllowMethodsFlag = cli.StringFlag{
+		Name:  "http.methods.allow",
+		Usage: "Comma separated list of methods callable over the HTTP-RPC interface. Accepts '*' wildcard (e.g. eth_*).",
+	}
+	HTTPDenyMethodsFlag = cli.StringFlag{
+		Name:  "http.methods.deny",
+		Usage: "Comma separated list of methods denied over the HTTP-RPC interface, overriding the allowed ones. Accepts '*' wildcard.",
+	}
+	HTTPRateLimitFlag = cli.Float64Flag{
+		Name:  "http.ratelimit",
+		Usage: "Maximum number of HTTP-RPC calls per second for each API key, token subject or unauthenticated address (0 = unlimited)",
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -565,6 +635,10 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 	}
+	GraphQLDebugFlag = cli.BoolFlag{
+		Name:  "graphql.debug",
+		Usage: "Enable the expensive GraphQL fields: transaction traces, calls with state overrides and storage ranges",
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -589,6 +663,26 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	WSJWTSecretFlag = cli.StringFlag{
+		Name:  "ws.jwtsecret",
+		Usage: "File holding the hex encoded secret of the HS256 JSON web tokens authenticating WS-RPC connections",
+	}
+	WSAPIKeysFlag = cli.StringFlag{
+		Name:  "ws.apikeys",
+		Usage: "File holding the API keys authenticating WS-RPC connections, one per line",
+	}
+	WSAllowMethodsFlag = cli.StringFlag{
+		Name:  "ws.methods.allow",
+		Usage: "Comma separated list of methods callable over the WS-RPC interface. Accepts '*' wildcard (e.g. eth_*).",
+	}
+	WSDenyMethodsFlag = cli.StringFlag{
+		Name:  "ws.methods.deny",
+		Usage: "Comma separated list of methods denied over the WS-RPC interface, overriding the allowed ones. Accepts '*' wildcard.",
+	}
+	WSRateLimitFlag = cli.Float64Flag{
+		Name:  "ws.ratelimit",
+		Usage: "Maximum number of WS-RPC calls per second for each API key, token subject or unauthenticated address (0 = unlimited)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -982,6 +1076,7 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(HTTPVirtualHostsFlag.Name))
 	}
+	setRPCAccess(ctx, &cfg.HTTPAccess, HTTPJWTSecretFlag, HTTPAPIKeysFlag, HTTPAllowMethodsFlag, HTTPDenyMethodsFlag, HTTPRateLimitFlag)
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -993,6 +1088,9 @@ func setGraphQL(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
 		cfg.GraphQLVirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(GraphQLDebugFlag.Name) {
+		cfg.GraphQLDebug = ctx.GlobalBool(GraphQLDebugFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -1031,6 +1129,74 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSApiFlag.Name) {
 		cfg.WSModules = splitAndTrim(ctx.GlobalString(WSApiFlag.Name))
 	}
+	setRPCAccess(ctx, &cfg.WSAccess, WSJWTSecretFlag, WSAPIKeysFlag, WSAllowMethodsFlag, WSDenyMethodsFlag, WSRateLimitFlag)
+}
+
+// setRPCLimits applies the resource limits of the RPC calls from the command line
+// flags into the node configuration.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		cfg.RPCLimits.BatchItems = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		cfg.RPCLimits.ResponseSize = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCCallTimeoutFlag.Name) {
+		cfg.RPCLimits.CallTimeout = ctx.GlobalDuration(RPCCallTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMethodTimeoutsFlag.Name) {
+		cfg.RPCLimits.MethodTimeouts = make(map[string]time.Duration)
+		for _, entry := range splitAndTrim(ctx.GlobalString(RPCMethodTimeoutsFlag.Name)) {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				Fatalf("Invalid method timeout %q, want method=duration", entry)
+			}
+			timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+			if err != nil {
+				Fatalf("Invalid timeout of method %s: %v", parts[0], err)
+			}
+			cfg.RPCLimits.MethodTimeouts[strings.TrimSpace(parts[0])] = timeout
+		}
+	}
+	if ctx.GlobalIsSet(RPCSlowCallFlag.Name) {
+		cfg.RPCLimits.SlowCallThreshold = ctx.GlobalDuration(RPCSlowCallFlag.Name)
+	}
+}
+
+// setRPCAccess applies the access restriction flags of an RPC listener into
+// its configuration.
+func setRPCAccess(ctx *cli.Context, cfg *node.RPCAccessConfig, secret, keys, allow, deny cli.StringFlag, limit cli.Float64Flag) {
+	if ctx.GlobalIsSet(secret.Name) {
+		blob, err := ioutil.ReadFile(ctx.GlobalString(secret.Name))
+		if err != nil {
+			Fatalf("Failed to read JWT secret: %v", err)
+		}
+		cfg.JWTSecret = strings.TrimSpace(string(blob))
+		if !strings.HasPrefix(cfg.JWTSecret, "0x") {
+			cfg.JWTSecret = "0x" + cfg.JWTSecret
+		}
+	}
+	if ctx.GlobalIsSet(keys.Name) {
+		blob, err := ioutil.ReadFile(ctx.GlobalString(keys.Name))
+		if err != nil {
+			Fatalf("Failed to read API keys: %v", err)
+		}
+		cfg.APIKeys = nil
+		for _, key := range strings.Split(string(blob), "\n") {
+			if key = strings.TrimSpace(key); key != "" {
+				cfg.APIKeys = append(cfg.APIKeys, key)
+			}
+		}
+	}
+	if ctx.GlobalIsSet(allow.Name) {
+		cfg.AllowMethods = splitAndTrim(ctx.GlobalString(allow.Name))
+	}
+	if ctx.GlobalIsSet(deny.Name) {
+		cfg.DenyMethods = splitAndTrim(ctx.GlobalString(deny.Name))
+	}
+	if ctx.GlobalIsSet(limit.Name) {
+		cfg.RateLimit = ctx.GlobalFloat64(limit.Name)
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1251,6 +1417,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setHTTP(ctx, cfg)
 	setGraphQL(ctx, cfg)
 	setWS(ctx, cfg)
+	setRPCLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
@@ -1414,6 +1581,28 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	}
 }
 
+// consensusEngineType returns the consensus engine selected on the command line,
+// if any, failing on engines which are neither ethash nor registered.
+func consensusEngineType(ctx *cli.Context) ctypes.ConsensusEngineT {
+	var engineType ctypes.ConsensusEngineT
+	if !ctx.GlobalIsSet(ConsensusEngineFlag.Name) {
+		return engineType
+	}
+	if err := engineType.UnmarshalText([]byte(ctx.GlobalString(ConsensusEngineFlag.Name))); err != nil {
+		Fatalf("Invalid --%s: %v", ConsensusEngineFlag.Name, err)
+	}
+	if !engineType.IsUnknown() && !engineType.IsEthash() && !consensus.HasEngine(engineType) {
+		Fatalf("Consensus engine %v not available", engineType)
+	}
+	return engineType
+}
+
+func setConsensusEngine(ctx *cli.Context, cfg *eth.Config) {
+	if ctx.GlobalIsSet(ConsensusEngineFlag.Name) {
+		cfg.ConsensusEngine = consensusEngineType(ctx)
+	}
+}
+
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EthashCacheDirFlag.Name) {
 		cfg.Ethash.CacheDir = ctx.GlobalString(EthashCacheDirFlag.Name)
@@ -1439,6 +1628,12 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EthashDatasetsLockMmapFlag.Name) {
 		cfg.Ethash.DatasetsLockMmap = ctx.GlobalBool(EthashDatasetsLockMmapFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerStratumFlag.Name) {
+		cfg.Ethash.StratumAddr = ctx.GlobalString(MinerStratumFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumDifficultyFlag.Name) {
+		cfg.Ethash.StratumDifficulty = ctx.GlobalFloat64(MinerStratumDifficultyFlag.Name)
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1575,6 +1770,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO, ctx.GlobalString(SyncModeFlag.Name) == "light")
 	setTxPool(ctx, &cfg.TxPool)
+	setConsensusEngine(ctx, cfg)
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
@@ -1606,6 +1802,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(AddressIndexFlag.Name) {
+		cfg.AddressIndex = ctx.GlobalBool(AddressIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(AddressIndexInternalFlag.Name) {
+		cfg.AddressIndexInternal = ctx.GlobalBool(AddressIndexInternalFlag.Name)
+	}
+	if ctx.GlobalIsSet(ECBP1100Flag.Name) {
+		cfg.ECBP1100 = new(big.Int).SetUint64(ctx.GlobalUint64(ECBP1100Flag.Name))
+	}
+	if ctx.GlobalIsSet(ECBP1100NoDisableFlag.Name) {
+		cfg.ECBP1100NoDisable = ctx.GlobalBool(ECBP1100NoDisableFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1774,14 +1982,15 @@ func setDNSDiscoveryDefaults(cfg *eth.Config, genesis common.Hash) {
 	}
 }
 
-// RegisterEthService adds an Ethereum client to the stack.
-func RegisterEthService(stack *node.Node, cfg *eth.Config) ethapi.Backend {
+// RegisterEthService adds an Ethereum client to the stack. The full node service
+// is returned as well, or nil for light clients.
+func RegisterEthService(stack *node.Node, cfg *eth.Config) (ethapi.Backend, *eth.Ethereum) {
 	if cfg.SyncMode == downloader.LightSync {
 		backend, err := les.New(stack, cfg)
 		if err != nil {
 			Fatalf("Failed to register the Ethereum service: %v", err)
 		}
-		return backend.ApiBackend
+		return backend.ApiBackend, nil
 	} else {
 		backend, err := eth.New(stack, cfg)
 		if err != nil {
@@ -1793,7 +2002,7 @@ func RegisterEthService(stack *node.Node, cfg *eth.Config) ethapi.Backend {
 				Fatalf("Failed to create the LES server: %v", err)
 			}
 		}
-		return backend.APIBackend
+		return backend.APIBackend, backend
 	}
 }
 
@@ -1813,12 +2022,26 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 }
 
 // RegisterGraphQLService is a utility function to construct a new service and register it against a node.
-func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.Config) {
-	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
+// Transactions are traced by the full node service, light clients don't support tracing.
+func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, ethereum *eth.Ethereum, cfg node.Config) {
+	var tracer graphql.Tracer
+	if cfg.GraphQLDebug && ethereum != nil {
+		tracer = &graphQLTracer{eth.NewPrivateDebugAPI(ethereum)}
+	}
+	if err := graphql.New(stack, backend, cfg.GraphQLDebug, tracer, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
 		Fatalf("Failed to register the GraphQL service: %v", err)
 	}
 }
 
+// graphQLTracer traces transactions for the GraphQL debugging fields.
+type graphQLTracer struct {
+	api *eth.PrivateDebugAPI
+}
+
+func (t *graphQLTracer) TraceTransaction(ctx context.Context, hash common.Hash, tracer *string) (interface{}, error) {
+	return t.api.TraceTransaction(ctx, hash, &eth.TraceConfig{Tracer: tracer})
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -1935,12 +2158,15 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readOnly bool) (chain *core.B
 	if err != nil {
 		Fatalf("%v", err)
 	}
+	engineType := consensusEngineType(ctx)
+	if engineType.IsUnknown() {
+		engineType = config.GetConsensusEngineType()
+	}
 	var engine consensus.Engine
-	if config.GetConsensusEngineType().IsClique() {
-		engine = clique.New(&ctypes.CliqueConfig{
-			Period: config.GetCliquePeriod(),
-			Epoch:  config.GetCliqueEpoch(),
-		}, chainDb)
+	if consensus.HasEngine(engineType) {
+		if engine, err = consensus.NewEngine(engineType, config, chainDb); err != nil {
+			Fatalf("%v", err)
+		}
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1953,6 +2179,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readOnly bool) (chain *core.B
 				DatasetsInMem:    eth.DefaultConfig.Ethash.DatasetsInMem,
 				DatasetsOnDisk:   eth.DefaultConfig.Ethash.DatasetsOnDisk,
 				DatasetsLockMmap: eth.DefaultConfig.Ethash.DatasetsLockMmap,
+				ECIP1099Block:    config.GetEthashECIP1099Transition(),
 			}, nil, false)
 		}
 	}
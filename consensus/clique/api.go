@@ -17,7 +17,9 @@
 package clique
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -175,3 +177,88 @@ func (api *API) Status() (*status, error) {
 		NumBlocks:     numBlocks,
 	}, nil
 }
+
+// maxVoteRange is the maximum number of blocks GetVotes is allowed to scan in a
+// single request.
+const maxVoteRange = 10000
+
+// resolveNumber converts a possibly symbolic block number into an absolute one
+// on the canonical chain.
+func (api *API) resolveNumber(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return api.chain.CurrentHeader().Number.Uint64()
+	}
+	return uint64(number)
+}
+
+// GetVotes retrieves the votes cast in the canonical blocks of the given range
+// (inclusive on both ends), along with whether they were counted and whether
+// they passed their proposal.
+func (api *API) GetVotes(from, to rpc.BlockNumber) ([]*VoteRecord, error) {
+	start, end := api.resolveNumber(from), api.resolveNumber(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid block range: %d > %d", start, end)
+	}
+	if end-start >= maxVoteRange {
+		return nil, fmt.Errorf("block range too large: %d > %d", end-start+1, maxVoteRange)
+	}
+	records := []*VoteRecord{}
+	for n := start; n <= end; n++ {
+		header := api.chain.GetHeaderByNumber(n)
+		if header == nil {
+			break
+		}
+		record, err := api.clique.voteRecord(api.chain, header)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// GetSignerHistory retrieves the times the given account was added to or removed
+// from the set of authorized signers on the canonical chain, in chronological
+// order. Only the blocks processed by this node are covered.
+func (api *API) GetSignerHistory(address common.Address) ([]*SignerChange, error) {
+	history := []*SignerChange{}
+	for _, change := range readSignerHistory(api.clique.db, address) {
+		if header := api.chain.GetHeaderByNumber(change.Block); header != nil && header.Hash() == change.Hash {
+			history = append(history, change)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Block < history[j].Block
+	})
+	return history, nil
+}
+
+// SignerChanges creates a subscription that fires whenever an account is added
+// to or removed from the set of authorized signers.
+func (api *API) SignerChanges(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := make(chan *SignerChange, 16)
+		sub := api.clique.SubscribeSignerChanges(changes)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case change := <-changes:
+				notifier.Notify(rpcSub.ID, change)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -20,6 +20,7 @@ package clique
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"math/rand"
@@ -35,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/vars"
@@ -139,7 +141,7 @@ var (
 )
 
 // SignerFn hashes and signs the data to be signed by a backing account.
-type SignerFn func(signer accounts.Account, mimeType string, message []byte) ([]byte, error)
+type SignerFn = consensus.SignerFn
 
 // ecrecover extracts the Ethereum account address from a signed header.
 func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
@@ -181,10 +183,22 @@ type Clique struct {
 	signFn SignerFn       // Signer function to authorize hashes with
 	lock   sync.RWMutex   // Protects the signer fields
 
+	signerFeed  event.Feed // Feed of modifications of the authorized signer set
+	voteLogLock sync.Mutex // Serializes updates to the indexed voting history
+
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
 }
 
+func init() {
+	consensus.RegisterEngine(ctypes.ConsensusEngineT_Clique, func(config ctypes.ChainConfigurator, db ethdb.Database) (consensus.Engine, error) {
+		return New(&ctypes.CliqueConfig{
+			Period: config.GetCliquePeriod(),
+			Epoch:  config.GetCliqueEpoch(),
+		}, db), nil
+	})
+}
+
 // New creates a Clique proof-of-authority consensus engine with the initial
 // signers set to the ones provided by the user.
 func New(config *ctypes.CliqueConfig, db ethdb.Database) *Clique {
@@ -324,6 +338,14 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	if parent.Time+c.config.Period > header.Time {
 		return errInvalidTimestamp
 	}
+	// Verify the header's EIP-1559 attributes, if the fork is enabled.
+	if !chain.Config().IsEnabled(chain.Config().GetEIP1559Transition, header.Number) {
+		if header.BaseFee != nil {
+			return fmt.Errorf("invalid baseFee before fork: have %d, want <nil>", header.BaseFee)
+		}
+	} else if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		return err
+	}
 	// Retrieve the snapshot needed to verify this header and cache it
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
@@ -382,6 +404,11 @@ func (c *Clique) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 				if err := snap.store(c.db); err != nil {
 					return nil, err
 				}
+				if number == 0 {
+					if err := c.storeGenesisSigners(snap); err != nil {
+						return nil, err
+					}
+				}
 				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
 				break
 			}
@@ -413,6 +440,9 @@ func (c *Clique) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	if err != nil {
 		return nil, err
 	}
+	if err := c.storeVoteLog(snap); err != nil {
+		return nil, err
+	}
 	c.recents.Add(snap.Hash, snap)
 
 	// If we've generated a new checkpoint snapshot, save to disk
@@ -579,6 +609,26 @@ func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// PreserveLocalBlocks implements consensus.Preserver, disabling the preference
+// for local blocks during reorgs as it may introduce a deadlock.
+//
+// e.g. If there are 7 available signers
+//
+// r1   A
+// r2     B
+// r3       C
+// r4         D
+// r5   A      [X] F G
+// r6    [X]
+//
+// In the round5, the inturn signer E is offline, so the worst case
+// is A, F and G sign the block of round5 and reject the block of opponents
+// and in the round6, the last available signer B is offline, the whole
+// network is stuck.
+func (c *Clique) PreserveLocalBlocks() bool {
+	return false
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
@@ -715,7 +765,7 @@ func CliqueRLP(header *types.Header) []byte {
 }
 
 func encodeSigHeader(w io.Writer, header *types.Header) {
-	err := rlp.Encode(w, []interface{}{
+	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -731,8 +781,11 @@ func encodeSigHeader(w io.Writer, header *types.Header) {
 		header.Extra[:len(header.Extra)-crypto.SignatureLength], // Yes, this will panic if extra is too short
 		header.MixDigest,
 		header.Nonce,
-	})
-	if err != nil {
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	if err := rlp.Encode(w, enc); err != nil {
 		panic("can't encode: " + err.Error())
 	}
 }
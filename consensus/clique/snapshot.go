@@ -46,6 +46,28 @@ type Tally struct {
 	Votes     int  `json:"votes"`     // Number of votes until now wanting to pass the proposal
 }
 
+// VoteRecord is the outcome of the vote carried by a single block, as observed
+// while applying the block on top of the voting state of its parent.
+type VoteRecord struct {
+	Block     uint64         `json:"block"`     // Block number the vote was cast in
+	Hash      common.Hash    `json:"hash"`      // Hash of the block the vote was cast in
+	Signer    common.Address `json:"signer"`    // Authorized signer that cast this vote
+	Address   common.Address `json:"address"`   // Account being voted on to change its authorization
+	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the voted account
+	Counted   bool           `json:"counted"`   // Whether the vote was meaningful and entered the tally
+	Votes     int            `json:"votes"`     // Number of votes for the proposal after this one was cast
+	Passed    bool           `json:"passed"`    // Whether this vote pushed the proposal through
+}
+
+// SignerChange is a single modification of the set of authorized signers.
+type SignerChange struct {
+	Block      uint64           `json:"block"`      // Block number the change took effect in
+	Hash       common.Hash      `json:"hash"`       // Hash of the block the change took effect in
+	Address    common.Address   `json:"address"`    // Account whose authorization was changed
+	Authorized bool             `json:"authorized"` // Whether the account was added or removed
+	Voters     []common.Address `json:"voters"`     // Signers whose votes passed the change (none for genesis)
+}
+
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	config   *ctypes.CliqueConfig // Consensus engine parameters to fine tune behavior
@@ -57,6 +79,9 @@ type Snapshot struct {
 	Recents map[uint64]common.Address   `json:"recents"` // Set of recent signers for spam protections
 	Votes   []*Vote                     `json:"votes"`   // List of votes cast in chronological order
 	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
+
+	voteLog   []*VoteRecord   // Votes cast in the headers applied to reach this snapshot, not persisted
+	signerLog []*SignerChange // Signer changes caused by the applied headers, not persisted
 }
 
 // signersAscending implements the sort interface to allow sorting a list of addresses
@@ -250,7 +275,8 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		default:
 			return nil, errInvalidVote
 		}
-		if snap.cast(header.Coinbase, authorize) {
+		counted := snap.cast(header.Coinbase, authorize)
+		if counted {
 			snap.Votes = append(snap.Votes, &Vote{
 				Signer:    signer,
 				Block:     number,
@@ -258,8 +284,37 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				Authorize: authorize,
 			})
 		}
+		// Track the outcome of the vote for the governance history
+		var record *VoteRecord
+		if header.Coinbase != (common.Address{}) {
+			record = &VoteRecord{
+				Block:     number,
+				Hash:      header.Hash(),
+				Signer:    signer,
+				Address:   header.Coinbase,
+				Authorize: authorize,
+				Counted:   counted,
+				Votes:     snap.Tally[header.Coinbase].Votes,
+			}
+			snap.voteLog = append(snap.voteLog, record)
+		}
 		// If the vote passed, update the list of signers
 		if tally := snap.Tally[header.Coinbase]; tally.Votes > len(snap.Signers)/2 {
+			change := &SignerChange{
+				Block:      number,
+				Hash:       header.Hash(),
+				Address:    header.Coinbase,
+				Authorized: tally.Authorize,
+			}
+			for _, vote := range snap.Votes {
+				if vote.Address == header.Coinbase {
+					change.Voters = append(change.Voters, vote.Signer)
+				}
+			}
+			snap.signerLog = append(snap.signerLog, change)
+			if record != nil {
+				record.Passed = true
+			}
 			if tally.Authorize {
 				snap.Signers[header.Coinbase] = struct{}{}
 			} else {
@@ -0,0 +1,196 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	voteRecordPrefix    = []byte("clique-vote-")   // voteRecordPrefix + hash -> vote record of the block
+	signerHistoryPrefix = []byte("clique-signer-") // signerHistoryPrefix + address -> signer changes of the account
+)
+
+// voteRecordKey = voteRecordPrefix + hash
+func voteRecordKey(hash common.Hash) []byte {
+	return append(append([]byte{}, voteRecordPrefix...), hash.Bytes()...)
+}
+
+// signerHistoryKey = signerHistoryPrefix + address
+func signerHistoryKey(address common.Address) []byte {
+	return append(append([]byte{}, signerHistoryPrefix...), address.Bytes()...)
+}
+
+// readVoteRecord retrieves the vote cast in the block with the given hash, or
+// nil if the block carried no vote or was not indexed yet.
+func readVoteRecord(db ethdb.KeyValueReader, hash common.Hash) *VoteRecord {
+	blob, err := db.Get(voteRecordKey(hash))
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	record := new(VoteRecord)
+	if err := json.Unmarshal(blob, record); err != nil {
+		log.Error("Invalid clique vote record", "hash", hash, "err", err)
+		return nil
+	}
+	return record
+}
+
+// readSignerHistory retrieves all the indexed authorization changes of the given
+// account, across all the chains the node has seen, in the order they were
+// encountered.
+func readSignerHistory(db ethdb.KeyValueReader, address common.Address) []*SignerChange {
+	blob, err := db.Get(signerHistoryKey(address))
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var history []*SignerChange
+	if err := json.Unmarshal(blob, &history); err != nil {
+		log.Error("Invalid clique signer history", "address", address, "err", err)
+		return nil
+	}
+	return history
+}
+
+// storeVoteLog persists the vote records and signer changes gathered while the
+// given snapshot was being applied, notifying subscribers of all signer changes
+// that were not indexed before (i.e. were not seen on a previous application of
+// the same headers).
+func (c *Clique) storeVoteLog(snap *Snapshot) error {
+	if len(snap.voteLog) == 0 && len(snap.signerLog) == 0 {
+		return nil
+	}
+	fresh, err := c.indexVoteLog(snap)
+	if err != nil {
+		return err
+	}
+	snap.voteLog, snap.signerLog = nil, nil
+
+	for _, change := range fresh {
+		c.signerFeed.Send(change)
+	}
+	return nil
+}
+
+// indexVoteLog writes the vote records and signer changes of the snapshot into
+// the database, returning the signer changes that were not known before.
+func (c *Clique) indexVoteLog(snap *Snapshot) ([]*SignerChange, error) {
+	c.voteLogLock.Lock()
+	defer c.voteLogLock.Unlock()
+
+	batch := c.db.NewBatch()
+	for _, record := range snap.voteLog {
+		blob, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := batch.Put(voteRecordKey(record.Hash), blob); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		fresh     []*SignerChange
+		histories = make(map[common.Address][]*SignerChange)
+	)
+	for _, change := range snap.signerLog {
+		history, ok := histories[change.Address]
+		if !ok {
+			history = readSignerHistory(c.db, change.Address)
+		}
+		known := false
+		for _, prev := range history {
+			if prev.Hash == change.Hash {
+				known = true
+				break
+			}
+		}
+		if !known {
+			history = append(history, change)
+			fresh = append(fresh, change)
+		}
+		histories[change.Address] = history
+	}
+	for address, history := range histories {
+		blob, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		if err := batch.Put(signerHistoryKey(address), blob); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+// storeGenesisSigners records the signers of the genesis snapshot as having
+// been authorized in the genesis block.
+func (c *Clique) storeGenesisSigners(snap *Snapshot) error {
+	for _, signer := range snap.signers() {
+		snap.signerLog = append(snap.signerLog, &SignerChange{
+			Block:      snap.Number,
+			Hash:       snap.Hash,
+			Address:    signer,
+			Authorized: true,
+		})
+	}
+	return c.storeVoteLog(snap)
+}
+
+// voteRecord retrieves the outcome of the vote cast in the given header. If the
+// header was not indexed yet, its vote is derived from the voting snapshot of
+// its parent. Nil is returned for headers that carry no vote.
+func (c *Clique) voteRecord(chain consensus.ChainHeaderReader, header *types.Header) (*VoteRecord, error) {
+	number := header.Number.Uint64()
+	if number == 0 || header.Coinbase == (common.Address{}) {
+		return nil, nil
+	}
+	if record := readVoteRecord(c.db, header.Hash()); record != nil {
+		return record, nil
+	}
+	parent, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := parent.apply([]*types.Header{header})
+	if err != nil {
+		return nil, err
+	}
+	var record *VoteRecord
+	if len(snap.voteLog) > 0 {
+		record = snap.voteLog[0]
+	}
+	if err := c.storeVoteLog(snap); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// SubscribeSignerChanges registers a subscription for modifications of the set
+// of authorized signers.
+func (c *Clique) SubscribeSignerChanges(ch chan<- *SignerChange) event.Subscription {
+	return c.signerFeed.Subscribe(ch)
+}
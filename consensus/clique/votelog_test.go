@@ -0,0 +1,149 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that the votes cast and the signer changes they cause are indexed while
+// importing a chain and are exposed through the API.
+func TestVoteLog(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	votes := []testerVote{
+		{signer: "A", voted: "C", auth: true},
+		{signer: "B"},
+		{signer: "A", voted: "C", auth: true}, // Meaningless re-vote
+		{signer: "B", voted: "C", auth: true}, // Passes the proposal
+		{signer: "C", voted: "C", auth: true}, // Not counted, C is already a signer
+	}
+	signers := []common.Address{accounts.address("A"), accounts.address("B")}
+	sort.Sort(signersAscending(signers))
+
+	genesis := &genesisT.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(signers)+extraSeal),
+	}
+	for j, signer := range signers {
+		copy(genesis.ExtraData[extraVanity+j*common.AddressLength:], signer[:])
+	}
+	db := rawdb.NewMemoryDatabase()
+	core.MustCommitGenesis(db, genesis)
+
+	config := *params.TestChainConfig
+	config.Clique = &ctypes.CliqueConfig{Period: 1, Epoch: 30000}
+	engine := New(config.Clique, db)
+	engine.fakeDiff = true
+
+	blocks, _ := core.GenerateChain(&config, core.GenesisToBlock(genesis, db), engine, db, len(votes), func(j int, gen *core.BlockGen) {
+		gen.SetCoinbase(accounts.address(votes[j].voted))
+		if votes[j].auth {
+			var nonce types.BlockNonce
+			copy(nonce[:], nonceAuthVote)
+			gen.SetNonce(nonce)
+		}
+	})
+	for j, block := range blocks {
+		header := block.Header()
+		if j > 0 {
+			header.ParentHash = blocks[j-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		header.Difficulty = diffInTurn
+
+		accounts.sign(header, votes[j].signer)
+		blocks[j] = block.WithSeal(header)
+	}
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	changes := make(chan *SignerChange, 16)
+	sub := engine.SubscribeSignerChanges(changes)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	// Check the recorded votes, dropping one to ensure it's derived on demand
+	api := &API{chain: chain, clique: engine}
+	db.Delete(voteRecordKey(blocks[0].Hash()))
+
+	records, err := api.GetVotes(1, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to retrieve votes: %v", err)
+	}
+	want := []VoteRecord{
+		{Block: 1, Signer: accounts.address("A"), Counted: true, Votes: 1},
+		{Block: 3, Signer: accounts.address("A"), Counted: true, Votes: 1},
+		{Block: 4, Signer: accounts.address("B"), Counted: true, Votes: 2, Passed: true},
+		{Block: 5, Signer: accounts.address("C"), Counted: false, Votes: 0},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("vote count mismatch: have %d, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		want[i].Hash = blocks[want[i].Block-1].Hash()
+		want[i].Address, want[i].Authorize = accounts.address("C"), true
+		if *record != want[i] {
+			t.Errorf("vote %d: mismatch: have %+v, want %+v", i, record, want[i])
+		}
+	}
+	// Check the signer history and the emitted notifications
+	history, err := api.GetSignerHistory(accounts.address("C"))
+	if err != nil {
+		t.Fatalf("failed to retrieve signer history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("signer history length mismatch: have %d, want 1", len(history))
+	}
+	if change := history[0]; change.Block != 4 || change.Hash != blocks[3].Hash() || !change.Authorized || len(change.Voters) != 2 {
+		t.Errorf("signer change mismatch: have %+v", change)
+	}
+	var notified []*SignerChange
+	for len(changes) > 0 {
+		notified = append(notified, <-changes)
+	}
+	if len(notified) != len(signers)+1 {
+		t.Fatalf("signer notification count mismatch: have %d, want %d", len(notified), len(signers)+1)
+	}
+	for i, change := range notified[:len(signers)] {
+		if change.Address != signers[i] || change.Block != 0 {
+			t.Errorf("genesis notification %d mismatch: have %+v", i, change)
+		}
+	}
+	if change := notified[len(signers)]; change.Address != accounts.address("C") || change.Block != 4 {
+		t.Errorf("signer notification mismatch: have %+v", change)
+	}
+	history, _ = api.GetSignerHistory(accounts.address("A"))
+	if len(history) != 1 || history[0].Block != 0 || !history[0].Authorized {
+		t.Errorf("genesis signer history mismatch: have %+v", history)
+	}
+}
@@ -20,6 +20,7 @@ package consensus
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -129,3 +130,26 @@ type PoW interface {
 	// Hashrate returns the current mining hashrate of a PoW consensus engine.
 	Hashrate() float64
 }
+
+// SignerFn hashes and signs the data to be signed by a backing account.
+type SignerFn func(signer accounts.Account, mimeType string, message []byte) ([]byte, error)
+
+// Authorizer is a consensus engine sealing blocks with the key of a local
+// account, such as the proof-of-authority engines.
+type Authorizer interface {
+	Engine
+
+	// Authorize injects the account to seal blocks with, along with the function
+	// signing data with its key.
+	Authorize(signer common.Address, signFn SignerFn)
+}
+
+// Preserver is a consensus engine deciding whether the locally sealed blocks are
+// preferred over competing blocks of equal difficulty during reorgs. The local
+// blocks of engines not implementing it are preferred.
+type Preserver interface {
+	Engine
+
+	// PreserveLocalBlocks reports whether the locally sealed blocks are preferred.
+	PreserveLocalBlocks() bool
+}
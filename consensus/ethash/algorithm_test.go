@@ -56,6 +56,55 @@ func TestSizeCalculations(t *testing.T) {
 	}
 }
 
+// Tests that the epochs of ECIP-1099 are twice as long as the default ones after
+// the transition, and that their seeds and sizes follow the doubled length.
+func TestECIP1099Epochs(t *testing.T) {
+	fork := uint64(11_700_000)
+
+	tests := []struct {
+		block  uint64
+		length uint64
+		epoch  uint64
+		seed   uint64 // Epoch of the same seed with the default length
+	}{
+		{0, epochLengthDefault, 0, 0},
+		{29_999, epochLengthDefault, 0, 0},
+		{30_000, epochLengthDefault, 1, 1},
+		{11_699_999, epochLengthDefault, 389, 389},
+		{11_700_000, epochLengthECIP1099, 195, 390},
+		{11_729_999, epochLengthECIP1099, 195, 390},
+		{11_730_000, epochLengthECIP1099, 195, 390},
+		{11_759_999, epochLengthECIP1099, 195, 390},
+		{11_760_000, epochLengthECIP1099, 196, 392},
+	}
+	for i, tt := range tests {
+		length := calcEpochLength(tt.block, &fork)
+		if length != tt.length {
+			t.Errorf("test %d: epoch length mismatch: have %d, want %d", i, length, tt.length)
+		}
+		epoch := calcEpoch(tt.block, length)
+		if epoch != tt.epoch {
+			t.Errorf("test %d: epoch mismatch: have %d, want %d", i, epoch, tt.epoch)
+		}
+		if have, want := seedHash(epoch, length), seedHash(tt.seed, epochLengthDefault); !bytes.Equal(have, want) {
+			t.Errorf("test %d: seed mismatch: have %x, want %x", i, have, want)
+		}
+		if have, want := SeedHash(tt.block, &fork), seedHash(tt.seed, epochLengthDefault); !bytes.Equal(have, want) {
+			t.Errorf("test %d: exported seed mismatch: have %x, want %x", i, have, want)
+		}
+		if have, want := cacheSize(epoch), cacheSizes[tt.epoch]; have != want {
+			t.Errorf("test %d: cache size mismatch: have %d, want %d", i, have, want)
+		}
+		if have, want := datasetSize(epoch), datasetSizes[tt.epoch]; have != want {
+			t.Errorf("test %d: dataset size mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	// Without the transition, the default epoch length always applies
+	if length := calcEpochLength(20_000_000, nil); length != epochLengthDefault {
+		t.Errorf("epoch length mismatch without transition: have %d, want %d", length, epochLengthDefault)
+	}
+}
+
 // Tests that verification caches can be correctly generated.
 func TestCacheGeneration(t *testing.T) {
 	tests := []struct {
@@ -108,7 +157,7 @@ func TestCacheGeneration(t *testing.T) {
 	}
 	for i, tt := range tests {
 		cache := make([]uint32, tt.size/4)
-		generateCache(cache, tt.epoch, seedHash(tt.epoch*epochLength+1))
+		generateCache(cache, tt.epoch, seedHash(tt.epoch, epochLengthDefault))
 
 		want := make([]uint32, tt.size/4)
 		prepare(want, tt.cache)
@@ -648,7 +697,7 @@ func TestDatasetGeneration(t *testing.T) {
 	}
 	for i, tt := range tests {
 		cache := make([]uint32, tt.cacheSize/4)
-		generateCache(cache, tt.epoch, seedHash(tt.epoch*epochLength+1))
+		generateCache(cache, tt.epoch, seedHash(tt.epoch, epochLengthDefault))
 
 		dataset := make([]uint32, tt.datasetSize/4)
 		generateDataset(dataset, tt.epoch, cache)
@@ -729,7 +778,7 @@ func TestConcurrentDiskCacheGeneration(t *testing.T) {
 
 		go func(idx int) {
 			defer pend.Done()
-			ethash := New(Config{cachedir, 0, 1, false, "", 0, 0, false, ModeNormal, nil}, nil, false)
+			ethash := New(Config{cachedir, 0, 1, false, "", 0, 0, false, ModeNormal, nil, "", 0, nil}, nil, false)
 			defer ethash.Close()
 			if err := ethash.VerifySeal(nil, block.Header()); err != nil {
 				t.Errorf("proc %d: block verification failed: %v", idx, err)
@@ -742,7 +791,7 @@ func TestConcurrentDiskCacheGeneration(t *testing.T) {
 // Benchmarks the cache generation performance.
 func BenchmarkCacheGeneration(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		cache := make([]uint32, cacheSize(1)/4)
+		cache := make([]uint32, cacheSize(0)/4)
 		generateCache(cache, 0, make([]byte, 32))
 	}
 }
@@ -761,14 +810,14 @@ func BenchmarkSmallDatasetGeneration(b *testing.B) {
 
 // Benchmarks the light verification performance.
 func BenchmarkHashimotoLight(b *testing.B) {
-	cache := make([]uint32, cacheSize(1)/4)
+	cache := make([]uint32, cacheSize(0)/4)
 	generateCache(cache, 0, make([]byte, 32))
 
 	hash := hexutil.MustDecode("0xc9149cc0386e689d789a1c2f3d5d169a61a6218ed30e74414dc736e442ef3d1f")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		hashimotoLight(datasetSize(1), cache, hash, 0)
+		hashimotoLight(datasetSize(0), cache, hash, 0)
 	}
 }
 
@@ -18,9 +18,12 @@ package ethash
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -106,7 +109,76 @@ func (api *API) SubmitHashRate(rate hexutil.Uint64, id common.Hash) bool {
 	return true
 }
 
+// GetStratumWorkers returns the share accounting of the workers connected to the
+// stratum server, by worker name.
+func (api *API) GetStratumWorkers() (map[string]StratumWorker, error) {
+	workers := api.ethash.StratumWorkers()
+	if workers == nil {
+		return nil, errors.New("stratum server not running")
+	}
+	return workers, nil
+}
+
 // GetHashrate returns the current hashrate for local CPU miner and remote miner.
 func (api *API) GetHashrate() uint64 {
 	return uint64(api.ethash.Hashrate())
 }
+
+// maxProjectionRange is the maximum number of blocks a projection request may
+// span, and maxProjections the maximum number of blocks it may report. Every
+// block of the range is computed, so longer projections are left to echainspec.
+const (
+	maxProjectionRange = 100000
+	maxProjections     = 10000
+)
+
+// CalculatorAPI exposes the block reward and difficulty calculations of the
+// chain configuration for the RPC interface. It is not public, so it is only
+// served if the ethash namespace is enabled explicitly.
+type CalculatorAPI struct {
+	chain consensus.ChainHeaderReader
+}
+
+// ProjectionArgs represents the optional arguments of a block projection.
+type ProjectionArgs struct {
+	Step       *hexutil.Uint64 `json:"step"`       // Report every step-th block (default 1)
+	Difficulty *hexutil.Big    `json:"difficulty"` // Difficulty of the parent of the first block (default from the chain)
+	BlockTime  *hexutil.Uint64 `json:"blockTime"`  // Seconds between blocks (default DefaultProjectionBlockTime)
+}
+
+// ProjectBlocks computes the block rewards, uncle rewards, difficulty bomb delay
+// and projected difficulty of the blocks in the range [from, to] under the chain
+// configuration of the node.
+func (api *CalculatorAPI) ProjectBlocks(from, to hexutil.Uint64, args *ProjectionArgs) ([]*BlockProjection, error) {
+	if args == nil {
+		args = new(ProjectionArgs)
+	}
+	step := uint64(1)
+	if args.Step != nil && *args.Step > 0 {
+		step = uint64(*args.Step)
+	}
+	if to < from {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	if span := uint64(to - from); span >= maxProjectionRange {
+		return nil, fmt.Errorf("block range too large: %d > %d", span+1, maxProjectionRange)
+	} else if span/step >= maxProjections {
+		return nil, fmt.Errorf("too many projected blocks: %d > %d, increase the step", span/step+1, maxProjections)
+	}
+	blockTime := uint64(DefaultProjectionBlockTime)
+	if args.BlockTime != nil {
+		blockTime = uint64(*args.BlockTime)
+	}
+	var difficulty *big.Int
+	if args.Difficulty != nil {
+		difficulty = args.Difficulty.ToInt()
+	} else if from > 0 {
+		// Start from the actual parent if known, otherwise from the current head
+		parent := api.chain.GetHeaderByNumber(uint64(from) - 1)
+		if parent == nil {
+			parent = api.chain.CurrentHeader()
+		}
+		difficulty = parent.Difficulty
+	}
+	return ProjectBlocks(api.chain.Config(), uint64(from), uint64(to), step, difficulty, blockTime)
+}
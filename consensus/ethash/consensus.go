@@ -254,15 +254,25 @@ func (ethash *Ethash) verifyHeader(chain consensus.ChainHeaderReader, header, pa
 		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
 	}
 
-	// Verify that the gas limit remains within allowed bounds
-	diff := int64(parent.GasLimit) - int64(header.GasLimit)
-	if diff < 0 {
-		diff *= -1
-	}
-	limit := parent.GasLimit / vars.GasLimitBoundDivisor
+	// Verify the block's gas usage and (if applicable) verify the base fee.
+	if !chain.Config().IsEnabled(chain.Config().GetEIP1559Transition, header.Number) {
+		// Verify BaseFee not present before EIP-1559 fork.
+		if header.BaseFee != nil {
+			return fmt.Errorf("invalid baseFee before fork: have %d, expected 'nil'", header.BaseFee)
+		}
+		// Verify that the gas limit remains within allowed bounds
+		diff := int64(parent.GasLimit) - int64(header.GasLimit)
+		if diff < 0 {
+			diff *= -1
+		}
+		limit := parent.GasLimit / vars.GasLimitBoundDivisor
 
-	if uint64(diff) >= limit || header.GasLimit < vars.MinGasLimit {
-		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
+		if uint64(diff) >= limit || header.GasLimit < vars.MinGasLimit {
+			return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
+		}
+	} else if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		// Verify the header's EIP-1559 attributes.
+		return err
 	}
 	// Verify that the block number is parent's +1
 	if diff := new(big.Int).Sub(header.Number, parent.Number); diff.Cmp(big.NewInt(1)) != 0 {
@@ -357,9 +367,21 @@ func CalcDifficulty(config ctypes.ChainConfigurator, time uint64, parent *types.
 	// after adjustment and before bomb
 	out.Set(math.BigMax(out, vars.MinimumDifficulty))
 
-	if config.IsEnabled(config.GetEthashECIP1041Transition, next) {
+	exPeriodRef := explosionPeriodRef(config, parent)
+	if exPeriodRef == nil {
 		return out
 	}
+	return out.Add(out, explosion(exPeriodRef))
+}
+
+// explosionPeriodRef returns the (possibly delayed) block number the difficulty
+// bomb of the block following parent is computed from, or nil if the bomb has
+// been defused.
+func explosionPeriodRef(config ctypes.ChainConfigurator, parent *types.Header) *big.Int {
+	next := new(big.Int).Add(parent.Number, big1)
+	if config.IsEnabled(config.GetEthashECIP1041Transition, next) {
+		return nil
+	}
 
 	// EXPLOSION delays
 
@@ -425,8 +447,12 @@ func CalcDifficulty(config ctypes.ChainConfigurator, time uint64, parent *types.
 
 	}
 
-	// EXPLOSION
+	return exPeriodRef
+}
 
+// explosion returns the difficulty added by the bomb for the given period
+// reference block number.
+func explosion(exPeriodRef *big.Int) *big.Int {
 	// the 'periodRef' (from above) represents the many ways of hackishly modifying the reference number
 	// (ie the 'currentBlock') in order to lie to the function about what time it really is
 	//
@@ -440,8 +466,7 @@ func CalcDifficulty(config ctypes.ChainConfigurator, time uint64, parent *types.
 	} else {
 		x.SetUint64(0)
 	}
-	out.Add(out, x)
-	return out
+	return x
 }
 
 // Some weird constants to avoid constant memory allocs for them.
@@ -502,7 +527,7 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 	if !fulldag {
 		cache := ethash.cache(number)
 
-		size := datasetSize(number)
+		size := datasetSize(cache.epoch)
 		if ethash.config.PowMode == ModeTest {
 			size = 32 * 1024
 		}
@@ -557,7 +582,7 @@ func (ethash *Ethash) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 func (ethash *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
 
-	rlp.Encode(hasher, []interface{}{
+	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -571,7 +596,11 @@ func (ethash *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 		header.GasUsed,
 		header.Time,
 		header.Extra,
-	})
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	rlp.Encode(hasher, enc)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -586,27 +615,36 @@ var (
 // reward. The total reward consists of the static block reward and rewards for
 // included uncles. The coinbase of each uncle block is also rewarded.
 func accumulateRewards(config ctypes.ChainConfigurator, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	minerReward, uncleRewards := GetRewards(config, header, uncles)
+	for i, uncle := range uncles {
+		state.AddBalance(uncle.Coinbase, uncleRewards[i])
+	}
+	state.AddBalance(header.Coinbase, minerReward)
+}
+
+// GetRewards calculates the mining reward of the given block: the reward of its
+// coinbase, including the rewards for included uncles, and the reward of the
+// coinbase of each uncle.
+func GetRewards(config ctypes.ChainConfigurator, header *types.Header, uncles []*types.Header) (*big.Int, []*big.Int) {
 	if config.IsEnabled(config.GetEthashECIP1017Transition, header.Number) {
-		ecip1017BlockReward(config, state, header, uncles)
-		return
+		return ecip1017BlockReward(config, header, uncles)
 	}
 
 	blockReward := ctypes.EthashBlockReward(config, header.Number)
 
 	// Accumulate the rewards for the miner and any included uncles
 	reward := new(big.Int).Set(blockReward)
-	r := new(big.Int)
-	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
+	uncleRewards := make([]*big.Int, len(uncles))
+	for i, uncle := range uncles {
+		r := new(big.Int).Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
+		uncleRewards[i] = r
 
-		r.Div(blockReward, big32)
-		reward.Add(reward, r)
+		reward.Add(reward, new(big.Int).Div(blockReward, big32))
 	}
-	state.AddBalance(header.Coinbase, reward)
+	return reward, uncleRewards
 }
 
 // As of "Era 2" (zero-index era 1), uncle miners and winners are rewarded equally for each included block.
@@ -48,7 +48,7 @@ var (
 	two256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
 
 	// sharedEthash is a full instance that can be shared between multiple users.
-	sharedEthash = New(Config{"", 3, 0, false, "", 1, 0, false, ModeNormal, nil}, nil, false)
+	sharedEthash = New(Config{"", 3, 0, false, "", 1, 0, false, ModeNormal, nil, "", 0, nil}, nil, false)
 
 	// algorithmRevision is the data structure version used for file naming.
 	algorithmRevision = 23
@@ -155,75 +155,121 @@ func memoryMapAndGenerate(path string, size uint64, lock bool, generator func(bu
 // lru tracks caches or datasets by their last use time, keeping at most N of them.
 type lru struct {
 	what string
-	new  func(epoch uint64) interface{}
+	new  func(epoch uint64, epochLength uint64) interface{}
 	mu   sync.Mutex
 	// Items are kept in a LRU cache, but there is a special case:
 	// We always keep an item for (highest seen epoch) + 1 as the 'future item'.
 	cache      *simplelru.LRU
-	future     uint64
+	future     epochKey
 	futureItem interface{}
+
+	ecip1099FBlock *uint64 // Block number at which the epoch length doubles, if any
+}
+
+// epochKey identifies the epoch of an item. Epochs of different lengths are
+// distinct, even if they share their number.
+type epochKey struct {
+	epoch  uint64
+	length uint64
 }
 
 // newlru create a new least-recently-used cache for either the verification caches
 // or the mining datasets.
-func newlru(what string, maxItems int, new func(epoch uint64) interface{}) *lru {
+func newlru(what string, maxItems int, new func(epoch uint64, epochLength uint64) interface{}, ecip1099FBlock *uint64) *lru {
 	if maxItems <= 0 {
 		maxItems = 1
 	}
 	cache, _ := simplelru.NewLRU(maxItems, func(key, value interface{}) {
-		log.Trace("Evicted ethash "+what, "epoch", key)
+		log.Trace("Evicted ethash "+what, "epoch", key.(epochKey).epoch, "length", key.(epochKey).length)
 	})
-	return &lru{what: what, new: new, cache: cache}
+	return &lru{what: what, new: new, cache: cache, ecip1099FBlock: ecip1099FBlock}
 }
 
 // get retrieves or creates an item for the given epoch. The first return value is always
 // non-nil. The second return value is non-nil if lru thinks that an item will be useful in
 // the near future.
-func (lru *lru) get(epoch uint64) (item, future interface{}) {
+func (lru *lru) get(epoch uint64, epochLength uint64) (item, future interface{}) {
 	lru.mu.Lock()
 	defer lru.mu.Unlock()
 
 	// Get or create the item for the requested epoch.
-	item, ok := lru.cache.Get(epoch)
+	key := epochKey{epoch, epochLength}
+	item, ok := lru.cache.Get(key)
 	if !ok {
-		if lru.future > 0 && lru.future == epoch {
+		if lru.futureItem != nil && lru.future == key {
 			item = lru.futureItem
 		} else {
-			log.Trace("Requiring new ethash "+lru.what, "epoch", epoch)
-			item = lru.new(epoch)
+			log.Trace("Requiring new ethash "+lru.what, "epoch", epoch, "length", epochLength)
+			item = lru.new(epoch, epochLength)
 		}
-		lru.cache.Add(epoch, item)
-	}
-	// Update the 'future item' if epoch is larger than previously seen.
-	if epoch < maxEpoch-1 && lru.future < epoch+1 {
-		log.Trace("Requiring new future ethash "+lru.what, "epoch", epoch+1)
-		future = lru.new(epoch + 1)
-		lru.future = epoch + 1
+		lru.cache.Add(key, item)
+	}
+	// Update the 'future item' if epoch is later than previously seen. The next
+	// epoch may be of a different length if it crosses the ECIP-1099 transition.
+	nextBlock := (epoch + 1) * epochLength
+	nextLength := calcEpochLength(nextBlock, lru.ecip1099FBlock)
+	next := epochKey{calcEpoch(nextBlock, nextLength), nextLength}
+
+	if next.epoch < maxEpoch && (lru.futureItem == nil || calcEpochBlock(lru.future.epoch, lru.future.length) < calcEpochBlock(next.epoch, next.length)) {
+		log.Trace("Requiring new future ethash "+lru.what, "epoch", next.epoch, "length", next.length)
+		future = lru.new(next.epoch, next.length)
+		lru.future = next
 		lru.futureItem = future
 	}
 	return item, future
 }
 
+// ethashFile returns the name of the file storing the cache or dataset (kind) of
+// an epoch. Epochs of the ECIP-1099 length share their seed with the default
+// length epoch starting at the same block, so their length is part of the name.
+func ethashFile(kind string, epoch uint64, epochLength uint64) string {
+	var endian string
+	if !isLittleEndian() {
+		endian = ".be"
+	}
+	seed := seedHash(epoch, epochLength)
+	if epochLength != epochLengthDefault {
+		return fmt.Sprintf("%s-R%d-%d-%x%s", kind, algorithmRevision, epochLength, seed[:8], endian)
+	}
+	return fmt.Sprintf("%s-R%d-%x%s", kind, algorithmRevision, seed[:8], endian)
+}
+
+// removeOld deletes the files of kind stored for the epochs at least limit
+// epochs older than the given one, including the default length epochs preceding
+// an ECIP-1099 one.
+func removeOld(dir string, kind string, epoch uint64, epochLength uint64, limit int) {
+	for ep := int(epoch) - limit; ep >= 0; ep-- {
+		os.Remove(filepath.Join(dir, ethashFile(kind, uint64(ep), epochLength)))
+	}
+	if epochLength != epochLengthDefault {
+		ratio := int(epochLength / epochLengthDefault)
+		for ep := (int(epoch)-limit+1)*ratio - 1; ep >= 0; ep-- {
+			os.Remove(filepath.Join(dir, ethashFile(kind, uint64(ep), epochLengthDefault)))
+		}
+	}
+}
+
 // cache wraps an ethash cache with some metadata to allow easier concurrent use.
 type cache struct {
-	epoch uint64    // Epoch for which this cache is relevant
-	dump  *os.File  // File descriptor of the memory mapped cache
-	mmap  mmap.MMap // Memory map itself to unmap before releasing
-	cache []uint32  // The actual cache data content (may be memory mapped)
-	once  sync.Once // Ensures the cache is generated only once
+	epoch       uint64    // Epoch for which this cache is relevant
+	epochLength uint64    // Epoch length (ECIP-1099)
+	dump        *os.File  // File descriptor of the memory mapped cache
+	mmap        mmap.MMap // Memory map itself to unmap before releasing
+	cache       []uint32  // The actual cache data content (may be memory mapped)
+	once        sync.Once // Ensures the cache is generated only once
 }
 
 // newCache creates a new ethash verification cache and returns it as a plain Go
 // interface to be usable in an LRU cache.
-func newCache(epoch uint64) interface{} {
-	return &cache{epoch: epoch}
+func newCache(epoch uint64, epochLength uint64) interface{} {
+	return &cache{epoch: epoch, epochLength: epochLength}
 }
 
 // generate ensures that the cache content is generated before use.
 func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 	c.once.Do(func() {
-		size := cacheSize(c.epoch*epochLength + 1)
-		seed := seedHash(c.epoch*epochLength + 1)
+		size := cacheSize(c.epoch)
+		seed := seedHash(c.epoch, c.epochLength)
 		if test {
 			size = 1024
 		}
@@ -234,12 +280,8 @@ func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 			return
 		}
 		// Disk storage is needed, this will get fancy
-		var endian string
-		if !isLittleEndian() {
-			endian = ".be"
-		}
-		path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))
-		logger := log.New("epoch", c.epoch)
+		path := filepath.Join(dir, ethashFile("cache", c.epoch, c.epochLength))
+		logger := log.New("epoch", c.epoch, "length", c.epochLength)
 
 		// We're about to mmap the file, ensure that the mapping is cleaned up when the
 		// cache becomes unused.
@@ -263,11 +305,7 @@ func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 			generateCache(c.cache, c.epoch, seed)
 		}
 		// Iterate over all previous instances and delete old ones
-		for ep := int(c.epoch) - limit; ep >= 0; ep-- {
-			seed := seedHash(uint64(ep)*epochLength + 1)
-			path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))
-			os.Remove(path)
-		}
+		removeOld(dir, "cache", c.epoch, c.epochLength, limit)
 	})
 }
 
@@ -282,18 +320,19 @@ func (c *cache) finalizer() {
 
 // dataset wraps an ethash dataset with some metadata to allow easier concurrent use.
 type dataset struct {
-	epoch   uint64    // Epoch for which this cache is relevant
-	dump    *os.File  // File descriptor of the memory mapped cache
-	mmap    mmap.MMap // Memory map itself to unmap before releasing
-	dataset []uint32  // The actual cache data content
-	once    sync.Once // Ensures the cache is generated only once
-	done    uint32    // Atomic flag to determine generation status
+	epoch       uint64    // Epoch for which this cache is relevant
+	epochLength uint64    // Epoch length (ECIP-1099)
+	dump        *os.File  // File descriptor of the memory mapped cache
+	mmap        mmap.MMap // Memory map itself to unmap before releasing
+	dataset     []uint32  // The actual cache data content
+	once        sync.Once // Ensures the cache is generated only once
+	done        uint32    // Atomic flag to determine generation status
 }
 
 // newDataset creates a new ethash mining dataset and returns it as a plain Go
 // interface to be usable in an LRU cache.
-func newDataset(epoch uint64) interface{} {
-	return &dataset{epoch: epoch}
+func newDataset(epoch uint64, epochLength uint64) interface{} {
+	return &dataset{epoch: epoch, epochLength: epochLength}
 }
 
 // generate ensures that the dataset content is generated before use.
@@ -302,9 +341,9 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 		// Mark the dataset generated after we're done. This is needed for remote
 		defer atomic.StoreUint32(&d.done, 1)
 
-		csize := cacheSize(d.epoch*epochLength + 1)
-		dsize := datasetSize(d.epoch*epochLength + 1)
-		seed := seedHash(d.epoch*epochLength + 1)
+		csize := cacheSize(d.epoch)
+		dsize := datasetSize(d.epoch)
+		seed := seedHash(d.epoch, d.epochLength)
 		if test {
 			csize = 1024
 			dsize = 32 * 1024
@@ -320,12 +359,8 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 			return
 		}
 		// Disk storage is needed, this will get fancy
-		var endian string
-		if !isLittleEndian() {
-			endian = ".be"
-		}
-		path := filepath.Join(dir, fmt.Sprintf("full-R%d-%x%s", algorithmRevision, seed[:8], endian))
-		logger := log.New("epoch", d.epoch)
+		path := filepath.Join(dir, ethashFile("full", d.epoch, d.epochLength))
+		logger := log.New("epoch", d.epoch, "length", d.epochLength)
 
 		// We're about to mmap the file, ensure that the mapping is cleaned up when the
 		// cache becomes unused.
@@ -352,11 +387,7 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 			generateDataset(d.dataset, d.epoch, cache)
 		}
 		// Iterate over all previous instances and delete old ones
-		for ep := int(d.epoch) - limit; ep >= 0; ep-- {
-			seed := seedHash(uint64(ep)*epochLength + 1)
-			path := filepath.Join(dir, fmt.Sprintf("full-R%d-%x%s", algorithmRevision, seed[:8], endian))
-			os.Remove(path)
-		}
+		removeOld(dir, "full", d.epoch, d.epochLength, limit)
 	})
 }
 
@@ -376,15 +407,20 @@ func (d *dataset) finalizer() {
 	}
 }
 
-// MakeCache generates a new ethash cache and optionally stores it to disk.
-func MakeCache(block uint64, dir string) {
-	c := cache{epoch: block / epochLength}
+// MakeCache generates a new ethash cache and optionally stores it to disk. The
+// ECIP-1099 transition block of the chain, if any, determines the epoch length.
+func MakeCache(block uint64, ecip1099FBlock *uint64, dir string) {
+	epochLength := calcEpochLength(block, ecip1099FBlock)
+	c := cache{epoch: calcEpoch(block, epochLength), epochLength: epochLength}
 	c.generate(dir, math.MaxInt32, false, false)
 }
 
 // MakeDataset generates a new ethash dataset and optionally stores it to disk.
-func MakeDataset(block uint64, dir string) {
-	d := dataset{epoch: block / epochLength}
+// The ECIP-1099 transition block of the chain, if any, determines the epoch
+// length.
+func MakeDataset(block uint64, ecip1099FBlock *uint64, dir string) {
+	epochLength := calcEpochLength(block, ecip1099FBlock)
+	d := dataset{epoch: calcEpoch(block, epochLength), epochLength: epochLength}
 	d.generate(dir, math.MaxInt32, false, false)
 }
 
@@ -411,6 +447,20 @@ type Config struct {
 	DatasetsLockMmap bool
 	PowMode          Mode
 
+	// ECIP1099Block is the block number at which the epoch length doubles
+	// (ECIP-1099), nil if never.
+	ECIP1099Block *uint64 `toml:",omitempty"`
+
+	// StratumAddr is the TCP address to serve the work of the remote sealer on
+	// with the stratum protocol, disabled if empty.
+	StratumAddr string `toml:",omitempty"`
+
+	// StratumDifficulty is the share difficulty of the NiceHash stratum miners,
+	// in units of 2^32 hashes. Shares meeting it are accounted to their worker,
+	// but only the ones meeting the block target are submitted. Zero, or above
+	// the block difficulty, uses the block difficulty.
+	StratumDifficulty float64 `toml:",omitempty"`
+
 	Log log.Logger `toml:"-"`
 }
 
@@ -457,8 +507,8 @@ func New(config Config, notify []string, noverify bool) *Ethash {
 	}
 	ethash := &Ethash{
 		config:   config,
-		caches:   newlru("cache", config.CachesInMem, newCache),
-		datasets: newlru("dataset", config.DatasetsInMem, newDataset),
+		caches:   newlru("cache", config.CachesInMem, newCache, config.ECIP1099Block),
+		datasets: newlru("dataset", config.DatasetsInMem, newDataset, config.ECIP1099Block),
 		update:   make(chan struct{}),
 		hashrate: metrics.NewMeterForced(),
 	}
@@ -466,13 +516,22 @@ func New(config Config, notify []string, noverify bool) *Ethash {
 	return ethash
 }
 
+// StratumError returns the error the stratum server failed to start with, or
+// nil if it is running or disabled.
+func (ethash *Ethash) StratumError() error {
+	if ethash.remote == nil {
+		return nil
+	}
+	return ethash.remote.stratumErr
+}
+
 // NewTester creates a small sized ethash PoW scheme useful only for testing
 // purposes.
 func NewTester(notify []string, noverify bool) *Ethash {
 	ethash := &Ethash{
 		config:   Config{PowMode: ModeTest, Log: log.Root()},
-		caches:   newlru("cache", 1, newCache),
-		datasets: newlru("dataset", 1, newDataset),
+		caches:   newlru("cache", 1, newCache, nil),
+		datasets: newlru("dataset", 1, newDataset, nil),
 		update:   make(chan struct{}),
 		hashrate: metrics.NewMeterForced(),
 	}
@@ -553,8 +612,8 @@ func (ethash *Ethash) Close() error {
 // by first checking against a list of in-memory caches, then against caches
 // stored on disk, and finally generating one if none can be found.
 func (ethash *Ethash) cache(block uint64) *cache {
-	epoch := block / epochLength
-	currentI, futureI := ethash.caches.get(epoch)
+	epochLength := calcEpochLength(block, ethash.config.ECIP1099Block)
+	currentI, futureI := ethash.caches.get(calcEpoch(block, epochLength), epochLength)
 	current := currentI.(*cache)
 
 	// Wait for generation finish.
@@ -576,8 +635,8 @@ func (ethash *Ethash) cache(block uint64) *cache {
 // generates on a background thread.
 func (ethash *Ethash) dataset(block uint64, async bool) *dataset {
 	// Retrieve the requested ethash dataset
-	epoch := block / epochLength
-	currentI, futureI := ethash.datasets.get(epoch)
+	epochLength := calcEpochLength(block, ethash.config.ECIP1099Block)
+	currentI, futureI := ethash.datasets.get(calcEpoch(block, epochLength), epochLength)
 	current := currentI.(*dataset)
 
 	// If async is specified, generate everything in a background thread
@@ -672,11 +731,19 @@ func (ethash *Ethash) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 			Service:   &API{ethash},
 			Public:    true,
 		},
+		{
+			Namespace: "ethash",
+			Version:   "1.0",
+			Service:   &CalculatorAPI{chain},
+			Public:    false,
+		},
 	}
 }
 
 // SeedHash is the seed to use for generating a verification cache and the mining
-// dataset.
-func SeedHash(block uint64) []byte {
-	return seedHash(block)
+// dataset. The ECIP-1099 transition block of the chain, if any, determines the
+// epoch length.
+func SeedHash(block uint64, ecip1099FBlock *uint64) []byte {
+	epochLength := calcEpochLength(block, ecip1099FBlock)
+	return seedHash(calcEpoch(block, epochLength), epochLength)
 }
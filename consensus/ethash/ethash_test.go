@@ -78,10 +78,10 @@ func TestCacheFileEvict(t *testing.T) {
 func verifyTest(wg *sync.WaitGroup, e *Ethash, workerIndex, epochs int) {
 	defer wg.Done()
 
-	const wiggle = 4 * epochLength
+	const wiggle = 4 * epochLengthDefault
 	r := rand.New(rand.NewSource(int64(workerIndex)))
 	for epoch := 0; epoch < epochs; epoch++ {
-		block := int64(epoch)*epochLength - wiggle/2 + r.Int63n(wiggle)
+		block := int64(epoch)*epochLengthDefault - wiggle/2 + r.Int63n(wiggle)
 		if block < 0 {
 			block = 0
 		}
@@ -90,6 +90,30 @@ func verifyTest(wg *sync.WaitGroup, e *Ethash, workerIndex, epochs int) {
 	}
 }
 
+// Tests that the future item of the lru crosses the ECIP-1099 transition into
+// the first epoch of the doubled length.
+func TestLRUFutureECIP1099(t *testing.T) {
+	fork := uint64(4 * epochLengthDefault)
+	lru := newlru("cache", 3, newCache, &fork)
+
+	_, future := lru.get(2, epochLengthDefault)
+	if c := future.(*cache); c.epoch != 3 || c.epochLength != epochLengthDefault {
+		t.Fatalf("future mismatch: have epoch %d length %d, want epoch 3 length %d", c.epoch, c.epochLength, epochLengthDefault)
+	}
+	_, transition := lru.get(3, epochLengthDefault)
+	if c := transition.(*cache); c.epoch != 2 || c.epochLength != epochLengthECIP1099 {
+		t.Fatalf("future mismatch: have epoch %d length %d, want epoch 2 length %d", c.epoch, c.epochLength, epochLengthECIP1099)
+	}
+	// The future item must be reused once its epoch is requested
+	item, future := lru.get(2, epochLengthECIP1099)
+	if item != transition {
+		t.Fatalf("future item not reused")
+	}
+	if c := future.(*cache); c.epoch != 3 || c.epochLength != epochLengthECIP1099 {
+		t.Fatalf("future mismatch: have epoch %d length %d, want epoch 3 length %d", c.epoch, c.epochLength, epochLengthECIP1099)
+	}
+}
+
 func TestRemoteSealer(t *testing.T) {
 	ethash := NewTester(nil, false)
 	defer ethash.Close()
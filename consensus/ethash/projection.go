@@ -0,0 +1,113 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+// DefaultProjectionBlockTime is the number of seconds assumed between blocks
+// when projecting difficulties, if not specified otherwise.
+const DefaultProjectionBlockTime = 13
+
+var errProjectGenesis = errors.New("genesis block cannot be projected")
+
+// BlockProjection is the issuance and difficulty expected for a block under a
+// chain configuration, assuming a steady block time and no uncles.
+type BlockProjection struct {
+	Number               hexutil.Uint64 `json:"number"`
+	Era                  *hexutil.Big   `json:"era,omitempty"`        // Zero-indexed ECIP-1017 era, if enabled
+	BlockReward          *hexutil.Big   `json:"blockReward"`          // Reward of the block miner, excluding uncle inclusion rewards
+	UncleInclusionReward *hexutil.Big   `json:"uncleInclusionReward"` // Additional reward of the block miner for each included uncle
+	UncleReward          *hexutil.Big   `json:"uncleReward"`          // Reward of the miner of an uncle one block deep
+	BombDelay            *hexutil.Big   `json:"bombDelay"`            // Number of blocks the difficulty bomb is delayed by, nil if defused
+	Bomb                 *hexutil.Big   `json:"bomb"`                 // Difficulty added by the difficulty bomb
+	Difficulty           *hexutil.Big   `json:"difficulty"`           // Projected difficulty of the block
+}
+
+// ProjectBlocks computes the projections of the blocks in the range [from, to]
+// under the given chain configuration, reporting every step-th block. The
+// difficulty is the one of the parent of the first block, and the blocks are
+// assumed to be mined blockTime seconds apart.
+func ProjectBlocks(config ctypes.ChainConfigurator, from, to, step uint64, difficulty *big.Int, blockTime uint64) ([]*BlockProjection, error) {
+	if from == 0 {
+		return nil, errProjectGenesis
+	}
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	if step == 0 {
+		step = 1
+	}
+	var (
+		projections []*BlockProjection
+		parent      = &types.Header{
+			Number:     new(big.Int).SetUint64(from - 1),
+			Difficulty: new(big.Int).Set(difficulty),
+			UncleHash:  types.EmptyUncleHash,
+		}
+	)
+	for number := from; number <= to; number++ {
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Time:       parent.Time + blockTime,
+			UncleHash:  types.EmptyUncleHash,
+			Difficulty: CalcDifficulty(config, parent.Time+blockTime, parent),
+		}
+		if (number-from)%step == 0 {
+			projections = append(projections, projectBlock(config, parent, header))
+		}
+		parent = header
+
+		if number == to { // Avoid overflowing at the end of the uint64 range
+			break
+		}
+	}
+	return projections, nil
+}
+
+// projectBlock assembles the rewards and the difficulty components of a single
+// block from its projected header.
+func projectBlock(config ctypes.ChainConfigurator, parent, header *types.Header) *BlockProjection {
+	projection := &BlockProjection{
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Difficulty: (*hexutil.Big)(header.Difficulty),
+		Bomb:       new(hexutil.Big),
+	}
+	if config.IsEnabled(config.GetEthashECIP1017Transition, header.Number) {
+		eraLen := new(big.Int).SetUint64(*config.GetEthashECIP1017EraRounds())
+		projection.Era = (*hexutil.Big)(GetBlockEra(header.Number, eraLen))
+	}
+	reward, _ := GetRewards(config, header, nil)
+	projection.BlockReward = (*hexutil.Big)(reward)
+
+	uncle := &types.Header{Number: new(big.Int).Set(parent.Number)}
+	withUncle, uncleRewards := GetRewards(config, header, []*types.Header{uncle})
+	projection.UncleInclusionReward = (*hexutil.Big)(withUncle.Sub(withUncle, reward))
+	projection.UncleReward = (*hexutil.Big)(uncleRewards[0])
+
+	if ref := explosionPeriodRef(config, parent); ref != nil {
+		projection.BombDelay = (*hexutil.Big)(new(big.Int).Sub(header.Number, ref))
+		projection.Bomb = (*hexutil.Big)(explosion(ref))
+	}
+	return projection
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that block projections report the ECIP-1017 era rewards and the bomb
+// delays of the configuration.
+func TestProjectBlocks(t *testing.T) {
+	ether := big.NewInt(1e18)
+
+	tests := []struct {
+		name   string
+		number uint64
+		era    *big.Int
+		reward *big.Int
+		uncle  *big.Int
+		delay  *big.Int
+	}{
+		{
+			name:   "classic era 1",
+			number: 4999999,
+			era:    nil,
+			reward: new(big.Int).Mul(big.NewInt(5), ether),
+			uncle:  new(big.Int).Div(new(big.Int).Mul(big.NewInt(35), ether), big.NewInt(8)),
+			delay:  big.NewInt(4999999 - 3000000),
+		},
+		{
+			name:   "classic era 2",
+			number: 5000001,
+			era:    big.NewInt(1),
+			reward: new(big.Int).Mul(big.NewInt(4), ether),
+			uncle:  new(big.Int).Div(new(big.Int).Mul(big.NewInt(4), ether), big.NewInt(32)),
+			delay:  big.NewInt(2000000),
+		},
+		{
+			name:   "classic era 3",
+			number: 10000001,
+			era:    big.NewInt(2),
+			reward: new(big.Int).Div(new(big.Int).Mul(big.NewInt(32), ether), big.NewInt(10)),
+			uncle:  new(big.Int).Div(new(big.Int).Mul(big.NewInt(32), ether), big.NewInt(320)),
+			delay:  nil, // Defused by ECIP-1041
+		},
+	}
+	for _, tt := range tests {
+		projections, err := ProjectBlocks(params.ClassicChainConfig, tt.number, tt.number, 1, big.NewInt(1e15), 13)
+		if err != nil {
+			t.Fatalf("%s: projection failed: %v", tt.name, err)
+		}
+		if len(projections) != 1 {
+			t.Fatalf("%s: projection count mismatch: have %d, want 1", tt.name, len(projections))
+		}
+		p := projections[0]
+		if (p.Era == nil) != (tt.era == nil) || (tt.era != nil && p.Era.ToInt().Cmp(tt.era) != 0) {
+			t.Errorf("%s: era mismatch: have %v, want %v", tt.name, p.Era, tt.era)
+		}
+		if p.BlockReward.ToInt().Cmp(tt.reward) != 0 {
+			t.Errorf("%s: block reward mismatch: have %v, want %v", tt.name, p.BlockReward.ToInt(), tt.reward)
+		}
+		if p.UncleReward.ToInt().Cmp(tt.uncle) != 0 {
+			t.Errorf("%s: uncle reward mismatch: have %v, want %v", tt.name, p.UncleReward.ToInt(), tt.uncle)
+		}
+		if (p.BombDelay == nil) != (tt.delay == nil) || (tt.delay != nil && p.BombDelay.ToInt().Cmp(tt.delay) != 0) {
+			t.Errorf("%s: bomb delay mismatch: have %v, want %v", tt.name, p.BombDelay, tt.delay)
+		}
+	}
+}
+
+// Tests that projected difficulties follow the difficulty adjustment algorithm
+// and that sampling with a step reports the same values.
+func TestProjectBlocksDifficulty(t *testing.T) {
+	config := params.MainnetChainConfig
+
+	all, err := ProjectBlocks(config, 9200000, 9200009, 1, big.NewInt(2e15), 20)
+	if err != nil {
+		t.Fatalf("projection failed: %v", err)
+	}
+	sampled, err := ProjectBlocks(config, 9200000, 9200009, 3, big.NewInt(2e15), 20)
+	if err != nil {
+		t.Fatalf("sampled projection failed: %v", err)
+	}
+	if len(all) != 10 || len(sampled) != 4 {
+		t.Fatalf("projection count mismatch: have %d/%d, want 10/4", len(all), len(sampled))
+	}
+	parent := &types.Header{Number: big.NewInt(9199999), Difficulty: big.NewInt(2e15), UncleHash: types.EmptyUncleHash}
+	for i, p := range all {
+		want := CalcDifficulty(config, parent.Time+20, parent)
+		if p.Difficulty.ToInt().Cmp(want) != 0 {
+			t.Errorf("block %d: difficulty mismatch: have %v, want %v", p.Number, p.Difficulty.ToInt(), want)
+		}
+		if i%3 == 0 && sampled[i/3].Difficulty.ToInt().Cmp(want) != 0 {
+			t.Errorf("block %d: sampled difficulty mismatch: have %v, want %v", p.Number, sampled[i/3].Difficulty.ToInt(), want)
+		}
+		if p.BombDelay.ToInt().Uint64() != 9000000 {
+			t.Errorf("block %d: bomb delay mismatch: have %v, want %d", p.Number, p.BombDelay.ToInt(), 9000000)
+		}
+		parent = &types.Header{Number: new(big.Int).SetUint64(uint64(p.Number)), Difficulty: want, Time: parent.Time + 20, UncleHash: types.EmptyUncleHash}
+	}
+	if _, err := ProjectBlocks(config, 0, 10, 1, big.NewInt(1), 13); err != errProjectGenesis {
+		t.Errorf("genesis projection error mismatch: have %v, want %v", err, errProjectGenesis)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	crand "crypto/rand"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"math/rand"
@@ -209,6 +210,9 @@ type remoteSealer struct {
 	submitRateCh chan *hashrate   // Channel used for remote sealer to submit their mining hashrate
 	requestExit  chan struct{}
 	exitCh       chan struct{}
+
+	stratum    *stratumServer // Stratum server pushing work to miners, nil if disabled
+	stratumErr error          // Error starting the stratum server, if it failed
 }
 
 // sealTask wraps a seal block with relative result channel for remote sealer thread.
@@ -259,6 +263,13 @@ func startRemoteSealer(ethash *Ethash, urls []string, noverify bool) *remoteSeal
 		requestExit:  make(chan struct{}),
 		exitCh:       make(chan struct{}),
 	}
+	if addr := ethash.config.StratumAddr; addr != "" {
+		server, err := startStratumServer(s, addr)
+		if err != nil {
+			s.stratumErr = fmt.Errorf("failed to start stratum server on %s: %v", addr, err)
+		}
+		s.stratum = server
+	}
 	go s.loop()
 	return s
 }
@@ -268,6 +279,9 @@ func (s *remoteSealer) loop() {
 		s.ethash.config.Log.Trace("Ethash remote sealer is exiting")
 		s.cancelNotify()
 		s.reqWG.Wait()
+		if s.stratum != nil {
+			s.stratum.close()
+		}
 		close(s.exitCh)
 	}()
 
@@ -282,6 +296,9 @@ func (s *remoteSealer) loop() {
 			s.results = work.results
 			s.makeWork(work.block)
 			s.notifyWork()
+			if s.stratum != nil {
+				s.stratum.notify(s.currentWork, work.block.NumberU64())
+			}
 
 		case work := <-s.fetchWorkCh:
 			// Return current mining work to remote miner.
@@ -345,7 +362,7 @@ func (s *remoteSealer) loop() {
 func (s *remoteSealer) makeWork(block *types.Block) {
 	hash := s.ethash.SealHash(block.Header())
 	s.currentWork[0] = hash.Hex()
-	s.currentWork[1] = common.BytesToHash(SeedHash(block.NumberU64())).Hex()
+	s.currentWork[1] = common.BytesToHash(SeedHash(block.NumberU64(), s.ethash.config.ECIP1099Block)).Hex()
 	s.currentWork[2] = common.BytesToHash(new(big.Int).Div(two256, block.Difficulty()).Bytes()).Hex()
 	s.currentWork[3] = hexutil.EncodeBig(block.Number())
 
@@ -0,0 +1,733 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	stratumReadTimeout    = 10 * time.Minute // Maximum time between two requests of a miner
+	stratumWriteTimeout   = 10 * time.Second // Maximum time to deliver a message to a miner
+	stratumMaxLineLength  = 16 * 1024        // Maximum length of a request, in bytes
+	stratumExtranonceSize = 2                // Bytes of the nonce assigned by the server to NiceHash miners
+)
+
+// Dialects of the stratum protocol served, picked for each connection by the
+// first request of the miner.
+const (
+	// stratumUnknown is the dialect of a connection before its first request.
+	stratumUnknown = iota
+
+	// stratumEthProxy is the EthereumStratum/1.0 dialect of eth-proxy, wrapping
+	// the eth_getWork/eth_submitWork methods, after a eth_submitLogin. New work
+	// is pushed as an unsolicited eth_getWork result.
+	stratumEthProxy
+
+	// stratumNiceHash is the NiceHash EthereumStratum/1.0.0 dialect, where the
+	// server assigns an extranonce prefix to each miner and pushes jobs with
+	// mining.notify, after a mining.subscribe and a mining.authorize.
+	stratumNiceHash
+)
+
+var (
+	errStratumNotLoggedIn   = errors.New("not logged in")
+	errStratumDialect       = errors.New("method not supported by the stratum dialect in use")
+	errStratumNotSubscribed = errors.New("not subscribed")
+	errStratumExtranonces   = errors.New("no extranonce available")
+)
+
+// stratumTwo32 is the ethash difficulty of a share of stratum difficulty 1.
+var stratumTwo32 = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 32))
+
+// StratumWorker is the share accounting of a worker of the stratum server.
+type StratumWorker struct {
+	Valid     uint64    `json:"valid"`     // Number of accepted solutions
+	Shares    uint64    `json:"shares"`    // Number of accepted shares not solving the block
+	Stale     uint64    `json:"stale"`     // Number of solutions rejected for outdated work
+	Invalid   uint64    `json:"invalid"`   // Number of solutions rejected for the current work
+	Hashrate  uint64    `json:"hashrate"`  // Last hash rate reported by the worker
+	LastShare time.Time `json:"lastShare"` // Time of the last submitted solution
+}
+
+// stratumRequest is a request of a stratum miner.
+type stratumRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []string        `json:"params"`
+	Worker string          `json:"worker"` // Name of the worker, eth-proxy only
+}
+
+// stratumResponse is a response to a stratum miner, or an unsolicited work
+// package pushed to an eth-proxy miner.
+type stratumResponse struct {
+	ID      json.RawMessage `json:"id"`
+	Version string          `json:"jsonrpc,omitempty"`
+	Result  interface{}     `json:"result"`
+	Error   interface{}     `json:"error"`
+}
+
+// stratumNotification is a notification to a NiceHash miner.
+type stratumNotification struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params interface{}     `json:"params"`
+}
+
+// stratumJob is a recent work package pushed to the miners.
+type stratumJob struct {
+	number   uint64   // Block number of the work
+	boundary *big.Int // Target a solution must meet to seal the block
+}
+
+// stratumServer serves the work of a remote sealer to miners connected over TCP
+// with the stratum protocol, pushing new work as soon as the sealer gets it.
+type stratumServer struct {
+	sealer   *remoteSealer
+	listener net.Listener
+
+	shareDifficulty float64  // Share difficulty of NiceHash miners, zero if none
+	shareTarget     *big.Int // Target a share must meet, nil if none
+
+	lock        sync.Mutex
+	work        [4]string                  // Current work package, empty until the first one
+	works       map[common.Hash]stratumJob // Recent work packages by sealhash
+	conns       map[*stratumConn]struct{}  // Open miner connections
+	extranonces map[uint16]struct{}        // Extranonces assigned to open connections
+	extranonce  uint16                     // Next extranonce to assign
+	workers     map[string]*StratumWorker  // Share accounting by worker name
+	closed      bool
+
+	wg sync.WaitGroup
+}
+
+// startStratumServer starts serving the work of the given remote sealer on the
+// TCP address.
+func startStratumServer(sealer *remoteSealer, addr string) (*stratumServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &stratumServer{
+		sealer:      sealer,
+		listener:    listener,
+		works:       make(map[common.Hash]stratumJob),
+		conns:       make(map[*stratumConn]struct{}),
+		extranonces: make(map[uint16]struct{}),
+		workers:     make(map[string]*StratumWorker),
+	}
+	if diff := sealer.ethash.config.StratumDifficulty; diff > 0 {
+		difficulty, _ := new(big.Float).Mul(big.NewFloat(diff), stratumTwo32).Int(nil)
+		if difficulty.Sign() > 0 {
+			s.shareDifficulty, s.shareTarget = diff, new(big.Int).Div(two256, difficulty)
+		}
+	}
+	s.wg.Add(1)
+	go s.accept()
+
+	sealer.ethash.config.Log.Info("Stratum server started", "addr", listener.Addr())
+	return s, nil
+}
+
+// accept runs the connections of the miners until the server is closed.
+func (s *stratumServer) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return
+		}
+		c := newStratumConn(s, conn)
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.lock.Unlock()
+
+		go c.serve()
+	}
+}
+
+// close stops accepting miners, and disconnects the current ones.
+func (s *stratumServer) close() {
+	s.listener.Close()
+
+	s.lock.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+}
+
+// notify pushes a new work package of the given block to all the miners.
+func (s *stratumServer) notify(work [4]string, number uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.work = work
+	s.works[common.HexToHash(work[0])] = stratumJob{
+		number:   number,
+		boundary: new(big.Int).SetBytes(common.HexToHash(work[2]).Bytes()),
+	}
+	for hash, job := range s.works {
+		if job.number+staleThreshold <= number {
+			delete(s.works, hash)
+		}
+	}
+	for c := range s.conns {
+		c.queueWork(work)
+	}
+}
+
+// currentWork returns the current work package, and whether there is one.
+func (s *stratumServer) currentWork() ([4]string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.work, s.work[0] != ""
+}
+
+// job returns a recent work package, and whether it is known.
+func (s *stratumServer) job(sealhash common.Hash) (stratumJob, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	job, ok := s.works[sealhash]
+	return job, ok
+}
+
+// assignExtranonce reserves an extranonce for a connection.
+func (s *stratumServer) assignExtranonce() (uint16, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := 0; i <= 0xffff; i++ {
+		extranonce := s.extranonce
+		s.extranonce++
+		if _, ok := s.extranonces[extranonce]; !ok {
+			s.extranonces[extranonce] = struct{}{}
+			return extranonce, nil
+		}
+	}
+	return 0, errStratumExtranonces
+}
+
+// submitWork submits a solution to the remote sealer, accounting it to the
+// given worker, and returns whether it was accepted.
+func (s *stratumServer) submitWork(worker string, nonce types.BlockNonce, digest common.Hash, sealhash common.Hash) bool {
+	errc := make(chan error, 1)
+	select {
+	case s.sealer.submitWorkCh <- &mineResult{nonce: nonce, mixDigest: digest, hash: sealhash, errc: errc}:
+	case <-s.sealer.requestExit:
+		return false
+	}
+	err := <-errc
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.worker(worker)
+	stats.LastShare = time.Now()
+	switch {
+	case err == nil:
+		stats.Valid++
+	case common.HexToHash(s.work[0]) != sealhash:
+		stats.Stale++
+	default:
+		stats.Invalid++
+	}
+	return err == nil
+}
+
+// submitShare accounts a solution not solving the block to the given worker,
+// and returns whether it was accepted as a share of the current work.
+func (s *stratumServer) submitShare(worker string, sealhash common.Hash, result *big.Int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.worker(worker)
+	stats.LastShare = time.Now()
+	switch {
+	case s.shareTarget == nil || result.Cmp(s.shareTarget) > 0:
+		stats.Invalid++
+		return false
+	case common.HexToHash(s.work[0]) != sealhash:
+		stats.Stale++
+		return false
+	default:
+		stats.Shares++
+		return true
+	}
+}
+
+// submitHashrate submits the hash rate of a worker to the remote sealer.
+func (s *stratumServer) submitHashrate(worker string, rate uint64, id common.Hash) bool {
+	done := make(chan struct{})
+	select {
+	case s.sealer.submitRateCh <- &hashrate{id: id, rate: rate, done: done}:
+	case <-s.sealer.requestExit:
+		return false
+	}
+	<-done
+
+	s.lock.Lock()
+	s.worker(worker).Hashrate = rate
+	s.lock.Unlock()
+
+	return true
+}
+
+// worker returns the share accounting of a worker, creating it if needed. The
+// lock must be held.
+func (s *stratumServer) worker(name string) *StratumWorker {
+	stats := s.workers[name]
+	if stats == nil {
+		stats = new(StratumWorker)
+		s.workers[name] = stats
+	}
+	return stats
+}
+
+// stats returns a copy of the share accounting of all the workers.
+func (s *stratumServer) stats() map[string]StratumWorker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := make(map[string]StratumWorker, len(s.workers))
+	for name, worker := range s.workers {
+		stats[name] = *worker
+	}
+	return stats
+}
+
+// stratumConn is the connection of a stratum miner.
+type stratumConn struct {
+	server *stratumServer
+	conn   net.Conn
+	log    log.Logger
+
+	// Session of the miner, only modified by serve until the work is pushed
+	dialect    int
+	subscribed bool
+	loggedIn   bool
+	extranonce uint16
+	worker     string
+
+	work   chan [4]string // Latest work package to push to the miner
+	closed chan struct{}
+
+	writeLock sync.Mutex
+}
+
+func newStratumConn(server *stratumServer, conn net.Conn) *stratumConn {
+	return &stratumConn{
+		server: server,
+		conn:   conn,
+		log:    server.sealer.ethash.config.Log.New("miner", conn.RemoteAddr()),
+		work:   make(chan [4]string, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// serve handles the requests of the miner until it disconnects.
+func (c *stratumConn) serve() {
+	defer c.server.wg.Done()
+	defer c.close()
+
+	c.log.Debug("Stratum miner connected")
+	reader := bufio.NewReaderSize(c.conn, stratumMaxLineLength)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(stratumReadTimeout))
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			c.log.Debug("Stratum miner disconnected", "err", err)
+			return
+		}
+		if isPrefix {
+			c.log.Debug("Stratum request too long")
+			return
+		}
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		req := new(stratumRequest)
+		if err := json.Unmarshal(line, req); err != nil {
+			c.log.Debug("Invalid stratum request", "err", err)
+			return
+		}
+		result, err := c.handle(req)
+		if err != nil {
+			c.log.Debug("Stratum request failed", "method", req.Method, "err", err)
+		}
+		if err := c.reply(req.ID, result, err); err != nil {
+			c.log.Debug("Failed to reply to stratum miner", "err", err)
+			return
+		}
+	}
+}
+
+// close releases the resources of the connection.
+func (c *stratumConn) close() {
+	c.conn.Close()
+	close(c.closed)
+
+	c.server.lock.Lock()
+	delete(c.server.conns, c)
+	if c.dialect == stratumNiceHash && c.subscribed {
+		delete(c.server.extranonces, c.extranonce)
+	}
+	c.server.lock.Unlock()
+}
+
+// setDialect sets the dialect of the connection, failing if the miner already
+// uses another one.
+func (c *stratumConn) setDialect(dialect int) error {
+	switch c.dialect {
+	case dialect:
+		return nil
+	case stratumUnknown:
+		c.dialect = dialect
+		return nil
+	default:
+		return errStratumDialect
+	}
+}
+
+// handle executes a request of the miner, returning its result.
+func (c *stratumConn) handle(req *stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		if err := c.setDialect(stratumNiceHash); err != nil {
+			return nil, err
+		}
+		if !c.subscribed {
+			extranonce, err := c.server.assignExtranonce()
+			if err != nil {
+				return nil, err
+			}
+			c.extranonce, c.subscribed = extranonce, true
+		}
+		extranonce := c.extranonceHex()
+		return []interface{}{[]string{"mining.notify", extranonce, "EthereumStratum/1.0.0"}, extranonce}, nil
+
+	case "mining.extranonce.subscribe":
+		// The extranonce of a connection never changes, so there is nothing to notify
+		if err := c.setDialect(stratumNiceHash); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	case "mining.authorize":
+		if err := c.setDialect(stratumNiceHash); err != nil {
+			return nil, err
+		}
+		if !c.subscribed {
+			return nil, errStratumNotSubscribed
+		}
+		if len(req.Params) < 1 {
+			return nil, errors.New("missing worker name")
+		}
+		c.login(req.Params[0])
+		return true, nil
+
+	case "mining.submit":
+		if c.dialect != stratumNiceHash {
+			return nil, errStratumDialect
+		}
+		if !c.loggedIn {
+			return nil, errStratumNotLoggedIn
+		}
+		if len(req.Params) < 3 {
+			return nil, errors.New("expected worker, job and nonce")
+		}
+		return c.submitNiceHash(req.Params[1], req.Params[2])
+
+	case "eth_submitLogin":
+		if err := c.setDialect(stratumEthProxy); err != nil {
+			return nil, err
+		}
+		if len(req.Params) < 1 {
+			return nil, errors.New("missing login")
+		}
+		worker := req.Params[0]
+		if req.Worker != "" {
+			worker += "." + req.Worker
+		}
+		c.login(worker)
+		return true, nil
+
+	case "eth_getWork":
+		if c.dialect != stratumEthProxy {
+			return nil, errStratumDialect
+		}
+		if !c.loggedIn {
+			return nil, errStratumNotLoggedIn
+		}
+		work, ok := c.server.currentWork()
+		if !ok {
+			return nil, errNoMiningWork
+		}
+		return work, nil
+
+	case "eth_submitWork":
+		if c.dialect != stratumEthProxy {
+			return nil, errStratumDialect
+		}
+		if !c.loggedIn {
+			return nil, errStratumNotLoggedIn
+		}
+		if len(req.Params) < 3 {
+			return nil, errors.New("expected nonce, sealhash and mix digest")
+		}
+		nonce, err := hexutil.Decode(req.Params[0])
+		if err != nil || len(nonce) != 8 {
+			return nil, fmt.Errorf("invalid nonce %q", req.Params[0])
+		}
+		return c.server.submitWork(c.worker, types.EncodeNonce(binary.BigEndian.Uint64(nonce)), common.HexToHash(req.Params[2]), common.HexToHash(req.Params[1])), nil
+
+	case "eth_submitHashrate":
+		if !c.loggedIn {
+			return nil, errStratumNotLoggedIn
+		}
+		if len(req.Params) < 2 {
+			return nil, errors.New("expected hash rate and id")
+		}
+		rate, err := hexutil.DecodeUint64(req.Params[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hash rate %q", req.Params[0])
+		}
+		return c.server.submitHashrate(c.worker, rate, common.HexToHash(req.Params[1])), nil
+
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// login authenticates the miner as the given worker, and starts pushing work
+// to it. Any login is accepted, the address to mine to being the one of the
+// node.
+func (c *stratumConn) login(worker string) {
+	if c.loggedIn {
+		return
+	}
+	c.worker, c.loggedIn = worker, true
+	c.log.Debug("Stratum miner logged in", "worker", worker)
+
+	c.server.lock.Lock()
+	if c.server.work[0] != "" {
+		c.queueWork(c.server.work)
+	}
+	c.server.lock.Unlock()
+
+	c.server.wg.Add(1)
+	go c.push()
+}
+
+// queueWork replaces the work waiting to be pushed to the miner, if any, with
+// the given one. The lock of the server must be held.
+func (c *stratumConn) queueWork(work [4]string) {
+	select {
+	case <-c.work:
+	default:
+	}
+	c.work <- work
+}
+
+// push sends the latest work to the miner until it disconnects.
+func (c *stratumConn) push() {
+	defer c.server.wg.Done()
+
+	var difficulty float64 // Last difficulty sent to a NiceHash miner
+	for {
+		select {
+		case work := <-c.work:
+			var err error
+			switch c.dialect {
+			case stratumEthProxy:
+				err = c.write(&stratumResponse{ID: json.RawMessage("0"), Version: "2.0", Result: work})
+
+			case stratumNiceHash:
+				diff := stratumDifficulty(work[2])
+				if share := c.server.shareDifficulty; share > 0 && share < diff {
+					diff = share
+				}
+				if diff != difficulty {
+					if err = c.write(&stratumNotification{ID: json.RawMessage("null"), Method: "mining.set_difficulty", Params: []float64{diff}}); err != nil {
+						break
+					}
+					difficulty = diff
+				}
+				err = c.write(&stratumNotification{
+					ID:     json.RawMessage("null"),
+					Method: "mining.notify",
+					Params: []interface{}{strings.TrimPrefix(work[0], "0x"), strings.TrimPrefix(work[1], "0x"), strings.TrimPrefix(work[0], "0x"), true},
+				})
+			}
+			if err != nil {
+				c.log.Debug("Failed to push work to stratum miner", "err", err)
+				c.conn.Close()
+				return
+			}
+			c.log.Trace("Pushed work to stratum miner", "sealhash", work[0])
+
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// submitNiceHash submits the solution of a NiceHash miner, made of the nonce
+// suffix following its extranonce. Solutions not meeting the block target are
+// only accounted as shares.
+func (c *stratumConn) submitNiceHash(job string, suffix string) (bool, error) {
+	suffix = strings.TrimPrefix(suffix, "0x")
+	if len(suffix) != 2*(8-stratumExtranonceSize) {
+		return false, fmt.Errorf("invalid nonce %q", suffix)
+	}
+	low, err := strconv.ParseUint(suffix, 16, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid nonce %q", suffix)
+	}
+	var (
+		sealhash = common.HexToHash(job)
+		nonce    = uint64(c.extranonce)<<(8*(8-stratumExtranonceSize)) | low
+		digest   common.Hash
+	)
+	// Unknown or outdated jobs are left to the sealer to reject and account
+	if work, ok := c.server.job(sealhash); ok {
+		var result *big.Int
+		digest, result = c.server.sealer.ethash.stratumHash(work.number, sealhash, nonce)
+		if result.Cmp(work.boundary) > 0 {
+			return c.server.submitShare(c.worker, sealhash, result), nil
+		}
+	}
+	return c.server.submitWork(c.worker, types.EncodeNonce(nonce), digest, sealhash), nil
+}
+
+// extranonceHex returns the extranonce assigned to the miner, hex encoded.
+func (c *stratumConn) extranonceHex() string {
+	var extranonce [2]byte
+	binary.BigEndian.PutUint16(extranonce[:], c.extranonce)
+	return hex.EncodeToString(extranonce[:stratumExtranonceSize])
+}
+
+// reply sends the result of a request to the miner.
+func (c *stratumConn) reply(id json.RawMessage, result interface{}, err error) error {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	res := &stratumResponse{ID: id, Result: result}
+	if c.dialect == stratumEthProxy {
+		res.Version = "2.0"
+	}
+	if err != nil {
+		res.Result = nil
+		if c.dialect == stratumNiceHash {
+			res.Error = []interface{}{20, err.Error(), nil}
+		} else {
+			res.Error = map[string]interface{}{"code": -1, "message": err.Error()}
+		}
+	}
+	return c.write(res)
+}
+
+// write sends a message to the miner.
+func (c *stratumConn) write(msg interface{}) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	_, err = c.conn.Write(append(blob, '\n'))
+	return err
+}
+
+// stratumDifficulty converts a hex encoded ethash boundary to a stratum share
+// difficulty, in units of 2^32 hashes.
+func stratumDifficulty(target string) float64 {
+	boundary := new(big.Int).SetBytes(common.HexToHash(target).Bytes())
+	if boundary.Sign() == 0 {
+		return 0
+	}
+	difficulty := new(big.Float).SetInt(new(big.Int).Div(two256, boundary))
+	diff, _ := difficulty.Quo(difficulty, stratumTwo32).Float64()
+	return diff
+}
+
+// stratumHash recomputes the mix digest of a proof-of-work solution, which the
+// miners of some stratum dialects don't submit, along with its result to check
+// against the share and block targets.
+func (ethash *Ethash) stratumHash(number uint64, sealhash common.Hash, nonce uint64) (common.Hash, *big.Int) {
+	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
+		return common.Hash{}, new(big.Int)
+	}
+	cache := ethash.cache(number)
+
+	size := datasetSize(cache.epoch)
+	if ethash.config.PowMode == ModeTest {
+		size = 32 * 1024
+	}
+	digest, result := hashimotoLight(size, cache.cache, sealhash.Bytes(), nonce)
+
+	// Caches are unmapped in a finalizer. Ensure that the cache stays alive
+	// until after the call to hashimotoLight so it's not unmapped while being used.
+	runtime.KeepAlive(cache)
+
+	return common.BytesToHash(digest), new(big.Int).SetBytes(result)
+}
+
+// StratumWorkers returns the share accounting of the workers of the stratum
+// server by name, or nil if it isn't running.
+func (ethash *Ethash) StratumWorkers() map[string]StratumWorker {
+	if ethash.remote == nil || ethash.remote.stratum == nil {
+		return nil
+	}
+	return ethash.remote.stratum.stats()
+}
@@ -0,0 +1,389 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// newStratumTester creates a test mode ethash engine without local mining,
+// serving its work over stratum on a random local port with the given share
+// difficulty.
+func newStratumTester(t *testing.T, difficulty float64) (*Ethash, string) {
+	ethash := &Ethash{
+		config:   Config{PowMode: ModeTest, Log: log.Root(), StratumAddr: "127.0.0.1:0", StratumDifficulty: difficulty},
+		caches:   newlru("cache", 1, newCache, nil),
+		datasets: newlru("dataset", 1, newDataset, nil),
+		update:   make(chan struct{}),
+		hashrate: metrics.NewMeterForced(),
+		threads:  -1,
+	}
+	ethash.remote = startRemoteSealer(ethash, nil, false)
+	if ethash.remote.stratum == nil {
+		t.Fatal("stratum server not started")
+	}
+	return ethash, ethash.remote.stratum.listener.Addr().String()
+}
+
+// stratumTestMiner is a fake stratum miner.
+type stratumTestMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+// stratumTestMessage is a message received by the fake miner.
+type stratumTestMessage struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  json.RawMessage   `json:"error"`
+}
+
+func newStratumTestMiner(t *testing.T, addr string) *stratumTestMiner {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	return &stratumTestMiner{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// call sends a request and returns its response.
+func (m *stratumTestMiner) call(method string, worker string, params ...string) *stratumTestMessage {
+	m.id++
+	blob, _ := json.Marshal(map[string]interface{}{"id": m.id, "method": method, "params": params, "worker": worker})
+	if _, err := m.conn.Write(append(blob, '\n')); err != nil {
+		m.t.Fatalf("failed to send %s: %v", method, err)
+	}
+	msg := m.read()
+	if string(msg.ID) != fmt.Sprint(m.id) {
+		m.t.Fatalf("%s response id mismatch: have %s, want %d", method, msg.ID, m.id)
+	}
+	return msg
+}
+
+// read returns the next message from the server.
+func (m *stratumTestMiner) read() *stratumTestMessage {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := m.reader.ReadBytes('\n')
+	if err != nil {
+		m.t.Fatalf("failed to read stratum message: %v", err)
+	}
+	msg := new(stratumTestMessage)
+	if err := json.Unmarshal(line, msg); err != nil {
+		m.t.Fatalf("failed to decode stratum message %s: %v", line, err)
+	}
+	return msg
+}
+
+// mine searches a nonce starting with the given prefix solving the block,
+// returning it along with its mix digest.
+func mine(ethash *Ethash, header *types.Header, prefix uint64) (uint64, common.Hash) {
+	var (
+		cache  = ethash.cache(header.Number.Uint64())
+		hash   = ethash.SealHash(header).Bytes()
+		target = new(big.Int).Div(two256, header.Difficulty)
+	)
+	for nonce := prefix; ; nonce++ {
+		digest, result := hashimotoLight(32*1024, cache.cache, hash, nonce)
+		if new(big.Int).SetBytes(result).Cmp(target) <= 0 {
+			return nonce, common.BytesToHash(digest)
+		}
+	}
+}
+
+// expectBlock waits for a sealed block, and checks its seal.
+func expectBlock(t *testing.T, ethash *Ethash, results chan *types.Block, nonce uint64) {
+	select {
+	case block := <-results:
+		if block.Nonce() != nonce {
+			t.Errorf("block nonce mismatch: have %x, want %x", block.Nonce(), nonce)
+		}
+		if err := ethash.verifySeal(nil, block.Header(), false); err != nil {
+			t.Errorf("invalid block seal: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sealed block timeout")
+	}
+}
+
+func TestStratumNiceHash(t *testing.T) {
+	ethash, addr := newStratumTester(t, 0)
+	defer ethash.Close()
+
+	miner, other := newStratumTestMiner(t, addr), newStratumTestMiner(t, addr)
+	defer miner.conn.Close()
+	defer other.conn.Close()
+
+	// Subscribe the miners, which must get distinct extranonces
+	if res := miner.call("mining.authorize", "", "user.rig", "x"); string(res.Error) == "null" {
+		t.Fatalf("authorized before subscribing: %s", res.Result)
+	}
+	var extranonces []string
+	for _, m := range []*stratumTestMiner{miner, other} {
+		var result []json.RawMessage
+		if err := json.Unmarshal(m.call("mining.subscribe", "", "testminer/1.0.0", "EthereumStratum/1.0.0").Result, &result); err != nil || len(result) != 2 {
+			t.Fatalf("invalid subscription result: %v", result)
+		}
+		var extranonce string
+		json.Unmarshal(result[1], &extranonce)
+		extranonces = append(extranonces, extranonce)
+	}
+	if extranonces[0] != "0000" || extranonces[1] != "0001" {
+		t.Fatalf("extranonce mismatch: have %v, want [0000 0001]", extranonces)
+	}
+	if res := miner.call("mining.authorize", "", "user.rig", "x"); string(res.Result) != "true" {
+		t.Fatalf("authorization failed: %s", res.Error)
+	}
+	if res := miner.call("eth_getWork", ""); string(res.Error) == "null" {
+		t.Fatalf("eth-proxy method accepted from NiceHash miner")
+	}
+	// Push work and check the miner is notified
+	var (
+		header   = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+		sealhash = ethash.SealHash(header)
+		results  = make(chan *types.Block, 1)
+	)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	msg := miner.read()
+	if msg.Method != "mining.set_difficulty" || string(msg.Params[0]) != "2.3283064365386963e-8" {
+		t.Fatalf("difficulty notification mismatch: have %s %s", msg.Method, msg.Params)
+	}
+	var job []interface{}
+	msg = miner.read()
+	blob, _ := json.Marshal(msg.Params)
+	json.Unmarshal(blob, &job)
+	want := []interface{}{sealhash.Hex()[2:], common.BytesToHash(SeedHash(1, nil)).Hex()[2:], sealhash.Hex()[2:], true}
+	if msg.Method != "mining.notify" || fmt.Sprint(job) != fmt.Sprint(want) {
+		t.Fatalf("job notification mismatch: have %s %v, want %v", msg.Method, job, want)
+	}
+	// Submit an invalid and a valid solution
+	if res := miner.call("mining.submit", "", "user.rig", sealhash.Hex()[2:], "000000000000"); string(res.Result) == "true" {
+		t.Fatalf("invalid solution accepted")
+	}
+	nonce, _ := mine(ethash, header, 0)
+	if res := miner.call("mining.submit", "", "user.rig", sealhash.Hex()[2:], fmt.Sprintf("%012x", nonce)); string(res.Result) != "true" {
+		t.Fatalf("valid solution rejected: %s", res.Error)
+	}
+	expectBlock(t, ethash, results, nonce)
+
+	// Report the hash rate of the miner
+	if res := miner.call("eth_submitHashrate", "", "0x64", common.HexToHash("0x1").Hex()); string(res.Result) != "true" {
+		t.Fatalf("hash rate rejected: %s", res.Error)
+	}
+	if rate := ethash.Hashrate(); rate != 100 {
+		t.Errorf("hash rate mismatch: have %v, want 100", rate)
+	}
+	stats := ethash.StratumWorkers()["user.rig"]
+	if stats.Valid != 1 || stats.Invalid != 1 || stats.Stale != 0 || stats.Hashrate != 100 {
+		t.Errorf("worker accounting mismatch: have %+v", stats)
+	}
+}
+
+// Tests that NiceHash miners are sent the share difficulty, and that only their
+// shares solving the block are submitted to the sealer.
+func TestStratumNiceHashShares(t *testing.T) {
+	ethash, addr := newStratumTester(t, 10/float64(1<<32))
+	defer ethash.Close()
+
+	miner := newStratumTestMiner(t, addr)
+	defer miner.conn.Close()
+
+	miner.call("mining.subscribe", "", "testminer/1.0.0", "EthereumStratum/1.0.0")
+	if res := miner.call("mining.authorize", "", "user.rig", "x"); string(res.Result) != "true" {
+		t.Fatalf("authorization failed: %s", res.Error)
+	}
+	var (
+		stale    = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)}
+		header   = &types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(1000)}
+		sealhash = ethash.SealHash(header)
+		results  = make(chan *types.Block, 1)
+	)
+	for _, h := range []*types.Header{stale, header} {
+		ethash.Seal(nil, types.NewBlockWithHeader(h), results, nil)
+		if h == stale {
+			if msg := miner.read(); msg.Method != "mining.set_difficulty" || string(msg.Params[0]) != "2.3283064365386963e-9" {
+				t.Fatalf("difficulty notification mismatch: have %s %s", msg.Method, msg.Params)
+			}
+		}
+		if msg := miner.read(); msg.Method != "mining.notify" {
+			t.Fatalf("job notification mismatch: have %s", msg.Method)
+		}
+	}
+	// Submit solutions failing the share target, and meeting only the share
+	// target of the stale and the current work
+	shares := func(header *types.Header) (invalid uint64, share uint64) {
+		var (
+			cache       = ethash.cache(header.Number.Uint64())
+			hash        = ethash.SealHash(header).Bytes()
+			shareTarget = new(big.Int).Div(two256, big.NewInt(10))
+			blockTarget = new(big.Int).Div(two256, header.Difficulty)
+		)
+		for nonce, found := uint64(0), 0; found != 3; nonce++ {
+			_, result := hashimotoLight(32*1024, cache.cache, hash, nonce)
+			switch res := new(big.Int).SetBytes(result); {
+			case res.Cmp(shareTarget) > 0 && found&1 == 0:
+				invalid, found = nonce, found|1
+			case res.Cmp(shareTarget) <= 0 && res.Cmp(blockTarget) > 0 && found&2 == 0:
+				share, found = nonce, found|2
+			}
+		}
+		return invalid, share
+	}
+	invalid, share := shares(header)
+	if res := miner.call("mining.submit", "", "user.rig", sealhash.Hex()[2:], fmt.Sprintf("%012x", invalid)); string(res.Result) == "true" {
+		t.Fatalf("invalid share accepted")
+	}
+	if res := miner.call("mining.submit", "", "user.rig", sealhash.Hex()[2:], fmt.Sprintf("%012x", share)); string(res.Result) != "true" {
+		t.Fatalf("valid share rejected: %s", res.Error)
+	}
+	_, share = shares(stale)
+	if res := miner.call("mining.submit", "", "user.rig", ethash.SealHash(stale).Hex()[2:], fmt.Sprintf("%012x", share)); string(res.Result) == "true" {
+		t.Fatalf("stale share accepted")
+	}
+	select {
+	case block := <-results:
+		t.Fatalf("block sealed from a share: %x", block.Nonce())
+	default:
+	}
+	nonce, _ := mine(ethash, header, 0)
+	if res := miner.call("mining.submit", "", "user.rig", sealhash.Hex()[2:], fmt.Sprintf("%012x", nonce)); string(res.Result) != "true" {
+		t.Fatalf("valid solution rejected: %s", res.Error)
+	}
+	expectBlock(t, ethash, results, nonce)
+
+	stats := ethash.StratumWorkers()["user.rig"]
+	if stats.Valid != 1 || stats.Shares != 1 || stats.Invalid != 1 || stats.Stale != 1 {
+		t.Errorf("worker accounting mismatch: have %+v", stats)
+	}
+}
+
+func TestStratumEthProxy(t *testing.T) {
+	ethash, addr := newStratumTester(t, 0)
+	defer ethash.Close()
+
+	miner := newStratumTestMiner(t, addr)
+	defer miner.conn.Close()
+
+	if res := miner.call("eth_getWork", ""); string(res.Error) == "null" {
+		t.Fatalf("work fetched before login: %s", res.Result)
+	}
+	if res := miner.call("eth_submitLogin", "rig", "0x0000000000000000000000000000000000000001"); string(res.Result) != "true" {
+		t.Fatalf("login failed: %s", res.Error)
+	}
+	if res := miner.call("eth_getWork", ""); string(res.Error) == "null" {
+		t.Fatalf("work fetched before any was available: %s", res.Result)
+	}
+	if res := miner.call("mining.subscribe", ""); string(res.Error) == "null" {
+		t.Fatalf("NiceHash method accepted from eth-proxy miner")
+	}
+	// Push two work packages, the first one getting stale once the second one
+	// is pushed
+	var (
+		stale   = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+		header  = &types.Header{Number: big.NewInt(9), Difficulty: big.NewInt(200)}
+		results = make(chan *types.Block, 1)
+	)
+	for _, h := range []*types.Header{stale, header} {
+		ethash.Seal(nil, types.NewBlockWithHeader(h), results, nil)
+
+		var work [4]string
+		msg := miner.read()
+		if err := json.Unmarshal(msg.Result, &work); err != nil || string(msg.ID) != "0" {
+			t.Fatalf("invalid work push: %s %s", msg.ID, msg.Result)
+		}
+		if want := ethash.SealHash(h).Hex(); work[0] != want {
+			t.Fatalf("work hash mismatch: have %s, want %s", work[0], want)
+		}
+	}
+	var work [4]string
+	if err := json.Unmarshal(miner.call("eth_getWork", "").Result, &work); err != nil || work[0] != ethash.SealHash(header).Hex() {
+		t.Fatalf("current work mismatch: have %v", work)
+	}
+	// Submit a stale and a valid solution
+	nonce, digest := mine(ethash, stale, 0)
+	if res := miner.call("eth_submitWork", "", fmt.Sprintf("0x%016x", nonce), ethash.SealHash(stale).Hex(), digest.Hex()); string(res.Result) == "true" {
+		t.Fatalf("stale solution accepted")
+	}
+	nonce, digest = mine(ethash, header, 0)
+	res := miner.call("eth_submitWork", "", fmt.Sprintf("0x%016x", nonce), ethash.SealHash(header).Hex(), digest.Hex())
+	if string(res.Result) != "true" {
+		t.Fatalf("valid solution rejected: %s", res.Error)
+	}
+	expectBlock(t, ethash, results, nonce)
+
+	worker := "0x0000000000000000000000000000000000000001.rig"
+	stats, ok := ethash.StratumWorkers()[worker]
+	if !ok || stats.Valid != 1 || stats.Stale != 1 || stats.Invalid != 0 {
+		t.Errorf("worker accounting mismatch: have %+v, workers %v", stats, ethash.StratumWorkers())
+	}
+}
+
+func TestStratumDifficulty(t *testing.T) {
+	tests := []struct {
+		difficulty *big.Int
+		want       float64
+	}{
+		{new(big.Int).Lsh(big.NewInt(1), 32), 1},
+		{new(big.Int).Lsh(big.NewInt(1), 33), 2},
+		{big.NewInt(4000000000000), 931.3225746154785},
+	}
+	for i, tt := range tests {
+		target := common.BytesToHash(new(big.Int).Div(two256, tt.difficulty).Bytes()).Hex()
+		if have := stratumDifficulty(target); have != tt.want {
+			t.Errorf("test %d: difficulty mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that failing to start the stratum server is reported.
+func TestStratumStartFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	ethash := &Ethash{
+		config:   Config{PowMode: ModeTest, Log: log.Root(), StratumAddr: listener.Addr().String()},
+		caches:   newlru("cache", 1, newCache, nil),
+		datasets: newlru("dataset", 1, newDataset, nil),
+		update:   make(chan struct{}),
+		hashrate: metrics.NewMeterForced(),
+		threads:  -1,
+	}
+	ethash.remote = startRemoteSealer(ethash, nil, false)
+	defer ethash.Close()
+
+	if err := ethash.StratumError(); err == nil {
+		t.Error("stratum server started on a used address")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+// EngineConstructor creates a consensus engine from the chain configuration.
+// The database may be used by the engine to persist its own data, such as voting
+// snapshots.
+type EngineConstructor func(config ctypes.ChainConfigurator, db ethdb.Database) (Engine, error)
+
+var (
+	enginesLock sync.RWMutex
+	engines     = make(map[ctypes.ConsensusEngineT]EngineConstructor)
+)
+
+// RegisterEngine makes a consensus engine available for the chain configurations
+// and users selecting the given engine type. Engine packages usually register
+// themselves during initialization. It panics if the type is registered twice.
+func RegisterEngine(t ctypes.ConsensusEngineT, constructor EngineConstructor) {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	if _, exists := engines[t]; exists {
+		panic(fmt.Sprintf("consensus engine %v already registered", t))
+	}
+	engines[t] = constructor
+}
+
+// HasEngine reports whether a consensus engine is registered for the type.
+func HasEngine(t ctypes.ConsensusEngineT) bool {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	_, ok := engines[t]
+	return ok
+}
+
+// EngineTypes returns the sorted types of the registered consensus engines.
+func EngineTypes() []ctypes.ConsensusEngineT {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	types := make([]ctypes.ConsensusEngineT, 0, len(engines))
+	for t := range engines {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// NewEngine creates the consensus engine registered for the given type.
+func NewEngine(t ctypes.ConsensusEngineT, config ctypes.ChainConfigurator, db ethdb.Database) (Engine, error) {
+	enginesLock.RLock()
+	constructor, ok := engines[t]
+	enginesLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown consensus engine %v", t)
+	}
+	return constructor(config, db)
+}
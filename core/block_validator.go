@@ -138,3 +138,29 @@ func CalcGasLimit(parent *types.Block, gasFloor, gasCeil uint64) uint64 {
 	}
 	return limit
 }
+
+// CalcGasLimit1559 calculates the next block gas limit once EIP-1559 is active.
+// Usage no longer drives the limit; it only moves towards the desired value
+// within the allowed bound divisor.
+func CalcGasLimit1559(parentGasLimit, desiredLimit uint64) uint64 {
+	delta := parentGasLimit/vars.GasLimitBoundDivisor - 1
+	limit := parentGasLimit
+	if desiredLimit < vars.MinGasLimit {
+		desiredLimit = vars.MinGasLimit
+	}
+	// If we're outside our allowed gas range, we try to hone towards them
+	if limit < desiredLimit {
+		limit = parentGasLimit + delta
+		if limit > desiredLimit {
+			limit = desiredLimit
+		}
+		return limit
+	}
+	if limit > desiredLimit {
+		limit = parentGasLimit - delta
+		if limit < desiredLimit {
+			limit = desiredLimit
+		}
+	}
+	return limit
+}
@@ -185,6 +185,8 @@ type BlockChain struct {
 	running       int32          // 0 if chain is running, 1 when stopped
 	procInterrupt int32          // interrupt signaler for block processing
 
+	artificialFinality int32 // 1 if the artificial finality features (ECBP1100) are enabled, 0 otherwise
+
 	engine     consensus.Engine
 	validator  Validator  // Block and state validator interface
 	prefetcher Prefetcher // Block state prefetcher interface
@@ -322,7 +324,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig ctyp
 	}
 	// Load any existing snapshot, regenerating it if loading failed
 	if bc.cacheConfig.SnapshotLimit > 0 {
-		bc.snaps = snapshot.New(bc.db, bc.stateCache.TrieDB(), bc.cacheConfig.SnapshotLimit, bc.CurrentBlock().Root(), !bc.cacheConfig.SnapshotWait)
+		bc.snaps, _ = snapshot.New(bc.db, bc.stateCache.TrieDB(), bc.cacheConfig.SnapshotLimit, bc.CurrentBlock().Root(), !bc.cacheConfig.SnapshotWait, true, false)
 	}
 	// Take ownership of this particular state
 	go bc.update()
@@ -1658,9 +1660,6 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	abort, results := bc.engine.VerifyHeaders(bc, headers, seals)
 	defer close(abort)
 
-	//handle for pirlguard checks when time comes, then pass the penalty check length
-	errChain := bc.checkChainForAttack(chain)
-
 	// Peek the error for the first block to decide the directing import logic
 	it := newInsertIterator(chain, results, bc.validator)
 
@@ -1727,11 +1726,6 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		// If there are any still remaining, mark as ignored
 		return it.index, err
-	//catch pirlgurad penalty in chain
-	case errChain == ErrPenaltyInChain:
-		stats.ignored += len(it.chain)
-		bc.reportBlock(block, nil, errChain)
-		return it.index, errChain
 	// Some other error occurred, abort
 	case err != nil:
 		bc.futureBlocks.Remove(block.Hash())
@@ -2048,6 +2042,9 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 // potential missing transactions and post an event about them.
 func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
+		oldHead = oldBlock.Header()
+		newHead = newBlock.Header()
+
 		newChain    types.Blocks
 		oldChain    types.Blocks
 		commonBlock *types.Block
@@ -2147,6 +2144,13 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("invalid new chain")
 		}
 	}
+	// Reject the reorg if it breaks artificial finality, before touching any of
+	// the canonical data
+	if len(oldChain) > 0 && bc.IsArtificialFinalityActive(oldHead.Number) {
+		if err := bc.ecbp1100(commonBlock.Header(), oldHead, newHead); err != nil {
+			return err
+		}
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Info
@@ -0,0 +1,147 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ecbp1100Denominator is the denominator of the antigravity curve, the value
+	// of the curve numerator for a common ancestor of age zero.
+	ecbp1100Denominator = big.NewInt(128)
+
+	// ecbp1100XCap is the age in seconds (floor(8000*pi)) beyond which the
+	// antigravity curve stays at its maximum.
+	ecbp1100XCap = big.NewInt(25132)
+
+	// ecbp1100Amplitude is the amplitude of the antigravity curve, making the
+	// maximum required ratio of total difficulty 1+2*15 = 31.
+	ecbp1100Amplitude = big.NewInt(15)
+
+	// ecbp1100Height is the span of the curve numerator from zero to the cap,
+	// denominator*amplitude*2.
+	ecbp1100Height = new(big.Int).Mul(new(big.Int).Mul(ecbp1100Denominator, ecbp1100Amplitude), big.NewInt(2))
+)
+
+// EnableArtificialFinality enables or disables the artificial finality features
+// of the chain, currently ECBP1100 (MESS, modified exponential subjective
+// scoring).
+//
+// The setting is independent from the activation of the features by the chain
+// configuration: while ECBP1100 isn't activated, enabling it has no effect until
+// the transition block is reached. The method is idempotent.
+func (bc *BlockChain) EnableArtificialFinality(enable bool, logValues ...interface{}) {
+	var status int32
+	if enable {
+		status = 1
+	}
+	if atomic.SwapInt32(&bc.artificialFinality, status) == status {
+		return
+	}
+	if !bc.chainConfig.IsEnabled(bc.chainConfig.GetECBP1100Transition, bc.CurrentHeader().Number) {
+		return
+	}
+	if enable {
+		log.Info("Enabled artificial finality features", logValues...)
+	} else {
+		log.Warn("Disabled artificial finality features", logValues...)
+	}
+}
+
+// IsArtificialFinalityEnabled returns whether the artificial finality features
+// are enabled, regardless of their activation by the chain configuration.
+func (bc *BlockChain) IsArtificialFinalityEnabled() bool {
+	return atomic.LoadInt32(&bc.artificialFinality) == 1
+}
+
+// IsArtificialFinalityActive returns whether reorgs from the given block on are
+// subject to the artificial finality features, that is whether they are both
+// enabled and activated by the chain configuration.
+func (bc *BlockChain) IsArtificialFinalityActive(number *big.Int) bool {
+	return bc.IsArtificialFinalityEnabled() && bc.chainConfig.IsEnabled(bc.chainConfig.GetECBP1100Transition, number)
+}
+
+// ecbp1100 implements the ECBP1100 (MESS) artificial finality rule: a reorg from
+// the current head to the proposed one is only allowed if the total difficulty
+// the proposed segment adds since the common ancestor exceeds the one of the
+// current segment by the antigravity factor, which grows with the age of the
+// common ancestor. Long-range reorgs thus require a far heavier chain, while
+// competing tips near the head are still decided by total difficulty.
+func (bc *BlockChain) ecbp1100(commonAncestor, current, proposed *types.Header) error {
+	var (
+		commonTd   = bc.GetTd(commonAncestor.Hash(), commonAncestor.Number.Uint64())
+		currentTd  = bc.GetTd(current.Hash(), current.Number.Uint64())
+		proposedTd = bc.GetTd(proposed.Hash(), proposed.Number.Uint64())
+	)
+	if commonTd == nil || currentTd == nil || proposedTd == nil {
+		return fmt.Errorf("missing total difficulty for artificial finality: common %v, current %v, proposed %v", commonTd, currentTd, proposedTd)
+	}
+	var (
+		currentSpan  = new(big.Int).Sub(currentTd, commonTd)
+		proposedSpan = new(big.Int).Sub(proposedTd, commonTd)
+		age          = current.Time - commonAncestor.Time
+	)
+	want := ecbp1100AntiGravity(new(big.Int).SetUint64(age))
+	want.Mul(want, currentSpan)
+	have := new(big.Int).Mul(proposedSpan, ecbp1100Denominator)
+
+	if have.Cmp(want) < 0 {
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(have), new(big.Float).SetInt(want)).Float64()
+		return fmt.Errorf("%w: ECBP1100 (MESS) age=%v ratio=%.6f common=%d (%x) current=%d (%x) proposed=%d (%x)", ErrReorgFinality,
+			common.PrettyDuration(time.Duration(age)*time.Second), ratio,
+			commonAncestor.Number, commonAncestor.Hash().Bytes()[:4],
+			current.Number, current.Hash().Bytes()[:4],
+			proposed.Number, proposed.Hash().Bytes()[:4])
+	}
+	return nil
+}
+
+// ecbp1100AntiGravity returns the numerator of the ECBP1100 antigravity curve
+// for a common ancestor of age x seconds, over ecbp1100Denominator. The curve is
+// a cubic approximation of a sine, computed with integers only:
+//
+//	denominator + (3*x^2 - 2*x^3/xcap) * height / xcap^2
+//
+// with x capped at xcap, so it rises from 1 to 31 times the denominator.
+func ecbp1100AntiGravity(x *big.Int) *big.Int {
+	if x.Cmp(ecbp1100XCap) > 0 {
+		x = ecbp1100XCap
+	}
+	// 3 * x^2
+	square := new(big.Int).Mul(x, x)
+	out := new(big.Int).Mul(square, big.NewInt(3))
+
+	// 2 * x^3 / xcap
+	cube := new(big.Int).Mul(square, x)
+	cube.Mul(cube, big.NewInt(2))
+	cube.Div(cube, ecbp1100XCap)
+
+	// (3*x^2 - 2*x^3/xcap) * height / xcap^2
+	out.Sub(out, cube)
+	out.Mul(out, ecbp1100Height)
+	out.Div(out, new(big.Int).Mul(ecbp1100XCap, ecbp1100XCap))
+
+	return out.Add(out, ecbp1100Denominator)
+}
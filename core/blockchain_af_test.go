@@ -0,0 +1,162 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+)
+
+func TestEcbp1100AntiGravity(t *testing.T) {
+	tests := []struct {
+		age  int64
+		want int64
+	}{
+		{0, 128},
+		{1, 128},
+		{30, 128},
+		{1000, 145},
+		{12566, 2048},
+		{25132, 3968},
+		{25133, 3968},
+		{1000000, 3968},
+	}
+	for i, tt := range tests {
+		if have := ecbp1100AntiGravity(big.NewInt(tt.age)); have.Int64() != tt.want {
+			t.Errorf("test %d, age %d: antigravity mismatch: have %v, want %d", i, tt.age, have, tt.want)
+		}
+	}
+}
+
+// newArtificialFinalityChain creates a blockchain with 100 blocks of 10 seconds
+// on top of the genesis, and ECBP1100 activated at the given block (if any).
+// It returns the chain, along with its blocks from the genesis on and the
+// database they were generated in, to generate competing segments from.
+func newArtificialFinalityChain(t *testing.T, transition *uint64) (*BlockChain, []*types.Block, ethdb.Database) {
+	config := *params.TestChainConfig
+	if err := config.SetECBP1100Transition(transition); err != nil {
+		t.Fatalf("failed to set ECBP1100 transition: %v", err)
+	}
+	var (
+		gspec   = &genesisT.Genesis{Config: &config}
+		db      = rawdb.NewMemoryDatabase()
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = MustCommitGenesis(gendb, gspec)
+	)
+	MustCommitGenesis(db, gspec)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 100, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert local chain: %v", err)
+	}
+	return chain, append([]*types.Block{genesis}, blocks...), gendb
+}
+
+func TestEcbp1100Reorg(t *testing.T) {
+	zero, far := uint64(0), uint64(1000)
+
+	tests := []struct {
+		name       string
+		transition *uint64
+		enabled    bool
+		fork       uint64 // Number of the common ancestor
+		length     int    // Length of the competing segment
+		blockTime  int64  // Block time of the competing segment
+		reorg      bool   // Whether the competing segment becomes canonical
+	}{
+		// Slightly heavier segments from an old common ancestor are rejected by
+		// MESS, but accepted without it
+		{"old-enabled", &zero, true, 0, 100, 1, false},
+		{"old-disabled", &zero, false, 0, 100, 1, true},
+		{"old-inactive", &far, true, 0, 100, 1, true},
+		{"old-unconfigured", nil, true, 0, 100, 1, true},
+
+		// Far heavier segments from an old common ancestor are accepted
+		{"old-heavy", &zero, true, 0, 200, 10, true},
+
+		// Heavier segments near the head are decided by total difficulty
+		{"recent", &zero, true, 97, 4, 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, local, gendb := newArtificialFinalityChain(t, tt.transition)
+			defer chain.Stop()
+
+			chain.EnableArtificialFinality(tt.enabled)
+			if chain.IsArtificialFinalityEnabled() != tt.enabled {
+				t.Fatalf("artificial finality status mismatch: have %v, want %v", !tt.enabled, tt.enabled)
+			}
+			var (
+				head      = chain.CurrentBlock()
+				parent    = local[tt.fork]
+				blocks, _ = GenerateChain(chain.Config(), parent, ethash.NewFaker(), gendb, tt.length, func(i int, b *BlockGen) {
+					b.OffsetTime(tt.blockTime - 10)
+					b.SetCoinbase(common.Address{1})
+				})
+			)
+			if td := new(big.Int).Add(chain.GetTd(parent.Hash(), parent.NumberU64()), totalDifficulty(blocks)); td.Cmp(chain.GetTd(head.Hash(), head.NumberU64())) <= 0 {
+				t.Fatalf("competing segment isn't heavier: have %v, local %v", td, chain.GetTd(head.Hash(), head.NumberU64()))
+			}
+			// Import the segment block by block, like a node would keep receiving it,
+			// and check the outcome once it is complete
+			var err error
+			for _, block := range blocks {
+				if _, err = chain.InsertChain(types.Blocks{block}); err != nil && !errors.Is(err, ErrReorgFinality) {
+					t.Fatalf("failed to insert block %d: %v", block.NumberU64(), err)
+				}
+			}
+			if tt.reorg {
+				if err != nil {
+					t.Fatalf("failed to reorg to competing segment: %v", err)
+				}
+				if last := blocks[len(blocks)-1]; chain.CurrentBlock().Hash() != last.Hash() {
+					t.Fatalf("head mismatch: have %d, want %d", chain.CurrentBlock().NumberU64(), last.NumberU64())
+				}
+				return
+			}
+			if !errors.Is(err, ErrReorgFinality) {
+				t.Fatalf("competing segment error mismatch: have %v, want %v", err, ErrReorgFinality)
+			}
+			if chain.CurrentBlock().Hash() != head.Hash() {
+				t.Fatalf("head changed: have %d, want %d", chain.CurrentBlock().NumberU64(), head.NumberU64())
+			}
+		})
+	}
+}
+
+// totalDifficulty sums the difficulties of the given blocks.
+func totalDifficulty(blocks []*types.Block) *big.Int {
+	td := new(big.Int)
+	for _, block := range blocks {
+		td.Add(td, block.Difficulty())
+	}
+	return td
+}
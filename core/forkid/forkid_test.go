@@ -161,8 +161,11 @@ func TestCreation(t *testing.T) {
 				{9573000, ID{Hash: checksumToBytes(0x7ba22882), Next: 10500839}},
 				{9573001, ID{Hash: checksumToBytes(0x7ba22882), Next: 10500839}},
 				{10500838, ID{Hash: checksumToBytes(0x7ba22882), Next: 10500839}},
-				{10500839, ID{Hash: checksumToBytes(0x9007bfcc), Next: 100000000}},
-				{10500840, ID{Hash: checksumToBytes(0x9007bfcc), Next: 100000000}},
+				{10500839, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}},
+				{10500840, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}},
+				{11699999, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}},
+				{11700000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 0}},
+				{11700001, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 0}},
 			},
 		},
 		{
@@ -178,8 +181,8 @@ func TestCreation(t *testing.T) {
 				{1705549, ID{Hash: checksumToBytes(0x8f3698e0), Next: 2200013}},
 				{1705550, ID{Hash: checksumToBytes(0x8f3698e0), Next: 2200013}},
 				{2200012, ID{Hash: checksumToBytes(0x8f3698e0), Next: 2200013}},
-				{2200013, ID{Hash: checksumToBytes(0x6f402821), Next: 100000000}},
-				{2200014, ID{Hash: checksumToBytes(0x6f402821), Next: 100000000}},
+				{2200013, ID{Hash: checksumToBytes(0x6f402821), Next: 0}},
+				{2200014, ID{Hash: checksumToBytes(0x6f402821), Next: 0}},
 			},
 		},
 		{
@@ -197,8 +200,11 @@ func TestCreation(t *testing.T) {
 				{301243, ID{Hash: checksumToBytes(0x604f6ee1), Next: 999983}},
 				{301244, ID{Hash: checksumToBytes(0x604f6ee1), Next: 999983}},
 				{999982, ID{Hash: checksumToBytes(0x604f6ee1), Next: 999983}},
-				{999983, ID{Hash: checksumToBytes(0xf42f5539), Next: 0}},
-				{999984, ID{Hash: checksumToBytes(0xf42f5539), Next: 0}},
+				{999983, ID{Hash: checksumToBytes(0xf42f5539), Next: 2520000}},
+				{999984, ID{Hash: checksumToBytes(0xf42f5539), Next: 2520000}},
+				{2519999, ID{Hash: checksumToBytes(0xf42f5539), Next: 2520000}},
+				{2520000, ID{Hash: checksumToBytes(0x66b5c286), Next: 0}},
+				{2520001, ID{Hash: checksumToBytes(0x66b5c286), Next: 0}},
 			},
 		},
 	}
@@ -321,7 +327,7 @@ func TestGatherForks(t *testing.T) {
 		{
 			"classic",
 			params.ClassicChainConfig,
-			[]uint64{1150000, 2500000, 3000000, 5000000, 5900000, 8772000, 9573000, 10500839},
+			[]uint64{1150000, 2500000, 3000000, 5000000, 5900000, 8772000, 9573000, 10500839, 11700000},
 		},
 		{
 			"mainnet",
@@ -331,7 +337,7 @@ func TestGatherForks(t *testing.T) {
 		{
 			"mordor",
 			params.MordorChainConfig,
-			[]uint64{301_243, 999_983},
+			[]uint64{301_243, 999_983, 2_520_000},
 		},
 		{
 			"kotti",
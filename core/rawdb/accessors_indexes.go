@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -173,3 +174,97 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// Roles an address may have in a transaction of the address transaction index.
+const (
+	AddressTxFrom     uint8 = 1 << iota // Address is the sender of the transaction
+	AddressTxTo                         // Address is the recipient of the transaction
+	AddressTxCreate                     // Address is the contract created by the transaction
+	AddressTxInternal                   // Address is touched by an internal call of the transaction
+)
+
+// AddressTxEntry is a transaction an address took part in, as recorded by the
+// address transaction index.
+type AddressTxEntry struct {
+	BlockNumber uint64 `rlp:"-"` // Stored in the database key
+	Index       uint32 `rlp:"-"` // Stored in the database key
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	Roles       uint8
+}
+
+// ReadAddressTxEntries retrieves the indexed transactions of an address, starting
+// at the given block number and transaction index up to and including the block
+// number to. At most limit entries are returned.
+func ReadAddressTxEntries(db ethdb.Iteratee, address common.Address, from uint64, index uint32, to uint64, limit int) []*AddressTxEntry {
+	prefix := append(append([]byte{}, addressTxPrefix...), address.Bytes()...)
+	start := addressTxKey(address, from, index)[len(prefix):]
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	var entries []*AddressTxEntry
+	for len(entries) < limit && it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+12 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		entry := new(AddressTxEntry)
+		if err := rlp.DecodeBytes(it.Value(), entry); err != nil {
+			log.Error("Invalid address transaction entry RLP", "address", address, "blob", it.Value(), "err", err)
+			continue
+		}
+		entry.BlockNumber, entry.Index = number, binary.BigEndian.Uint32(key[len(prefix)+8:])
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// WriteAddressTxEntry stores a transaction an address took part in into the
+// address transaction index.
+func WriteAddressTxEntry(db ethdb.KeyValueWriter, address common.Address, entry *AddressTxEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to RLP encode address transaction entry", "err", err)
+	}
+	if err := db.Put(addressTxKey(address, entry.BlockNumber, entry.Index), data); err != nil {
+		log.Crit("Failed to store address transaction entry", "err", err)
+	}
+}
+
+// DeleteAddressTxIndex removes the entire address transaction index, along with
+// the progress of its chain indexer.
+func DeleteAddressTxIndex(db ethdb.KeyValueStore) error {
+	batch := db.NewBatch()
+	for _, prefix := range [][]byte{addressTxPrefix, AddressTxIndexPrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			// Skip the trie nodes sharing the prefix by chance
+			key := it.Key()
+			if len(key) == common.HashLength {
+				continue
+			}
+			if bytes.Equal(prefix, addressTxPrefix) && len(key) != len(addressTxPrefix)+common.AddressLength+12 {
+				continue
+			}
+			batch.Delete(key)
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
@@ -44,17 +44,22 @@ func InitDatabaseFromFreezer(db ethdb.Database) {
 		logged = start.Add(-7 * time.Second) // Unindex during import is fast, don't double log
 		hash   common.Hash
 	)
-	for i := uint64(0); i < frozen; i++ {
-		// Since the freezer has all data in sequential order on a file,
-		// it would be 'neat' to read more data in one go, and let the
-		// freezerdb return N items (e.g up to 1000 items per go)
-		// That would require an API change in Ancients though
-		if h, err := db.Ancient(freezerHashTable, i); err != nil {
+	for i := uint64(0); i < frozen; {
+		// Since the freezer has all data in sequential order, read the hashes
+		// in batches, in a single call to the ancient stores supporting it
+		count := frozen - i
+		if count > freezerRemoteBatchItems {
+			count = freezerRemoteBatchItems
+		}
+		hashes, err := readAncients(db, freezerHashTable, i, count)
+		if err != nil {
 			log.Crit("Failed to init database from freezer", "err", err)
-		} else {
+		}
+		for _, h := range hashes {
 			hash = common.BytesToHash(h)
+			WriteHeaderNumber(batch, hash, i)
+			i++
 		}
-		WriteHeaderNumber(batch, hash, i)
 		// If enough data was accumulated in memory or we're at the last block, dump to disk
 		if batch.ValueSize() > ethdb.IdealBatchSize {
 			if err := batch.Write(); err != nil {
@@ -78,6 +83,10 @@ func InitDatabaseFromFreezer(db ethdb.Database) {
 	log.Info("Initialized database from freezer", "blocks", frozen, "elapsed", common.PrettyDuration(time.Since(start)))
 }
 
+// ancientBodiesBatch is the maximum number of frozen block bodies read in one go
+// while iterating over the transactions of blocks.
+const ancientBodiesBatch = 128
+
 type blockTxHashes struct {
 	number uint64
 	hashes []common.Hash
@@ -110,8 +119,42 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 			n, end = to-1, from-1
 		}
 		defer close(rlpCh)
+
+		// Frozen bodies are read in batches, in a single call to the ancient
+		// stores supporting it
+		var (
+			frozen, _ = db.Ancients()
+			bodies    [][]byte // Batch of frozen bodies, starting at number first
+			first     uint64
+		)
 		for n != end {
-			data := ReadCanonicalBodyRLP(db, n)
+			var data rlp.RawValue
+			if n < frozen && (n < first || n-first >= uint64(len(bodies))) {
+				last := n
+				if reverse {
+					first = from
+					if n-from >= ancientBodiesBatch {
+						first = n - ancientBodiesBatch + 1
+					}
+				} else {
+					first, last = n, n+ancientBodiesBatch-1
+					if last >= to {
+						last = to - 1
+					}
+					if last >= frozen {
+						last = frozen - 1
+					}
+				}
+				var err error
+				if bodies, err = readAncients(db, freezerBodiesTable, first, last-first+1); err != nil {
+					log.Warn("Failed to read ancient block bodies", "first", first, "last", last, "err", err)
+				}
+			}
+			if n >= first && n-first < uint64(len(bodies)) {
+				data = bodies[n-first]
+			} else {
+				data = ReadCanonicalBodyRLP(db, n)
+			}
 			// Feed the block to the aggregator, or abort on interrupt
 			select {
 			case rlpCh <- &numberRlp{n, data}:
@@ -54,6 +54,19 @@ func (frdb *freezerdb) Close() error {
 	return nil
 }
 
+// AncientRange retrieves consecutive ancient items of a kind in a single call if
+// the ancient store supports range reads, or else the item at number start only.
+func (frdb *freezerdb) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	if rr, ok := frdb.AncientStore.(ancientRangeReader); ok {
+		return rr.AncientRange(kind, start, count, maxBytes)
+	}
+	blob, err := frdb.AncientStore.Ancient(kind, start)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{blob}, nil
+}
+
 // nofreezedb is a database wrapper that disables freezer data retrievals.
 type nofreezedb struct {
 	ethdb.KeyValueStore
@@ -341,6 +354,7 @@ func InspectDatabase(db ethdb.Database) error {
 		storageSnapSize common.StorageSize
 		preimageSize    common.StorageSize
 		bloomBitsSize   common.StorageSize
+		addressTxSize   common.StorageSize
 		cliqueSnapsSize common.StorageSize
 
 		// Ancient store statistics
@@ -388,6 +402,8 @@ func InspectDatabase(db ethdb.Database) error {
 			preimageSize += size
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBitsSize += size
+		case bytes.HasPrefix(key, addressTxPrefix) && len(key) == (len(addressTxPrefix)+common.AddressLength+12):
+			addressTxSize += size
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnapsSize += size
 		case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
@@ -433,6 +449,7 @@ func InspectDatabase(db ethdb.Database) error {
 		{"Key-Value store", "Block hash->number", hashNumPairing.String()},
 		{"Key-Value store", "Transaction index", txlookupSize.String()},
 		{"Key-Value store", "Bloombit index", bloomBitsSize.String()},
+		{"Key-Value store", "Address transaction index", addressTxSize.String()},
 		{"Key-Value store", "Trie nodes", trieSize.String()},
 		{"Key-Value store", "Trie preimages", preimageSize.String()},
 		{"Key-Value store", "Account snapshot", accountSnapSize.String()},
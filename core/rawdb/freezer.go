@@ -128,11 +128,25 @@ func newFreezer(datadir string, namespace string) (*freezer, error) {
 	return freezer, nil
 }
 
+// NewFreezer creates a freezer over the append-only flat files in datadir,
+// without the background process moving chain data from a key-value store.
+// It is intended to back standalone ancient stores, like remote freezer servers.
+func NewFreezer(datadir string, namespace string) (ethdb.AncientStore, error) {
+	f, err := newFreezer(datadir, namespace)
+	if err != nil {
+		return nil, err
+	}
+	f.quit = nil // There is no freezing loop to signal on close.
+	return f, nil
+}
+
 // Close terminates the chain freezer, unmapping all the data files.
 func (f *freezer) Close() error {
 	var errs []error
 	f.closeOnce.Do(func() {
-		f.quit <- struct{}{}
+		if f.quit != nil {
+			f.quit <- struct{}{}
+		}
 		for _, table := range f.tables {
 			if err := table.Close(); err != nil {
 				errs = append(errs, err)
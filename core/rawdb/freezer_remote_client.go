@@ -1,6 +1,7 @@
 package rawdb
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -8,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params/vars"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/snappy"
 )
 
 // FreezerRemoteClient is an RPC client implementing the interface of ethdb.AncientStore.
@@ -16,6 +18,9 @@ import (
 type FreezerRemoteClient struct {
 	client *rpc.Client
 	quit   chan struct{}
+
+	caps        FreezerRemoteCapabilities // Negotiated with the server on construction
+	compression string                    // Blob compression used for batch methods
 }
 
 const (
@@ -27,17 +32,141 @@ const (
 	FreezerMethodAppendAncient    = "freezer_appendAncient"
 	FreezerMethodTruncateAncients = "freezer_truncateAncients"
 	FreezerMethodSync             = "freezer_sync"
+
+	// The following methods are part of protocol version 2, and are only used
+	// if the server reports support for them with freezer_capabilities.
+	FreezerMethodCapabilities   = "freezer_capabilities"
+	FreezerMethodAppendAncients = "freezer_appendAncients"
+	FreezerMethodAncientRange   = "freezer_ancientRange"
+)
+
+const (
+	// FreezerRemoteProtocolVersion is the latest version of the remote freezer protocol.
+	// Version 1 servers implement the per-item methods only, and version 2 servers
+	// add capabilities negotiation, batch appends and range reads.
+	FreezerRemoteProtocolVersion = 2
+
+	// FreezerRemoteCompressionNone indicates blobs are sent as-is.
+	FreezerRemoteCompressionNone = ""
+
+	// FreezerRemoteCompressionSnappy indicates blobs are individually snappy encoded.
+	FreezerRemoteCompressionSnappy = "snappy"
 )
 
+const (
+	// freezerRemoteBatchItems is the maximum number of blocks sent in one batch append.
+	freezerRemoteBatchItems = 1024
+
+	// freezerRemoteBatchBytes is the maximum (uncompressed) size of the blocks sent in
+	// one batch append. It is kept well below the default 5MB HTTP request limit of
+	// RPC servers, allowing for the inflation of base64 encoding.
+	freezerRemoteBatchBytes = 2 * 1024 * 1024
+)
+
+// FreezerRemoteCapabilities describes the protocol features supported by a remote
+// freezer server, as returned by freezer_capabilities.
+type FreezerRemoteCapabilities struct {
+	Version     uint64   `json:"version"`
+	Compression []string `json:"compression"`
+}
+
+// FreezerRemoteBlock holds all binary blobs belonging to a block, as sent by
+// freezer_appendAncients.
+type FreezerRemoteBlock struct {
+	Hash     []byte `json:"hash"`
+	Header   []byte `json:"header"`
+	Body     []byte `json:"body"`
+	Receipts []byte `json:"receipts"`
+	Td       []byte `json:"td"`
+}
+
+func (b *FreezerRemoteBlock) size() int {
+	return len(b.Hash) + len(b.Header) + len(b.Body) + len(b.Receipts) + len(b.Td)
+}
+
+// Encode returns the block with all blobs compressed for the wire.
+func (b FreezerRemoteBlock) Encode(compression string) (FreezerRemoteBlock, error) {
+	return b.transform(compression, EncodeFreezerRemoteBlob)
+}
+
+// Decode returns the block with all blobs decompressed.
+func (b FreezerRemoteBlock) Decode(compression string) (FreezerRemoteBlock, error) {
+	return b.transform(compression, DecodeFreezerRemoteBlob)
+}
+
+func (b FreezerRemoteBlock) transform(compression string, fn func(string, []byte) ([]byte, error)) (res FreezerRemoteBlock, err error) {
+	if res.Hash, err = fn(compression, b.Hash); err != nil {
+		return res, err
+	}
+	if res.Header, err = fn(compression, b.Header); err != nil {
+		return res, err
+	}
+	if res.Body, err = fn(compression, b.Body); err != nil {
+		return res, err
+	}
+	if res.Receipts, err = fn(compression, b.Receipts); err != nil {
+		return res, err
+	}
+	res.Td, err = fn(compression, b.Td)
+	return res, err
+}
+
+// EncodeFreezerRemoteBlob compresses a blob for the wire.
+func EncodeFreezerRemoteBlob(compression string, blob []byte) ([]byte, error) {
+	switch compression {
+	case FreezerRemoteCompressionNone:
+		return blob, nil
+	case FreezerRemoteCompressionSnappy:
+		return snappy.Encode(nil, blob), nil
+	}
+	return nil, fmt.Errorf("unsupported compression: %q", compression)
+}
+
+// DecodeFreezerRemoteBlob decompresses a blob received from the wire.
+func DecodeFreezerRemoteBlob(compression string, blob []byte) ([]byte, error) {
+	switch compression {
+	case FreezerRemoteCompressionNone:
+		return blob, nil
+	case FreezerRemoteCompressionSnappy:
+		return snappy.Decode(nil, blob)
+	}
+	return nil, fmt.Errorf("unsupported compression: %q", compression)
+}
+
 // newFreezerRemoteClient constructs a rpc client to connect to a remote freezer
 func newFreezerRemoteClient(endpoint string) (*FreezerRemoteClient, error) {
 	client, err := rpc.Dial(endpoint)
 	if err != nil {
 		return nil, err
 	}
-	return &FreezerRemoteClient{
+	return NewFreezerRemoteClient(client)
+}
+
+// NewFreezerRemoteClient creates a remote freezer client over an established RPC
+// connection, negotiating the protocol version with the server.
+func NewFreezerRemoteClient(client *rpc.Client) (*FreezerRemoteClient, error) {
+	api := &FreezerRemoteClient{
 		client: client,
-	}, nil
+	}
+	if err := api.client.Call(&api.caps, FreezerMethodCapabilities); err != nil {
+		if rpcErr, ok := err.(rpc.Error); !ok || rpcErr.ErrorCode() != -32601 {
+			return nil, err
+		}
+		// Servers predating capabilities negotiation only support per-item methods.
+		api.caps = FreezerRemoteCapabilities{Version: 1}
+	}
+	for _, c := range api.caps.Compression {
+		if c == FreezerRemoteCompressionSnappy {
+			api.compression = c
+		}
+	}
+	log.Info("Negotiated remote freezer protocol", "version", api.caps.Version, "compression", api.compression)
+	return api, nil
+}
+
+// supportsBatch reports whether the server supports the batch methods.
+func (api *FreezerRemoteClient) supportsBatch() bool {
+	return api.caps.Version >= 2
 }
 
 // Close terminates the chain freezer, unmapping all the data files.
@@ -88,6 +217,72 @@ func (api *FreezerRemoteClient) AppendAncient(number uint64, hash, header, body,
 	return api.client.Call(nil, FreezerMethodAppendAncient, number, hash, header, body, receipts, td)
 }
 
+// AppendAncients injects a batch of consecutive blocks, the first being numbered start,
+// at the end of the append-only immutable table files.
+//
+// If the server doesn't support batch appends, the blocks are appended one by one.
+// On failure, some blocks may have been appended; Ancients reports how many.
+func (api *FreezerRemoteClient) AppendAncients(start uint64, blocks []FreezerRemoteBlock) error {
+	if !api.supportsBatch() {
+		for i, b := range blocks {
+			if err := api.AppendAncient(start+uint64(i), b.Hash, b.Header, b.Body, b.Receipts, b.Td); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	enc := make([]FreezerRemoteBlock, len(blocks))
+	for i, b := range blocks {
+		var err error
+		if enc[i], err = b.Encode(api.compression); err != nil {
+			return err
+		}
+	}
+	return api.client.Call(nil, FreezerMethodAppendAncients, start, enc, api.compression)
+}
+
+// AncientRange retrieves up to count consecutive ancient binary blobs of a kind,
+// starting at number start. Fewer items are returned if the range extends past the
+// frozen items, or once the blobs returned total at least maxBytes.
+// At least one item is returned, or an error if start isn't frozen.
+//
+// If the server doesn't support range reads, the items are retrieved one by one.
+func (api *FreezerRemoteClient) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	if !api.supportsBatch() {
+		frozen, err := api.Ancients()
+		if err != nil {
+			return nil, err
+		}
+		if start >= frozen {
+			return nil, errOutOfBounds
+		}
+		var (
+			res  [][]byte
+			size uint64
+		)
+		for n := start; n-start < count && n < frozen && (len(res) == 0 || size < maxBytes); n++ {
+			blob, err := api.Ancient(kind, n)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, blob)
+			size += uint64(len(blob))
+		}
+		return res, nil
+	}
+	var res [][]byte
+	if err := api.client.Call(&res, FreezerMethodAncientRange, kind, start, count, maxBytes, api.compression); err != nil {
+		return nil, err
+	}
+	for i := range res {
+		var err error
+		if res[i], err = DecodeFreezerRemoteBlob(api.compression, res[i]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 // TruncateAncients discards any recent data above the provided threshold number.
 func (api *FreezerRemoteClient) TruncateAncients(items uint64) error {
 	return api.client.Call(nil, FreezerMethodTruncateAncients, items)
@@ -98,6 +293,92 @@ func (api *FreezerRemoteClient) Sync() error {
 	return api.client.Call(nil, FreezerMethodSync)
 }
 
+// ancientBatchWriter is implemented by ancient stores supporting batch appends.
+type ancientBatchWriter interface {
+	AppendAncients(start uint64, blocks []FreezerRemoteBlock) error
+}
+
+// appendAncients appends a batch of consecutive blocks to the ancient store,
+// in a single call if the store supports it.
+func appendAncients(f ethdb.AncientStore, start uint64, blocks []FreezerRemoteBlock) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	if bw, ok := f.(ancientBatchWriter); ok {
+		return bw.AppendAncients(start, blocks)
+	}
+	for i, b := range blocks {
+		if err := f.AppendAncient(start+uint64(i), b.Hash, b.Header, b.Body, b.Receipts, b.Td); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ancientRangeReader is implemented by ancient stores supporting range reads.
+type ancientRangeReader interface {
+	AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error)
+}
+
+// readAncients retrieves count consecutive ancient items of a kind, starting at
+// number start, in as few calls as the ancient store allows. Stores not supporting
+// range reads are read one item at a time.
+func readAncients(db ethdb.AncientReader, kind string, start, count uint64) ([][]byte, error) {
+	items := make([][]byte, 0, count)
+	for uint64(len(items)) < count {
+		next := start + uint64(len(items))
+		rr, ok := db.(ancientRangeReader)
+		if !ok {
+			blob, err := db.Ancient(kind, next)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, blob)
+			continue
+		}
+		blobs, err := rr.AncientRange(kind, next, count-uint64(len(items)), freezerRemoteBatchBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(blobs) == 0 {
+			return nil, errOutOfBounds
+		}
+		items = append(items, blobs...)
+	}
+	return items, nil
+}
+
+// readFreezableBlock retrieves all the components of the canonical block number,
+// logging any which is missing.
+func readFreezableBlock(db ethdb.Reader, number uint64) (FreezerRemoteBlock, common.Hash, bool) {
+	hash := ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		log.Error("Canonical hash missing, can't freeze", "number", number)
+		return FreezerRemoteBlock{}, hash, false
+	}
+	header := ReadHeaderRLP(db, hash, number)
+	if len(header) == 0 {
+		log.Error("Block header missing, can't freeze", "number", number, "hash", hash)
+		return FreezerRemoteBlock{}, hash, false
+	}
+	body := ReadBodyRLP(db, hash, number)
+	if len(body) == 0 {
+		log.Error("Block body missing, can't freeze", "number", number, "hash", hash)
+		return FreezerRemoteBlock{}, hash, false
+	}
+	receipts := ReadReceiptsRLP(db, hash, number)
+	if len(receipts) == 0 {
+		log.Error("Block receipts missing, can't freeze", "number", number, "hash", hash)
+		return FreezerRemoteBlock{}, hash, false
+	}
+	td := ReadTdRLP(db, hash, number)
+	if len(td) == 0 {
+		log.Error("Total difficulty missing, can't freeze", "number", number, "hash", hash)
+		return FreezerRemoteBlock{}, hash, false
+	}
+	return FreezerRemoteBlock{Hash: hash[:], Header: header, Body: body, Receipts: receipts, Td: td}, hash, true
+}
+
 // freezeRemote is a background thread that periodically checks the blockchain for any
 // import progress and moves ancient data from the fast database into the freezer.
 //
@@ -177,39 +458,43 @@ func freezeRemote(db ethdb.KeyValueStore, f ethdb.AncientStore, quitChan chan st
 			ancients = make([]common.Hash, 0, limit-numFrozen)
 		)
 		for numFrozen < limit {
-			// Retrieves all the components of the canonical block
-			hash := ReadCanonicalHash(nfdb, numFrozen)
-			if hash == (common.Hash{}) {
-				log.Error("Canonical hash missing, can't freeze", "number", numFrozen)
-				break
-			}
-			header := ReadHeaderRLP(nfdb, hash, numFrozen)
-			if len(header) == 0 {
-				log.Error("Block header missing, can't freeze", "number", numFrozen, "hash", hash)
-				break
-			}
-			body := ReadBodyRLP(nfdb, hash, numFrozen)
-			if len(body) == 0 {
-				log.Error("Block body missing, can't freeze", "number", numFrozen, "hash", hash)
-				break
-			}
-			receipts := ReadReceiptsRLP(nfdb, hash, numFrozen)
-			if len(receipts) == 0 {
-				log.Error("Block receipts missing, can't freeze", "number", numFrozen, "hash", hash)
-				break
-			}
-			td := ReadTdRLP(nfdb, hash, numFrozen)
-			if len(td) == 0 {
-				log.Error("Total difficulty missing, can't freeze", "number", numFrozen, "hash", hash)
-				break
+			// Collect a batch of canonical blocks to send to the remote freezer
+			var (
+				batch  []FreezerRemoteBlock
+				hashes []common.Hash
+				size   int
+				broken bool
+			)
+			for n := numFrozen; n < limit && len(batch) < freezerRemoteBatchItems && size < freezerRemoteBatchBytes; n++ {
+				block, hash, ok := readFreezableBlock(nfdb, n)
+				if !ok {
+					broken = true
+					break
+				}
+				log.Trace("Deep froze ancient block", "number", n, "hash", hash)
+				batch = append(batch, block)
+				hashes = append(hashes, hash)
+				size += block.size()
 			}
-			log.Trace("Deep froze ancient block", "number", numFrozen, "hash", hash)
 			// Inject all the components into the relevant data tables
-			if err := f.AppendAncient(numFrozen, hash[:], header, body, receipts, td); err != nil {
+			if err := appendAncients(f, numFrozen, batch); err != nil {
+				log.Error("Failed to freeze ancient blocks", "number", numFrozen, "count", len(batch), "err", err)
+				broken = true
+				// Some of the batch may have been frozen before the failure.
+				frozen, err := f.Ancients()
+				if err != nil {
+					log.Crit("ancient db freeze", "error", err)
+				}
+				if frozen < numFrozen || frozen > numFrozen+uint64(len(batch)) {
+					log.Crit("Remote freezer items out of range", "frozen", frozen, "from", numFrozen, "count", len(batch))
+				}
+				hashes = hashes[:frozen-numFrozen]
+			}
+			numFrozen += uint64(len(hashes)) // Manually increment numFrozen (save a call)
+			ancients = append(ancients, hashes...)
+			if broken {
 				break
 			}
-			numFrozen++ // Manually increment numFrozen (save a call)
-			ancients = append(ancients, hash)
 		}
 		// Batch of blocks have been frozen, flush them before wiping from leveldb
 		if err := f.Sync(); err != nil {
@@ -96,3 +96,35 @@ func TestClient1(t *testing.T) {
 		t.Fatalf("got: %d, want: 670", n)
 	}
 }
+
+// TestClientFallback checks that the batch methods fall back to per-item calls
+// against servers implementing only protocol version 1.
+func TestClientFallback(t *testing.T) {
+	frClient, err := NewFreezerRemoteClient(rpc.DialInProc(newTestServer(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frClient.supportsBatch() {
+		t.Fatal("batch methods negotiated with version 1 server")
+	}
+	var blocks []FreezerRemoteBlock
+	for n := byte(0); n < 5; n++ {
+		blocks = append(blocks, FreezerRemoteBlock{[]byte{n}, []byte{n}, []byte{n}, []byte{n}, []byte{n}})
+	}
+	if err := frClient.AppendAncients(0, blocks); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if n, err := frClient.Ancients(); err != nil || n != 5 {
+		t.Fatalf("ancients: want 5, got %d (err %v)", n, err)
+	}
+	res, err := frClient.AncientRange(FreezerRemoteHeaderTable, 1, 10, 2)
+	if err != nil {
+		t.Fatalf("ancient range: %v", err)
+	}
+	if want := [][]byte{{1}, {2}}; len(res) != len(want) || !bytes.Equal(res[0], want[0]) || !bytes.Equal(res[1], want[1]) {
+		t.Fatalf("ancient range: want %x, got %x", want, res)
+	}
+	if _, err := frClient.AncientRange(FreezerRemoteHeaderTable, 5, 1, 2); err == nil {
+		t.Fatal("ancient range starting at head: want error")
+	}
+}
@@ -0,0 +1,496 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements the offline pruning of the historical state data,
+// based on the state snapshot.
+package pruner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// stateBloomFilePrefix is the filename prefix of state bloom filter.
+	stateBloomFilePrefix = "statebloom"
+
+	// stateBloomFileSuffix is the filename suffix of state bloom filter.
+	stateBloomFileSuffix = "bf.gz"
+
+	// stateBloomFileTempSuffix is the filename suffix of state bloom filter
+	// while it is being written out to detect write aborts.
+	stateBloomFileTempSuffix = ".tmp"
+
+	// rangeCompactionThreshold is the minimal deleted entry number for
+	// triggering range compaction. It's a quite arbitrary number but just
+	// to avoid triggering range compaction because of small deletion.
+	rangeCompactionThreshold = 100000
+
+	// snapshotCache is the size in megabytes of the read cache of the snapshot
+	// tree opened for pruning.
+	snapshotCache = 256
+
+	// maxSnapshotLayers is the maximum number of snapshot layers inspected, the
+	// 128 layers of recent states along with the disk layer.
+	maxSnapshotLayers = 129
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256(nil)
+)
+
+// Pruner is an offline tool to prune the stale state with the help of the
+// snapshot. The workflow of pruner is very simple:
+//
+//   - iterate the snapshot, reconstruct the relevant state
+//   - iterate the database, delete all other state entries which
+//     don't belong to the target state and the genesis state
+//
+// It can take several hours (around 2 hours for mainnet) to finish the whole
+// pruning work. It's recommended to run this offline tool periodically in
+// order to release disk usage and improve the disk read performance to some
+// extent.
+type Pruner struct {
+	db            ethdb.Database
+	stateBloom    *stateBloom
+	datadir       string
+	trieCachePath string
+	headHeader    *types.Header
+	snaptree      *snapshot.Tree
+}
+
+// NewPruner creates the pruner instance, loading the snapshot of the current
+// head state. The state bloom filter is bloomSize megabytes large.
+func NewPruner(db ethdb.Database, datadir, trieCachePath string, bloomSize uint64) (*Pruner, error) {
+	headBlock := rawdb.ReadHeadBlock(db)
+	if headBlock == nil {
+		return nil, errors.New("failed to load head block")
+	}
+	snaptree, err := snapshot.New(db, trie.NewDatabase(db), snapshotCache, headBlock.Root(), false, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot (was the node run with --snapshot and stopped gracefully?): %v", err)
+	}
+	// Sanitize the bloom filter size if it's too small.
+	if bloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", bloomSize, "updated(MB)", 256)
+		bloomSize = 256
+	}
+	stateBloom, err := newStateBloomWithSize(bloomSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Pruner{
+		db:            db,
+		stateBloom:    stateBloom,
+		datadir:       datadir,
+		trieCachePath: trieCachePath,
+		headHeader:    headBlock.Header(),
+		snaptree:      snaptree,
+	}, nil
+}
+
+// Prune deletes all historical state nodes except the nodes belonging to the
+// specified state version. If the state version isn't specified, the bottom-most
+// snapshot layer with its state available is used as the target, normally the
+// persisted disk layer.
+func (p *Pruner) Prune(root common.Hash) error {
+	// If the state bloom filter is already committed previously, reuse it for
+	// pruning instead of generating a new one. It's mandatory because a part of
+	// the state may already be deleted, the recovery procedure is necessary.
+	if path, _, err := findBloomFilter(p.datadir); err != nil {
+		return err
+	} else if path != "" {
+		return RecoverPruning(p.datadir, p.db, p.trieCachePath)
+	}
+	layers := p.snaptree.Snapshots(p.headHeader.Root, maxSnapshotLayers, false)
+	if root == (common.Hash{}) {
+		// Pick the bottom-most layer with state available, ignoring HEAD and
+		// HEAD-1, whose states are usually available but which are the ones
+		// most likely to be reorged.
+		for i := len(layers) - 1; i >= 2; i-- {
+			if hasState(p.db, layers[i].Root()) {
+				root = layers[i].Root()
+				break
+			}
+		}
+		if root == (common.Hash{}) {
+			return fmt.Errorf("no snapshot layer with available state below HEAD-1 (%d layers)", len(layers))
+		}
+		log.Info("Selecting bottom-most snapshot layer as the pruning target", "root", root)
+	} else {
+		if p.snaptree.Snapshot(root) == nil {
+			return fmt.Errorf("snapshot [%#x] missing", root)
+		}
+		// Ensure the root is really present. The weak assumption is the presence
+		// of root can indicate the presence of the entire trie.
+		if !hasState(p.db, root) {
+			return fmt.Errorf("associated state [%#x] is not present", root)
+		}
+		log.Info("Selecting user-specified state as the pruning target", "root", root)
+	}
+	// Before starting the pruning, delete the clean trie cache first. It's
+	// necessary, otherwise in the next restart we would hit the deleted state
+	// root in the clean cache, and the incomplete state would be picked up.
+	deleteCleanTrieCache(p.trieCachePath)
+
+	// Traverse the target state, re-construct the whole state trie and commit it
+	// into the bloom filter, along with the genesis state.
+	start := time.Now()
+	if err := snapshot.GenerateTrie(p.snaptree, root, p.db, p.stateBloom); err != nil {
+		return err
+	}
+	if err := extractGenesis(p.db, p.stateBloom); err != nil {
+		return err
+	}
+	filterName := bloomFilterName(p.datadir, root)
+
+	log.Info("Writing state bloom to disk", "name", filterName)
+	if err := p.stateBloom.Commit(filterName, filterName+stateBloomFileTempSuffix); err != nil {
+		return err
+	}
+	log.Info("State bloom filter committed", "name", filterName)
+
+	return prune(p.snaptree, root, p.db, p.stateBloom, filterName, middleRoots(p.db, p.headHeader, root, layers), start)
+}
+
+// RecoverPruning resumes the pruning procedure on system restart. This function
+// is supposed to be called while the node is starting up, before the chain is
+// loaded: if a previous pruning was interrupted after the state bloom filter
+// was committed, a part of the state may already be deleted, so the pruning has
+// to be finished for the node to be usable again.
+func RecoverPruning(datadir string, db ethdb.Database, trieCachePath string) error {
+	stateBloomPath, stateBloomRoot, err := findBloomFilter(datadir)
+	if err != nil {
+		return err
+	}
+	if stateBloomPath == "" {
+		return nil // nothing to recover
+	}
+	headBlock := rawdb.ReadHeadBlock(db)
+	if headBlock == nil {
+		return errors.New("failed to load head block")
+	}
+	// Load the snapshot tree in recovery mode: if the previous pruning got far
+	// enough to persist the target layer, the snapshot head doesn't match the
+	// chain head anymore.
+	snaptree, err := snapshot.New(db, trie.NewDatabase(db), snapshotCache, headBlock.Root(), false, false, true)
+	if err != nil {
+		return err
+	}
+	if snaptree.Snapshot(stateBloomRoot) == nil {
+		log.Error("Pruning target state is not existent", "root", stateBloomRoot)
+		return errors.New("non-existent target state")
+	}
+	stateBloom, err := newStateBloomFromDisk(stateBloomPath)
+	if err != nil {
+		return err
+	}
+	log.Info("Loaded state bloom filter", "path", stateBloomPath)
+
+	// Before starting the pruning, delete the clean trie cache first.
+	deleteCleanTrieCache(trieCachePath)
+
+	layers := snaptree.Snapshots(headBlock.Root(), maxSnapshotLayers, false)
+	return prune(snaptree, stateBloomRoot, db, stateBloom, stateBloomPath, middleRoots(db, headBlock.Header(), stateBloomRoot, layers), time.Now())
+}
+
+// prune deletes all state entries not contained in the state bloom from the
+// database, then drops the snapshot layers above the target one and finally
+// deletes the state bloom, marking the end of the pruning.
+func prune(snaptree *snapshot.Tree, root common.Hash, maindb ethdb.Database, stateBloom *stateBloom, bloomPath string, middleStateRoots map[common.Hash]struct{}, start time.Time) error {
+	// Delete all stale trie nodes in the disk. With the help of state bloom
+	// the trie nodes (and codes) belonging to the active state will be filtered
+	// out. A very small part of stale tries will also be filtered because of
+	// the false-positive rate of bloom filter, which is held low enough. The
+	// roots of the states more recent than the target are deleted forcibly, so
+	// the chain never rewinds onto one of them, left dangling.
+	var (
+		count  int
+		size   common.StorageSize
+		pstart = time.Now()
+		logged = time.Now()
+		batch  = maindb.NewBatch()
+		iter   = maindb.NewIterator(nil, nil)
+	)
+	for iter.Next() {
+		key := iter.Key()
+
+		// Trie nodes and contract codes are both keyed by their hashes, all
+		// other entries are longer or shorter.
+		if len(key) != common.HashLength {
+			continue
+		}
+		if _, exist := middleStateRoots[common.BytesToHash(key)]; exist {
+			log.Debug("Forcibly delete the middle state roots", "hash", common.BytesToHash(key))
+		} else if stateBloom.Contain(key) {
+			continue
+		}
+		count += 1
+		size += common.StorageSize(len(key) + len(iter.Value()))
+		batch.Delete(key)
+
+		if time.Since(logged) > 8*time.Second {
+			var eta time.Duration // Realistically will never remain uninited
+			if done := binary.BigEndian.Uint64(key[:8]); done > 0 {
+				var (
+					left  = math.MaxUint64 - binary.BigEndian.Uint64(key[:8])
+					speed = done/uint64(time.Since(pstart)/time.Millisecond+1) + 1 // +1s to avoid division by zero
+				)
+				eta = time.Duration(left/speed) * time.Millisecond
+			}
+			log.Info("Pruning state data", "nodes", count, "size", size,
+				"elapsed", common.PrettyDuration(time.Since(pstart)), "eta", common.PrettyDuration(eta))
+			logged = time.Now()
+		}
+		// Recreate the iterator after every batch commit in order
+		// to allow the underlying compactor to delete the entries.
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+
+			iter.Release()
+			iter = maindb.NewIterator(nil, key)
+		}
+	}
+	iter.Release()
+	if batch.ValueSize() > 0 {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	log.Info("Pruned state data", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+
+	// Pruning is done, now drop the useless layers from the snapshot. Firstly,
+	// flush the target layer into the disk if it's a diff layer: all the diff
+	// layers below are merged into the disk layer.
+	if len(snaptree.Snapshots(root, 1, true)) > 0 {
+		if err := snaptree.Cap(root, 0); err != nil {
+			return err
+		}
+	}
+	// Secondly, flush the snapshot journal into the disk from the target layer,
+	// silently dropping all the layers above it. Eventually the entire snapshot
+	// tree is converted into a single disk layer with the pruning target as the
+	// root.
+	if _, err := snaptree.Journal(root); err != nil {
+		return err
+	}
+	// Delete the state bloom, it marks the entire pruning procedure is finished.
+	// If any crashes or manual exit happens before this, RecoverPruning will
+	// pick it up in the next restart to redo all the things.
+	os.RemoveAll(bloomPath)
+
+	// Start compactions, which remove the deleted data from the disk immediately.
+	// Note for small pruning, the compaction is skipped.
+	if count >= rangeCompactionThreshold {
+		cstart := time.Now()
+		for b := 0x00; b <= 0xf0; b += 0x10 {
+			var (
+				start = []byte{byte(b)}
+				end   = []byte{byte(b + 0x10)}
+			)
+			if b == 0xf0 {
+				end = nil
+			}
+			log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", start, end), "elapsed", common.PrettyDuration(time.Since(cstart)))
+			if err := maindb.Compact(start, end); err != nil {
+				log.Error("Database compaction failed", "error", err)
+				return err
+			}
+		}
+		log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
+	}
+	log.Info("State pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// middleRoots collects the state roots more recent than the pruning target, to
+// be deleted forcibly: the roots of the canonical blocks from the head down to
+// the target, bounded by the snapshot disk layer, along with the roots of the
+// snapshot layers above the target.
+func middleRoots(db ethdb.Reader, head *types.Header, root common.Hash, layers []snapshot.Snapshot) map[common.Hash]struct{} {
+	roots := make(map[common.Hash]struct{})
+	for _, layer := range layers {
+		if layer.Root() == root {
+			break
+		}
+		roots[layer.Root()] = struct{}{}
+	}
+	var bottom common.Hash
+	if len(layers) > 0 {
+		bottom = layers[len(layers)-1].Root()
+	}
+	for header := head; header != nil && header.Root != root && header.Root != bottom; {
+		roots[header.Root] = struct{}{}
+		if header.Number.Sign() == 0 {
+			break
+		}
+		header = rawdb.ReadHeader(db, header.ParentHash, header.Number.Uint64()-1)
+	}
+	// The genesis state is always retained
+	delete(roots, root)
+	if genesis := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, 0), 0); genesis != nil {
+		delete(roots, genesis.Root)
+	}
+	return roots
+}
+
+// extractGenesis loads the genesis state and commits all the state entries
+// into the given bloomfilter.
+func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
+	genesisHash := rawdb.ReadCanonicalHash(db, 0)
+	if genesisHash == (common.Hash{}) {
+		return errors.New("missing genesis hash")
+	}
+	genesis := rawdb.ReadBlock(db, genesisHash, 0)
+	if genesis == nil {
+		return errors.New("missing genesis block")
+	}
+	t, err := trie.NewSecure(genesis.Root(), trie.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	accIter := t.NodeIterator(nil)
+	for accIter.Next(true) {
+		hash := accIter.Hash()
+
+		// Embedded nodes don't have hash.
+		if hash != (common.Hash{}) {
+			stateBloom.Put(hash.Bytes(), nil)
+		}
+		// If it's a leaf node, yes we are touching an account,
+		// dig into the storage trie further.
+		if accIter.Leaf() {
+			var acc state.Account
+			if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+				return err
+			}
+			if acc.Root != emptyRoot {
+				storageTrie, err := trie.NewSecure(acc.Root, trie.NewDatabase(db))
+				if err != nil {
+					return err
+				}
+				storageIter := storageTrie.NodeIterator(nil)
+				for storageIter.Next(true) {
+					hash := storageIter.Hash()
+					if hash != (common.Hash{}) {
+						stateBloom.Put(hash.Bytes(), nil)
+					}
+				}
+				if storageIter.Error() != nil {
+					return storageIter.Error()
+				}
+			}
+			if !bytes.Equal(acc.CodeHash, emptyCode) {
+				stateBloom.Put(acc.CodeHash, nil)
+			}
+		}
+	}
+	return accIter.Error()
+}
+
+// hasState reports whether the root node of the given state is present in the
+// database, the weak assumption being that the entire trie is then.
+func hasState(db ethdb.KeyValueReader, root common.Hash) bool {
+	blob, _ := db.Get(root.Bytes())
+	return len(blob) > 0
+}
+
+// bloomFilterName returns the path of the state bloom filter of the given state
+// in the data directory.
+func bloomFilterName(datadir string, hash common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", stateBloomFilePrefix, hash.Hex(), stateBloomFileSuffix))
+}
+
+// isBloomFilter reports whether the given file name is the one of a committed
+// state bloom filter, along with the state root it belongs to.
+func isBloomFilter(filename string) (bool, common.Hash) {
+	filename = filepath.Base(filename)
+	if strings.HasPrefix(filename, stateBloomFilePrefix) && strings.HasSuffix(filename, stateBloomFileSuffix) {
+		return true, common.HexToHash(filename[len(stateBloomFilePrefix)+1 : len(filename)-len(stateBloomFileSuffix)-1])
+	}
+	return false, common.Hash{}
+}
+
+// findBloomFilter looks for a committed state bloom filter in the data directory,
+// returning its path and the state root it belongs to if one is found.
+func findBloomFilter(datadir string) (string, common.Hash, error) {
+	files, err := ioutil.ReadDir(datadir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", common.Hash{}, nil
+		}
+		return "", common.Hash{}, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if ok, root := isBloomFilter(file.Name()); ok {
+			return filepath.Join(datadir, file.Name()), root, nil
+		}
+	}
+	return "", common.Hash{}, nil
+}
+
+const warningLog = `
+
+WARNING!
+
+The clean trie cache is not found. Please delete it by yourself after the
+pruning. Remember don't start the Geth without deleting the clean trie cache
+otherwise the entire database may be damaged!
+
+Check the command description "geth snapshot prune-state --help" for more details.
+`
+
+// deleteCleanTrieCache deletes the journal of the clean trie cache, which may
+// reference deleted trie nodes after the pruning.
+func deleteCleanTrieCache(path string) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.Warn(warningLog)
+		return
+	}
+	os.RemoveAll(path)
+	log.Info("Deleted trie clean cache", "path", path)
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// newPrunerTestChain creates an archive chain with snapshots of the given length,
+// each block funding a new account, and stops it so that the snapshot journal
+// and all the states are persisted.
+func newPrunerTestChain(t *testing.T, length int) (ethdb.Database, []*types.Block) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		gspec  = &genesisT.Genesis{Config: params.TestChainConfig, Alloc: genesisT.GenesisAlloc{testAddress: {Balance: big.NewInt(vars.Ether)}}}
+		signer = types.NewEIP155Signer(gspec.Config.GetChainID())
+	)
+	genesis := core.MustCommitGenesis(db, gspec)
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, length, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(testAddress), common.Address{byte(i), 0x01}, big.NewInt(1000), vars.TxGas, nil, nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, prunerTestCacheConfig(), gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	return db, append([]*types.Block{genesis}, blocks...)
+}
+
+func prunerTestCacheConfig() *core.CacheConfig {
+	return &core.CacheConfig{
+		TrieCleanLimit:    256,
+		TrieDirtyLimit:    256,
+		TrieDirtyDisabled: true,
+		SnapshotLimit:     256,
+		SnapshotWait:      true,
+	}
+}
+
+func TestPruneState(t *testing.T) {
+	for _, interrupted := range []bool{false, true} {
+		db, blocks := newPrunerTestChain(t, 200)
+
+		datadir, err := ioutil.TempDir("", "pruner-test")
+		if err != nil {
+			t.Fatalf("failed to create temporary datadir: %v", err)
+		}
+		defer os.RemoveAll(datadir)
+
+		pruner, err := NewPruner(db, datadir, "", 256)
+		if err != nil {
+			t.Fatalf("failed to create pruner: %v", err)
+		}
+		target := blocks[150]
+		if interrupted {
+			// Commit the state bloom like an interrupted pruning would have, and
+			// resume it as on the node startup
+			if err := snapshot.GenerateTrie(pruner.snaptree, target.Root(), db, pruner.stateBloom); err != nil {
+				t.Fatalf("failed to generate target state: %v", err)
+			}
+			if err := extractGenesis(db, pruner.stateBloom); err != nil {
+				t.Fatalf("failed to extract genesis state: %v", err)
+			}
+			name := bloomFilterName(datadir, target.Root())
+			if err := pruner.stateBloom.Commit(name, name+stateBloomFileTempSuffix); err != nil {
+				t.Fatalf("failed to commit state bloom: %v", err)
+			}
+			if err := RecoverPruning(datadir, db, ""); err != nil {
+				t.Fatalf("failed to recover pruning: %v", err)
+			}
+		} else {
+			if err := pruner.Prune(target.Root()); err != nil {
+				t.Fatalf("failed to prune state: %v", err)
+			}
+		}
+		if path, _, _ := findBloomFilter(datadir); path != "" {
+			t.Errorf("state bloom %s left after pruning", path)
+		}
+		// The genesis and target states must be complete, the others gone
+		for _, block := range []*types.Block{blocks[0], target} {
+			if err := checkState(db, block.Root()); err != nil {
+				t.Errorf("interrupted %v: state of block %d incomplete: %v", interrupted, block.NumberU64(), err)
+			}
+		}
+		for _, block := range []*types.Block{blocks[1], blocks[149], blocks[151], blocks[200]} {
+			if hasState(db, block.Root()) {
+				t.Errorf("interrupted %v: state of block %d not pruned", interrupted, block.NumberU64())
+			}
+		}
+		// Restarting the chain must rewind it to the target state, with the
+		// snapshot matching
+		chain, err := core.NewBlockChain(db, prunerTestCacheConfig(), params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to restart blockchain: %v", err)
+		}
+		if head := chain.CurrentBlock(); head.Hash() != target.Hash() {
+			t.Errorf("interrupted %v: head mismatch: have %d, want %d", interrupted, head.NumberU64(), target.NumberU64())
+		}
+		if err := snapshot.VerifyState(chain.Snapshot(), target.Root()); err != nil {
+			t.Errorf("interrupted %v: snapshot mismatch: %v", interrupted, err)
+		}
+		chain.Stop()
+	}
+}
+
+// checkState iterates over all the nodes and codes of the given state.
+func checkState(db ethdb.Database, root common.Hash) error {
+	statedb, err := state.New(root, state.NewDatabase(db), nil)
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	return it.Error
+}
+
+func TestBloomFilterName(t *testing.T) {
+	root := common.HexToHash("0x0102030405060708091011121314151617181920212223242526272829303132")
+	name := bloomFilterName("datadir", root)
+
+	if ok, have := isBloomFilter(name); !ok || have != root {
+		t.Errorf("bloom filter name mismatch: have %v %x, want %x", ok, have, root)
+	}
+	if ok, _ := isBloomFilter(name + stateBloomFileTempSuffix); ok {
+		t.Errorf("temporary bloom filter accepted")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Tests that calls without fees, skipping the base fee check, don't pay the
+// coinbase a negative tip in blocks with a base fee.
+func TestNoBaseFeeCall(t *testing.T) {
+	var (
+		config   = eip1559Config()
+		from     = common.HexToAddress("0xa0")
+		to       = common.HexToAddress("0xb0")
+		coinbase = common.HexToAddress("0xc0")
+		zero     = new(big.Int)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	msg := types.NewMessage(from, &to, 0, zero, 100000, zero, zero, zero, nil, nil, false)
+	ctx := vm.Context{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		Coinbase:    coinbase,
+		BlockNumber: big.NewInt(1),
+		Time:        new(big.Int),
+		Difficulty:  new(big.Int),
+		GasLimit:    10000000,
+		GasPrice:    zero,
+		BaseFee:     big.NewInt(1000000000),
+	}
+	evm := vm.NewEVM(ctx, statedb, config, vm.Config{NoBaseFee: true})
+	result, err := ApplyMessage(evm, msg, new(GasPool).AddGas(ctx.GasLimit))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if result.UsedGas == 0 {
+		t.Fatal("call used no gas")
+	}
+	if balance := statedb.GetBalance(coinbase); balance.Sign() != 0 {
+		t.Errorf("coinbase balance mismatch: have %v, want 0", balance)
+	}
+}
@@ -34,6 +34,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/chipprgeth"
+	"github.com/ethereum/go-ethereum/params/types/goethereum"
 	"github.com/ethereum/go-ethereum/params/vars"
 )
 
@@ -87,6 +90,29 @@ func pricedDataTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key
 	return tx
 }
 
+func dynamicFeeTx(nonce uint64, gaslimit uint64, gasFee *big.Int, tip *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignNewTx(key, types.LatestSignerForChainID(params.TestChainConfig.ChainID), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: gasFee,
+		Gas:       gaslimit,
+		To:        &common.Address{},
+		Value:     big.NewInt(100),
+		Data:      nil,
+	})
+	return tx
+}
+
+// eip1559Config returns a copy of the test chain configuration with EIP-2718
+// and EIP-1559 enabled from the block after genesis.
+func eip1559Config() *goethereum.ChainConfig {
+	config := *params.TestChainConfig
+	config.BerlinBlock = big.NewInt(0)
+	config.LondonBlock = big.NewInt(1)
+	return &config
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
@@ -1736,6 +1762,144 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that dynamic fee transactions are only accepted once EIP-1559 is enabled
+// and that malformed fee caps are rejected.
+func TestTransactionPoolDynamicFeeValidation(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	tx := dynamicFeeTx(0, 100000, new(big.Int).SetUint64(vars.InitialBaseFee), big.NewInt(1), key)
+
+	// Pools scheduled for, but not yet past EIP-1559 must reject the new type
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := eip1559Config()
+	config.LondonBlock = big.NewInt(5)
+
+	pool := NewTxPool(testTxPoolConfig, config, blockchain)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(tx); err != ErrTxTypeNotSupported {
+		t.Fatalf("pre-1559 dynamic fee transaction error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	// Post-1559 pools accept it, but not if the tip exceeds the fee cap
+	pool1559 := NewTxPool(testTxPoolConfig, eip1559Config(), blockchain)
+	defer pool1559.Stop()
+
+	pool1559.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+	if err := pool1559.AddRemote(dynamicFeeTx(0, 100000, big.NewInt(1), big.NewInt(2), key)); err != ErrTipAboveFeeCap {
+		t.Fatalf("tip above fee cap error mismatch: have %v, want %v", err, ErrTipAboveFeeCap)
+	}
+	if err := pool1559.addRemoteSync(tx); err != nil {
+		t.Fatalf("failed to add dynamic fee transaction: %v", err)
+	}
+	if pending, _ := pool1559.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if err := validateTxPoolInternals(pool1559); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool and the signers agree on typed transactions for chain
+// configurations enabling only one of EIP-2718 and EIP-2930.
+func TestTransactionPoolTypedTxTransitions(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	for _, test := range []struct {
+		name             string
+		eip2718, eip2930 bool
+	}{
+		{"eip2718 only", true, false},
+		{"eip2930 only", false, true},
+		{"both", true, true},
+	} {
+		config := new(chipprgeth.ChipprGethChainConfig)
+		if err := confp.Convert(params.TestChainConfig, config); err != nil {
+			t.Fatalf("%s: failed to convert config: %v", test.name, err)
+		}
+		config.EIP2718FBlock, config.EIP2930FBlock = nil, nil
+		if test.eip2718 {
+			config.EIP2718FBlock = big.NewInt(0)
+		}
+		if test.eip2930 {
+			config.EIP2930FBlock = big.NewInt(0)
+		}
+		tx, err := types.SignNewTx(key, types.NewEIP2930Signer(config.GetChainID()), &types.AccessListTx{
+			ChainID:  config.GetChainID(),
+			Gas:      100000,
+			GasPrice: big.NewInt(1),
+			To:       &common.Address{},
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to sign transaction: %v", test.name, err)
+		}
+		enabled := test.eip2718 && test.eip2930
+
+		_, signerErr := types.Sender(types.MakeSigner(config, big.NewInt(1)), tx)
+		if (signerErr == nil) != enabled {
+			t.Errorf("%s: block signer error mismatch: have %v, want enabled %v", test.name, signerErr, enabled)
+		}
+		_, signerErr = types.Sender(types.LatestSigner(config), tx)
+		if (signerErr == nil) != enabled {
+			t.Errorf("%s: latest signer error mismatch: have %v, want enabled %v", test.name, signerErr, enabled)
+		}
+		pool := NewTxPool(testTxPoolConfig, config, blockchain)
+		poolErr := pool.addRemoteSync(tx)
+		pool.Stop()
+
+		if enabled && poolErr != nil {
+			t.Errorf("%s: failed to add access list transaction: %v", test.name, poolErr)
+		}
+		if !enabled && poolErr == nil {
+			t.Errorf("%s: pool accepted access list transaction", test.name)
+		}
+	}
+}
+
+// Tests that replacing a dynamic fee transaction requires bumping both the fee
+// cap and the tip by the configured price bump.
+func TestTransactionReplacementDynamicFee(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, eip1559Config(), blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	var (
+		feeCap     = int64(2 * vars.InitialBaseFee)
+		tip        = int64(100)
+		feeCapBump = (feeCap * (100 + int64(testTxPoolConfig.PriceBump))) / 100
+		tipBump    = (tip * (100 + int64(testTxPoolConfig.PriceBump))) / 100
+	)
+	if err := pool.addRemoteSync(dynamicFeeTx(0, 100000, big.NewInt(feeCap), big.NewInt(tip), key)); err != nil {
+		t.Fatalf("failed to add original pending transaction: %v", err)
+	}
+	if err := pool.AddRemote(dynamicFeeTx(0, 100000, big.NewInt(feeCapBump), big.NewInt(tip), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("fee cap only replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(dynamicFeeTx(0, 100000, big.NewInt(feeCap+1), big.NewInt(tipBump), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("tip only replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(dynamicFeeTx(0, 100000, big.NewInt(feeCapBump), big.NewInt(tipBump), key)); err != nil {
+		t.Fatalf("failed to replace original pending transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -90,6 +90,7 @@ type Context struct {
 	BlockNumber *big.Int       // Provides information for NUMBER
 	Time        *big.Int       // Provides information for TIME
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
+	BaseFee     *big.Int       // Provides the EIP-1559 base fee of the block (nil before the fork)
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -180,6 +181,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, gas, nil
 	}
+	defer evm.captureEnter(CALL, caller.Address(), addr, input, gas, value)(&ret, &leftOverGas, &err)
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(vars.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -256,6 +258,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, gas, nil
 	}
+	defer evm.captureEnter(CALLCODE, caller.Address(), addr, input, gas, value)(&ret, &leftOverGas, &err)
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(vars.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -299,6 +302,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, gas, nil
 	}
+	defer evm.captureEnter(DELEGATECALL, caller.Address(), addr, input, gas, nil)(&ret, &leftOverGas, &err)
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(vars.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -333,6 +337,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, gas, nil
 	}
+	defer evm.captureEnter(STATICCALL, caller.Address(), addr, input, gas, nil)(&ret, &leftOverGas, &err)
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(vars.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -388,8 +393,27 @@ func (c *codeAndHash) Hash() common.Hash {
 	return c.hash
 }
 
+// captureEnter notifies a CallTracer of entering a call frame at depth > 0.
+// The returned function notifies it of exiting the frame, with the results as
+// finally assigned by the caller, so it is meant to be deferred before any
+// check returning early, so that failed calls are traced as well:
+//
+//   defer evm.captureEnter(CALL, from, to, input, gas, value)(&ret, &leftOverGas, &err)
+func (evm *EVM) captureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) func(*[]byte, *uint64, *error) {
+	tracer, ok := evm.vmConfig.Tracer.(CallTracer)
+	if !evm.vmConfig.Debug || evm.depth == 0 || !ok {
+		return func(*[]byte, *uint64, *error) {}
+	}
+	tracer.CaptureEnter(typ, from, to, input, gas, value)
+	return func(ret *[]byte, leftOverGas *uint64, err *error) {
+		tracer.CaptureExit(*ret, gas-*leftOverGas, *err)
+	}
+}
+
 // create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, address common.Address) ([]byte, common.Address, uint64, error) {
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, address common.Address, typ OpCode) (ret []byte, createdAddr common.Address, leftOverGas uint64, err error) {
+	defer evm.captureEnter(typ, caller.Address(), address, codeAndHash.code, gas, value)(&ret, &leftOverGas, &err)
+
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
 	if evm.depth > int(vars.CallCreateDepth) {
@@ -400,6 +424,11 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	nonce := evm.StateDB.GetNonce(caller.Address())
 	evm.StateDB.SetNonce(caller.Address(), nonce+1)
+	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
+	// the access-list change should not be rolled back
+	if evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP2929Transition, evm.BlockNumber) {
+		evm.StateDB.AddAddressToAccessList(address)
+	}
 
 	// Ensure there's no existing contract already at the designated address
 	contractHash := evm.StateDB.GetCodeHash(address)
@@ -428,7 +457,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	start := time.Now()
 
-	ret, err := run(evm, contract, nil, false)
+	ret, err = run(evm, contract, nil, false)
 
 	// check whether the max code size has been exceeded
 	maxCodeSizeExceeded := evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP170Transition, evm.BlockNumber) && uint64(len(ret)) > vars.MaxCodeSize
@@ -468,7 +497,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
-	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr)
+	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr, CREATE)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -478,8 +507,11 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *big.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), common.Hash(salt.Bytes32()), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gas, endowment, contractAddr)
+	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2)
 }
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() ctypes.ChainConfigurator { return evm.chainConfig }
+
+// Config returns the configuration the interpreter was set up with.
+func (evm *EVM) Config() Config { return evm.vmConfig }
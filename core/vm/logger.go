@@ -111,6 +111,21 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// CallTracer is an optional extension of Tracer, implemented by tracers which
+// observe the nested call frames of an execution. Unlike CaptureState, the
+// capture methods are also called for calls into accounts without code and
+// precompiled contracts.
+//
+// CaptureEnter is called when a call frame is entered at depth > 0, before the
+// call depth and balance checks, with the gas available to the frame. Calls
+// failing those checks are captured as well. CaptureExit is called when the
+// frame returns, with the gas it used.
+type CallTracer interface {
+	Tracer
+	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int)
+	CaptureExit(output []byte, gasUsed uint64, err error)
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
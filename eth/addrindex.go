@@ -0,0 +1,237 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+const (
+	// AddressIndexSection is the number of blocks processed in a single section
+	// of the address transaction index.
+	AddressIndexSection = 1024
+
+	// AddressIndexConfirms is the number of confirmation blocks before a section
+	// of the address transaction index is generated.
+	AddressIndexConfirms = 256
+
+	// addressIndexThrottling is the time to wait between processing two
+	// consecutive index sections.
+	addressIndexThrottling = 100 * time.Millisecond
+)
+
+// AddressIndexer implements a core.ChainIndexer, recording the transactions each
+// address took part in as sender, recipient, created contract or, optionally,
+// as the target of an internal call.
+type AddressIndexer struct {
+	db       ethdb.Database   // database instance to write index data into
+	chain    *core.BlockChain // blockchain to re-execute blocks on for the internal calls
+	internal bool             // whether to trace the internal calls of the transactions
+	batch    ethdb.Batch      // batch collecting the entries of the current section
+
+	lock sync.Mutex
+	err  error // error of the last section processed, nil if it was committed
+}
+
+// NewAddressIndexer returns a chain indexer that records the transactions of
+// each address of the canonical chain, along with its backend. Indexing internal
+// calls requires the state of the indexed blocks, so sections whose state is
+// missing fail to be processed.
+func NewAddressIndexer(db ethdb.Database, chain *core.BlockChain, size, confirms uint64, internal bool) (*core.ChainIndexer, *AddressIndexer) {
+	backend := &AddressIndexer{
+		db:       db,
+		chain:    chain,
+		internal: internal,
+	}
+	table := rawdb.NewTable(db, string(rawdb.AddressTxIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, addressIndexThrottling, "addrindex"), backend
+}
+
+// Err returns the error the last processed section failed with, or nil if it
+// was committed.
+func (b *AddressIndexer) Err() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.err
+}
+
+// setErr records the outcome of processing a section.
+func (b *AddressIndexer) setErr(err error) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.err = err
+	return err
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new address index
+// section.
+func (b *AddressIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.batch = b.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the transactions of a new
+// block into the index.
+func (b *AddressIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+
+	block := rawdb.ReadBlock(b.db, hash, number)
+	if block == nil {
+		return b.setErr(fmt.Errorf("block #%d [%x…] not found", number, hash[:4]))
+	}
+	receipts := rawdb.ReadReceipts(b.db, hash, number, b.chain.Config())
+	if len(receipts) != len(block.Transactions()) {
+		return b.setErr(fmt.Errorf("receipts of block #%d [%x…] not found", number, hash[:4]))
+	}
+	roles := addressTxRoles(b.chain.Config(), block, receipts)
+	if b.internal {
+		// Committing the section without the internal calls of a block would
+		// leave it incomplete for good, fail it instead.
+		if err := b.traceInternal(block, roles); err != nil {
+			return b.setErr(fmt.Errorf("failed to trace internal calls of block #%d [%x…]: %v", number, hash[:4], err))
+		}
+	}
+	for i, addresses := range roles {
+		for address, role := range addresses {
+			rawdb.WriteAddressTxEntry(b.batch, address, &rawdb.AddressTxEntry{
+				BlockNumber: number,
+				Index:       uint32(i),
+				BlockHash:   hash,
+				TxHash:      block.Transactions()[i].Hash(),
+				Roles:       role,
+			})
+		}
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the entries of the
+// section into the database.
+func (b *AddressIndexer) Commit() error {
+	return b.setErr(b.batch.Write())
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (b *AddressIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// traceInternal re-executes the transactions of a block on top of its parent's
+// state, adding the accounts called into by the transactions to their roles.
+func (b *AddressIndexer) traceInternal(block *types.Block, roles []map[common.Address]uint8) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	parent := b.chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	statedb, err := b.chain.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	var (
+		config      = b.chain.Config()
+		signer      = types.MakeSigner(config, block.Number())
+		precompiles = vm.PrecompiledContractsForConfig(config, block.Number())
+	)
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(signer, block.BaseFee())
+		if err != nil {
+			return err
+		}
+		tracer := &addressTracer{roles: roles[i], precompiles: precompiles}
+		vmenv := vm.NewEVM(core.NewEVMContext(msg, block.Header(), b.chain, nil), statedb, config, vm.Config{Debug: true, Tracer: tracer})
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+			return err
+		}
+		statedb.Finalise(config.IsEnabled(config.GetEIP161dTransition, block.Number()))
+	}
+	return nil
+}
+
+// addressTxRoles collects the addresses taking part in each transaction of a
+// block as sender, recipient or created contract.
+func addressTxRoles(config ctypes.ChainConfigurator, block *types.Block, receipts types.Receipts) []map[common.Address]uint8 {
+	signer := types.MakeSigner(config, block.Number())
+
+	roles := make([]map[common.Address]uint8, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		roles[i] = make(map[common.Address]uint8)
+		if from, err := types.Sender(signer, tx); err == nil {
+			roles[i][from] |= rawdb.AddressTxFrom
+		}
+		if to := tx.To(); to != nil {
+			roles[i][*to] |= rawdb.AddressTxTo
+		} else if i < len(receipts) && receipts[i].ContractAddress != (common.Address{}) {
+			roles[i][receipts[i].ContractAddress] |= rawdb.AddressTxCreate
+		}
+	}
+	return roles
+}
+
+// addressTracer is a vm.CallTracer recording the accounts entered by the
+// internal calls and contract creations of a transaction.
+type addressTracer struct {
+	roles       map[common.Address]uint8
+	precompiles map[common.Address]vm.PrecompiledContract
+}
+
+func (t *addressTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+func (t *addressTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *addressTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *addressTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// CaptureEnter implements vm.CallTracer, recording the account entered by an
+// internal call, skipping the precompiled contracts.
+func (t *addressTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if _, ok := t.precompiles[to]; ok {
+		return
+	}
+	t.roles[to] |= rawdb.AddressTxInternal
+}
+
+func (t *addressTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
@@ -0,0 +1,208 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that the address index records the transactions of each address with
+// their roles, and that the API pages through both the indexed and the recent
+// unindexed blocks.
+func TestAddressIndex(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		forwarder = common.HexToAddress("0xc0de")
+		callee    = common.HexToAddress("0xca11ee")
+	)
+	// The forwarder calls into the callee with the value it receives
+	code := append(append(common.FromHex("60006000600060003473"), callee.Bytes()...), common.FromHex("5af100")...)
+	genesis := &genesisT.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc: genesisT.GenesisAlloc{
+			testBank:  {Balance: big.NewInt(vars.Ether)},
+			forwarder: {Code: code, Balance: new(big.Int)},
+		},
+	}
+	db := rawdb.NewMemoryDatabase()
+	signer := types.HomesteadSigner{}
+
+	// Cycle through transfers, calls into the forwarder and contract creations
+	blocks, _ := core.GenerateChain(genesis.Config, core.MustCommitGenesis(db, genesis), ethash.NewFaker(), db, 10, func(i int, b *core.BlockGen) {
+		var tx *types.Transaction
+		switch i % 3 {
+		case 0:
+			tx = types.NewTransaction(b.TxNonce(testBank), recipient, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil)
+		case 1:
+			tx = types.NewTransaction(b.TxNonce(testBank), forwarder, big.NewInt(1000), 100000, big.NewInt(1), nil)
+		case 2:
+			tx = types.NewContractCreation(b.TxNonce(testBank), new(big.Int), 100000, big.NewInt(1), nil)
+		}
+		tx, _ = types.SignTx(tx, signer, testBankKey)
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import test chain: %v", err)
+	}
+	// Index the first two sections, leaving the last blocks to be scanned
+	indexer, _ := NewAddressIndexer(db, chain, 4, 1, true)
+	indexer.Start(chain)
+	defer indexer.Close()
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if sections, _, _ := indexer.Sections(); sections == 2 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("indexing timed out")
+		}
+	}
+	api := NewPublicAddressIndexAPI(db, chain, indexer, 4, 1)
+
+	// transactions retrieves all the transactions of an address, page by page
+	transactions := func(address common.Address, from, to rpc.BlockNumber) (numbers []uint64, roles [][]string) {
+		var cursor *hexutil.Bytes
+		for pages := 0; ; pages++ {
+			page, err := api.GetTransactionsByAddress(context.Background(), address, from, to, cursor)
+			if err != nil {
+				t.Fatalf("failed to retrieve transactions of %x: %v", address, err)
+			}
+			if pages > 0 && len(page.Transactions) == 0 {
+				t.Fatalf("empty page of transactions of %x", address)
+			}
+			for _, tx := range page.Transactions {
+				block := chain.GetBlockByNumber(uint64(tx.BlockNumber))
+				if tx.BlockHash != block.Hash() || tx.Hash != block.Transactions()[tx.TransactionIndex].Hash() {
+					t.Errorf("transaction mismatch in block %d", tx.BlockNumber)
+				}
+				numbers = append(numbers, uint64(tx.BlockNumber))
+				roles = append(roles, tx.Roles)
+			}
+			if cursor = page.Cursor; cursor == nil {
+				return numbers, roles
+			}
+		}
+	}
+	created := crypto.CreateAddress(testBank, 2)
+
+	tests := []struct {
+		address    common.Address
+		from, to   rpc.BlockNumber
+		limit      int
+		wantBlocks []uint64
+		wantRoles  []string
+	}{
+		// All transactions of the sender, across the indexed and unindexed blocks
+		{testBank, 0, rpc.LatestBlockNumber, 1000, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []string{"from"}},
+		{testBank, 0, rpc.LatestBlockNumber, 3, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []string{"from"}},
+		{testBank, 3, 9, 2, []uint64{3, 4, 5, 6, 7, 8, 9}, []string{"from"}},
+		// Recipients and created contracts
+		{recipient, 0, rpc.LatestBlockNumber, 1, []uint64{1, 4, 7, 10}, []string{"to"}},
+		{forwarder, 0, rpc.LatestBlockNumber, 1000, []uint64{2, 5, 8}, []string{"to"}},
+		{created, 0, rpc.LatestBlockNumber, 1000, []uint64{3}, []string{"create"}},
+		// Internal calls, only recorded in the indexed blocks
+		{callee, 0, rpc.LatestBlockNumber, 1000, []uint64{2, 5}, []string{"internal"}},
+		{callee, 3, 7, 1000, []uint64{5}, []string{"internal"}},
+	}
+	for i, tt := range tests {
+		api.limit = tt.limit
+		numbers, roles := transactions(tt.address, tt.from, tt.to)
+		if !reflect.DeepEqual(numbers, tt.wantBlocks) {
+			t.Errorf("test %d: block mismatch: have %v, want %v", i, numbers, tt.wantBlocks)
+		}
+		for j := range roles {
+			if !reflect.DeepEqual(roles[j], tt.wantRoles) {
+				t.Errorf("test %d: role mismatch in block %d: have %v, want %v", i, numbers[j], roles[j], tt.wantRoles)
+			}
+		}
+	}
+	// Dropping the index removes the entries of the indexed blocks
+	if entries := rawdb.ReadAddressTxEntries(db, testBank, 0, 0, 10, 100); len(entries) != 7 {
+		t.Errorf("index entry count mismatch: have %d, want %d", len(entries), 7)
+	}
+	if err := rawdb.DeleteAddressTxIndex(db); err != nil {
+		t.Fatalf("failed to drop index: %v", err)
+	}
+	if entries := rawdb.ReadAddressTxEntries(db, testBank, 0, 0, 10, 100); len(entries) != 0 {
+		t.Errorf("dropped index has %d entries", len(entries))
+	}
+}
+
+// Tests that indexing internal calls fails the sections of blocks whose state is
+// missing instead of committing them without the internal calls.
+func TestAddressIndexMissingState(t *testing.T) {
+	genesis := &genesisT.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc:  genesisT.GenesisAlloc{testBank: {Balance: big.NewInt(vars.Ether)}},
+	}
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := core.GenerateChain(genesis.Config, core.MustCommitGenesis(db, genesis), ethash.NewFaker(), db, 4, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testBank), common.Address{0x01}, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, testBankKey)
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true}, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import test chain: %v", err)
+	}
+	// Drop the state the second block is executed on
+	if err := db.Delete(blocks[0].Root().Bytes()); err != nil {
+		t.Fatalf("failed to delete state: %v", err)
+	}
+	indexer, backend := NewAddressIndexer(db, chain, 4, 1, true)
+	indexer.Start(chain)
+	defer indexer.Close()
+
+	for start := time.Now(); backend.Err() == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("section processing did not fail")
+		}
+	}
+	if sections, _, _ := indexer.Sections(); sections != 0 {
+		t.Errorf("incomplete section committed: have %d sections", sections)
+	}
+	if entries := rawdb.ReadAddressTxEntries(db, testBank, 0, 0, 4, 100); len(entries) != 0 {
+		t.Errorf("failed section has %d entries", len(entries))
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -264,6 +265,32 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// Ecbp1100 sets the activation block of the ECBP1100 (MESS) artificial finality
+// features, overriding the chain configuration until the node is restarted.
+func (api *PrivateAdminAPI) Ecbp1100(blockNr rpc.BlockNumber) (bool, error) {
+	var number uint64
+	switch blockNr {
+	case rpc.LatestBlockNumber:
+		number = api.eth.blockchain.CurrentBlock().NumberU64()
+	case rpc.PendingBlockNumber:
+		number = api.eth.blockchain.CurrentBlock().NumberU64() + 1
+	default:
+		number = uint64(blockNr)
+	}
+	if err := api.eth.blockchain.Config().SetECBP1100Transition(&number); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EnableArtificialFinality enables or disables the artificial finality features
+// of the chain. Unless configured not to, the node still toggles them on its
+// own as its peers and sync state change.
+func (api *PrivateAdminAPI) EnableArtificialFinality(enable bool) bool {
+	api.eth.blockchain.EnableArtificialFinality(enable, "reason", "admin")
+	return api.eth.blockchain.IsArtificialFinalityEnabled()
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -301,6 +328,25 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(false, false, true), nil
 }
 
+// ArtificialFinalityStatus is the status of the artificial finality features
+// of the chain.
+type ArtificialFinalityStatus struct {
+	ECBP1100Transition *hexutil.Uint64 `json:"ecbp1100Transition"` // Activation block of ECBP1100 (MESS), nil if not configured
+	Enabled            bool            `json:"enabled"`            // Whether the features are enabled by the node
+	Active             bool            `json:"active"`             // Whether the features are enabled and activated at the head
+}
+
+// ArtificialFinality retrieves the status of the artificial finality features
+// of the chain.
+func (api *PublicDebugAPI) ArtificialFinality() *ArtificialFinalityStatus {
+	chain := api.eth.BlockChain()
+	return &ArtificialFinalityStatus{
+		ECBP1100Transition: (*hexutil.Uint64)(chain.Config().GetECBP1100Transition()),
+		Enabled:            chain.IsArtificialFinalityEnabled(),
+		Active:             chain.IsArtificialFinalityActive(chain.CurrentBlock().Number()),
+	}
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -351,6 +397,126 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// ExportedReceipts are the receipts of a block streamed by debug_exportReceipts,
+// either as a list of RPC receipt objects or as the RLP encoded list of their
+// consensus encodings. If the receipts of a block can't be exported, the last
+// notification of the stream carries the error instead of the receipts.
+type ExportedReceipts struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Receipts    interface{}    `json:"receipts,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// exportReceipts retrieves the receipts of a canonical block in the given format.
+func (api *PrivateDebugAPI) exportReceipts(number uint64, encoding string) (*ExportedReceipts, error) {
+	db := api.eth.ChainDb()
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	receipts := rawdb.ReadRawReceipts(db, hash, number)
+	if receipts == nil {
+		return nil, fmt.Errorf("receipts of block #%d not found", number)
+	}
+	result := &ExportedReceipts{BlockNumber: hexutil.Uint64(number), BlockHash: hash}
+	if encoding == "rlp" {
+		blob, err := rlp.EncodeToBytes(receipts)
+		if err != nil {
+			return nil, err
+		}
+		result.Receipts = hexutil.Bytes(blob)
+		return result, nil
+	}
+	block := rawdb.ReadBlock(db, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	txs := block.Transactions()
+	if err := receipts.DeriveFields(api.eth.blockchain.Config(), hash, number, txs); err != nil {
+		return nil, err
+	}
+	fields := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		fields[i] = ethapi.RPCMarshalReceipt(receipt, txs[i], hash, number, uint64(i), block.BaseFee())
+	}
+	result.Receipts = fields
+	return result, nil
+}
+
+// ExportReceipts creates a subscription streaming the receipts of the canonical
+// blocks in the given range, one notification per block in chain order. The
+// format is either "json" (default) or "rlp", the latter skipping the derivation
+// of the contextual receipt fields and thus being considerably cheaper for
+// large ranges of frozen blocks. The export stops at the first block whose
+// receipts are unavailable, with a notification carrying the error.
+func (api *PrivateDebugAPI) ExportReceipts(ctx context.Context, from, to rpc.BlockNumber, format *string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	encoding := "json"
+	if format != nil {
+		encoding = *format
+	}
+	if encoding != "json" && encoding != "rlp" {
+		return nil, fmt.Errorf("unknown receipt format %q", encoding)
+	}
+	head := api.eth.blockchain.CurrentBlock().NumberU64()
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			return head
+		}
+		return uint64(number)
+	}
+	start, end := resolve(from), resolve(to)
+	if start > end {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", end, start)
+	}
+	if end > head {
+		return nil, fmt.Errorf("end block #%d not found", end)
+	}
+	// Ensure the receipts of the range bounds are available before starting, so
+	// exports of missing blocks fail right away
+	for _, number := range []uint64{start, end} {
+		hash := rawdb.ReadCanonicalHash(api.eth.ChainDb(), number)
+		if hash == (common.Hash{}) || !rawdb.HasReceipts(api.eth.ChainDb(), hash, number) {
+			return nil, fmt.Errorf("receipts of block #%d not found", number)
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			begin  = time.Now()
+			logged = time.Now()
+		)
+		for number := start; number <= end; number++ {
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Exporting receipts", "start", start, "end", end, "current", number, "elapsed", common.PrettyDuration(time.Since(begin)))
+				logged = time.Now()
+			}
+			result, err := api.exportReceipts(number, encoding)
+			if err != nil {
+				log.Warn("Receipt export failed", "number", number, "err", err)
+				result = &ExportedReceipts{BlockNumber: hexutil.Uint64(number), Error: err.Error()}
+			}
+			select {
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			default:
+			}
+			if err := notifier.Notify(rpcSub.ID, result); err != nil || result.Error != "" {
+				return
+			}
+		}
+		log.Info("Exported receipts", "start", start, "end", end, "elapsed", common.PrettyDuration(time.Since(begin)))
+	}()
+	return rpcSub, nil
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -0,0 +1,192 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxAddressTransactions is the maximum number of transactions returned by a
+// single page of eth_getTransactionsByAddress.
+const maxAddressTransactions = 1000
+
+var errInvalidAddressCursor = errors.New("invalid address transaction cursor")
+
+// addressTxRoleNames are the names of the roles of an address in a transaction,
+// in the order of their flags.
+var addressTxRoleNames = []string{"from", "to", "create", "internal"}
+
+// AddressTransaction is a transaction an address took part in.
+type AddressTransaction struct {
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	Hash             common.Hash    `json:"hash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Roles            []string       `json:"roles"`
+}
+
+// AddressTransactions is a page of the transactions an address took part in.
+type AddressTransactions struct {
+	Transactions []*AddressTransaction `json:"transactions"`
+	Cursor       *hexutil.Bytes        `json:"cursor"` // Position of the next page, nil if there are no more transactions
+}
+
+// PublicAddressIndexAPI provides an API to list the transactions of an address
+// from the address transaction index.
+type PublicAddressIndexAPI struct {
+	db       ethdb.Database
+	chain    *core.BlockChain
+	indexer  *core.ChainIndexer
+	size     uint64
+	confirms uint64
+	limit    int // Maximum number of transactions in a page
+}
+
+// NewPublicAddressIndexAPI creates a new address transaction index API.
+func NewPublicAddressIndexAPI(db ethdb.Database, chain *core.BlockChain, indexer *core.ChainIndexer, size, confirms uint64) *PublicAddressIndexAPI {
+	return &PublicAddressIndexAPI{db: db, chain: chain, indexer: indexer, size: size, confirms: confirms, limit: maxAddressTransactions}
+}
+
+// GetTransactionsByAddress returns the transactions the address took part in
+// within the block range, in chain order. Large results are paginated; the
+// returned cursor is passed back to retrieve the next page. Transactions of
+// recent blocks not yet covered by the index are found by scanning the blocks,
+// without internal calls.
+func (api *PublicAddressIndexAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber, cursor *hexutil.Bytes) (*AddressTransactions, error) {
+	head := api.chain.CurrentBlock().NumberU64()
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 || uint64(number) > head {
+			return head
+		}
+		return uint64(number)
+	}
+	from, to := resolve(fromBlock), resolve(toBlock)
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	var index uint32
+	if cursor != nil {
+		if len(*cursor) != 12 {
+			return nil, errInvalidAddressCursor
+		}
+		number := binary.BigEndian.Uint64(*cursor)
+		if number < from || number > to {
+			return nil, errInvalidAddressCursor
+		}
+		from, index = number, binary.BigEndian.Uint32((*cursor)[8:])
+	}
+	// Reject ranges reaching far past the index, as scanning them is costly
+	sections, _, _ := api.indexer.Sections()
+	indexed := sections * api.size
+
+	unindexed := from
+	if unindexed < indexed {
+		unindexed = indexed
+	}
+	if to >= unindexed && to-unindexed >= api.size+api.confirms {
+		return nil, fmt.Errorf("address transaction index not available past block %d yet", int64(indexed)-1)
+	}
+	// Gather one more entry than needed to find the start of the next page
+	var entries []*rawdb.AddressTxEntry
+	if from < indexed {
+		end := to
+		if end >= indexed {
+			end = indexed - 1
+		}
+		entries = api.indexedEntries(address, from, index, end, api.limit+1)
+	}
+	for number := unindexed; number <= to && len(entries) <= api.limit; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.chain.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		roles := addressTxRoles(api.chain.Config(), block, api.chain.GetReceiptsByHash(block.Hash()))
+		for i, addresses := range roles {
+			if role := addresses[address]; role != 0 && (number != from || uint32(i) >= index) {
+				entries = append(entries, &rawdb.AddressTxEntry{
+					BlockNumber: number,
+					Index:       uint32(i),
+					BlockHash:   block.Hash(),
+					TxHash:      block.Transactions()[i].Hash(),
+					Roles:       role,
+				})
+			}
+		}
+	}
+	result := &AddressTransactions{Transactions: []*AddressTransaction{}}
+	if len(entries) > api.limit {
+		next := make(hexutil.Bytes, 12)
+		binary.BigEndian.PutUint64(next, entries[api.limit].BlockNumber)
+		binary.BigEndian.PutUint32(next[8:], entries[api.limit].Index)
+
+		result.Cursor, entries = &next, entries[:api.limit]
+	}
+	for _, entry := range entries {
+		tx := &AddressTransaction{
+			BlockHash:        entry.BlockHash,
+			BlockNumber:      hexutil.Uint64(entry.BlockNumber),
+			Hash:             entry.TxHash,
+			TransactionIndex: hexutil.Uint64(entry.Index),
+			Roles:            []string{},
+		}
+		for i, name := range addressTxRoleNames {
+			if entry.Roles&(1<<i) != 0 {
+				tx.Roles = append(tx.Roles, name)
+			}
+		}
+		result.Transactions = append(result.Transactions, tx)
+	}
+	return result, nil
+}
+
+// indexedEntries retrieves at most limit canonical entries of the address from
+// the index, skipping the ones left behind by reorgs.
+func (api *PublicAddressIndexAPI) indexedEntries(address common.Address, from uint64, index uint32, to uint64, limit int) []*rawdb.AddressTxEntry {
+	var entries []*rawdb.AddressTxEntry
+	for len(entries) < limit {
+		want := limit - len(entries)
+		batch := rawdb.ReadAddressTxEntries(api.db, address, from, index, to, want)
+		for _, entry := range batch {
+			if rawdb.ReadCanonicalHash(api.db, entry.BlockNumber) == entry.BlockHash {
+				entries = append(entries, entry)
+			}
+		}
+		if len(batch) < want {
+			break
+		}
+		last := batch[len(batch)-1]
+		if last.Index == ^uint32(0) {
+			from, index = last.BlockNumber+1, 0
+		} else {
+			from, index = last.BlockNumber, last.Index+1
+		}
+	}
+	return entries
+}
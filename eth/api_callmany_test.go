@@ -0,0 +1,148 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestCallMany(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		storage   = common.HexToAddress("0x5702")
+		reverter  = common.HexToAddress("0xfa11")
+		numberer  = common.HexToAddress("0x4e")
+	)
+	debug, closeFn := newTestTracerAPI(t, recipient, 2)
+	defer closeFn()
+	api := ethapi.NewPublicBlockChainAPI(debug.eth.APIBackend)
+
+	code := func(hex string) *hexutil.Bytes {
+		code := hexutil.Bytes(common.FromHex(hex))
+		return &code
+	}
+	overrides := &ethapi.StateOverride{
+		// Stores the calldata word with a log, or returns the stored word without calldata
+		storage: ethapi.OverrideAccount{Code: code("361560115760003560005560006000a0005b60005460005260206000f3")},
+		// Reverts with 0xdeadbeef
+		reverter: ethapi.OverrideAccount{Code: code("63deadbeef60e01b60005260046000fd")},
+		// Returns the block number
+		numberer: ethapi.OverrideAccount{Code: code("4360005260206000f3")},
+	}
+	word := hexutil.Bytes(common.LeftPadBytes([]byte{42}, 32))
+	transfer := func(nonce uint64) *hexutil.Bytes {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, recipient, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, testBankKey)
+		raw, _ := tx.MarshalBinary()
+		return (*hexutil.Bytes)(&raw)
+	}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	// Execute a bundle depending on the state changes of its previous messages
+	results, err := api.CallMany(context.Background(), []ethapi.CallManyArgs{
+		{Transaction: transfer(2)},
+		{CallArgs: ethapi.CallArgs{To: &storage, Data: &word}},
+		{CallArgs: ethapi.CallArgs{To: &storage}},
+		{CallArgs: ethapi.CallArgs{To: &reverter}},
+		{CallArgs: ethapi.CallArgs{To: &numberer}},
+	}, latest, overrides, &ethapi.BlockOverrides{Number: (*hexutil.Big)(big.NewInt(100))})
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), 5)
+	}
+	if results[0].GasUsed != hexutil.Uint64(vars.TxGas) || results[0].Error != "" {
+		t.Errorf("transfer result mismatch: have gas %d, error %q", results[0].GasUsed, results[0].Error)
+	}
+	if len(results[1].Logs) != 1 || results[1].Logs[0].Address != storage {
+		t.Errorf("store logs mismatch: have %v", results[1].Logs)
+	}
+	if !bytes.Equal(results[2].ReturnData, word) || len(results[2].Logs) != 0 {
+		t.Errorf("load result mismatch: have %x, logs %v", results[2].ReturnData, results[2].Logs)
+	}
+	if !strings.HasPrefix(results[3].Error, "execution reverted") || results[3].RevertReason.String() != "0xdeadbeef" {
+		t.Errorf("revert result mismatch: have error %q, reason %v", results[3].Error, results[3].RevertReason)
+	}
+	if new(big.Int).SetBytes(results[4].ReturnData).Int64() != 100 {
+		t.Errorf("block number mismatch: have %x, want %d", results[4].ReturnData, 100)
+	}
+	// The state changes of the bundle are discarded afterwards
+	results, err = api.CallMany(context.Background(), []ethapi.CallManyArgs{
+		{CallArgs: ethapi.CallArgs{To: &storage}},
+	}, latest, overrides, nil)
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	if !bytes.Equal(results[0].ReturnData, make([]byte, 32)) {
+		t.Errorf("state leaked between bundles: have %x", results[0].ReturnData)
+	}
+	// Invalid transactions abort the whole bundle
+	if _, err := api.CallMany(context.Background(), []ethapi.CallManyArgs{
+		{Transaction: transfer(2)},
+		{Transaction: transfer(2)},
+	}, latest, nil, nil); err == nil || !strings.HasPrefix(err.Error(), "call 1:") {
+		t.Errorf("nonce error mismatch: have %v", err)
+	}
+	if _, err := api.CallMany(context.Background(), []ethapi.CallManyArgs{
+		{CallArgs: ethapi.CallArgs{To: &storage}, Transaction: transfer(2)},
+	}, latest, nil, nil); err == nil {
+		t.Errorf("mixed call and transaction accepted")
+	}
+}
+
+// Tests that the size and the cumulative gas of a bundle are capped.
+func TestCallManyLimits(t *testing.T) {
+	recipient := common.HexToAddress("0xdeadbeef")
+	debug, closeFn := newTestTracerAPI(t, recipient, 2)
+	defer closeFn()
+
+	backend := debug.eth.APIBackend
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	transfer := func(nonce uint64) ethapi.CallManyArgs {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, recipient, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, testBankKey)
+		raw, _ := tx.MarshalBinary()
+		return ethapi.CallManyArgs{Transaction: (*hexutil.Bytes)(&raw)}
+	}
+	call := ethapi.CallManyArgs{CallArgs: ethapi.CallArgs{To: &recipient}}
+
+	if _, err := ethapi.DoCallMany(context.Background(), backend, make([]ethapi.CallManyArgs, 101), latest, nil, nil, 0, 0); err == nil || !strings.HasPrefix(err.Error(), "too many calls") {
+		t.Errorf("oversized bundle error mismatch: have %v", err)
+	}
+	// Transactions exceeding the gas left in the bundle abort it
+	gasCap := vars.TxGas * 3 / 2
+	if _, err := ethapi.DoCallMany(context.Background(), backend, []ethapi.CallManyArgs{transfer(2), transfer(3)}, latest, nil, nil, 0, gasCap); err == nil || !strings.HasPrefix(err.Error(), "call 1:") {
+		t.Errorf("bundle gas cap error mismatch: have %v", err)
+	}
+	// Calls are capped to the gas left in the bundle, until it is exhausted
+	if _, err := ethapi.DoCallMany(context.Background(), backend, []ethapi.CallManyArgs{transfer(2), call}, latest, nil, nil, 0, gasCap); err == nil || !strings.Contains(err.Error(), "(supplied gas 10500)") {
+		t.Errorf("capped call error mismatch: have %v", err)
+	}
+	if _, err := ethapi.DoCallMany(context.Background(), backend, []ethapi.CallManyArgs{transfer(2), call}, latest, nil, nil, 0, vars.TxGas); err == nil || !strings.Contains(err.Error(), "exhausted") {
+		t.Errorf("exhausted bundle gas cap error mismatch: have %v", err)
+	}
+}
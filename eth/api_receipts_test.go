@@ -0,0 +1,156 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestGetBlockReceipts(t *testing.T) {
+	debug, closeFn := newTestTracerAPI(t, common.HexToAddress("0xdeadbeef"), 3)
+	defer closeFn()
+	api := ethapi.NewPublicTransactionPoolAPI(debug.eth.APIBackend, new(ethapi.AddrLocker))
+
+	for number := uint64(0); number <= 3; number++ {
+		block := debug.eth.blockchain.GetBlockByNumber(number)
+		receipts, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve receipts: %v", number, err)
+		}
+		if len(receipts) != len(block.Transactions()) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", number, len(receipts), len(block.Transactions()))
+		}
+		for i, tx := range block.Transactions() {
+			want, err := api.GetTransactionReceipt(context.Background(), tx.Hash())
+			if err != nil {
+				t.Fatalf("block %d: failed to retrieve receipt %d: %v", number, i, err)
+			}
+			if !reflect.DeepEqual(receipts[i], want) {
+				t.Errorf("block %d: receipt %d mismatch: have %v, want %v", number, i, receipts[i], want)
+			}
+		}
+	}
+	if receipts, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(10)); receipts != nil || err != nil {
+		t.Errorf("unknown block receipts mismatch: have %v, %v", receipts, err)
+	}
+}
+
+func TestExportReceipts(t *testing.T) {
+	debug, closeFn := newTestTracerAPI(t, common.HexToAddress("0xdeadbeef"), 3)
+	defer closeFn()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", debug); err != nil {
+		t.Fatalf("failed to register debug API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	// export streams the receipts of a range of blocks in the given format, up to
+	// the first error
+	type exported struct {
+		BlockNumber hexutil.Uint64  `json:"blockNumber"`
+		BlockHash   common.Hash     `json:"blockHash"`
+		Receipts    json.RawMessage `json:"receipts"`
+		Error       string          `json:"error"`
+	}
+	export := func(from, to uint64, format string) []*exported {
+		results := make(chan *exported)
+		sub, err := client.Subscribe(context.Background(), "debug", results, "exportReceipts", hexutil.Uint64(from), hexutil.Uint64(to), format)
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		defer sub.Unsubscribe()
+
+		var all []*exported
+		for n := from; n <= to; n++ {
+			select {
+			case result := <-results:
+				if all = append(all, result); result.Error != "" {
+					return all
+				}
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("export of block %d timed out", n)
+			}
+		}
+		return all
+	}
+	// The RLP export hashes to the receipt roots of the blocks
+	for i, result := range export(0, 3, "rlp") {
+		block := debug.eth.blockchain.GetBlockByNumber(uint64(i))
+		if uint64(result.BlockNumber) != block.NumberU64() || result.BlockHash != block.Hash() {
+			t.Fatalf("block %d: export mismatch: have #%d [%x]", i, result.BlockNumber, result.BlockHash)
+		}
+		var blob hexutil.Bytes
+		if err := json.Unmarshal(result.Receipts, &blob); err != nil {
+			t.Fatalf("block %d: failed to decode export: %v", i, err)
+		}
+		var receipts types.Receipts
+		if err := rlp.DecodeBytes(blob, &receipts); err != nil {
+			t.Fatalf("block %d: failed to decode receipts: %v", i, err)
+		}
+		if root := types.DeriveSha(receipts); root != block.ReceiptHash() {
+			t.Errorf("block %d: receipt root mismatch: have %x, want %x", i, root, block.ReceiptHash())
+		}
+	}
+	// The JSON export matches the receipts of eth_getBlockReceipts
+	api := ethapi.NewPublicTransactionPoolAPI(debug.eth.APIBackend, new(ethapi.AddrLocker))
+	for _, result := range export(1, 2, "json") {
+		receipts, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(result.BlockNumber)))
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve receipts: %v", result.BlockNumber, err)
+		}
+		want, _ := json.Marshal(receipts)
+		if string(result.Receipts) != string(want) {
+			t.Errorf("block %d: export mismatch: have %s, want %s", result.BlockNumber, result.Receipts, want)
+		}
+	}
+	// Invalid requests are rejected
+	if _, err := client.Subscribe(context.Background(), "debug", make(chan *exported), "exportReceipts", hexutil.Uint64(0), hexutil.Uint64(1), "xml"); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+	if _, err := client.Subscribe(context.Background(), "debug", make(chan *exported), "exportReceipts", hexutil.Uint64(2), hexutil.Uint64(1), "rlp"); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	// Missing receipts fail the request at the range bounds, or end the stream
+	// with an error notification within the range
+	block := debug.eth.blockchain.GetBlockByNumber(2)
+	rawdb.DeleteReceipts(debug.eth.ChainDb(), block.Hash(), block.NumberU64())
+
+	if _, err := client.Subscribe(context.Background(), "debug", make(chan *exported), "exportReceipts", hexutil.Uint64(2), hexutil.Uint64(3), "rlp"); err == nil {
+		t.Errorf("range with missing receipts accepted")
+	}
+	results := export(1, 3, "json")
+	if len(results) != 2 || results[0].Error != "" || results[1].Error == "" || results[1].Receipts != nil {
+		t.Errorf("missing receipts export mismatch: have %q, %q with %s", results[0].Error, results[1].Error, results[1].Receipts)
+	}
+}
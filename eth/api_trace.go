@@ -0,0 +1,389 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/parity"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Trace types of trace_replay* methods.
+const (
+	TraceTypeTrace     = "trace"
+	TraceTypeVMTrace   = "vmTrace"
+	TraceTypeStateDiff = "stateDiff"
+)
+
+// maxTraceFilterRange is the maximum number of blocks trace_filter replays.
+const maxTraceFilterRange = 10000
+
+var errPendingTrace = errors.New("tracing the pending block is not supported")
+
+// PrivateTraceAPI is the collection of Parity (OpenEthereum) compatible trace_
+// methods, which replay transactions to trace them.
+type PrivateTraceAPI struct {
+	eth   *Ethereum
+	debug *PrivateDebugAPI
+}
+
+// NewPrivateTraceAPI creates a new API definition for the trace methods of the
+// Ethereum service.
+func NewPrivateTraceAPI(eth *Ethereum) *PrivateTraceAPI {
+	return &PrivateTraceAPI{eth: eth, debug: NewPrivateDebugAPI(eth)}
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// txReplay is the result of replaying a single transaction.
+type txReplay struct {
+	tracer    *parity.Tracer
+	stateDiff parity.StateDiff
+}
+
+// blockByNumber retrieves a block of the canonical chain.
+func (api *PrivateTraceAPI) blockByNumber(number rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+	switch number {
+	case rpc.PendingBlockNumber:
+		return nil, errPendingTrace
+	case rpc.LatestBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	default:
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// replayTx executes a transaction on the given state, tracing it.
+func (api *PrivateTraceAPI) replayTx(block *types.Block, msg core.Message, statedb *state.StateDB, vmTrace, stateDiff bool) (*txReplay, error) {
+	var pre *state.StateDB
+	if stateDiff {
+		pre = statedb.Copy()
+	}
+	tracer := parity.NewTracer(vmTrace)
+	vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: tracer})
+
+	if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+	statedb.Finalise(vmenv.ChainConfig().IsEnabled(vmenv.ChainConfig().GetEIP161dTransition, block.Number()))
+
+	replay := &txReplay{tracer: tracer}
+	if stateDiff {
+		// The sender pays for gas, and the coinbase receives it, outside of any frame.
+		tracer.Touch(msg.From())
+		tracer.Touch(block.Coinbase())
+		replay.stateDiff = parity.NewStateDiff(pre, statedb, tracer.Touched())
+	}
+	return replay, nil
+}
+
+// replayBlock executes all the transactions of a block in order, tracing them.
+func (api *PrivateTraceAPI) replayBlock(ctx context.Context, block *types.Block, vmTrace, stateDiff bool) ([]*txReplay, error) {
+	replays := make([]*txReplay, 0, len(block.Transactions()))
+	err := api.replayBlockTxs(ctx, block, vmTrace, stateDiff, func(replay *txReplay) bool {
+		replays = append(replays, replay)
+		return true
+	})
+	return replays, err
+}
+
+// replayBlockTxs executes the transactions of a block in order, tracing them
+// and passing each replay to fn until it returns false.
+func (api *PrivateTraceAPI) replayBlockTxs(ctx context.Context, block *types.Block, vmTrace, stateDiff bool, fn func(*txReplay) bool) error {
+	if block.NumberU64() == 0 {
+		return nil
+	}
+	statedb, err := api.debug.stateAtTransaction(block, 0, defaultTraceReexec)
+	if err != nil {
+		return err
+	}
+	signer := types.MakeSigner(api.eth.blockchain.Config(), block.Number())
+
+	for i, tx := range block.Transactions() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		replay, err := api.replayTx(block, msg, statedb, vmTrace, stateDiff)
+		if err != nil {
+			return fmt.Errorf("transaction %#x: %v", tx.Hash(), err)
+		}
+		if !fn(replay) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// rewardTraces returns the traces of the mining rewards of a block, if its
+// consensus engine pays any.
+func (api *PrivateTraceAPI) rewardTraces(block *types.Block) []*parity.Trace {
+	if _, ok := api.eth.engine.(*ethash.Ethash); !ok || block.NumberU64() == 0 {
+		return nil
+	}
+	minerReward, uncleRewards := ethash.GetRewards(api.eth.blockchain.Config(), block.Header(), block.Uncles())
+
+	traces := []*parity.Trace{parity.NewRewardTrace(block.Coinbase(), parity.RewardBlock, minerReward)}
+	for i, uncle := range block.Uncles() {
+		traces = append(traces, parity.NewRewardTrace(uncle.Coinbase, parity.RewardUncle, uncleRewards[i]))
+	}
+	for _, trace := range traces {
+		trace.Localize(block.Hash(), block.NumberU64(), nil, nil)
+	}
+	return traces
+}
+
+// blockTraces returns the localized traces of all transactions of a block,
+// followed by its reward traces.
+func (api *PrivateTraceAPI) blockTraces(ctx context.Context, block *types.Block) ([]*parity.Trace, error) {
+	traces := []*parity.Trace{}
+	err := api.traceBlock(ctx, block, func(trace *parity.Trace) bool {
+		traces = append(traces, trace)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// traceBlock passes the localized traces of all transactions of a block,
+// followed by its reward traces, to fn until it returns false. Transactions
+// are replayed only as long as fn asks for more traces.
+func (api *PrivateTraceAPI) traceBlock(ctx context.Context, block *types.Block, fn func(*parity.Trace) bool) error {
+	var (
+		position uint64
+		done     bool
+	)
+	err := api.replayBlockTxs(ctx, block, false, false, func(replay *txReplay) bool {
+		hash := block.Transactions()[position].Hash()
+		for _, trace := range replay.tracer.Traces() {
+			index := position
+			trace.Localize(block.Hash(), block.NumberU64(), &hash, &index)
+			if !fn(trace) {
+				done = true
+				return false
+			}
+		}
+		position++
+		return true
+	})
+	if err != nil || done {
+		return err
+	}
+	for _, trace := range api.rewardTraces(block) {
+		if !fn(trace) {
+			break
+		}
+	}
+	return nil
+}
+
+// Block returns the traces of all transactions of a block, along with the
+// traces of its mining rewards.
+func (api *PrivateTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*parity.Trace, error) {
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.blockTraces(ctx, block)
+}
+
+// Transaction returns the traces of a single transaction, or nil if the
+// transaction isn't known.
+func (api *PrivateTraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*parity.Trace, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, nil
+	}
+	block := api.eth.blockchain.GetBlock(blockHash, blockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	statedb, err := api.debug.stateAtTransaction(block, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	msg, _ := tx.AsMessage(types.MakeSigner(api.eth.blockchain.Config(), block.Number()), block.BaseFee())
+	statedb.Prepare(tx.Hash(), block.Hash(), int(index))
+
+	replay, err := api.replayTx(block, msg, statedb, false, false)
+	if err != nil {
+		return nil, err
+	}
+	traces := replay.tracer.Traces()
+	for _, trace := range traces {
+		trace.Localize(blockHash, blockNumber, &hash, &index)
+	}
+	return traces, nil
+}
+
+// Filter returns the traces of a range of blocks, including reward traces,
+// matching the given origin and destination addresses.
+//
+// A trace matches if its origin is one of FromAddress and its destination one
+// of ToAddress, where an empty list matches any address. Of the matching traces,
+// the first After are skipped, and at most Count are returned. At most
+// maxTraceFilterRange blocks are replayed, stopping once Count traces matched.
+func (api *PrivateTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*parity.Trace, error) {
+	from, to := rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		from = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		to = *args.ToBlock
+	}
+	if from == rpc.PendingBlockNumber || to == rpc.PendingBlockNumber {
+		return nil, errPendingTrace
+	}
+	head := api.eth.blockchain.CurrentBlock().NumberU64()
+	start, end := head, head
+	if from != rpc.LatestBlockNumber {
+		start = uint64(from)
+	}
+	if to != rpc.LatestBlockNumber {
+		end = uint64(to)
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid block range %d-%d", start, end)
+	}
+	if end-start >= maxTraceFilterRange {
+		return nil, fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", start, end, maxTraceFilterRange)
+	}
+	if end > head {
+		return nil, fmt.Errorf("block #%d not found", end)
+	}
+	var (
+		skip  uint64
+		count = ^uint64(0)
+	)
+	if args.After != nil {
+		skip = *args.After
+	}
+	if args.Count != nil {
+		count = *args.Count
+	}
+	matches := []*parity.Trace{}
+	for n := start; n <= end && uint64(len(matches)) < count; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.eth.blockchain.GetBlockByNumber(n)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", n)
+		}
+		err := api.traceBlock(ctx, block, func(trace *parity.Trace) bool {
+			if !matchAddress(trace.From(), args.FromAddress) || !matchAddress(trace.To(), args.ToAddress) {
+				return true
+			}
+			if skip > 0 {
+				skip--
+				return true
+			}
+			matches = append(matches, trace)
+			return uint64(len(matches)) < count
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// matchAddress checks whether an address is part of a filter set, an empty set
+// matching any address.
+func matchAddress(addr *common.Address, set []common.Address) bool {
+	if len(set) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	for _, a := range set {
+		if a == *addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayBlockTransactions replays all transactions of a block, returning for
+// each the requested trace types among "trace", "vmTrace" and "stateDiff".
+func (api *PrivateTraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*parity.TraceResults, error) {
+	var trace, vmTrace, stateDiff bool
+	for _, typ := range traceTypes {
+		switch typ {
+		case TraceTypeTrace:
+			trace = true
+		case TraceTypeVMTrace:
+			vmTrace = true
+		case TraceTypeStateDiff:
+			stateDiff = true
+		default:
+			return nil, fmt.Errorf("unknown trace type %q", typ)
+		}
+	}
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	replays, err := api.replayBlock(ctx, block, vmTrace, stateDiff)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*parity.TraceResults, len(replays))
+	for i, replay := range replays {
+		hash := block.Transactions()[i].Hash()
+		results[i] = &parity.TraceResults{
+			Output:          replay.tracer.Output(),
+			StateDiff:       replay.stateDiff,
+			Trace:           []*parity.Trace{},
+			TransactionHash: &hash,
+			VMTrace:         replay.tracer.VMTrace(),
+		}
+		if trace {
+			results[i].Trace = replay.tracer.Traces()
+		}
+	}
+	return results, nil
+}
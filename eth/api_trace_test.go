@@ -0,0 +1,152 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/eth/tracers/parity"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestTraceAPI(t *testing.T) {
+	recipient := common.HexToAddress("0xdeadbeef")
+	debug, closeFn := newTestTracerAPI(t, recipient, 3)
+	defer closeFn()
+	api := NewPrivateTraceAPI(debug.eth)
+	ctx := context.Background()
+
+	// Every block holds a single transfer, followed by the block reward.
+	block := debug.eth.blockchain.GetBlockByNumber(2)
+	traces, err := api.Block(ctx, 2)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want 2", len(traces))
+	}
+	transfer, reward := traces[0], traces[1]
+	if transfer.Type != parity.TypeCall || *transfer.From() != testBank || *transfer.To() != recipient {
+		t.Errorf("transfer trace mismatch: %+v", transfer)
+	}
+	if transfer.BlockNumber != 2 || *transfer.BlockHash != block.Hash() || *transfer.TransactionHash != block.Transactions()[0].Hash() || *transfer.TransactionPosition != 0 {
+		t.Errorf("transfer trace position mismatch: %+v", transfer)
+	}
+	if action := transfer.Action.(*parity.CallAction); action.Value.ToInt().Int64() != 1000 {
+		t.Errorf("transfer value mismatch: have %v, want 1000", action.Value)
+	}
+	minerReward, _ := ethash.GetRewards(debug.eth.blockchain.Config(), block.Header(), nil)
+	if action := reward.Action.(*parity.RewardAction); reward.Type != parity.TypeReward || action.Author != block.Coinbase() || action.Value.ToInt().Cmp(minerReward) != 0 {
+		t.Errorf("reward trace mismatch: %+v", reward.Action)
+	}
+	if reward.TransactionHash != nil || reward.TransactionPosition != nil {
+		t.Errorf("reward trace has a transaction: %+v", reward)
+	}
+	if _, err := api.Block(ctx, rpc.PendingBlockNumber); err == nil {
+		t.Error("traced pending block")
+	}
+
+	// Transactions are traced on their own.
+	hash := block.Transactions()[0].Hash()
+	if traces, err = api.Transaction(ctx, hash); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if len(traces) != 1 || traces[0].BlockNumber != 2 || *traces[0].TransactionHash != hash {
+		t.Errorf("transaction traces mismatch: %+v", traces)
+	}
+	if traces, err = api.Transaction(ctx, common.Hash{}); err != nil || traces != nil {
+		t.Errorf("unknown transaction traced: %v, %v", traces, err)
+	}
+
+	// Filters match on both ends, and paginate.
+	number := func(n int64) *rpc.BlockNumber {
+		return (*rpc.BlockNumber)(&n)
+	}
+	count := func(n uint64) *uint64 {
+		return &n
+	}
+	for i, tt := range []struct {
+		args  TraceFilterArgs
+		want  int
+		first uint64 // Block number of the first trace
+	}{
+		{TraceFilterArgs{FromBlock: number(1), ToBlock: number(3)}, 6, 1},
+		{TraceFilterArgs{FromBlock: number(1), ToBlock: number(3), ToAddress: []common.Address{recipient}}, 3, 1},
+		{TraceFilterArgs{FromBlock: number(1), ToBlock: number(3), ToAddress: []common.Address{block.Coinbase()}}, 3, 1},
+		{TraceFilterArgs{FromBlock: number(1), FromAddress: []common.Address{testBank}, ToAddress: []common.Address{block.Coinbase()}}, 0, 0},
+		{TraceFilterArgs{FromBlock: number(1), FromAddress: []common.Address{testBank}, After: count(1), Count: count(1)}, 1, 2},
+		{TraceFilterArgs{FromBlock: number(3), FromAddress: []common.Address{testBank}}, 1, 3},
+	} {
+		traces, err := api.Filter(ctx, tt.args)
+		if err != nil {
+			t.Errorf("test %d: filter failed: %v", i, err)
+			continue
+		}
+		if len(traces) != tt.want {
+			t.Errorf("test %d: trace count mismatch: have %d, want %d", i, len(traces), tt.want)
+			continue
+		}
+		if len(traces) > 0 && traces[0].BlockNumber != tt.first {
+			t.Errorf("test %d: first trace block mismatch: have %d, want %d", i, traces[0].BlockNumber, tt.first)
+		}
+	}
+	if _, err := api.Filter(ctx, TraceFilterArgs{FromBlock: number(3), ToBlock: number(1)}); err == nil {
+		t.Error("filtered inverted block range")
+	}
+	if _, err := api.Filter(ctx, TraceFilterArgs{FromBlock: number(0), ToBlock: number(maxTraceFilterRange)}); err == nil {
+		t.Error("filtered block range over the limit")
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := api.Filter(cctx, TraceFilterArgs{FromBlock: number(1), ToBlock: number(3)}); err != context.Canceled {
+		t.Errorf("cancelled filter error mismatch: have %v, want %v", err, context.Canceled)
+	}
+
+	// Replays return the requested trace types only.
+	results, err := api.ReplayBlockTransactions(ctx, 2, []string{TraceTypeTrace, TraceTypeStateDiff})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(results) != 1 || *results[0].TransactionHash != hash {
+		t.Fatalf("replay results mismatch: %+v", results)
+	}
+	result := results[0]
+	if len(result.Trace) != 1 || result.Trace[0].BlockHash != nil || result.VMTrace != nil {
+		t.Errorf("replay trace mismatch: %+v", result)
+	}
+	diff := result.StateDiff[recipient]
+	if diff == nil || diff.Balance.From.(*hexutil.Big).ToInt().Cmp(big.NewInt(1000)) != 0 || diff.Balance.To.(*hexutil.Big).ToInt().Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("recipient state diff mismatch: %+v", diff)
+	}
+	if diff := result.StateDiff[testBank]; diff == nil || diff.Nonce.From.(hexutil.Uint64) != 1 || diff.Nonce.To.(hexutil.Uint64) != 2 {
+		t.Errorf("sender state diff mismatch: %+v", diff)
+	}
+	if results, err = api.ReplayBlockTransactions(ctx, 2, []string{TraceTypeVMTrace}); err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(results[0].Trace) != 0 || results[0].StateDiff != nil || results[0].VMTrace == nil {
+		t.Errorf("VM trace replay mismatch: %+v", results[0])
+	}
+	if _, err := api.ReplayBlockTransactions(ctx, 2, []string{"bogus"}); err == nil {
+		t.Error("replayed with unknown trace type")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newTestTracerAPI creates an Ethereum service with a chain of blocks, each
+// transferring 1000 wei from testBank to the given recipient, and returns its
+// debug API.
+func newTestTracerAPI(t *testing.T, recipient common.Address, blocks int) (*PrivateDebugAPI, func()) {
+	genesis := &genesisT.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc:  genesisT.GenesisAlloc{testBank: {Balance: big.NewInt(vars.Ether)}},
+	}
+	db := rawdb.NewMemoryDatabase()
+	signer := types.HomesteadSigner{}
+	chain, _ := core.GenerateChain(genesis.Config, core.MustCommitGenesis(db, genesis), ethash.NewFaker(), db, blocks, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testBank), recipient, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil), signer, testBankKey)
+		b.AddTx(tx)
+	})
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create new node: %v", err)
+	}
+	config := &Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethservice, err := New(stack, config)
+	if err != nil {
+		t.Fatalf("can't create new ethereum service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	if _, err := ethservice.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	return NewPrivateDebugAPI(ethservice), func() { stack.Close() }
+}
+
+func TestTraceCall(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		unfunded  = common.HexToAddress("0xfeed")
+		contract  = common.HexToAddress("0xc0de")
+	)
+	api, closeFn := newTestTracerAPI(t, recipient, 2)
+	defer closeFn()
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	value := (*hexutil.Big)(big.NewInt(1))
+	balance := (*hexutil.Big)(big.NewInt(vars.Ether))
+	returns42 := hexutil.Bytes(common.FromHex("602a60005260206000f3"))
+	// Code returning the balance of the transfer recipient
+	returnsBalance := hexutil.Bytes(append(append([]byte{0x73}, recipient.Bytes()...), common.FromHex("3160005260206000f3")...))
+	overrideCode := func(code *hexutil.Bytes) *ethapi.StateOverride {
+		return &ethapi.StateOverride{contract: ethapi.OverrideAccount{Code: code}}
+	}
+	txIndex := func(n uint) *hexutil.Uint {
+		return (*hexutil.Uint)(&n)
+	}
+	tracer := `{step: function() {}, fault: function() {}, result: function(ctx) { return ctx.type + " " + ctx.gasUsed; }}`
+
+	for i, tt := range []struct {
+		args       ethapi.CallArgs
+		block      rpc.BlockNumberOrHash
+		config     *TraceCallConfig
+		wantErr    bool
+		wantReturn string          // For struct logger traces
+		wantGas    uint64          // For struct logger traces, if set
+		wantTracer json.RawMessage // For JS tracer traces
+	}{
+		// Simple transfer on top of the latest block
+		{
+			args:    ethapi.CallArgs{From: &testBank, To: &recipient, Value: value},
+			block:   latest,
+			wantGas: vars.TxGas,
+		},
+		// Transfer from an account without funds fails...
+		{
+			args:    ethapi.CallArgs{From: &unfunded, To: &recipient, Value: value},
+			block:   latest,
+			wantErr: true,
+		},
+		// ...unless its balance is overridden
+		{
+			args:  ethapi.CallArgs{From: &unfunded, To: &recipient, Value: value},
+			block: latest,
+			config: &TraceCallConfig{StateOverrides: &ethapi.StateOverride{
+				unfunded: ethapi.OverrideAccount{Balance: &balance},
+			}},
+			wantGas: vars.TxGas,
+		},
+		// Code overrides are executed
+		{
+			args:       ethapi.CallArgs{From: &testBank, To: &contract},
+			block:      latest,
+			config:     &TraceCallConfig{StateOverrides: overrideCode(&returns42)},
+			wantReturn: common.BigToHash(big.NewInt(42)).Hex()[2:],
+			wantGas:    vars.TxGas + 18,
+		},
+		// Calls see the state at the end of the block...
+		{
+			args:       ethapi.CallArgs{From: &testBank, To: &contract},
+			block:      rpc.BlockNumberOrHashWithNumber(1),
+			config:     &TraceCallConfig{StateOverrides: overrideCode(&returnsBalance)},
+			wantReturn: common.BigToHash(big.NewInt(1000)).Hex()[2:],
+		},
+		// ...or after a number of its transactions
+		{
+			args:       ethapi.CallArgs{From: &testBank, To: &contract},
+			block:      rpc.BlockNumberOrHashWithNumber(2),
+			config:     &TraceCallConfig{StateOverrides: overrideCode(&returnsBalance), TxIndex: txIndex(0)},
+			wantReturn: common.BigToHash(big.NewInt(1000)).Hex()[2:],
+		},
+		{
+			args:       ethapi.CallArgs{From: &testBank, To: &contract},
+			block:      rpc.BlockNumberOrHashWithNumber(2),
+			config:     &TraceCallConfig{StateOverrides: overrideCode(&returnsBalance), TxIndex: txIndex(1)},
+			wantReturn: common.BigToHash(big.NewInt(2000)).Hex()[2:],
+		},
+		// Transaction index out of range
+		{
+			args:    ethapi.CallArgs{From: &testBank, To: &recipient},
+			block:   rpc.BlockNumberOrHashWithNumber(2),
+			config:  &TraceCallConfig{TxIndex: txIndex(2)},
+			wantErr: true,
+		},
+		// JS tracers
+		{
+			args:       ethapi.CallArgs{From: &testBank, To: &contract},
+			block:      latest,
+			config:     &TraceCallConfig{Tracer: &tracer, StateOverrides: overrideCode(&returns42)},
+			wantTracer: json.RawMessage(`"CALL 18"`),
+		},
+		// Missing block
+		{
+			args:    ethapi.CallArgs{From: &testBank, To: &recipient},
+			block:   rpc.BlockNumberOrHashWithNumber(3),
+			wantErr: true,
+		},
+	} {
+		result, err := api.TraceCall(context.Background(), tt.args, tt.block, tt.config)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("test %d: want error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if tt.wantTracer != nil {
+			if got := string(result.(json.RawMessage)); got != string(tt.wantTracer) {
+				t.Errorf("test %d: tracer result mismatch: want %s, got %s", i, tt.wantTracer, got)
+			}
+			continue
+		}
+		res := result.(*ethapi.ExecutionResult)
+		if res.Failed || (tt.wantGas != 0 && res.Gas != tt.wantGas) || res.ReturnValue != tt.wantReturn {
+			t.Errorf("test %d: result mismatch: want gas %d, return %q, got failed %v, gas %d, return %q", i, tt.wantGas, tt.wantReturn, res.Failed, res.Gas, res.ReturnValue)
+		}
+	}
+}
@@ -29,16 +29,18 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
-	"github.com/ethereum/go-ethereum/consensus/clique"
+	_ "github.com/ethereum/go-ethereum/consensus/clique" // register the clique engine
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -79,6 +81,8 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	addressIndexer *core.ChainIndexer // Address transaction indexer, nil if disabled
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -107,6 +111,9 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.AddressIndex && config.AddressIndexInternal && !config.NoPruning {
+		return nil, errors.New("indexing internal calls requires an archive node (--gcmode=archive)")
+	}
 	if config.Miner.GasPrice == nil || config.Miner.GasPrice.Cmp(common.Big0) <= 0 {
 		log.Warn("Sanitizing invalid miner gas price", "provided", config.Miner.GasPrice, "updated", DefaultConfig.Miner.GasPrice)
 		config.Miner.GasPrice = new(big.Int).Set(DefaultConfig.Miner.GasPrice)
@@ -131,10 +138,20 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Try to recover an interrupted offline state pruning
+	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
+		log.Error("Failed to recover state", "error", err)
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
 	if _, ok := genesisErr.(*confp.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	if config.ECBP1100 != nil {
+		n := config.ECBP1100.Uint64()
+		if err := chainConfig.SetECBP1100Transition(&n); err != nil {
+			return nil, err
+		}
+	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
 	eth := &Ethereum{
@@ -142,7 +159,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		chainDb:           chainDb,
 		eventMux:          stack.EventMux(),
 		accountManager:    stack.AccountManager(),
-		engine:            CreateConsensusEngine(stack, chainConfig, &config.Ethash, config.Miner.Notify, config.Miner.Noverify, chainDb),
+		engine:            CreateConsensusEngine(stack, chainConfig, config.ConsensusEngine, &config.Ethash, config.Miner.Notify, config.Miner.Noverify, chainDb),
 		closeBloomHandler: make(chan struct{}),
 		networkID:         config.NetworkId,
 		gasPrice:          config.Miner.GasPrice,
@@ -151,6 +168,12 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		bloomIndexer:      NewBloomIndexer(chainDb, vars.BloomBitsBlocks, vars.BloomConfirms),
 		p2pServer:         stack.Server(),
 	}
+	if engine, ok := eth.engine.(*ethash.Ethash); ok {
+		if err := engine.StratumError(); err != nil {
+			engine.Close()
+			return nil, err
+		}
+	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
@@ -196,6 +219,11 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	if config.AddressIndex {
+		eth.addressIndexer, _ = NewAddressIndexer(chainDb, eth.blockchain, AddressIndexSection, AddressIndexConfirms, config.AddressIndexInternal)
+		eth.addressIndexer.Start(eth.blockchain)
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
@@ -214,6 +242,8 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(chainConfig, checkpoint, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit, config.Whitelist); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.noDisableArtificialFinality = config.ECBP1100NoDisable
+
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
@@ -256,14 +286,19 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
-// CreateConsensusEngine creates the required type of consensus engine instance for an Ethereum service
-func CreateConsensusEngine(stack *node.Node, chainConfig ctypes.ChainConfigurator, config *ethash.Config, notify []string, noverify bool, db ethdb.Database) consensus.Engine {
-	// If proof-of-authority is requested, set it up
-	if chainConfig.GetConsensusEngineType().IsClique() {
-		return clique.New(&ctypes.CliqueConfig{
-			Period: chainConfig.GetCliquePeriod(),
-			Epoch:  chainConfig.GetCliqueEpoch(),
-		}, db)
+// CreateConsensusEngine creates the required type of consensus engine instance for an Ethereum service.
+// The engine is the given one, if known, or else the one of the chain configuration.
+func CreateConsensusEngine(stack *node.Node, chainConfig ctypes.ChainConfigurator, engineType ctypes.ConsensusEngineT, config *ethash.Config, notify []string, noverify bool, db ethdb.Database) consensus.Engine {
+	if engineType.IsUnknown() {
+		engineType = chainConfig.GetConsensusEngineType()
+	}
+	// If a registered engine is requested (e.g. proof-of-authority), set it up
+	if consensus.HasEngine(engineType) {
+		engine, err := consensus.NewEngine(engineType, chainConfig, db)
+		if err != nil {
+			log.Crit("Failed to create consensus engine", "engine", engineType, "err", err)
+		}
+		return engine
 	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
@@ -278,14 +313,17 @@ func CreateConsensusEngine(stack *node.Node, chainConfig ctypes.ChainConfigurato
 		return ethash.NewShared()
 	default:
 		engine := ethash.New(ethash.Config{
-			CacheDir:         stack.ResolvePath(config.CacheDir),
-			CachesInMem:      config.CachesInMem,
-			CachesOnDisk:     config.CachesOnDisk,
-			CachesLockMmap:   config.CachesLockMmap,
-			DatasetDir:       config.DatasetDir,
-			DatasetsInMem:    config.DatasetsInMem,
-			DatasetsOnDisk:   config.DatasetsOnDisk,
-			DatasetsLockMmap: config.DatasetsLockMmap,
+			CacheDir:          stack.ResolvePath(config.CacheDir),
+			CachesInMem:       config.CachesInMem,
+			CachesOnDisk:      config.CachesOnDisk,
+			CachesLockMmap:    config.CachesLockMmap,
+			DatasetDir:        config.DatasetDir,
+			DatasetsInMem:     config.DatasetsInMem,
+			DatasetsOnDisk:    config.DatasetsOnDisk,
+			DatasetsLockMmap:  config.DatasetsLockMmap,
+			ECIP1099Block:     chainConfig.GetEthashECIP1099Transition(),
+			StratumAddr:       config.StratumAddr,
+			StratumDifficulty: config.StratumDifficulty,
 		}, notify, noverify)
 		engine.SetThreads(-1) // Disable CPU mining
 		return engine
@@ -300,6 +338,16 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append the address transaction history if it's being indexed
+	if s.addressIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicAddressIndexAPI(s.chainDb, s.blockchain, s.addressIndexer, AddressIndexSection, AddressIndexConfirms),
+			Public:    true,
+		})
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -340,6 +388,10 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s),
+		}, {
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPrivateTraceAPI(s),
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -408,23 +460,9 @@ func (s *Ethereum) isLocalBlock(block *types.Block) bool {
 // during the chain reorg depending on whether the author of block
 // is a local account.
 func (s *Ethereum) shouldPreserve(block *types.Block) bool {
-	// The reason we need to disable the self-reorg preserving for clique
-	// is it can be probable to introduce a deadlock.
-	//
-	// e.g. If there are 7 available signers
-	//
-	// r1   A
-	// r2     B
-	// r3       C
-	// r4         D
-	// r5   A      [X] F G
-	// r6    [X]
-	//
-	// In the round5, the inturn signer E is offline, so the worst case
-	// is A, F and G sign the block of round5 and reject the block of opponents
-	// and in the round6, the last available signer B is offline, the whole
-	// network is stuck.
-	if _, ok := s.engine.(*clique.Clique); ok {
+	// Engines taking turns to seal blocks, like clique, may deadlock if the
+	// local blocks are preferred, so they can disable the self-reorg preserving
+	if p, ok := s.engine.(consensus.Preserver); ok && !p.PreserveLocalBlocks() {
 		return false
 	}
 	return s.isLocalBlock(block)
@@ -468,13 +506,13 @@ func (s *Ethereum) StartMining(threads int) error {
 			log.Error("Cannot start mining without etherbase", "err", err)
 			return fmt.Errorf("etherbase missing: %v", err)
 		}
-		if clique, ok := s.engine.(*clique.Clique); ok {
+		if authorizer, ok := s.engine.(consensus.Authorizer); ok {
 			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 			if wallet == nil || err != nil {
 				log.Error("Etherbase account unavailable locally", "err", err)
 				return fmt.Errorf("signer missing: %v", err)
 			}
-			clique.Authorize(eb, wallet.SignData)
+			authorizer.Authorize(eb, wallet.SignData)
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -525,6 +563,10 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 		protos[i].Attributes = []enr.Entry{s.currentEthEntry()}
 		protos[i].DialCandidates = s.dialCandidates
 	}
+	// Serve the snap protocol if snapshots are maintained, or if snap syncing
+	if s.config.SnapshotCache > 0 || s.config.SyncMode == downloader.SnapSync {
+		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.protocolManager))...)
+	}
 	return protos
 }
 
@@ -558,6 +600,9 @@ func (s *Ethereum) Stop() error {
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
+	if s.addressIndexer != nil {
+		s.addressIndexer.Close()
+	}
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.blockchain.Stop()
@@ -122,6 +122,9 @@ type Config struct {
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
+	AddressIndex         bool `toml:",omitempty"` // Whether to index the transactions of each address
+	AddressIndexInternal bool `toml:",omitempty"` // Whether to index the addresses touched by internal calls too
+
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
@@ -154,6 +157,9 @@ type Config struct {
 	// Mining options
 	Miner miner.Config
 
+	// Consensus engine to run the chain with, overriding the chain configuration
+	ConsensusEngine ctypes.ConsensusEngineT `toml:",omitempty"`
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -187,4 +193,12 @@ type Config struct {
 
 	// CheckpointOracle is the configuration for checkpoint oracle.
 	CheckpointOracle *ctypes.CheckpointOracleConfig `toml:",omitempty"`
+
+	// ECBP1100 overrides the activation block of the ECBP1100 (MESS) artificial
+	// finality features configured for the chain.
+	ECBP1100 *big.Int `toml:",omitempty"`
+
+	// ECBP1100NoDisable keeps the artificial finality features enabled once
+	// they have been, even if the node loses its peers or falls out of sync.
+	ECBP1100NoDisable bool `toml:",omitempty"`
 }
@@ -28,6 +28,7 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -63,6 +64,8 @@ type downloadTester struct {
 	ancientReceipts map[common.Hash]types.Receipts // Ancient receipts belonging to the tester
 	ancientChainTd  map[common.Hash]*big.Int       // Ancient total difficulties of the blocks in the local chain
 
+	insertChainHook func(types.Blocks) error // Method to call before inserting a chain, failing it on error
+
 	lock sync.RWMutex
 }
 
@@ -291,6 +294,12 @@ func (dl *downloadTester) InsertHeaderChain(headers []*types.Header, checkFreq i
 func (dl *downloadTester) InsertChain(blocks types.Blocks) (i int, err error) {
 	dl.lock.Lock()
 	defer dl.lock.Unlock()
+
+	if dl.insertChainHook != nil {
+		if err := dl.insertChainHook(blocks); err != nil {
+			return 0, err
+		}
+	}
 	for i, block := range blocks {
 		if parent, ok := dl.ownBlocks[block.ParentHash()]; !ok {
 			return i, fmt.Errorf("InsertChain: unknown parent at position %d / %d", i, len(blocks))
@@ -1160,6 +1169,32 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that a peer feeding a chain rejected by the artificial finality rules is
+// dropped, as it would keep resending it otherwise.
+func TestReorgFinalityDropping(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.insertChainHook = func(types.Blocks) error {
+		return fmt.Errorf("%w: test reorg", core.ErrReorgFinality)
+	}
+	chain := testChainBase.shorten(blockCacheItems - 15)
+	tester.newPeer("peer", 65, chain)
+
+	err := tester.downloader.Synchronise("peer", chain.headBlock().Hash(), chain.td(chain.headBlock().Hash()), FullSync)
+	if !errors.Is(err, core.ErrReorgFinality) {
+		t.Fatalf("synchronisation error mismatch: have %v, want %v", err, core.ErrReorgFinality)
+	}
+	tester.lock.RLock()
+	_, ok := tester.peers["peer"]
+	tester.lock.RUnlock()
+	if ok {
+		t.Errorf("peer not dropped")
+	}
+}
+
 // Tests that synchronisation progress (origin block number, current block number
 // and highest block number) is tracked and updated correctly.
 func TestSyncProgress63Full(t *testing.T)  { testSyncProgress(t, 63, FullSync) }
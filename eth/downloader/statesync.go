@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/trie"
@@ -295,7 +296,15 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
-	s.err = s.loop()
+	if s.d.snapSync {
+		close(s.started)
+		s.err = s.d.SnapSyncer.Sync(s.root, s.cancel)
+		if s.err == snap.ErrCancelled {
+			s.err = errCancelStateFetch
+		}
+	} else {
+		s.err = s.loop()
+	}
 	close(s.done)
 }
 
@@ -0,0 +1,98 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestFeeHistory(t *testing.T) {
+	var cases = []struct {
+		london        bool
+		count         int
+		last          rpc.BlockNumber
+		percent       []float64
+		expFirst      uint64
+		expCount      int
+		expBaseFeeSet bool
+		expErr        error
+	}{
+		{false, 10, 30, nil, 21, 10, false, nil},
+		{false, 10, 30, []float64{0, 10}, 21, 10, false, nil},
+		{false, 10, 30, []float64{20, 10}, 0, 0, false, errInvalidPercentile},
+		{false, 100000000, 30, nil, 0, 31, false, nil},
+		{false, 100000000, rpc.LatestBlockNumber, nil, 0, 33, false, nil},
+		{false, 10, 40, nil, 0, 0, false, errRequestBeyondHead},
+		{false, 2, rpc.PendingBlockNumber, []float64{0, 10}, 31, 2, false, nil},
+		{true, 10, 30, []float64{0, 10}, 21, 10, true, nil},
+		{true, 0, rpc.LatestBlockNumber, nil, 0, 0, true, nil},
+	}
+	for i, c := range cases {
+		config := Config{
+			Blocks:     20,
+			Percentile: 60,
+			Default:    big.NewInt(vars.GWei),
+		}
+		backend := newTestBackend(t, c.london)
+		oracle := NewOracle(backend, config)
+
+		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
+
+		expReward := c.expCount
+		if len(c.percent) == 0 {
+			expReward = 0
+		}
+		expBaseFee := c.expCount
+		if expBaseFee != 0 {
+			expBaseFee++
+		}
+		if first.Uint64() != c.expFirst {
+			t.Fatalf("Test case %d: first block mismatch, want %d, got %d", i, c.expFirst, first)
+		}
+		if len(reward) != expReward {
+			t.Fatalf("Test case %d: reward array length mismatch, want %d, got %d", i, expReward, len(reward))
+		}
+		if len(baseFee) != expBaseFee {
+			t.Fatalf("Test case %d: baseFee array length mismatch, want %d, got %d", i, expBaseFee, len(baseFee))
+		}
+		if len(ratio) != c.expCount {
+			t.Fatalf("Test case %d: gasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(ratio))
+		}
+		for _, fee := range baseFee {
+			if (fee.Sign() != 0) != c.expBaseFeeSet {
+				t.Fatalf("Test case %d: base fee presence mismatch, want %v, got %d", i, c.expBaseFeeSet, fee)
+			}
+		}
+		for _, row := range reward {
+			// Every block carries a single transaction tipping its block number in gwei
+			for _, tip := range row {
+				if tip.Sign() == 0 {
+					t.Fatalf("Test case %d: zero reward in non-empty block", i)
+				}
+			}
+		}
+		if err != c.expErr && !errors.Is(err, c.expErr) {
+			t.Fatalf("Test case %d: error mismatch, want %v, got %v", i, c.expErr, err)
+		}
+	}
+}
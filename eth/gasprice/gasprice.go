@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/vars"
@@ -43,11 +44,15 @@ type Config struct {
 type OracleBackend interface {
 	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
 	ChainConfig() ctypes.ChainConfigurator
 }
 
 // Oracle recommends gas prices based on the content of recent
 // blocks. Suitable for both light and full clients.
+//
+// On chains with EIP-1559 enabled the oracle samples the effective tips paid
+// on top of each block's base fee instead of the raw gas prices.
 type Oracle struct {
 	backend   OracleBackend
 	lastHead  common.Hash
@@ -83,7 +88,28 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 
 // SuggestPrice returns a gasprice so that newly created transaction can
 // have a very high chance to be included in the following blocks.
+//
+// Once EIP-1559 is enabled for the next block, the suggestion is the sampled
+// tip on top of that block's base fee, making it suitable for legacy
+// transactions.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	tip, err := gpo.SuggestTipCap(ctx)
+	if err != nil {
+		return tip, err
+	}
+	config := gpo.backend.ChainConfig()
+	next := new(big.Int).Add(head.Number, common.Big1)
+	if !config.IsEnabled(config.GetEIP1559Transition, next) {
+		return tip, nil
+	}
+	return new(big.Int).Add(tip, misc.CalcBaseFee(config, head)), nil
+}
+
+// SuggestTipCap returns a tip cap so that newly created transaction can have
+// a very high chance to be included in the following blocks. Before EIP-1559
+// is enabled the tip is simply the full gas price paid.
+func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	headHash := head.Hash()
 
@@ -162,15 +188,25 @@ type getBlockPricesResult struct {
 	err    error
 }
 
-type transactionsByGasPrice []*types.Transaction
+type transactionsByGasPrice struct {
+	txs     []*types.Transaction
+	baseFee *big.Int
+}
 
-func (t transactionsByGasPrice) Len() int           { return len(t) }
-func (t transactionsByGasPrice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
-func (t transactionsByGasPrice) Less(i, j int) bool { return t[i].GasPriceCmp(t[j]) < 0 }
+func (t transactionsByGasPrice) Len() int      { return len(t.txs) }
+func (t transactionsByGasPrice) Swap(i, j int) { t.txs[i], t.txs[j] = t.txs[j], t.txs[i] }
+func (t transactionsByGasPrice) Less(i, j int) bool {
+	// It's okay to discard the error because a tx would never be
+	// accepted into a block with an invalid effective tip.
+	tip1, _ := t.txs[i].EffectiveGasTip(t.baseFee)
+	tip2, _ := t.txs[j].EffectiveGasTip(t.baseFee)
+	return tip1.Cmp(tip2) < 0
+}
 
-// getBlockPrices calculates the lowest transaction gas price in a given block
-// and sends it to the result channel. If the block is empty or all transactions
-// are sent by the miner itself(it doesn't make any sense to include this kind of
+// getBlockPrices calculates the lowest transaction tips in a given block and
+// sends them to the result channel. For blocks without a base fee the tip is
+// the gas price itself. If the block is empty or all transactions are sent by
+// the miner itself(it doesn't make any sense to include this kind of
 // transaction prices for sampling), nil gasprice is returned.
 func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, blockNum uint64, limit int, result chan getBlockPricesResult, quit chan struct{}) {
 	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNum))
@@ -184,13 +220,15 @@ func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, bloc
 	blockTxs := block.Transactions()
 	txs := make([]*types.Transaction, len(blockTxs))
 	copy(txs, blockTxs)
-	sort.Sort(transactionsByGasPrice(txs))
+	sorter := transactionsByGasPrice{txs: txs, baseFee: block.BaseFee()}
+	sort.Sort(sorter)
 
 	var prices []*big.Int
-	for _, tx := range txs {
+	for _, tx := range sorter.txs {
 		sender, err := types.Sender(signer, tx)
 		if err == nil && sender != block.Coinbase() {
-			prices = append(prices, tx.GasPrice())
+			tip, _ := tx.EffectiveGasTip(block.BaseFee())
+			prices = append(prices, tip)
 			if len(prices) >= limit {
 				break
 			}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -54,28 +55,62 @@ func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber)
 	return b.chain.GetBlockByNumber(uint64(number)), nil
 }
 
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
 func (b *testBackend) ChainConfig() ctypes.ChainConfigurator {
 	return b.chain.Config()
 }
 
-func newTestBackend(t *testing.T) *testBackend {
+// newTestBackend creates a chain of 32 blocks, each containing a single
+// transaction paying a tip of (block number) gwei. If london is set, EIP-1559
+// is enabled from genesis and the transactions are dynamic fee ones.
+func newTestBackend(t *testing.T, london bool) *testBackend {
 	var (
 		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig // needs copy because it is modified below
 		gspec  = &genesisT.Genesis{
-			Config: params.TestChainConfig,
+			Config: &config,
 			Alloc:  genesisT.GenesisAlloc{addr: {Balance: big.NewInt(math.MaxInt64)}},
 		}
-		signer = types.NewEIP155Signer(gspec.Config.GetChainID())
 	)
+	if london {
+		config.BerlinBlock = big.NewInt(0)
+		config.LondonBlock = big.NewInt(0)
+	}
+	signer := types.LatestSigner(gspec.Config)
 	engine := ethash.NewFaker()
 	db := rawdb.NewMemoryDatabase()
 	genesis := core.MustCommitGenesis(db, gspec)
 
 	// Generate testing blocks
-	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 32, func(i int, b *core.BlockGen) {
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 32, func(i int, b *core.BlockGen) {
 		b.SetCoinbase(common.Address{1})
-		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.HexToAddress("deadbeef"), big.NewInt(100), 21000, big.NewInt(int64(i+1)*vars.GWei), nil), signer, key)
+
+		var txdata types.TxData
+		if london {
+			txdata = &types.DynamicFeeTx{
+				ChainID:   gspec.Config.GetChainID(),
+				Nonce:     b.TxNonce(addr),
+				To:        &common.Address{},
+				Gas:       21000,
+				GasFeeCap: big.NewInt(int64(i+1)*vars.GWei + int64(vars.InitialBaseFee)),
+				GasTipCap: big.NewInt(int64(i+1) * vars.GWei),
+				Data:      []byte{},
+			}
+		} else {
+			txdata = &types.LegacyTx{
+				Nonce:    b.TxNonce(addr),
+				To:       &common.Address{},
+				Gas:      21000,
+				GasPrice: big.NewInt(int64(i+1) * vars.GWei),
+				Value:    big.NewInt(100),
+				Data:     []byte{},
+			}
+		}
+		tx, err := types.SignNewTx(key, signer, txdata)
 		if err != nil {
 			t.Fatalf("failed to create tx: %v", err)
 		}
@@ -84,7 +119,7 @@ func newTestBackend(t *testing.T) *testBackend {
 	// Construct testing chain
 	diskdb := rawdb.NewMemoryDatabase()
 	core.MustCommitGenesis(diskdb, gspec)
-	chain, err := core.NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	chain, err := core.NewBlockChain(diskdb, nil, gspec.Config, engine, vm.Config{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to create local chain, %v", err)
 	}
@@ -106,7 +141,7 @@ func TestSuggestPrice(t *testing.T) {
 		Percentile: 60,
 		Default:    big.NewInt(vars.GWei),
 	}
-	backend := newTestBackend(t)
+	backend := newTestBackend(t, false)
 	oracle := NewOracle(backend, config)
 
 	// The gas price sampled is: 32G, 31G, 30G, 29G, 28G, 27G
@@ -119,3 +154,33 @@ func TestSuggestPrice(t *testing.T) {
 		t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
 	}
 }
+
+func TestSuggestTipCap(t *testing.T) {
+	config := Config{
+		Blocks:     3,
+		Percentile: 60,
+		Default:    big.NewInt(vars.GWei),
+	}
+	backend := newTestBackend(t, true)
+	oracle := NewOracle(backend, config)
+
+	// The tips sampled are: 32G, 31G, 30G, 29G, 28G, 27G
+	tip, err := oracle.SuggestTipCap(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended tip: %v", err)
+	}
+	expect := big.NewInt(vars.GWei * int64(30))
+	if tip.Cmp(expect) != 0 {
+		t.Fatalf("Tip mismatch, want %d, got %d", expect, tip)
+	}
+	// Legacy gas price suggestions must include the pending base fee
+	price, err := oracle.SuggestPrice(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+	}
+	head := backend.CurrentHeader()
+	expect = new(big.Int).Add(expect, misc.CalcBaseFee(backend.ChainConfig(), head))
+	if price.Cmp(expect) != 0 {
+		t.Fatalf("Gas price mismatch, want %d, got %d", expect, price)
+	}
+}
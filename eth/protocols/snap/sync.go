@@ -0,0 +1,1969 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+const (
+	// maxRequestSize is the maximum number of bytes to request from a remote peer.
+	maxRequestSize = 512 * 1024
+
+	// maxStorageSetRequestCount is the maximum number of contracts to request the
+	// storage of in a single query. If this number is too low, we're not filling
+	// responses fully and waste round trip times. If it's too high, we're capping
+	// responses and waste bandwidth.
+	maxStorageSetRequestCount = maxRequestSize / 1024
+
+	// maxCodeRequestCount is the maximum number of bytecode blobs to request in a
+	// single query. If this number is too low, we're not filling responses fully
+	// and waste round trip times. If it's too high, we're capping responses and
+	// waste bandwidth.
+	//
+	// Depoyed bytecodes are currently capped at 24KB, so the minimum request
+	// size should be maxRequestSize / 24K. Assuming that most contracts do not
+	// come close to that, requesting 4x should be a good approximation.
+	maxCodeRequestCount = maxRequestSize / (24 * 1024) * 4
+
+	// maxTrieRequestCount is the maximum number of trie node blobs to request in
+	// a single query. If this number is too low, we're not filling responses fully
+	// and waste round trip times. If it's too high, we're capping responses and
+	// waste bandwidth.
+	maxTrieRequestCount = 512
+
+	// accountConcurrency is the number of chunks to split the account trie into
+	// to allow concurrent retrievals.
+	accountConcurrency = 16
+)
+
+var (
+	// requestTimeout is the maximum time a peer is allowed to spend on serving
+	// a single network request.
+	requestTimeout = 10 * time.Second
+
+	// logInterval is the time between two consecutive sync progress reports.
+	logInterval = 8 * time.Second
+)
+
+// ErrCancelled is returned from snap syncing if the operation was prematurely
+// terminated.
+var ErrCancelled = errors.New("sync cancelled")
+
+// accountRequest tracks a pending account range request to ensure responses are
+// to actual requests and to validate any security constraints.
+//
+// Concurrency note: account requests and responses are handled concurrently from
+// the main runloop to allow Merkle proof verifications on the peer's thread and
+// to drop on invalid response. The request struct must contain all the data to
+// construct the response without accessing runloop internals (i.e. task). That
+// is only included to allow the runloop to match a response to the task being
+// synced without having yet another set of maps.
+type accountRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	deliver chan *accountResponse // Channel to deliver successful response on
+	revert  chan *accountRequest  // Channel to deliver request failure on
+	cancel  chan struct{}         // Channel to track sync cancellation
+	timeout *time.Timer           // Timer to track delivery timeout
+	stale   chan struct{}         // Channel to signal the request was dropped
+	done    bool                  // Flag whether the request was already finalized (runloop only)
+
+	root   common.Hash // State root the accounts are requested from
+	origin common.Hash // First account requested to allow continuation checks
+	limit  common.Hash // Last account requested to allow non-overlapping chunking
+
+	task *accountTask // Task which this request is filling (only access fields through the runloop!!)
+}
+
+// accountResponse is an already Merkle-verified remote response to an account
+// range request. It contains the subtrie for the requested account range and
+// the database that's going to be filled with the internal nodes on commit.
+type accountResponse struct {
+	req *accountRequest // Request that this response fulfils
+
+	hashes   []common.Hash    // Account hashes in the returned range
+	accounts []*state.Account // Expanded accounts in the returned range
+
+	cont bool // Whether the account range has a continuation
+}
+
+// bytecodeRequest tracks a pending bytecode request to ensure responses are to
+// actual requests and to validate any security constraints.
+//
+// Concurrency note: bytecode requests and responses are handled concurrently from
+// the main runloop to allow Keccak256 hash verifications on the peer's thread and
+// to drop on invalid response. The request struct must contain all the data to
+// construct the response without accessing runloop internals (i.e. task). That
+// is only included to allow the runloop to match a response to the task being
+// synced without having yet another set of maps.
+type bytecodeRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	deliver chan *bytecodeResponse // Channel to deliver successful response on
+	revert  chan *bytecodeRequest  // Channel to deliver request failure on
+	cancel  chan struct{}          // Channel to track sync cancellation
+	timeout *time.Timer            // Timer to track delivery timeout
+	stale   chan struct{}          // Channel to signal the request was dropped
+	done    bool                   // Flag whether the request was already finalized (runloop only)
+
+	hashes []common.Hash // Bytecode hashes to validate responses
+	task   *accountTask  // Task which this request is filling, nil if healing (only access fields through the runloop!!)
+}
+
+// bytecodeResponse is an already verified remote response to a bytecode request.
+type bytecodeResponse struct {
+	req   *bytecodeRequest // Request that this response fulfils
+	codes [][]byte         // Actual bytecodes to store into the database (nil = missing)
+}
+
+// storageRequest tracks a pending storage ranges request to ensure responses are
+// to actual requests and to validate any security constraints.
+//
+// Concurrency note: storage requests and responses are handled concurrently from
+// the main runloop to allow Merkle proof verifications on the peer's thread and
+// to drop on invalid response. The request struct must contain all the data to
+// construct the response without accessing runloop internals (i.e. tasks). That
+// is only included to allow the runloop to match a response to the task being
+// synced without having yet another set of maps.
+type storageRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	deliver chan *storageResponse // Channel to deliver successful response on
+	revert  chan *storageRequest  // Channel to deliver request failure on
+	cancel  chan struct{}         // Channel to track sync cancellation
+	timeout *time.Timer           // Timer to track delivery timeout
+	stale   chan struct{}         // Channel to signal the request was dropped
+	done    bool                  // Flag whether the request was already finalized (runloop only)
+
+	root     common.Hash   // State root the storage is requested from
+	accounts []common.Hash // Account hashes to validate responses
+	roots    []common.Hash // Storage roots to validate responses
+	origin   common.Hash   // First storage slot requested to allow continuation checks
+
+	mainTask *accountTask // Task which this response belongs to (only access fields through the runloop!!)
+	subTask  *storageTask // Task which this response is filling (only access fields through the runloop!!)
+}
+
+// storageResponse is an already Merkle-verified remote response to a storage
+// range request. It contains the slots for the requested storage ranges, the
+// last of which might be incomplete if it belongs to a large contract.
+type storageResponse struct {
+	req *storageRequest // Request that this response fulfils
+
+	hashes [][]common.Hash // Storage slot hashes in the returned range
+	slots  [][][]byte      // Storage slot values in the returned range
+
+	cont bool // Whether the last storage range has a continuation
+}
+
+// trienodeHealRequest tracks a pending state trie request to ensure responses
+// are to actual requests and to validate any security constraints.
+//
+// Concurrency note: trie node requests and responses are handled concurrently from
+// the main runloop to allow Keccak256 hash verifications on the peer's thread and
+// to drop on invalid response. The request struct must contain all the data to
+// construct the response without accessing runloop internals (i.e. task).
+type trienodeHealRequest struct {
+	peer string // Peer to which this request is assigned
+	id   uint64 // Request ID of this request
+
+	deliver chan *trienodeHealResponse // Channel to deliver successful response on
+	revert  chan *trienodeHealRequest  // Channel to deliver request failure on
+	cancel  chan struct{}              // Channel to track sync cancellation
+	timeout *time.Timer                // Timer to track delivery timeout
+	stale   chan struct{}              // Channel to signal the request was dropped
+	done    bool                       // Flag whether the request was already finalized (runloop only)
+
+	hashes []common.Hash   // Trie node hashes to validate responses
+	paths  []trie.SyncPath // Trie node paths requested for rescheduling
+}
+
+// trienodeHealResponse is an already verified remote response to a trie node
+// request.
+type trienodeHealResponse struct {
+	req   *trienodeHealRequest // Request that this response fulfils
+	nodes [][]byte             // Actual trie nodes to store into the database (nil = missing)
+}
+
+// accountTask represents the sync task for a chunk of the account snapshot.
+type accountTask struct {
+	Next common.Hash // Next account to sync in this interval
+	Last common.Hash // Last account to sync in this interval
+
+	req  *accountRequest  // Pending request to fill this task
+	res  *accountResponse // Validate response filling this task
+	pend int              // Number of pending subtasks for this round
+
+	needCode  []bool // Flags whether the filling accounts need code retrieval
+	needState []bool // Flags whether the filling accounts need storage retrieval
+
+	codeTasks  map[common.Hash]struct{}     // Code hashes that need retrieval
+	stateTasks map[common.Hash]common.Hash  // Account hashes->roots that need full state retrieval
+	largeTasks map[common.Hash]*storageTask // Account hashes->tasks of large contracts being streamed
+
+	genTrie *trie.StackTrie // Stack trie generating the account trie chunk
+	done    bool            // Flag whether the task can be removed
+}
+
+// reset drops all the progress of the current round of the task, rewinding it
+// to its next account to sync.
+func (task *accountTask) reset() {
+	task.req, task.res, task.pend = nil, nil, 0
+	task.needCode, task.needState = nil, nil
+
+	task.codeTasks = make(map[common.Hash]struct{})
+	task.stateTasks = make(map[common.Hash]common.Hash)
+	task.largeTasks = make(map[common.Hash]*storageTask)
+}
+
+// storageTask represents the sync task for a large contract's storage, which
+// cannot be retrieved in a single response and is thus streamed in chunks.
+type storageTask struct {
+	root common.Hash     // Storage root hash the slots are verified against
+	next common.Hash     // Next storage slot to sync
+	req  *storageRequest // Pending request to fill this task
+
+	genTrie *trie.StackTrie // Stack trie generating the storage trie
+}
+
+// healTask represents the sync task for healing the snap-synced chunk boundaries.
+type healTask struct {
+	scheduler *trie.Sync // State trie sync scheduler defining the tasks
+
+	trieTasks map[common.Hash]trie.SyncPath // Set of trie node tasks currently queued for retrieval
+	codeTasks map[common.Hash]struct{}      // Set of byte code tasks currently queued for retrieval
+}
+
+// SyncPeer abstracts out the methods required for a peer to be synced against
+// with the goal of allowing the construction of mock peers without the full
+// blown networking.
+type SyncPeer interface {
+	// ID retrieves the peer's unique identifier.
+	ID() string
+
+	// RequestAccountRange fetches a batch of accounts rooted in a specific account
+	// trie, starting with the origin.
+	RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error
+
+	// RequestStorageRanges fetches a batch of storage slots belonging to one or
+	// more accounts. If slots from only one account is requested, an origin marker
+	// may also be used to retrieve from there.
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error
+
+	// RequestByteCodes fetches a batch of bytecodes by hash.
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+
+	// RequestTrieNodes fetches a batch of account or storage trie nodes rooted in
+	// a specific state trie.
+	RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error
+
+	// Log retrieves the peer's own contextual logger.
+	Log() log.Logger
+}
+
+// Syncer is an Ethereum account and storage trie syncer based on snapshots and
+// the  snap protocol. It's purpose is to download all the accounts and storage
+// slots from remote peers and reassemble chunks of the state trie, on top of
+// which a state sync can be run to fix any gaps / overlaps.
+//
+// Every network request has a variety of failure events:
+//   - The peer disconnects after task assignment, failing to send the request
+//   - The peer disconnects after sending the request, before delivering on it
+//   - The peer remains connected, but does not deliver a response in time
+//   - The peer delivers a stale response after a previous timeout
+//   - The peer delivers a refusal to serve the requested state
+type Syncer struct {
+	db     ethdb.KeyValueStore // Database to store the trie nodes into (and dedup)
+	bloom  *trie.SyncBloom     // Bloom filter to deduplicate nodes for state fixup
+	writer *syncWriter         // Batch writer shared by all the generated tries
+
+	root    common.Hash    // Current state trie root being synced
+	tasks   []*accountTask // Current account task set being synced
+	healer  *healTask      // Current state healing task being executed
+	update  chan struct{}  // Notification channel for possible sync progression
+	started bool           // Flag whether any sync cycle was already started
+
+	peers          map[string]SyncPeer // Currently active peers to download from
+	statelessPeers map[string]struct{} // Peers that failed to deliver state data
+
+	// Request tracking during syncing phase
+	accountIdlers  map[string]struct{} // Peers that aren't serving account requests
+	bytecodeIdlers map[string]struct{} // Peers that aren't serving bytecode requests
+	storageIdlers  map[string]struct{} // Peers that aren't serving storage requests
+	trienodeIdlers map[string]struct{} // Peers that aren't serving trie node requests
+
+	accountReqs  map[uint64]*accountRequest      // Account requests currently running
+	bytecodeReqs map[uint64]*bytecodeRequest     // Bytecode requests currently running
+	storageReqs  map[uint64]*storageRequest      // Storage requests currently running
+	trienodeReqs map[uint64]*trienodeHealRequest // Trie node requests currently running
+
+	accountResps  chan *accountResponse      // Delivered and verified account responses
+	bytecodeResps chan *bytecodeResponse     // Delivered and verified bytecode responses
+	storageResps  chan *storageResponse      // Delivered and verified storage responses
+	trienodeResps chan *trienodeHealResponse // Delivered and verified trie node responses
+
+	accountReqFails  chan *accountRequest      // Failed account range requests to revert
+	bytecodeReqFails chan *bytecodeRequest     // Failed bytecode requests to revert
+	storageReqFails  chan *storageRequest      // Failed storage requests to revert
+	trienodeReqFails chan *trienodeHealRequest // Failed trie node requests to revert
+
+	accountSynced  uint64             // Number of accounts downloaded
+	accountBytes   common.StorageSize // Number of account trie bytes persisted to disk
+	bytecodeSynced uint64             // Number of bytecodes downloaded
+	bytecodeBytes  common.StorageSize // Number of bytecode bytes downloaded
+	storageSynced  uint64             // Number of storage slots downloaded
+	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
+
+	trienodeHealSynced uint64             // Number of state trie nodes downloaded
+	trienodeHealBytes  common.StorageSize // Number of state trie bytes persisted to disk
+	bytecodeHealSynced uint64             // Number of bytecodes downloaded during healing
+
+	startTime time.Time // Time instance when snapshot sync started
+	logTime   time.Time // Time instance when status was last reported
+
+	lock sync.RWMutex // Protects fields that can change outside of sync (peers, reqs, root)
+}
+
+// NewSyncer creates a new snapshot syncer to download the Ethereum state over the
+// snap protocol.
+func NewSyncer(db ethdb.KeyValueStore, bloom *trie.SyncBloom) *Syncer {
+	return &Syncer{
+		db:     db,
+		bloom:  bloom,
+		writer: &syncWriter{batch: db.NewBatch(), bloom: bloom},
+
+		peers:          make(map[string]SyncPeer),
+		statelessPeers: make(map[string]struct{}),
+		update:         make(chan struct{}, 1),
+
+		accountIdlers:  make(map[string]struct{}),
+		bytecodeIdlers: make(map[string]struct{}),
+		storageIdlers:  make(map[string]struct{}),
+		trienodeIdlers: make(map[string]struct{}),
+
+		accountReqs:  make(map[uint64]*accountRequest),
+		bytecodeReqs: make(map[uint64]*bytecodeRequest),
+		storageReqs:  make(map[uint64]*storageRequest),
+		trienodeReqs: make(map[uint64]*trienodeHealRequest),
+
+		accountResps:  make(chan *accountResponse),
+		bytecodeResps: make(chan *bytecodeResponse),
+		storageResps:  make(chan *storageResponse),
+		trienodeResps: make(chan *trienodeHealResponse),
+
+		accountReqFails:  make(chan *accountRequest),
+		bytecodeReqFails: make(chan *bytecodeRequest),
+		storageReqFails:  make(chan *storageRequest),
+		trienodeReqFails: make(chan *trienodeHealRequest),
+	}
+}
+
+// Register injects a new data source into the syncer's peerset.
+func (s *Syncer) Register(peer SyncPeer) error {
+	// Make sure the peer is not registered yet
+	id := peer.ID()
+
+	s.lock.Lock()
+	if _, ok := s.peers[id]; ok {
+		log.Error("Snap peer already registered", "id", id)
+
+		s.lock.Unlock()
+		return errors.New("already registered")
+	}
+	s.peers[id] = peer
+
+	// Mark the peer as idle, even if no sync is running
+	s.accountIdlers[id] = struct{}{}
+	s.storageIdlers[id] = struct{}{}
+	s.bytecodeIdlers[id] = struct{}{}
+	s.trienodeIdlers[id] = struct{}{}
+	s.lock.Unlock()
+
+	// Notify any active syncs that a new peer can be assigned data
+	s.notify()
+	return nil
+}
+
+// Unregister removes a data source from the syncer's peerset, reverting all the
+// requests it was assigned.
+func (s *Syncer) Unregister(id string) error {
+	// Remove all traces of the peer from the registry
+	s.lock.Lock()
+	if _, ok := s.peers[id]; !ok {
+		log.Error("Snap peer not registered", "id", id)
+
+		s.lock.Unlock()
+		return errors.New("not registered")
+	}
+	delete(s.peers, id)
+
+	// Remove status markers, even if no sync is running
+	delete(s.statelessPeers, id)
+
+	delete(s.accountIdlers, id)
+	delete(s.storageIdlers, id)
+	delete(s.bytecodeIdlers, id)
+	delete(s.trienodeIdlers, id)
+
+	// Collect all the requests assigned to the peer
+	var (
+		accountReqs  []*accountRequest
+		bytecodeReqs []*bytecodeRequest
+		storageReqs  []*storageRequest
+		trienodeReqs []*trienodeHealRequest
+	)
+	for _, req := range s.accountReqs {
+		if req.peer == id {
+			accountReqs = append(accountReqs, req)
+		}
+	}
+	for _, req := range s.bytecodeReqs {
+		if req.peer == id {
+			bytecodeReqs = append(bytecodeReqs, req)
+		}
+	}
+	for _, req := range s.storageReqs {
+		if req.peer == id {
+			storageReqs = append(storageReqs, req)
+		}
+	}
+	for _, req := range s.trienodeReqs {
+		if req.peer == id {
+			trienodeReqs = append(trienodeReqs, req)
+		}
+	}
+	s.lock.Unlock()
+
+	// Revert all the requests outside of the lock, as the runloop needs it
+	for _, req := range accountReqs {
+		s.scheduleRevertAccountRequest(req)
+	}
+	for _, req := range bytecodeReqs {
+		s.scheduleRevertBytecodeRequest(req)
+	}
+	for _, req := range storageReqs {
+		s.scheduleRevertStorageRequest(req)
+	}
+	for _, req := range trienodeReqs {
+		s.scheduleRevertTrienodeHealRequest(req)
+	}
+	return nil
+}
+
+// Sync starts (or resumes a previous) sync cycle to iterate over an state trie
+// with the given root and reconstruct the nodes based on the snapshot leaves.
+// Previously downloaded segments will not be redownloaded or fixed, rather any
+// errors will be healed after the leaves are fully accumulated.
+func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
+	// Move the trie root from any previous value, revert stateless markers for
+	// any peers and initialize the syncer if it was not yet run
+	s.lock.Lock()
+	if !s.started {
+		s.tasks = newAccountTasks(s.writer)
+		s.started, s.startTime = true, time.Now()
+	} else if s.root != root {
+		// The pivot moved, drop all the unfinished contracts of the previous
+		// root, they will be retrieved afresh from the new one
+		for _, task := range s.tasks {
+			task.reset()
+		}
+	}
+	s.root = root
+	s.healer = &healTask{
+		scheduler: state.NewStateSync(root, s.db, s.bloom),
+		trieTasks: make(map[common.Hash]trie.SyncPath),
+		codeTasks: make(map[common.Hash]struct{}),
+	}
+	s.statelessPeers = make(map[string]struct{})
+	s.lock.Unlock()
+
+	// Create a channel to signal the termination of this sync cycle to all the
+	// requests still in flight, and revert them all on the way out
+	done := make(chan struct{})
+	defer close(done)
+	defer s.revertAll()
+
+	log.Debug("Starting snapshot sync cycle", "root", root)
+	for {
+		// Remove all completed tasks and terminate sync if everything's done
+		s.cleanAccountTasks()
+		if len(s.tasks) == 0 && s.healer.scheduler.Pending() == 0 {
+			s.reportHealProgress(true)
+			return s.writer.flush()
+		}
+		// Assign all the data retrieval tasks to any free peers
+		s.assignAccountTasks(done)
+		s.assignBytecodeTasks(done)
+		s.assignStorageTasks(done)
+
+		if len(s.tasks) == 0 {
+			// Sync phase done, run heal phase
+			s.assignTrienodeHealTasks(done)
+			s.assignBytecodeHealTasks(done)
+		}
+		// Wait for something to happen
+		select {
+		case <-s.update:
+			// Something happened (new peer, delivery, timeout), recheck tasks
+		case <-cancel:
+			return ErrCancelled
+
+		case req := <-s.accountReqFails:
+			s.revertAccountRequest(req)
+		case req := <-s.bytecodeReqFails:
+			s.revertBytecodeRequest(req)
+		case req := <-s.storageReqFails:
+			s.revertStorageRequest(req)
+		case req := <-s.trienodeReqFails:
+			s.revertTrienodeHealRequest(req)
+
+		case res := <-s.accountResps:
+			s.processAccountResponse(res)
+		case res := <-s.bytecodeResps:
+			s.processBytecodeResponse(res)
+		case res := <-s.storageResps:
+			s.processStorageResponse(res)
+		case res := <-s.trienodeResps:
+			s.processTrienodeHealResponse(res)
+		}
+		// Report stats if something meaningful happened
+		s.report(false)
+	}
+}
+
+// newAccountTasks splits the account hash space into accountConcurrency chunks,
+// each generating its own part of the account trie.
+func newAccountTasks(writer ethdb.KeyValueWriter) []*accountTask {
+	var (
+		tasks []*accountTask
+		next  common.Hash
+		step  = new(big.Int).Sub(
+			new(big.Int).Div(
+				new(big.Int).Exp(common.Big2, common.Big256, nil),
+				big.NewInt(accountConcurrency),
+			), common.Big1,
+		)
+	)
+	for i := 0; i < accountConcurrency; i++ {
+		last := common.BigToHash(new(big.Int).Add(next.Big(), step))
+		if i == accountConcurrency-1 {
+			// Make sure we don't overflow if the step is not a proper divisor
+			last = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		}
+		task := &accountTask{
+			Next:    next,
+			Last:    last,
+			genTrie: trie.NewStackTrie(writer),
+		}
+		task.reset()
+		tasks = append(tasks, task)
+
+		next = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+	}
+	return tasks
+}
+
+// cleanAccountTasks removes account range retrieval tasks that have already been
+// completed.
+func (s *Syncer) cleanAccountTasks() {
+	// If the sync was already done before, don't even bother
+	if len(s.tasks) == 0 {
+		return
+	}
+	// Sync wasn't finished previously, check for any task that can be finalized
+	for i := 0; i < len(s.tasks); i++ {
+		if s.tasks[i].done {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			i--
+		}
+	}
+	// If everything was just finalized, flush the generated tries to disk so
+	// the healer can find them, and report the snap sync completion
+	if len(s.tasks) == 0 {
+		if err := s.writer.flush(); err != nil {
+			log.Error("Failed to persist snapshot synced state", "err", err)
+		}
+		s.report(true)
+	}
+}
+
+// revertAll reverts all the requests still in flight when a sync cycle exits,
+// marking the assigned peers idle again.
+func (s *Syncer) revertAll() {
+	s.lock.Lock()
+	var (
+		accountReqs  []*accountRequest
+		bytecodeReqs []*bytecodeRequest
+		storageReqs  []*storageRequest
+		trienodeReqs []*trienodeHealRequest
+	)
+	for _, req := range s.accountReqs {
+		accountReqs = append(accountReqs, req)
+		s.markIdle(s.accountIdlers, req.peer)
+	}
+	for _, req := range s.bytecodeReqs {
+		bytecodeReqs = append(bytecodeReqs, req)
+		s.markIdle(s.bytecodeIdlers, req.peer)
+	}
+	for _, req := range s.storageReqs {
+		storageReqs = append(storageReqs, req)
+		s.markIdle(s.storageIdlers, req.peer)
+	}
+	for _, req := range s.trienodeReqs {
+		trienodeReqs = append(trienodeReqs, req)
+		s.markIdle(s.trienodeIdlers, req.peer)
+	}
+	s.lock.Unlock()
+
+	for _, req := range accountReqs {
+		s.revertAccountRequest(req)
+	}
+	for _, req := range bytecodeReqs {
+		s.revertBytecodeRequest(req)
+	}
+	for _, req := range storageReqs {
+		s.revertStorageRequest(req)
+	}
+	for _, req := range trienodeReqs {
+		s.revertTrienodeHealRequest(req)
+	}
+	if err := s.writer.flush(); err != nil {
+		log.Error("Failed to persist snapshot synced state", "err", err)
+	}
+}
+
+// markIdle marks a still registered peer as idle in the given idler set. The
+// caller must hold the lock.
+func (s *Syncer) markIdle(idlers map[string]struct{}, id string) {
+	if _, ok := s.peers[id]; ok {
+		idlers[id] = struct{}{}
+	}
+}
+
+// notify signals the runloop that something might have changed that allows
+// new tasks to be assigned.
+func (s *Syncer) notify() {
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+}
+
+// idlePeer retrieves an idle peer from the given idler set, skipping the ones
+// that already proved unable to serve the current state. The caller must hold
+// the lock.
+func (s *Syncer) idlePeer(idlers map[string]struct{}) (string, SyncPeer) {
+	for id := range idlers {
+		if _, ok := s.statelessPeers[id]; ok {
+			continue
+		}
+		return id, s.peers[id]
+	}
+	return "", nil
+}
+
+// newRequestID allocates a unique request id not used by any of the given
+// pending requests. The caller must hold the lock.
+func newRequestID(used func(uint64) bool) uint64 {
+	for {
+		id := uint64(rand.Int63())
+		if id == 0 || used(id) {
+			continue
+		}
+		return id
+	}
+}
+
+// assignAccountTasks attempts to match idle peers to pending account range
+// retrievals.
+func (s *Syncer) assignAccountTasks(cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Iterate over all the tasks and try to find a pending one
+	for _, task := range s.tasks {
+		// Skip any tasks already filling
+		if task.req != nil || task.res != nil {
+			continue
+		}
+		// Task pending retrieval, try to find an idle peer. If no such peer
+		// exists, we probably assigned tasks for all (or they are stateless).
+		// Abort the entire assignment mechanism.
+		idle, peer := s.idlePeer(s.accountIdlers)
+		if peer == nil {
+			return
+		}
+		// Matched a pending task to an idle peer, allocate a unique request id
+		reqid := newRequestID(func(id uint64) bool { _, ok := s.accountReqs[id]; return ok })
+
+		req := &accountRequest{
+			peer:    idle,
+			id:      reqid,
+			deliver: s.accountResps,
+			revert:  s.accountReqFails,
+			cancel:  cancel,
+			stale:   make(chan struct{}),
+			root:    s.root,
+			origin:  task.Next,
+			limit:   task.Last,
+			task:    task,
+		}
+		req.timeout = time.AfterFunc(requestTimeout, func() {
+			peer.Log().Debug("Account range request timed out", "reqid", reqid)
+			s.scheduleRevertAccountRequest(req)
+		})
+		s.accountReqs[reqid] = req
+		delete(s.accountIdlers, idle)
+
+		go func() {
+			// Attempt to send the remote request and revert if it fails
+			if err := peer.RequestAccountRange(reqid, req.root, req.origin, req.limit, maxRequestSize); err != nil {
+				peer.Log().Debug("Failed to request account range", "err", err)
+				s.scheduleRevertAccountRequest(req)
+			}
+		}()
+		// Inject the request into the task to block further assignments
+		task.req = req
+	}
+}
+
+// assignBytecodeTasks attempts to match idle peers to pending code retrievals.
+func (s *Syncer) assignBytecodeTasks(cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Iterate over all the tasks and try to find a pending one
+	for _, task := range s.tasks {
+		// Skip any tasks not in the bytecode retrieval phase
+		if task.res == nil {
+			continue
+		}
+		// Skip tasks that are already retrieving (or done with) all codes
+		for len(task.codeTasks) > 0 {
+			// Task pending retrieval, try to find an idle peer. If no such peer
+			// exists, we probably assigned tasks for all (or they are stateless).
+			// Abort the entire assignment mechanism.
+			idle, peer := s.idlePeer(s.bytecodeIdlers)
+			if peer == nil {
+				return
+			}
+			// Matched a pending task to an idle peer, allocate a unique request id
+			reqid := newRequestID(func(id uint64) bool { _, ok := s.bytecodeReqs[id]; return ok })
+
+			hashes := make([]common.Hash, 0, maxCodeRequestCount)
+			for hash := range task.codeTasks {
+				delete(task.codeTasks, hash)
+				hashes = append(hashes, hash)
+				if len(hashes) >= maxCodeRequestCount {
+					break
+				}
+			}
+			s.sendBytecodeRequest(cancel, idle, peer, reqid, hashes, task)
+		}
+	}
+}
+
+// sendBytecodeRequest tracks and dispatches a bytecode request, either for
+// filling an account task, or for healing. The caller must hold the lock.
+func (s *Syncer) sendBytecodeRequest(cancel chan struct{}, idle string, peer SyncPeer, reqid uint64, hashes []common.Hash, task *accountTask) {
+	req := &bytecodeRequest{
+		peer:    idle,
+		id:      reqid,
+		deliver: s.bytecodeResps,
+		revert:  s.bytecodeReqFails,
+		cancel:  cancel,
+		stale:   make(chan struct{}),
+		hashes:  hashes,
+		task:    task,
+	}
+	req.timeout = time.AfterFunc(requestTimeout, func() {
+		peer.Log().Debug("Bytecode request timed out", "reqid", reqid)
+		s.scheduleRevertBytecodeRequest(req)
+	})
+	s.bytecodeReqs[reqid] = req
+	delete(s.bytecodeIdlers, idle)
+
+	go func() {
+		// Attempt to send the remote request and revert if it fails
+		if err := peer.RequestByteCodes(reqid, hashes, maxRequestSize); err != nil {
+			log.Debug("Failed to request bytecodes", "err", err)
+			s.scheduleRevertBytecodeRequest(req)
+		}
+	}()
+}
+
+// assignStorageTasks attempts to match idle peers to pending storage range
+// retrievals.
+func (s *Syncer) assignStorageTasks(cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Iterate over all the tasks and try to find a pending one
+	for _, task := range s.tasks {
+		// Skip any tasks not in the storage retrieval phase
+		if task.res == nil {
+			continue
+		}
+		// Large contracts are streamed chunk by chunk, continue any idle ones
+		for account, st := range task.largeTasks {
+			if st.req != nil {
+				continue
+			}
+			idle, peer := s.idlePeer(s.storageIdlers)
+			if peer == nil {
+				return
+			}
+			reqid := newRequestID(func(id uint64) bool { _, ok := s.storageReqs[id]; return ok })
+			st.req = s.sendStorageRequest(cancel, idle, peer, reqid, []common.Hash{account}, []common.Hash{st.root}, st.next, task, st)
+		}
+		// Small contracts are requested in batches, retrieved in one go
+		for len(task.stateTasks) > 0 {
+			idle, peer := s.idlePeer(s.storageIdlers)
+			if peer == nil {
+				return
+			}
+			reqid := newRequestID(func(id uint64) bool { _, ok := s.storageReqs[id]; return ok })
+
+			var (
+				accounts = make([]common.Hash, 0, maxStorageSetRequestCount)
+				roots    = make([]common.Hash, 0, maxStorageSetRequestCount)
+			)
+			for account, root := range task.stateTasks {
+				delete(task.stateTasks, account)
+
+				accounts = append(accounts, account)
+				roots = append(roots, root)
+
+				if len(accounts) >= maxStorageSetRequestCount {
+					break
+				}
+			}
+			s.sendStorageRequest(cancel, idle, peer, reqid, accounts, roots, common.Hash{}, task, nil)
+		}
+	}
+}
+
+// sendStorageRequest tracks and dispatches a storage ranges request. The caller
+// must hold the lock.
+func (s *Syncer) sendStorageRequest(cancel chan struct{}, idle string, peer SyncPeer, reqid uint64, accounts []common.Hash, roots []common.Hash, origin common.Hash, task *accountTask, subtask *storageTask) *storageRequest {
+	req := &storageRequest{
+		peer:     idle,
+		id:       reqid,
+		deliver:  s.storageResps,
+		revert:   s.storageReqFails,
+		cancel:   cancel,
+		stale:    make(chan struct{}),
+		root:     s.root,
+		accounts: accounts,
+		roots:    roots,
+		origin:   origin,
+		mainTask: task,
+		subTask:  subtask,
+	}
+	req.timeout = time.AfterFunc(requestTimeout, func() {
+		peer.Log().Debug("Storage request timed out", "reqid", reqid)
+		s.scheduleRevertStorageRequest(req)
+	})
+	s.storageReqs[reqid] = req
+	delete(s.storageIdlers, idle)
+
+	go func() {
+		// Attempt to send the remote request and revert if it fails
+		var origin []byte
+		if subtask != nil {
+			origin = req.origin[:]
+		}
+		if err := peer.RequestStorageRanges(reqid, req.root, accounts, origin, nil, maxRequestSize); err != nil {
+			log.Debug("Failed to request storage", "err", err)
+			s.scheduleRevertStorageRequest(req)
+		}
+	}()
+	return req
+}
+
+// assignTrienodeHealTasks attempts to match idle peers to trie node requests to
+// heal any trie errors caused by the snap sync's chunked retrieval model.
+func (s *Syncer) assignTrienodeHealTasks(cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		// Refill the queued tasks from the scheduler if we're running low
+		if len(s.healer.trieTasks) == 0 {
+			nodes, paths, codes := s.healer.scheduler.MissingNodes(maxTrieRequestCount)
+			for i, hash := range nodes {
+				s.healer.trieTasks[hash] = paths[i]
+			}
+			for _, hash := range codes {
+				s.healer.codeTasks[hash] = struct{}{}
+			}
+		}
+		// If all the heal tasks are bytecodes or already downloading, bail
+		if len(s.healer.trieTasks) == 0 {
+			return
+		}
+		// Task pending retrieval, try to find an idle peer. If no such peer
+		// exists, we probably assigned tasks for all (or they are stateless).
+		// Abort the entire assignment mechanism.
+		idle, peer := s.idlePeer(s.trienodeIdlers)
+		if peer == nil {
+			return
+		}
+		// Matched a pending task to an idle peer, allocate a unique request id
+		reqid := newRequestID(func(id uint64) bool { _, ok := s.trienodeReqs[id]; return ok })
+
+		var (
+			hashes   = make([]common.Hash, 0, maxTrieRequestCount)
+			paths    = make([]trie.SyncPath, 0, maxTrieRequestCount)
+			pathsets = make([]TrieNodePathSet, 0, maxTrieRequestCount)
+		)
+		for hash, path := range s.healer.trieTasks {
+			delete(s.healer.trieTasks, hash)
+
+			hashes = append(hashes, hash)
+			paths = append(paths, path)
+			pathsets = append(pathsets, TrieNodePathSet(path))
+
+			if len(hashes) >= maxTrieRequestCount {
+				break
+			}
+		}
+		req := &trienodeHealRequest{
+			peer:    idle,
+			id:      reqid,
+			deliver: s.trienodeResps,
+			revert:  s.trienodeReqFails,
+			cancel:  cancel,
+			stale:   make(chan struct{}),
+			hashes:  hashes,
+			paths:   paths,
+		}
+		req.timeout = time.AfterFunc(requestTimeout, func() {
+			peer.Log().Debug("Trienode heal request timed out", "reqid", reqid)
+			s.scheduleRevertTrienodeHealRequest(req)
+		})
+		s.trienodeReqs[reqid] = req
+		delete(s.trienodeIdlers, idle)
+
+		go func(root common.Hash) {
+			// Attempt to send the remote request and revert if it fails
+			if err := peer.RequestTrieNodes(reqid, root, pathsets, maxRequestSize); err != nil {
+				log.Debug("Failed to request trienode healers", "err", err)
+				s.scheduleRevertTrienodeHealRequest(req)
+			}
+		}(s.root)
+	}
+}
+
+// assignBytecodeHealTasks attempts to match idle peers to bytecode requests to
+// heal any trie errors caused by the snap sync's chunked retrieval model.
+func (s *Syncer) assignBytecodeHealTasks(cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for len(s.healer.codeTasks) > 0 {
+		// Task pending retrieval, try to find an idle peer. If no such peer
+		// exists, we probably assigned tasks for all (or they are stateless).
+		// Abort the entire assignment mechanism.
+		idle, peer := s.idlePeer(s.bytecodeIdlers)
+		if peer == nil {
+			return
+		}
+		// Matched a pending task to an idle peer, allocate a unique request id
+		reqid := newRequestID(func(id uint64) bool { _, ok := s.bytecodeReqs[id]; return ok })
+
+		hashes := make([]common.Hash, 0, maxCodeRequestCount)
+		for hash := range s.healer.codeTasks {
+			delete(s.healer.codeTasks, hash)
+
+			hashes = append(hashes, hash)
+			if len(hashes) >= maxCodeRequestCount {
+				break
+			}
+		}
+		s.sendBytecodeRequest(cancel, idle, peer, reqid, hashes, nil)
+	}
+}
+
+// scheduleRevertAccountRequest asks the event loop to clean up an account range
+// request and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertAccountRequest(req *accountRequest) {
+	select {
+	case req.revert <- req:
+		// Sync event loop notified
+	case <-req.cancel:
+		// Sync cycle got cancelled
+	case <-req.stale:
+		// Request already reverted
+	}
+}
+
+// revertAccountRequest cleans up an account range request and returns all failed
+// retrieval tasks to the scheduler for reassignment.
+//
+// Note, this needs to run on the event runloop thread to reschedule to idle peers.
+// On peer threads, use scheduleRevertAccountRequest.
+func (s *Syncer) revertAccountRequest(req *accountRequest) {
+	// Ensure the request wasn't already finalized
+	if req.done {
+		return
+	}
+	req.done = true
+	close(req.stale)
+
+	// Remove the request from the tracked set
+	s.lock.Lock()
+	delete(s.accountReqs, req.id)
+	s.lock.Unlock()
+
+	// If there's a timeout timer still running, abort it and mark the account
+	// task as not-pending, ready for resheduling
+	req.timeout.Stop()
+	if req.task.req == req {
+		req.task.req = nil
+	}
+}
+
+// scheduleRevertBytecodeRequest asks the event loop to clean up a bytecode request
+// and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertBytecodeRequest(req *bytecodeRequest) {
+	select {
+	case req.revert <- req:
+		// Sync event loop notified
+	case <-req.cancel:
+		// Sync cycle got cancelled
+	case <-req.stale:
+		// Request already reverted
+	}
+}
+
+// revertBytecodeRequest cleans up a bytecode request and returns all failed
+// retrieval tasks to the scheduler for reassignment.
+//
+// Note, this needs to run on the event runloop thread to reschedule to idle peers.
+// On peer threads, use scheduleRevertBytecodeRequest.
+func (s *Syncer) revertBytecodeRequest(req *bytecodeRequest) {
+	// Ensure the request wasn't already finalized
+	if req.done {
+		return
+	}
+	req.done = true
+	close(req.stale)
+
+	// Remove the request from the tracked set
+	s.lock.Lock()
+	delete(s.bytecodeReqs, req.id)
+	s.lock.Unlock()
+
+	// If there's a timeout timer still running, abort it and mark the code
+	// retrievals as not-pending, ready for resheduling
+	req.timeout.Stop()
+	for _, hash := range req.hashes {
+		if req.task != nil {
+			req.task.codeTasks[hash] = struct{}{}
+		} else {
+			s.healer.codeTasks[hash] = struct{}{}
+		}
+	}
+}
+
+// scheduleRevertStorageRequest asks the event loop to clean up a storage range
+// request and return all failed retrieval tasks to the scheduler for reassignment.
+func (s *Syncer) scheduleRevertStorageRequest(req *storageRequest) {
+	select {
+	case req.revert <- req:
+		// Sync event loop notified
+	case <-req.cancel:
+		// Sync cycle got cancelled
+	case <-req.stale:
+		// Request already reverted
+	}
+}
+
+// revertStorageRequest cleans up a storage range request and returns all failed
+// retrieval tasks to the scheduler for reassignment.
+//
+// Note, this needs to run on the event runloop thread to reschedule to idle peers.
+// On peer threads, use scheduleRevertStorageRequest.
+func (s *Syncer) revertStorageRequest(req *storageRequest) {
+	// Ensure the request wasn't already finalized
+	if req.done {
+		return
+	}
+	req.done = true
+	close(req.stale)
+
+	// Remove the request from the tracked set
+	s.lock.Lock()
+	delete(s.storageReqs, req.id)
+	s.lock.Unlock()
+
+	// If there's a timeout timer still running, abort it and mark the storage
+	// task as not-pending, ready for resheduling
+	req.timeout.Stop()
+	if req.subTask != nil {
+		if req.subTask.req == req {
+			req.subTask.req = nil
+		}
+		return
+	}
+	for i, account := range req.accounts {
+		req.mainTask.stateTasks[account] = req.roots[i]
+	}
+}
+
+// scheduleRevertTrienodeHealRequest asks the event loop to clean up a trienode
+// heal request and return all failed retrieval tasks to the scheduler for
+// reassignment.
+func (s *Syncer) scheduleRevertTrienodeHealRequest(req *trienodeHealRequest) {
+	select {
+	case req.revert <- req:
+		// Sync event loop notified
+	case <-req.cancel:
+		// Sync cycle got cancelled
+	case <-req.stale:
+		// Request already reverted
+	}
+}
+
+// revertTrienodeHealRequest cleans up a trienode heal request and returns all
+// failed retrieval tasks to the scheduler for reassignment.
+//
+// Note, this needs to run on the event runloop thread to reschedule to idle peers.
+// On peer threads, use scheduleRevertTrienodeHealRequest.
+func (s *Syncer) revertTrienodeHealRequest(req *trienodeHealRequest) {
+	// Ensure the request wasn't already finalized
+	if req.done {
+		return
+	}
+	req.done = true
+	close(req.stale)
+
+	// Remove the request from the tracked set
+	s.lock.Lock()
+	delete(s.trienodeReqs, req.id)
+	s.lock.Unlock()
+
+	// If there's a timeout timer still running, abort it and mark the trie node
+	// retrievals as not-pending, ready for resheduling
+	req.timeout.Stop()
+	for i, hash := range req.hashes {
+		s.healer.trieTasks[hash] = req.paths[i]
+	}
+}
+
+// processAccountResponse integrates an already validated account range response
+// into the account tasks.
+func (s *Syncer) processAccountResponse(res *accountResponse) {
+	// Ensure the request wasn't already finalized (e.g. reverted on timeout)
+	if res.req.done {
+		return
+	}
+	res.req.done = true
+	close(res.req.stale)
+
+	task := res.req.task
+	if task.req == res.req {
+		task.req = nil
+	}
+	// Ensure that the response doesn't overflow into the subsequent task
+	last := task.Last.Big()
+	for i, hash := range res.hashes {
+		// Mark the range complete if the last is already included.
+		// Keep iteration to delete the extra states if exists.
+		cmp := hash.Big().Cmp(last)
+		if cmp == 0 {
+			res.cont = false
+			continue
+		}
+		if cmp > 0 {
+			// Chunk overflown, cut off excess
+			res.hashes = res.hashes[:i]
+			res.accounts = res.accounts[:i]
+			res.cont = false // Mark range completed
+			break
+		}
+	}
+	task.res = res
+
+	// Ensure that all the contract code and storage tries are available. If
+	// some are already in the local database, skip retrieving them.
+	task.needCode = make([]bool, len(res.accounts))
+	task.needState = make([]bool, len(res.accounts))
+	task.pend = 0
+
+	for i, account := range res.accounts {
+		// Check if the account is a contract with an unknown code
+		if !bytes.Equal(account.CodeHash, emptyCode[:]) {
+			if ok, _ := s.db.Has(account.CodeHash); !ok {
+				task.codeTasks[common.BytesToHash(account.CodeHash)] = struct{}{}
+				task.needCode[i] = true
+				task.pend++
+			}
+		}
+		// Check if the account is a contract with an unknown storage trie
+		if account.Root != emptyRoot {
+			if ok, _ := s.db.Has(account.Root[:]); !ok {
+				task.stateTasks[res.hashes[i]] = account.Root
+				task.needState[i] = true
+				task.pend++
+			}
+		}
+	}
+	// If the account range contained no contracts, or all have been fully filled
+	// beforehand, short circuit storage filling and forward to the next task
+	if task.pend == 0 {
+		s.forwardAccountTask(task)
+	}
+}
+
+// processBytecodeResponse integrates an already validated bytecode response
+// into the account tasks or the healer.
+func (s *Syncer) processBytecodeResponse(res *bytecodeResponse) {
+	// Ensure the request wasn't already finalized (e.g. reverted on timeout)
+	if res.req.done {
+		return
+	}
+	res.req.done = true
+	close(res.req.stale)
+
+	if res.req.task == nil {
+		s.processBytecodeHealResponse(res)
+		return
+	}
+	task := res.req.task
+	for i, hash := range res.req.hashes {
+		code := res.codes[i]
+
+		// If the bytecode was not delivered, reschedule it
+		if code == nil {
+			task.codeTasks[hash] = struct{}{}
+			continue
+		}
+		// Code was delivered, mark it not needed any more
+		for j, account := range task.res.accounts {
+			if task.needCode[j] && hash == common.BytesToHash(account.CodeHash) {
+				task.needCode[j] = false
+				task.pend--
+			}
+		}
+		// Push the bytecode into a database batch
+		s.bytecodeSynced++
+		s.bytecodeBytes += common.StorageSize(len(code))
+
+		if err := s.writer.Put(hash[:], code); err != nil {
+			log.Crit("Failed to persist bytecodes", "err", err)
+		}
+	}
+	// If this delivery completed the last pending task, forward the account task
+	// to the next chunk
+	if task.pend == 0 {
+		s.forwardAccountTask(task)
+	}
+}
+
+// processStorageResponse integrates an already validated storage response
+// into the account tasks.
+func (s *Syncer) processStorageResponse(res *storageResponse) {
+	// Ensure the request wasn't already finalized (e.g. reverted on timeout)
+	if res.req.done {
+		return
+	}
+	res.req.done = true
+	close(res.req.stale)
+
+	var (
+		req  = res.req
+		task = req.mainTask
+	)
+	if req.subTask != nil && req.subTask.req == req {
+		req.subTask.req = nil
+	}
+	// Reschedule all the accounts the remote peer did not get to
+	for i := len(res.hashes); i < len(req.accounts); i++ {
+		if req.subTask == nil {
+			task.stateTasks[req.accounts[i]] = req.roots[i]
+		}
+	}
+	// Iterate over all the accounts and generate their storage tries
+	for i, account := range req.accounts[:len(res.hashes)] {
+		s.storageSynced += uint64(len(res.hashes[i]))
+
+		// Pick the stack trie to feed the slots into: either the streamed one
+		// of a large contract or a fresh one for the small contracts
+		var genTrie *trie.StackTrie
+		switch {
+		case req.subTask != nil:
+			genTrie = req.subTask.genTrie
+		case i == len(res.hashes)-1 && res.cont:
+			// Large contract detected, stream the rest in chunks
+			req.subTask = &storageTask{
+				root:    req.roots[i],
+				genTrie: trie.NewStackTrie(s.writer),
+			}
+			task.largeTasks[account] = req.subTask
+			genTrie = req.subTask.genTrie
+		default:
+			genTrie = trie.NewStackTrie(s.writer)
+		}
+		for j, hash := range res.hashes[i] {
+			if err := genTrie.TryUpdate(hash[:], res.slots[i][j]); err != nil {
+				log.Error("Failed to insert storage slot", "account", account, "slot", hash, "err", err)
+			}
+			s.storageBytes += common.StorageSize(common.HashLength + len(res.slots[i][j]))
+		}
+		// If the contract is still being streamed, move the marker and wait
+		if i == len(res.hashes)-1 && res.cont {
+			if n := len(res.hashes[i]); n > 0 {
+				req.subTask.next = incHash(res.hashes[i][n-1])
+			}
+			continue
+		}
+		// Storage trie fully retrieved, persist it and mark the account done
+		delete(task.largeTasks, account)
+
+		root, err := genTrie.Commit()
+		if err != nil || root != req.roots[i] {
+			log.Warn("Storage trie generation failed, retrying", "account", account, "have", root, "want", req.roots[i], "err", err)
+			task.stateTasks[account] = req.roots[i]
+			continue
+		}
+		if task.res == nil {
+			continue
+		}
+		for j, hash := range task.res.hashes {
+			if hash == account && task.needState[j] {
+				task.needState[j] = false
+				task.pend--
+			}
+		}
+	}
+	// If this delivery completed the last pending task, forward the account task
+	// to the next chunk
+	if task.res != nil && task.pend == 0 {
+		s.forwardAccountTask(task)
+	}
+}
+
+// forwardAccountTask takes a filled account task and persists anything available
+// into the account trie chunk, forwarding the task to its next batch.
+func (s *Syncer) forwardAccountTask(task *accountTask) {
+	// Remove any pending delivery
+	res := task.res
+	if res == nil {
+		return // nothing to forward
+	}
+	task.res = nil
+
+	// All the accounts of the range are fully filled, generate the trie chunk
+	for i, hash := range res.hashes {
+		blob, err := rlp.EncodeToBytes(res.accounts[i])
+		if err != nil {
+			panic(err) // Really shouldn't ever happen
+		}
+		if err := task.genTrie.TryUpdate(hash[:], blob); err != nil {
+			log.Error("Failed to insert account", "hash", hash, "err", err)
+		}
+		s.accountSynced++
+		s.accountBytes += common.StorageSize(common.HashLength + len(blob))
+
+		task.Next = incHash(hash)
+	}
+	// If the range is complete, commit the remainder of the trie chunk
+	if !res.cont {
+		if _, err := task.genTrie.Commit(); err != nil {
+			log.Error("Failed to commit account trie chunk", "err", err)
+		}
+		task.done = true
+	}
+}
+
+// processTrienodeHealResponse integrates an already validated trienode response
+// into the healer tasks.
+func (s *Syncer) processTrienodeHealResponse(res *trienodeHealResponse) {
+	// Ensure the request wasn't already finalized (e.g. reverted on timeout)
+	if res.req.done {
+		return
+	}
+	res.req.done = true
+	close(res.req.stale)
+
+	for i, hash := range res.req.hashes {
+		node := res.nodes[i]
+
+		// If the trie node was not delivered, reschedule it
+		if node == nil {
+			s.healer.trieTasks[hash] = res.req.paths[i]
+			continue
+		}
+		// Push the trie node into the state syncer
+		s.trienodeHealSynced++
+		s.trienodeHealBytes += common.StorageSize(len(node))
+
+		_, _, err := s.healer.scheduler.Process([]trie.SyncResult{{Hash: hash, Data: node}})
+		switch err {
+		case nil:
+		case trie.ErrAlreadyProcessed, trie.ErrNotRequested:
+			// Duplicate delivery of an already processed node, ignore
+		default:
+			log.Error("Invalid trienode processed", "hash", hash, "err", err)
+		}
+	}
+	s.commitHealer()
+}
+
+// processBytecodeHealResponse integrates an already validated bytecode response
+// into the healer tasks.
+func (s *Syncer) processBytecodeHealResponse(res *bytecodeResponse) {
+	for i, hash := range res.req.hashes {
+		code := res.codes[i]
+
+		// If the bytecode was not delivered, reschedule it
+		if code == nil {
+			s.healer.codeTasks[hash] = struct{}{}
+			continue
+		}
+		// Push the bytecode into the state syncer
+		s.bytecodeHealSynced++
+		s.bytecodeBytes += common.StorageSize(len(code))
+
+		_, _, err := s.healer.scheduler.Process([]trie.SyncResult{{Hash: hash, Data: code}})
+		switch err {
+		case nil:
+		case trie.ErrAlreadyProcessed, trie.ErrNotRequested:
+			// Duplicate delivery of an already processed code, ignore
+		default:
+			log.Error("Invalid bytecode processed", "hash", hash, "err", err)
+		}
+	}
+	s.commitHealer()
+}
+
+// commitHealer flushes all the data completed by the state healer to disk.
+func (s *Syncer) commitHealer() {
+	batch := s.db.NewBatch()
+	if err := s.healer.scheduler.Commit(batch); err != nil {
+		log.Error("Failed to commit healing data", "err", err)
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to persist healing data", "err", err)
+	}
+}
+
+// OnAccounts is a callback method to invoke when a range of accounts are
+// received from a remote peer.
+func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	size := common.StorageSize(len(hashes) * common.HashLength)
+	for _, account := range accounts {
+		size += common.StorageSize(len(account))
+	}
+	for _, node := range proof {
+		size += common.StorageSize(len(node))
+	}
+	logger := peer.Log().New("reqid", id)
+	logger.Trace("Delivering range of accounts", "hashes", len(hashes), "accounts", len(accounts), "proofs", len(proof), "bytes", size)
+
+	// Whether or not the response is valid, we can mark the peer as idle and
+	// notify the scheduler to assign a new task. If the response is invalid,
+	// we'll drop the peer in a bit.
+	s.lock.Lock()
+	s.markIdle(s.accountIdlers, peer.ID())
+	s.notify()
+
+	// Ensure the response is for a valid request
+	req, ok := s.accountReqs[id]
+	if !ok {
+		// Request stale, perhaps the peer timed out but came through in the end
+		logger.Warn("Unexpected account range packet")
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.accountReqs, id)
+
+	// Clean up the request timeout timer, we'll see how to proceed further based
+	// on the actual delivered content
+	req.timeout.Stop()
+
+	// Response is valid, but check if peer is signalling that it does not have
+	// the requested data. For account range queries that means the state being
+	// retrieved was either already pruned remotely, or the peer is not yet
+	// synced to our head.
+	if len(hashes) == 0 && len(proof) == 0 {
+		logger.Debug("Peer rejected account range request", "root", req.root)
+		s.statelessPeers[peer.ID()] = struct{}{}
+		s.lock.Unlock()
+
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertAccountRequest(req)
+		return nil
+	}
+	s.lock.Unlock()
+
+	// Reconstruct a partial trie from the response and verify it
+	keys := make([][]byte, len(hashes))
+	for i, key := range hashes {
+		keys[i] = common.CopyBytes(key[:])
+	}
+	nodes := make(light.NodeList, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	proofdb := nodes.NodeSet()
+
+	err, cont := trie.VerifyRangeProof(req.root, req.origin[:], keys, accounts, proofdb, proofdb)
+	if err != nil {
+		logger.Warn("Account range failed proof", "err", err)
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertAccountRequest(req)
+		return err
+	}
+	accs := make([]*state.Account, len(accounts))
+	for i, account := range accounts {
+		acc := new(state.Account)
+		if err := rlp.DecodeBytes(account, acc); err != nil {
+			panic(err) // We created these blobs, we must be able to decode them
+		}
+		accs[i] = acc
+	}
+	response := &accountResponse{
+		req:      req,
+		hashes:   hashes,
+		accounts: accs,
+		cont:     cont,
+	}
+	select {
+	case req.deliver <- response:
+	case <-req.cancel:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// OnByteCodes is a callback method to invoke when a batch of contract
+// bytes codes are received from a remote peer.
+func (s *Syncer) OnByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error {
+	var size common.StorageSize
+	for _, code := range bytecodes {
+		size += common.StorageSize(len(code))
+	}
+	logger := peer.Log().New("reqid", id)
+	logger.Trace("Delivering set of bytecodes", "bytecodes", len(bytecodes), "bytes", size)
+
+	// Whether or not the response is valid, we can mark the peer as idle and
+	// notify the scheduler to assign a new task. If the response is invalid,
+	// we'll drop the peer in a bit.
+	s.lock.Lock()
+	s.markIdle(s.bytecodeIdlers, peer.ID())
+	s.notify()
+
+	// Ensure the response is for a valid request
+	req, ok := s.bytecodeReqs[id]
+	if !ok {
+		// Request stale, perhaps the peer timed out but came through in the end
+		logger.Warn("Unexpected bytecode packet")
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.bytecodeReqs, id)
+
+	// Clean up the request timeout timer, we'll see how to proceed further based
+	// on the actual delivered content
+	req.timeout.Stop()
+
+	// Response is valid, but check if peer is signalling that it does not have
+	// the requested data. For bytecode range queries that means the peer is not
+	// yet synced.
+	if len(bytecodes) == 0 {
+		logger.Debug("Peer rejected bytecode request")
+		s.statelessPeers[peer.ID()] = struct{}{}
+		s.lock.Unlock()
+
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertBytecodeRequest(req)
+		return nil
+	}
+	s.lock.Unlock()
+
+	// Cross reference the requested bytecodes with the response to find gaps
+	// that the serving node is missing
+	codes, err := matchHashes(req.hashes, bytecodes)
+	if err != nil {
+		logger.Warn("Unexpected bytecodes", "err", err)
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertBytecodeRequest(req)
+		return err
+	}
+	// Response validated, send it to the scheduler for filling
+	response := &bytecodeResponse{
+		req:   req,
+		codes: codes,
+	}
+	select {
+	case req.deliver <- response:
+	case <-req.cancel:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// OnStorage is a callback method to invoke when ranges of storage slots
+// are received from a remote peer.
+func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) error {
+	// Gather some trace stats to aid in debugging issues
+	var (
+		hashCount int
+		slotCount int
+		size      common.StorageSize
+	)
+	for _, hashset := range hashes {
+		size += common.StorageSize(common.HashLength * len(hashset))
+		hashCount += len(hashset)
+	}
+	for _, slotset := range slots {
+		for _, slot := range slotset {
+			size += common.StorageSize(len(slot))
+		}
+		slotCount += len(slotset)
+	}
+	for _, node := range proof {
+		size += common.StorageSize(len(node))
+	}
+	logger := peer.Log().New("reqid", id)
+	logger.Trace("Delivering ranges of storage slots", "accounts", len(hashes), "hashes", hashCount, "slots", slotCount, "proofs", len(proof), "size", size)
+
+	// Whether or not the response is valid, we can mark the peer as idle and
+	// notify the scheduler to assign a new task. If the response is invalid,
+	// we'll drop the peer in a bit.
+	s.lock.Lock()
+	s.markIdle(s.storageIdlers, peer.ID())
+	s.notify()
+
+	// Ensure the response is for a valid request
+	req, ok := s.storageReqs[id]
+	if !ok {
+		// Request stale, perhaps the peer timed out but came through in the end
+		logger.Warn("Unexpected storage ranges packet")
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.storageReqs, id)
+
+	// Clean up the request timeout timer, we'll see how to proceed further based
+	// on the actual delivered content
+	req.timeout.Stop()
+
+	// Reject the response if the hash sets and slot sets don't match, or if the
+	// peer sent more data than requested.
+	if len(hashes) != len(slots) {
+		s.lock.Unlock()
+		s.scheduleRevertStorageRequest(req) // reschedule request
+		logger.Warn("Hash and slot set size mismatch", "hashset", len(hashes), "slotset", len(slots))
+		return errors.New("hash and slot set size mismatch")
+	}
+	if len(hashes) > len(req.accounts) {
+		s.lock.Unlock()
+		s.scheduleRevertStorageRequest(req) // reschedule request
+		logger.Warn("Hash set larger than requested", "hashset", len(hashes), "requested", len(req.accounts))
+		return errors.New("hash set larger than requested")
+	}
+	// Response is valid, but check if peer is signalling that it does not have
+	// the requested data. For storage range queries that means the state being
+	// retrieved was either already pruned remotely, or the peer is not yet
+	// synced to our head.
+	if len(hashes) == 0 {
+		logger.Debug("Peer rejected storage request")
+		s.statelessPeers[peer.ID()] = struct{}{}
+		s.lock.Unlock()
+
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertStorageRequest(req)
+		return nil
+	}
+	s.lock.Unlock()
+
+	// Reconstruct the partial tries from the response and verify them
+	var cont bool
+	for i := 0; i < len(hashes); i++ {
+		// Convert the keys and proofs into an internal format
+		keys := make([][]byte, len(hashes[i]))
+		for j, key := range hashes[i] {
+			keys[j] = common.CopyBytes(key[:])
+		}
+		var err error
+		if i < len(hashes)-1 || len(proof) == 0 {
+			// If no proof was attached, the response must be the entire storage
+			// trie, as such, check the root hash
+			err, _ = trie.VerifyRangeProof(req.roots[i], nil, keys, slots[i], nil, nil)
+		} else {
+			// The last slot range is incomplete, check the boundary proofs
+			nodes := make(light.NodeList, len(proof))
+			for i, node := range proof {
+				nodes[i] = node
+			}
+			proofdb := nodes.NodeSet()
+
+			var origin common.Hash
+			if i == 0 {
+				origin = req.origin
+			}
+			err, cont = trie.VerifyRangeProof(req.roots[i], origin[:], keys, slots[i], proofdb, proofdb)
+		}
+		if err != nil {
+			// The peer delivered invalid data, reschedule the request
+			s.scheduleRevertStorageRequest(req)
+			logger.Warn("Storage slots failed proof", "err", err)
+			return err
+		}
+	}
+	// Partial tries reconstructed, send them to the scheduler for storage filling
+	response := &storageResponse{
+		req:    req,
+		hashes: hashes,
+		slots:  slots,
+		cont:   cont,
+	}
+	select {
+	case req.deliver <- response:
+	case <-req.cancel:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// OnTrieNodes is a callback method to invoke when a batch of trie nodes
+// are received from a remote peer.
+func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error {
+	var size common.StorageSize
+	for _, node := range trienodes {
+		size += common.StorageSize(len(node))
+	}
+	logger := peer.Log().New("reqid", id)
+	logger.Trace("Delivering set of healing trienodes", "trienodes", len(trienodes), "bytes", size)
+
+	// Whether or not the response is valid, we can mark the peer as idle and
+	// notify the scheduler to assign a new task. If the response is invalid,
+	// we'll drop the peer in a bit.
+	s.lock.Lock()
+	s.markIdle(s.trienodeIdlers, peer.ID())
+	s.notify()
+
+	// Ensure the response is for a valid request
+	req, ok := s.trienodeReqs[id]
+	if !ok {
+		// Request stale, perhaps the peer timed out but came through in the end
+		logger.Warn("Unexpected trienode heal packet")
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.trienodeReqs, id)
+
+	// Clean up the request timeout timer, we'll see how to proceed further based
+	// on the actual delivered content
+	req.timeout.Stop()
+
+	// Response is valid, but check if peer is signalling that it does not have
+	// the requested data. For bytecode range queries that means the peer is not
+	// yet synced.
+	if len(trienodes) == 0 {
+		logger.Debug("Peer rejected trienode heal request")
+		s.statelessPeers[peer.ID()] = struct{}{}
+		s.lock.Unlock()
+
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertTrienodeHealRequest(req)
+		return nil
+	}
+	s.lock.Unlock()
+
+	// Cross reference the requested trienodes with the response to find gaps
+	// that the serving node is missing
+	nodes, err := matchHashes(req.hashes, trienodes)
+	if err != nil {
+		logger.Warn("Unexpected healing trienodes", "err", err)
+		// Signal this request as failed, and ready for rescheduling
+		s.scheduleRevertTrienodeHealRequest(req)
+		return err
+	}
+	// Response validated, send it to the scheduler for filling
+	response := &trienodeHealResponse{
+		req:   req,
+		nodes: nodes,
+	}
+	select {
+	case req.deliver <- response:
+	case <-req.cancel:
+	case <-req.stale:
+	}
+	return nil
+}
+
+// matchHashes cross references the requested hashes with the delivered blobs,
+// which must be a subset of the requested ones in the same order. The returned
+// slice is aligned with the requested hashes, with nils for any gaps.
+func matchHashes(hashes []common.Hash, blobs [][]byte) ([][]byte, error) {
+	matched := make([][]byte, len(hashes))
+	for i, j := 0, 0; i < len(blobs); i++ {
+		// Find the next hash that we've been served, leaving misses with nils
+		hash := crypto.Keccak256Hash(blobs[i])
+		for j < len(hashes) && hash != hashes[j] {
+			j++
+		}
+		if j < len(hashes) {
+			matched[j] = blobs[i]
+			j++
+			continue
+		}
+		// We've either ran out of hashes, or got unrequested data
+		return nil, fmt.Errorf("%d unexpected items", len(blobs)-i)
+	}
+	return matched, nil
+}
+
+// report calculates various status reports and provides it to the user.
+func (s *Syncer) report(force bool) {
+	if len(s.tasks) > 0 {
+		s.reportSyncProgress(force)
+		return
+	}
+	s.reportHealProgress(force)
+}
+
+// reportSyncProgress calculates various status reports and provides it to the user.
+func (s *Syncer) reportSyncProgress(force bool) {
+	// Don't report all the events, just occasionally
+	if !force && time.Since(s.logTime) < logInterval {
+		return
+	}
+	// Don't report anything until we have a meaningful progress
+	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
+	if synced == 0 {
+		return
+	}
+	accountGaps := new(big.Int)
+	for _, task := range s.tasks {
+		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
+	}
+	accountFills := new(big.Int).Sub(math.MaxBig256, accountGaps)
+	if accountFills.BitLen() == 0 {
+		return
+	}
+	s.logTime = time.Now()
+	estBytes := float64(new(big.Int).Div(
+		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), math.MaxBig256),
+		accountFills,
+	).Uint64())
+
+	elapsed := time.Since(s.startTime)
+	estTime := elapsed / time.Duration(synced) * time.Duration(estBytes)
+
+	// Create a mega progress report
+	var (
+		progress = fmt.Sprintf("%.2f%%", float64(synced)*100/estBytes)
+		accounts = fmt.Sprintf("%d@%v", s.accountSynced, s.accountBytes.TerminalString())
+		storage  = fmt.Sprintf("%d@%v", s.storageSynced, s.storageBytes.TerminalString())
+		bytecode = fmt.Sprintf("%d@%v", s.bytecodeSynced, s.bytecodeBytes.TerminalString())
+	)
+	log.Info("State sync in progress", "synced", progress, "state", synced,
+		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(estTime-elapsed))
+}
+
+// reportHealProgress calculates various status reports and provides it to the user.
+func (s *Syncer) reportHealProgress(force bool) {
+	// Don't report all the events, just occasionally
+	if !force && time.Since(s.logTime) < logInterval {
+		return
+	}
+	s.logTime = time.Now()
+
+	// Create a mega progress report
+	var (
+		trienode = fmt.Sprintf("%d@%v", s.trienodeHealSynced, s.trienodeHealBytes.TerminalString())
+		bytecode = fmt.Sprintf("%d", s.bytecodeHealSynced)
+	)
+	log.Info("State heal in progress", "nodes", trienode, "codes", bytecode,
+		"pending", s.healer.scheduler.Pending())
+}
+
+// syncWriter is a database writer batching up the generated trie nodes and
+// codes, also marking them in the sync bloom so the healer doesn't consider
+// them missing.
+type syncWriter struct {
+	batch ethdb.Batch
+	bloom *trie.SyncBloom
+}
+
+// Put inserts the given value into the batch, flushing it to disk if it grew
+// large enough.
+func (w *syncWriter) Put(key []byte, value []byte) error {
+	if err := w.batch.Put(key, value); err != nil {
+		return err
+	}
+	if w.bloom != nil {
+		w.bloom.Add(key)
+	}
+	if w.batch.ValueSize() > ethdb.IdealBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Delete inserts a key removal into the batch.
+func (w *syncWriter) Delete(key []byte) error {
+	return w.batch.Delete(key)
+}
+
+// flush writes out all the accumulated data to disk.
+func (w *syncWriter) flush() error {
+	if err := w.batch.Write(); err != nil {
+		return err
+	}
+	w.batch.Reset()
+	return nil
+}
+
+// incHash returns the next hash, in lexicographical order (a.k.a plus one).
+func incHash(h common.Hash) common.Hash {
+	return common.BigToHash(new(big.Int).Add(h.Big(), common.Big1))
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testPeer is a mock snap peer serving the state of a set of in-memory chains,
+// delivering the responses straight into the syncer.
+type testPeer struct {
+	id     string
+	chains []*core.BlockChain
+	syncer *Syncer
+	logger log.Logger
+
+	stateless bool          // Whether to refuse serving any data
+	accounts  int32         // Number of account range responses served
+	onAccount func(n int32) // Hook invoked after each account range response
+}
+
+func newTestPeer(id string, syncer *Syncer, chains ...*core.BlockChain) *testPeer {
+	return &testPeer{
+		id:     id,
+		chains: chains,
+		syncer: syncer,
+		logger: log.New("id", id),
+	}
+}
+
+func (p *testPeer) ID() string      { return p.id }
+func (p *testPeer) Log() log.Logger { return p.logger }
+
+// chain returns the source chain having a snapshot for the requested root.
+func (p *testPeer) chain(root common.Hash) *core.BlockChain {
+	for _, chain := range p.chains {
+		if chain.Snapshot().Snapshot(root) != nil {
+			return chain
+		}
+	}
+	return p.chains[0]
+}
+
+func (p *testPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	go func() {
+		var packet AccountRangePacket
+		if !p.stateless {
+			packet.Accounts, packet.Proof = serviceGetAccountRangeQuery(p.chain(root), &GetAccountRangePacket{
+				ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes,
+			})
+		}
+		hashes, accounts, err := packet.Unpack()
+		if err != nil {
+			panic(err)
+		}
+		if err := p.syncer.OnAccounts(p, id, hashes, accounts, packet.Proof); err != nil {
+			p.logger.Error("Failed to deliver account range", "err", err)
+		}
+		if n := atomic.AddInt32(&p.accounts, 1); p.onAccount != nil {
+			p.onAccount(n)
+		}
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	go func() {
+		var packet StorageRangesPacket
+		if !p.stateless {
+			packet.Slots, packet.Proof = serviceGetStorageRangesQuery(p.chain(root), &GetStorageRangesPacket{
+				ID: id, Root: root, Accounts: accounts, Origin: origin, Limit: limit, Bytes: bytes,
+			})
+		}
+		hashes, slots := packet.Unpack()
+		if err := p.syncer.OnStorage(p, id, hashes, slots, packet.Proof); err != nil {
+			p.logger.Error("Failed to deliver storage ranges", "err", err)
+		}
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	go func() {
+		var codes [][]byte
+		if !p.stateless {
+			codes = serviceGetByteCodesQuery(p.chains[0], &GetByteCodesPacket{ID: id, Hashes: hashes, Bytes: bytes})
+		}
+		if err := p.syncer.OnByteCodes(p, id, codes); err != nil {
+			p.logger.Error("Failed to deliver bytecodes", "err", err)
+		}
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error {
+	go func() {
+		var nodes [][]byte
+		if !p.stateless {
+			var err error
+			if nodes, err = serviceGetTrieNodesQuery(p.chain(root), &GetTrieNodesPacket{ID: id, Root: root, Paths: paths, Bytes: bytes}); err != nil {
+				p.logger.Error("Failed to serve trie nodes", "err", err)
+			}
+		}
+		if err := p.syncer.OnTrieNodes(p, id, nodes); err != nil {
+			p.logger.Error("Failed to deliver trie nodes", "err", err)
+		}
+	}()
+	return nil
+}
+
+// makeTestChain creates an in-memory chain with a snapshot, whose genesis state
+// contains plain accounts, small contracts and a large contract whose storage
+// cannot be retrieved in a single response. The seed is mixed into the values
+// to allow creating differing states.
+func makeTestChain(t *testing.T, seed int64) *core.BlockChain {
+	alloc := make(genesisT.GenesisAlloc)
+	for i := int64(0); i < 1000; i++ {
+		alloc[common.BigToAddress(big.NewInt(i+1))] = genesisT.GenesisAccount{
+			Balance: big.NewInt(i*seed + 1),
+		}
+	}
+	for i := int64(0); i < 50; i++ {
+		storage := make(map[common.Hash]common.Hash)
+		for j := int64(0); j < 10; j++ {
+			storage[common.BigToHash(big.NewInt(j+1))] = common.BigToHash(big.NewInt(i*j + seed))
+		}
+		alloc[common.BigToAddress(big.NewInt(i+100000))] = genesisT.GenesisAccount{
+			Balance: big.NewInt(1),
+			Code:    []byte(fmt.Sprintf("code-%d", i%25)),
+			Storage: storage,
+		}
+	}
+	storage := make(map[common.Hash]common.Hash)
+	for j := int64(0); j < 20000; j++ {
+		storage[common.BigToHash(big.NewInt(j+1))] = common.BigToHash(big.NewInt(j + seed))
+	}
+	alloc[common.BigToAddress(big.NewInt(200000))] = genesisT.GenesisAccount{
+		Balance: big.NewInt(1),
+		Code:    []byte("large-code"),
+		Storage: storage,
+	}
+	db := rawdb.NewMemoryDatabase()
+	core.MustCommitGenesis(db, &genesisT.Genesis{Config: params.TestChainConfig, Alloc: alloc})
+
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	if chain.Snapshot() == nil {
+		t.Fatalf("source chain snapshot missing")
+	}
+	return chain
+}
+
+// verifyState checks that the entire state with the given root, including all
+// the storage tries and contract codes, is available in the database.
+func verifyState(t *testing.T, db ethdb.KeyValueStore, root common.Hash) {
+	t.Helper()
+
+	statedb, err := state.New(root, state.NewDatabase(rawdb.NewDatabase(db)), nil)
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("synced state incomplete: %v", it.Error)
+	}
+}
+
+// runSync runs a sync cycle against the given root, failing on timeout.
+func runSync(t *testing.T, syncer *Syncer, root common.Hash, cancel chan struct{}) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- syncer.Sync(root, cancel) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Minute):
+		t.Fatalf("sync timed out")
+		return nil
+	}
+}
+
+// Tests that a full state including storage and codes can be snap synced from
+// a single peer.
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	var (
+		source = makeTestChain(t, 1)
+		sinkdb = memorydb.New()
+		syncer = NewSyncer(sinkdb, trie.NewSyncBloom(1, memorydb.New()))
+	)
+	defer source.Stop()
+
+	syncer.Register(newTestPeer("source", syncer, source))
+	if err := runSync(t, syncer, source.CurrentBlock().Root(), make(chan struct{})); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	verifyState(t, sinkdb, source.CurrentBlock().Root())
+}
+
+// Tests that peers refusing to serve the requested state are skipped and the
+// sync completes from the remaining ones.
+func TestSyncStatelessPeer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		source = makeTestChain(t, 1)
+		sinkdb = memorydb.New()
+		syncer = NewSyncer(sinkdb, trie.NewSyncBloom(1, memorydb.New()))
+	)
+	defer source.Stop()
+
+	stateless := newTestPeer("stateless", syncer, source)
+	stateless.stateless = true
+
+	syncer.Register(stateless)
+	syncer.Register(newTestPeer("source", syncer, source))
+
+	if err := runSync(t, syncer, source.CurrentBlock().Root(), make(chan struct{})); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	verifyState(t, sinkdb, source.CurrentBlock().Root())
+}
+
+// Tests that a sync cycle cancelled midway through can be resumed against a
+// different state root, healing the chunks retrieved from the stale state.
+func TestSyncPivotMove(t *testing.T) {
+	t.Parallel()
+
+	var (
+		stale  = makeTestChain(t, 1)
+		fresh  = makeTestChain(t, 2)
+		sinkdb = memorydb.New()
+		syncer = NewSyncer(sinkdb, trie.NewSyncBloom(1, memorydb.New()))
+		cancel = make(chan struct{})
+	)
+	defer stale.Stop()
+	defer fresh.Stop()
+
+	peer := newTestPeer("source", syncer, stale, fresh)
+	peer.onAccount = func(n int32) {
+		if n == accountConcurrency/2 {
+			close(cancel)
+		}
+	}
+	syncer.Register(peer)
+
+	if err := runSync(t, syncer, stale.CurrentBlock().Root(), cancel); err != ErrCancelled {
+		t.Fatalf("stale sync error mismatch: have %v, want %v", err, ErrCancelled)
+	}
+	if err := runSync(t, syncer, fresh.CurrentBlock().Root(), make(chan struct{})); err != nil {
+		t.Fatalf("fresh sync failed: %v", err)
+	}
+	verifyState(t, sinkdb, fresh.CurrentBlock().Root())
+}
@@ -34,6 +34,14 @@ const (
 	forceSyncCycle      = 10 * time.Second // Time interval to force syncs, even if few peers are available
 	defaultMinSyncPeers = 5                // Amount of peers desired to start syncing
 
+	// minArtificialFinalityPeers is the amount of peers required to enable the
+	// artificial finality features of the chain. Below it, they are disabled.
+	minArtificialFinalityPeers = defaultMinSyncPeers
+
+	// artificialFinalitySafetyInterval is the age of the local head beyond which
+	// the node is considered out of sync, disabling artificial finality.
+	artificialFinalitySafetyInterval = 30 * 13 * time.Second
+
 	// This is the target size for the packs of transactions sent by txsyncLoop64.
 	// A pack can get larger than this if a single transactions exceeds this size.
 	txsyncPackSize = 100 * 1024
@@ -205,14 +213,22 @@ func (cs *chainSyncer) loop() {
 	cs.force = time.NewTimer(forceSyncCycle)
 	defer cs.force.Stop()
 
+	// The safety ticker rechecks whether artificial finality can stay enabled,
+	// even if nothing happens on the network.
+	safety := time.NewTicker(forceSyncCycle)
+	defer safety.Stop()
+
 	for {
 		if op := cs.nextSyncOp(); op != nil {
 			cs.startSync(op)
 		}
+		cs.updateArtificialFinality()
 
 		select {
 		case <-cs.peerEventCh:
 			// Peer information changed, recheck.
+		case <-safety.C:
+			// Recheck artificial finality.
 		case <-cs.doneCh:
 			cs.doneCh = nil
 			cs.force.Reset(forceSyncCycle)
@@ -265,6 +281,32 @@ func (cs *chainSyncer) nextSyncOp() *chainSyncOp {
 	return op
 }
 
+// updateArtificialFinality enables the artificial finality features of the chain
+// once the node is synced with enough peers, and disables them otherwise (unless
+// configured not to), since a node out of sync must be free to follow the network.
+func (cs *chainSyncer) updateArtificialFinality() {
+	minPeers := minArtificialFinalityPeers
+	if minPeers > cs.pm.maxPeers {
+		minPeers = cs.pm.maxPeers
+	}
+	var (
+		peers  = cs.pm.peers.Len()
+		head   = cs.pm.blockchain.CurrentBlock()
+		age    = time.Since(time.Unix(int64(head.Time()), 0))
+		synced = cs.doneCh == nil && atomic.LoadUint32(&cs.pm.fastSync) == 0 && age < artificialFinalitySafetyInterval
+	)
+	switch {
+	case synced && peers >= minPeers:
+		cs.pm.blockchain.EnableArtificialFinality(true, "peers", peers, "head", head.Number())
+	case cs.pm.noDisableArtificialFinality:
+		// Once enabled, artificial finality stays so
+	case peers < minPeers:
+		cs.pm.blockchain.EnableArtificialFinality(false, "reason", "low peers", "peers", peers)
+	case age >= artificialFinalitySafetyInterval:
+		cs.pm.blockchain.EnableArtificialFinality(false, "reason", "stale head", "head", head.Number(), "age", common.PrettyAge(time.Unix(int64(head.Time()), 0)))
+	}
+}
+
 func peerToSyncOp(mode downloader.SyncMode, p *peer) *chainSyncOp {
 	peerHead, peerTD := p.Head()
 	return &chainSyncOp{mode: mode, peer: p, td: peerTD, head: peerHead}
@@ -274,6 +316,9 @@ func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
 	if atomic.LoadUint32(&cs.pm.fastSync) == 1 {
 		block := cs.pm.blockchain.CurrentFastBlock()
 		td := cs.pm.blockchain.GetTdByHash(block.Hash())
+		if atomic.LoadUint32(&cs.pm.snapSync) == 1 {
+			return downloader.SnapSync, td
+		}
 		return downloader.FastSync, td
 	} else {
 		head := cs.pm.blockchain.CurrentHeader()
@@ -290,7 +335,7 @@ func (cs *chainSyncer) startSync(op *chainSyncOp) {
 
 // doSync synchronizes the local blockchain with a remote peer.
 func (pm *ProtocolManager) doSync(op *chainSyncOp) error {
-	if op.mode == downloader.FastSync {
+	if op.mode == downloader.FastSync || op.mode == downloader.SnapSync {
 		// Before launch the fast sync, we have to ensure user uses the same
 		// txlookup limit.
 		// The main concern here is: during the fast sync Geth won't index the
@@ -316,6 +361,7 @@ func (pm *ProtocolManager) doSync(op *chainSyncOp) error {
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
 		log.Info("Fast sync complete, auto disabling")
 		atomic.StoreUint32(&pm.fastSync, 0)
+		atomic.StoreUint32(&pm.snapSync, 0)
 	}
 
 	// If we've successfully finished a sync cycle and passed any required checkpoint,
@@ -0,0 +1,309 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.RegisterNative("callTracer", func() tracers.ResultTracer { return NewCallTracer() })
+}
+
+// precompiles are the precompiled contracts skipped by the call tracer. As in
+// the JavaScript tracer, these are all the ones known, regardless of the chain
+// configuration.
+var precompiles = vm.PrecompiledContractsForConfig(params.AllEthashProtocolChanges, big.NewInt(0))
+
+// callFrame is a call reported by the call tracer, with its fields in the order
+// of their JSON encoding. Unset fields are omitted.
+type callFrame struct {
+	Type    string       `json:"type,omitempty"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	// Execution details, not part of the result
+	gasIn   uint64
+	gasCost uint64
+	gas     *uint64 // Gas available to the call, if known
+	outOff  uint64
+	outLen  uint64
+}
+
+// CallTracer is a native implementation of the JavaScript callTracer, reporting
+// all the internal calls made by a transaction. Its results are identical to
+// those of the JavaScript tracer, quirks included.
+type CallTracer struct {
+	callstack []*callFrame
+	// descended tracks whether we've just descended from an outer transaction into
+	// an inner call.
+	descended bool
+
+	// Context of the traced transaction
+	typ     string
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	gasUsed uint64
+	output  []byte
+	time    time.Duration
+	err     error
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+	failed    error  // Interruption reported by the trace
+}
+
+// NewCallTracer creates a native call tracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{callstack: []*callFrame{{}}}
+}
+
+// CaptureStart implements vm.Tracer, recording the transaction context.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to = from, to
+	t.input = common.CopyBytes(input)
+	t.gas = gas
+	t.value = new(big.Int).Set(value)
+	return nil
+}
+
+// top returns the innermost call being executed.
+func (t *CallTracer) top() *callFrame {
+	return t.callstack[len(t.callstack)-1]
+}
+
+// pop removes the innermost call being executed.
+func (t *CallTracer) pop() *callFrame {
+	call := t.top()
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	return call
+}
+
+// CaptureState implements vm.Tracer, tracking the calls made by each step.
+func (t *CallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if t.failed != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.failed = t.reason
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		inOff := stack.Back(1).Uint64()
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inOff+stack.Back(2).Uint64())),
+			gasIn:   gas,
+			gasCost: cost,
+			Value:   "0x" + stack.Back(0).ToBig().Text(16),
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		top := t.top()
+		top.Calls = append(top.Calls, &callFrame{Type: op.String()})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.Address(stack.Back(1).Bytes20())
+		if _, ok := precompiles[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff := stack.Back(2 + off).Uint64()
+		call := &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			To:      hexutil.Encode(to.Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inOff+stack.Back(3+off).Uint64())),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Uint64(),
+			outLen:  stack.Back(5 + off).Uint64(),
+		}
+		if op != vm.DELEGATECALL && op != vm.STATICCALL {
+			call.Value = "0x" + stack.Back(2).ToBig().Text(16)
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	if t.descended {
+		if depth >= len(t.callstack) {
+			allowance := gas
+			t.top().gas = &allowance
+		}
+		// Otherwise the call was made to a plain account, whose true gas amount
+		// isn't known.
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.top().Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		// Pop off the last call and get the execution results
+		call := t.pop()
+
+		if call.Type == "CREATE" || call.Type == "CREATE2" {
+			// If the call was a CREATE, retrieve the contract address and output code
+			call.GasUsed = jsHex(int64(call.gasIn) - int64(call.gasCost) - int64(gas))
+
+			if ret := stack.Back(0); !ret.IsZero() {
+				addr := common.Address(ret.Bytes20())
+				call.To = hexutil.Encode(addr.Bytes())
+				call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else if call.gas != nil {
+			// If the call was a contract call, retrieve the gas usage and output
+			call.GasUsed = jsHex(int64(call.gasIn) - int64(call.gasCost) + int64(*call.gas) - int64(gas))
+
+			if ret := stack.Back(0); !ret.IsZero() {
+				call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outOff+call.outLen))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		if call.gas != nil {
+			call.Gas = jsHex(int64(*call.gas))
+		}
+		// Inject the call into the previous one
+		top := t.top()
+		top.Calls = append(top.Calls, call)
+	}
+	return nil
+}
+
+// fault handles the failure of the innermost call.
+func (t *CallTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.top().Error != "" {
+		return
+	}
+	// Pop off the just failed call
+	call := t.pop()
+	call.Error = err.Error()
+
+	// Consume all available gas
+	if call.gas != nil {
+		call.Gas = jsHex(int64(*call.gas))
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent, unless it was the last call
+	if len(t.callstack) == 0 {
+		t.callstack = append(t.callstack, call)
+		return
+	}
+	top := t.top()
+	top.Calls = append(top.Calls, call)
+}
+
+// CaptureFault implements vm.Tracer, handling the failure of the innermost call.
+func (t *CallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	if t.failed == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer, recording the results of the transaction.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	t.output = common.CopyBytes(output)
+	t.gasUsed = gasUsed
+	t.time = elapsed
+	t.err = err
+	return nil
+}
+
+// GetResult implements tracers.ResultTracer, returning the call tree of the
+// transaction.
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	if t.failed != nil {
+		return nil, t.failed
+	}
+	result := &callFrame{
+		Type:    t.typ,
+		From:    hexutil.Encode(t.from.Bytes()),
+		To:      hexutil.Encode(t.to.Bytes()),
+		Value:   "0x" + t.value.Text(16),
+		Gas:     jsHex(int64(t.gas)),
+		GasUsed: jsHex(int64(t.gasUsed)),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time.String(),
+		Calls:   t.callstack[0].Calls,
+	}
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	} else if t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Error != "" {
+		result.Output = ""
+	}
+	return encodeJSON(result)
+}
+
+// Stop implements tracers.ResultTracer, terminating the trace.
+func (t *CallTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
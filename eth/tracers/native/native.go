@@ -0,0 +1,59 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package native is a collection of transaction tracers written in Go, which
+// replace the JavaScript tracers of the same name.
+//
+// Importing the package registers the tracers in the eth/tracers registry. Their
+// results are byte for byte identical to the ones of the JavaScript tracers, so
+// the formatting helpers below mirror the JavaScript semantics.
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// jsHex formats a number like bigInt(n).toString(16) prefixed by 0x.
+func jsHex(n int64) string {
+	if n < 0 {
+		return "0x-" + strconv.FormatInt(-n, 16)
+	}
+	return "0x" + strconv.FormatInt(n, 16)
+}
+
+// memorySlice returns a copy of the memory in [begin, end), or nothing if out of
+// bounds, like the slice method of the memory of JavaScript tracers.
+func memorySlice(memory *vm.Memory, begin, end uint64) []byte {
+	if end <= begin || end > uint64(memory.Len()) {
+		return nil
+	}
+	return memory.GetCopy(int64(begin), int64(end-begin))
+}
+
+// encodeJSON encodes a value like JSON.stringify, without escaping HTML.
+func encodeJSON(v interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
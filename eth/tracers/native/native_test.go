@@ -0,0 +1,164 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// callTrace is the result of a call tracer, as stored in the test fixtures.
+type callTrace struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []callTrace     `json:"calls,omitempty"`
+}
+
+// callTracerTest is a call tracer test fixture of eth/tracers/testdata.
+type callTracerTest struct {
+	Genesis *genesisT.Genesis `json:"genesis"`
+	Context *struct {
+		Number     math.HexOrDecimal64   `json:"number"`
+		Difficulty *math.HexOrDecimal256 `json:"difficulty"`
+		Time       math.HexOrDecimal64   `json:"timestamp"`
+		GasLimit   math.HexOrDecimal64   `json:"gasLimit"`
+		Miner      common.Address        `json:"miner"`
+	} `json:"context"`
+	Input  string     `json:"input"`
+	Result *callTrace `json:"result"`
+}
+
+// timeField matches the execution time in call tracer results, which differs
+// between runs.
+var timeField = regexp.MustCompile(`,"time":"[^"]*"`)
+
+// runFixture executes the transaction of a test fixture with the given tracer.
+func runFixture(t *testing.T, test *callTracerTest, tracer tracers.ResultTracer) json.RawMessage {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return timeField.ReplaceAll(res, nil)
+}
+
+// TestFixtures checks the native tracers against the JavaScript tracers, and
+// the call tracer against the expected results, on the call tracer fixtures.
+func TestFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "call_tracer_*.json"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no tracer tests found")
+	}
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			for _, name := range []string{"callTracer", "prestateTracer"} {
+				jsTracer, err := tracers.New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript %s: %v", name, err)
+				}
+				nativeTracer, err := tracers.NewTracer(name)
+				if err != nil {
+					t.Fatalf("failed to create native %s: %v", name, err)
+				}
+				if _, ok := nativeTracer.(*tracers.Tracer); ok {
+					t.Fatalf("%s isn't native", name)
+				}
+				want, have := runFixture(t, test, jsTracer), runFixture(t, test, nativeTracer)
+				if string(have) != string(want) {
+					t.Errorf("%s result mismatch:\nhave %s\nwant %s", name, have, want)
+				}
+				if name != "callTracer" {
+					continue
+				}
+				ret := new(callTrace)
+				if err := json.Unmarshal(have, ret); err != nil {
+					t.Fatalf("failed to unmarshal trace result: %v", err)
+				}
+				if !reflect.DeepEqual(ret, test.Result) {
+					t.Errorf("trace mismatch:\nhave %+v\nwant %+v", ret, test.Result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.RegisterNative("prestateTracer", func() tracers.ResultTracer { return NewPrestateTracer() })
+}
+
+// prestateAccount is the state of an account before the transaction.
+type prestateAccount struct {
+	Balance *big.Int
+	Nonce   int64
+	Code    []byte
+	Storage *orderedMap // Slot hex to value hex
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *prestateAccount) MarshalJSON() ([]byte, error) {
+	return encodeJSON(&struct {
+		Balance string      `json:"balance"`
+		Nonce   int64       `json:"nonce"`
+		Code    string      `json:"code"`
+		Storage *orderedMap `json:"storage"`
+	}{
+		Balance: "0x" + a.Balance.Text(16),
+		Nonce:   a.Nonce,
+		Code:    hexutil.Encode(a.Code),
+		Storage: a.Storage,
+	})
+}
+
+// orderedMap is a JSON object keeping its keys in insertion order, like the
+// objects of JavaScript.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := encodeJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := encodeJSON(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// PrestateTracer is a native implementation of the JavaScript prestateTracer,
+// reporting the accounts and storage accessed by a transaction, as they were
+// before its execution. Its results are identical to those of the JavaScript
+// tracer.
+type PrestateTracer struct {
+	prestate *orderedMap // Address hex to *prestateAccount, nil until the first step
+	db       vm.StateDB
+
+	// Context of the traced transaction
+	create bool
+	from   common.Address
+	to     common.Address
+	value  *big.Int
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+	failed    error  // Interruption reported by the trace
+}
+
+// NewPrestateTracer creates a native prestate tracer.
+func NewPrestateTracer() *PrestateTracer {
+	return &PrestateTracer{}
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *PrestateTracer) lookupAccount(addr common.Address) *prestateAccount {
+	key := hexutil.Encode(addr.Bytes())
+	if acc, ok := t.prestate.get(key); ok {
+		return acc.(*prestateAccount)
+	}
+	acc := &prestateAccount{
+		Balance: new(big.Int).Set(t.db.GetBalance(addr)),
+		Nonce:   int64(t.db.GetNonce(addr)),
+		Code:    common.CopyBytes(t.db.GetCode(addr)),
+		Storage: newOrderedMap(),
+	}
+	t.prestate.set(key, acc)
+	return acc
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *PrestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	storage := t.lookupAccount(addr).Storage
+	slot := hexutil.Encode(key.Bytes())
+	if _, ok := storage.get(slot); !ok {
+		storage.set(slot, hexutil.Encode(t.db.GetState(addr, key).Bytes()))
+	}
+}
+
+// CaptureStart implements vm.Tracer, recording the transaction context.
+func (t *PrestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create = create
+	t.from, t.to = from, to
+	t.value = new(big.Int).Set(value)
+	return nil
+}
+
+// CaptureState implements vm.Tracer, adding the state accessed by each step to
+// the prestate.
+func (t *PrestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if t.failed != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.failed = t.reason
+		return nil
+	}
+	// Add the current account if we just started tracing
+	if t.prestate == nil {
+		t.prestate = newOrderedMap()
+		t.db = env.StateDB
+
+		// Balance will potentially be wrong here, since this will include the value
+		// sent along with the message. We fix that in GetResult.
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.Address(stack.Back(0).Bytes20()))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		offset := stack.Back(1).Uint64()
+		code := memorySlice(memory, offset, offset+stack.Back(2).Uint64())
+		salt := common.Hash(stack.Back(3).Bytes32())
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.Address(stack.Back(1).Bytes20()))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.Hash(stack.Back(0).Bytes32()))
+	}
+	return nil
+}
+
+// CaptureFault implements vm.Tracer.
+func (t *PrestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer.
+func (t *PrestateTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	return nil
+}
+
+// GetResult implements tracers.ResultTracer, returning the prestate.
+//
+// Like the JavaScript tracer, accounts which weren't accessed during execution
+// are read from the state as it is when the result is retrieved.
+func (t *PrestateTracer) GetResult() (json.RawMessage, error) {
+	if t.failed != nil {
+		return nil, t.failed
+	}
+	if t.prestate == nil {
+		// Nothing was executed, so there is no state to report.
+		return encodeJSON(newOrderedMap())
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin
+	from, to := t.lookupAccount(t.from), t.lookupAccount(t.to)
+	to.Balance = new(big.Int).Sub(to.Balance, t.value)
+	from.Balance = new(big.Int).Add(from.Balance, t.value)
+
+	// Decrement the caller's nonce, and remove empty create targets
+	from.Nonce--
+	if t.create {
+		// We can blindly delete the contract prestate, as any existing state would
+		// have caused the transaction to be rejected as invalid in the first place.
+		t.prestate.delete(hexutil.Encode(t.to.Bytes()))
+	}
+	return encodeJSON(t.prestate)
+}
+
+// Stop implements tracers.ResultTracer, terminating the trace.
+func (t *PrestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parity
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// StateDiff is the change of the accounts modified by a transaction.
+type StateDiff map[common.Address]*AccountDiff
+
+// AccountDiff is the change of a single account.
+type AccountDiff struct {
+	Balance *Diff                 `json:"balance"`
+	Code    *Diff                 `json:"code"`
+	Nonce   *Diff                 `json:"nonce"`
+	Storage map[common.Hash]*Diff `json:"storage"`
+}
+
+// Diff is the change of a single value, which is either unchanged, born (the
+// account was created), died (the account was removed), or changed.
+type Diff struct {
+	From interface{} // Nil if born
+	To   interface{} // Nil if died
+}
+
+// MarshalJSON implements json.Marshaler, encoding the diff in Parity's format.
+func (d *Diff) MarshalJSON() ([]byte, error) {
+	switch {
+	case d.From == nil && d.To == nil:
+		return json.Marshal("=")
+	case d.From == nil:
+		return json.Marshal(map[string]interface{}{"+": d.To})
+	case d.To == nil:
+		return json.Marshal(map[string]interface{}{"-": d.From})
+	}
+	return json.Marshal(map[string]interface{}{"*": map[string]interface{}{"from": d.From, "to": d.To}})
+}
+
+// unchanged is the diff of a value which wasn't modified.
+var unchanged = &Diff{}
+
+// account is the state of an account, as exposed in state diffs.
+type account struct {
+	balance *big.Int
+	code    []byte
+	nonce   uint64
+}
+
+func readAccount(db vm.StateDB, addr common.Address) *account {
+	if !db.Exist(addr) {
+		return nil
+	}
+	return &account{
+		balance: new(big.Int).Set(db.GetBalance(addr)),
+		code:    db.GetCode(addr),
+		nonce:   db.GetNonce(addr),
+	}
+}
+
+func (a *account) values() (balance, code, nonce interface{}) {
+	return (*hexutil.Big)(a.balance), hexutil.Bytes(a.code), hexutil.Uint64(a.nonce)
+}
+
+// NewStateDiff computes the diff between the state before and after a
+// transaction, over the given accounts and storage slots. Accounts which
+// haven't changed are omitted.
+func NewStateDiff(pre, post vm.StateDB, touched map[common.Address]map[common.Hash]struct{}) StateDiff {
+	diff := make(StateDiff)
+	for addr, slots := range touched {
+		from, to := readAccount(pre, addr), readAccount(post, addr)
+		switch {
+		case from == nil && to == nil:
+			continue
+
+		case from == nil:
+			balance, code, nonce := to.values()
+			d := &AccountDiff{
+				Balance: &Diff{To: balance},
+				Code:    &Diff{To: code},
+				Nonce:   &Diff{To: nonce},
+				Storage: make(map[common.Hash]*Diff),
+			}
+			for slot := range slots {
+				if value := post.GetState(addr, slot); value != (common.Hash{}) {
+					d.Storage[slot] = &Diff{To: value}
+				}
+			}
+			diff[addr] = d
+
+		case to == nil:
+			balance, code, nonce := from.values()
+			d := &AccountDiff{
+				Balance: &Diff{From: balance},
+				Code:    &Diff{From: code},
+				Nonce:   &Diff{From: nonce},
+				Storage: make(map[common.Hash]*Diff),
+			}
+			for slot := range slots {
+				if value := pre.GetState(addr, slot); value != (common.Hash{}) {
+					d.Storage[slot] = &Diff{From: value}
+				}
+			}
+			diff[addr] = d
+
+		default:
+			d := &AccountDiff{
+				Balance: unchanged,
+				Code:    unchanged,
+				Nonce:   unchanged,
+				Storage: make(map[common.Hash]*Diff),
+			}
+			changed := false
+			if from.balance.Cmp(to.balance) != 0 {
+				d.Balance, changed = &Diff{From: (*hexutil.Big)(from.balance), To: (*hexutil.Big)(to.balance)}, true
+			}
+			if !bytes.Equal(from.code, to.code) {
+				d.Code, changed = &Diff{From: hexutil.Bytes(from.code), To: hexutil.Bytes(to.code)}, true
+			}
+			if from.nonce != to.nonce {
+				d.Nonce, changed = &Diff{From: hexutil.Uint64(from.nonce), To: hexutil.Uint64(to.nonce)}, true
+			}
+			for slot := range slots {
+				if before, after := pre.GetState(addr, slot), post.GetState(addr, slot); before != after {
+					d.Storage[slot], changed = &Diff{From: before, To: after}, true
+				}
+			}
+			if changed {
+				diff[addr] = d
+			}
+		}
+	}
+	return diff
+}
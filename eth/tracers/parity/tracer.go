@@ -0,0 +1,402 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parity
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// frame is a call frame, or a self-destruct, of the traced execution.
+type frame struct {
+	typ     vm.OpCode
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	err     error
+	calls   []*frame
+
+	vmTrace   *VMTrace     // Operations executed in the frame, if VM tracing is enabled
+	pending   *VMOperation // Last operation, awaiting its effects
+	pendingOp vm.OpCode
+	memOff    uint64 // Memory area written by the pending operation
+	memSize   uint64
+}
+
+// Tracer is a vm.Tracer collecting the call frames of a transaction, from which
+// it produces Parity's flat traces. Optionally, it records a VM trace of the
+// executed operations.
+//
+// The tracer also records the accounts and storage slots written to by the
+// transaction, for computing its state diff.
+type Tracer struct {
+	vmTracing bool
+	root      *frame
+	frames    []*frame // Stack of the currently executing frames
+
+	touched map[common.Address]map[common.Hash]struct{}
+}
+
+// NewTracer creates a tracer for a single transaction. If vmTracing is set, the
+// executed operations are recorded as well.
+func NewTracer(vmTracing bool) *Tracer {
+	return &Tracer{
+		vmTracing: vmTracing,
+		touched:   make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// touch records an account as touched by the transaction.
+func (t *Tracer) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := t.touched[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		t.touched[addr] = slots
+	}
+	return slots
+}
+
+// Touch records an account as touched by the transaction, without it having
+// been part of any call frame, such as the coinbase receiving the fees.
+func (t *Tracer) Touch(addr common.Address) {
+	t.touch(addr)
+}
+
+// Touched returns the accounts, and their storage slots, written to by the
+// transaction.
+func (t *Tracer) Touched() map[common.Address]map[common.Hash]struct{} {
+	return t.touched
+}
+
+// enter pushes a new frame.
+func (t *Tracer) enter(f *frame) {
+	if t.vmTracing {
+		f.vmTrace = &VMTrace{Ops: []*VMOperation{}}
+	}
+	t.touch(f.from)
+	t.touch(f.to)
+	if len(t.frames) == 0 {
+		t.root = f
+	} else {
+		parent := t.frames[len(t.frames)-1]
+		parent.calls = append(parent.calls, f)
+		if parent.pending != nil {
+			parent.pending.Sub = f.vmTrace
+		}
+	}
+	t.frames = append(t.frames, f)
+}
+
+// exit pops the current frame, setting its results.
+func (t *Tracer) exit(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	f := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	f.output = common.CopyBytes(output)
+	f.gasUsed = gasUsed
+	f.err = err
+
+	// The last operation of a frame halted it, so it has no effects besides the
+	// gas it used.
+	if f.pending != nil {
+		f.pending.Ex = &VMExecutedOperation{Push: []*hexutil.Big{}, Used: f.gas - gasUsed}
+		f.pending = nil
+	}
+}
+
+// CaptureStart implements vm.Tracer, entering the top-level frame.
+func (t *Tracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.enter(&frame{
+		typ:   typ,
+		from:  from,
+		to:    to,
+		input: common.CopyBytes(input),
+		gas:   gas,
+		value: new(big.Int).Set(value),
+	})
+	return nil
+}
+
+// CaptureEnter implements vm.CallTracer, entering a nested frame.
+func (t *Tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	switch {
+	case value != nil:
+		value = new(big.Int).Set(value)
+	case typ == vm.DELEGATECALL && len(t.frames) > 0:
+		// Delegated calls run with the value of their caller.
+		value = t.frames[len(t.frames)-1].value
+	default:
+		value = new(big.Int)
+	}
+	t.enter(&frame{
+		typ:   typ,
+		from:  from,
+		to:    to,
+		input: common.CopyBytes(input),
+		gas:   gas,
+		value: value,
+	})
+}
+
+// CaptureState implements vm.Tracer, recording self-destructs, storage writes
+// and, if enabled, the executed operations.
+func (t *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if len(t.frames) == 0 {
+		return nil
+	}
+	f := t.frames[len(t.frames)-1]
+	if f.pending != nil {
+		t.executed(f, gas, memory, stack)
+	}
+	// Operations failing before execution are not part of the trace.
+	if err != nil {
+		return nil
+	}
+	switch op {
+	case vm.SSTORE:
+		slot := common.Hash(stack.Back(0).Bytes32())
+		t.touch(contract.Address())[slot] = struct{}{}
+	case vm.SELFDESTRUCT:
+		beneficiary := common.Address(stack.Back(0).Bytes20())
+		t.touch(beneficiary)
+		f.calls = append(f.calls, &frame{
+			typ:   vm.SELFDESTRUCT,
+			from:  contract.Address(),
+			to:    beneficiary,
+			value: new(big.Int).Set(env.StateDB.GetBalance(contract.Address())),
+		})
+	}
+	if !t.vmTracing {
+		return nil
+	}
+	if len(f.vmTrace.Ops) == 0 {
+		f.vmTrace.Code = common.CopyBytes(contract.Code)
+	}
+	f.pending, f.pendingOp = &VMOperation{Cost: cost, PC: pc}, op
+	f.vmTrace.Ops = append(f.vmTrace.Ops, f.pending)
+
+	f.memOff, f.memSize = memoryWritten(op, stack)
+	if op == vm.SSTORE {
+		f.pending.Ex = &VMExecutedOperation{
+			Store: &VMStorageDiff{
+				Key: (*hexutil.Big)(stack.Back(0).ToBig()),
+				Val: (*hexutil.Big)(stack.Back(1).ToBig()),
+			},
+		}
+	}
+	return nil
+}
+
+// executed records the effects of the pending operation of a frame, given the
+// state of the frame once it has been executed.
+func (t *Tracer) executed(f *frame, gas uint64, memory *vm.Memory, stack *vm.Stack) {
+	op := f.pending
+	f.pending = nil
+
+	ex := op.Ex
+	if ex == nil {
+		ex = new(VMExecutedOperation)
+	}
+	ex.Used = gas
+
+	data := stack.Data()
+	n := pushed(f.pendingOp)
+	if n > len(data) {
+		n = len(data)
+	}
+	ex.Push = make([]*hexutil.Big, 0, n)
+	for _, item := range data[len(data)-n:] {
+		ex.Push = append(ex.Push, (*hexutil.Big)(item.ToBig()))
+	}
+	if f.memSize > 0 && f.memOff+f.memSize <= uint64(memory.Len()) {
+		ex.Mem = &VMMemoryDiff{
+			Data: memory.GetCopy(int64(f.memOff), int64(f.memSize)),
+			Off:  f.memOff,
+		}
+	}
+	op.Ex = ex
+}
+
+// CaptureFault implements vm.Tracer, marking the failed operation as such.
+func (t *Tracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	// REVERT itself executes successfully, only failing its frame.
+	if len(t.frames) == 0 || err == vm.ErrExecutionReverted {
+		return nil
+	}
+	f := t.frames[len(t.frames)-1]
+	if f.pending != nil {
+		f.pending.Ex = nil
+		f.pending = nil
+	}
+	return nil
+}
+
+// CaptureExit implements vm.CallTracer, exiting a nested frame.
+func (t *Tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(output, gasUsed, err)
+}
+
+// CaptureEnd implements vm.Tracer, exiting the top-level frame.
+func (t *Tracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) error {
+	t.exit(output, gasUsed, err)
+	return nil
+}
+
+// Output returns the return data of the transaction.
+func (t *Tracer) Output() []byte {
+	if t.root == nil {
+		return nil
+	}
+	return t.root.output
+}
+
+// Traces returns the flat traces of the transaction, in depth-first order.
+func (t *Tracer) Traces() []*Trace {
+	traces := []*Trace{}
+	if t.root != nil {
+		traces = flatten(traces, t.root, []int{})
+	}
+	return traces
+}
+
+// VMTrace returns the trace of the operations executed by the transaction, or
+// nil if VM tracing isn't enabled.
+func (t *Tracer) VMTrace() *VMTrace {
+	if t.root == nil || t.root.vmTrace == nil {
+		return nil
+	}
+	return t.root.vmTrace
+}
+
+// flatten appends the traces of a frame and its nested frames.
+func flatten(traces []*Trace, f *frame, address []int) []*Trace {
+	trace := &Trace{
+		Subtraces:    len(f.calls),
+		TraceAddress: address,
+	}
+	if f.err != nil {
+		trace.Error = errorString(f.err)
+	}
+	switch f.typ {
+	case vm.CREATE, vm.CREATE2:
+		trace.Type = TypeCreate
+		trace.Action = &CreateAction{
+			From:  f.from,
+			Gas:   hexutil.Uint64(f.gas),
+			Init:  f.input,
+			Value: (*hexutil.Big)(f.value),
+		}
+		trace.Result = &CreateResult{
+			Address: f.to,
+			Code:    f.output,
+			GasUsed: hexutil.Uint64(f.gasUsed),
+		}
+	case vm.SELFDESTRUCT:
+		trace.Type = TypeSuicide
+		trace.Action = &SuicideAction{
+			Address:       f.from,
+			Balance:       (*hexutil.Big)(f.value),
+			RefundAddress: f.to,
+		}
+	default:
+		trace.Type = TypeCall
+		trace.Action = &CallAction{
+			CallType: strings.ToLower(f.typ.String()),
+			From:     f.from,
+			Gas:      hexutil.Uint64(f.gas),
+			Input:    f.input,
+			To:       f.to,
+			Value:    (*hexutil.Big)(f.value),
+		}
+		trace.Result = &CallResult{
+			GasUsed: hexutil.Uint64(f.gasUsed),
+			Output:  f.output,
+		}
+	}
+	traces = append(traces, trace)
+	for i, call := range f.calls {
+		sub := make([]int, len(address)+1)
+		copy(sub, address)
+		sub[len(address)] = i
+		traces = flatten(traces, call, sub)
+	}
+	return traces
+}
+
+// memoryWritten returns the memory area written by an operation, given the
+// stack before its execution.
+func memoryWritten(op vm.OpCode, stack *vm.Stack) (offset, size uint64) {
+	area := func(off, size int) (uint64, uint64) {
+		o, s := stack.Back(off), stack.Back(size)
+		if !o.IsUint64() || !s.IsUint64() {
+			return 0, 0
+		}
+		return o.Uint64(), s.Uint64()
+	}
+	switch op {
+	case vm.MSTORE:
+		return stack.Back(0).Uint64(), 32
+	case vm.MSTORE8:
+		return stack.Back(0).Uint64(), 1
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		return area(0, 2)
+	case vm.EXTCODECOPY:
+		return area(1, 3)
+	case vm.CALL, vm.CALLCODE:
+		return area(5, 6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		return area(4, 5)
+	}
+	return 0, 0
+}
+
+// pushed returns the number of stack items pushed, or modified, by an operation.
+func pushed(op vm.OpCode) int {
+	switch {
+	case op.IsPush():
+		return 1
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.BEGINSUB, vm.JUMPSUB, vm.RETURNSUB:
+		return 0
+	}
+	return 1
+}
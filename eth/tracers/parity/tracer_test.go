@@ -0,0 +1,261 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parity
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+var (
+	origin   = common.HexToAddress("0x00000000000000000000000000000000000000a0")
+	caller   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	storer   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	reverter = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	suicider = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	refund   = common.HexToAddress("0x00000000000000000000000000000000000000ee")
+)
+
+// callCode returns the code of a contract calling the given contracts in turn,
+// without value nor data.
+func callCode(targets ...common.Address) []byte {
+	var code []byte
+	for _, target := range targets {
+		code = append(code,
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+			byte(vm.PUSH1), target[19], byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.POP),
+		)
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// trace executes a call to the caller contract, which calls a contract storing
+// a value, one reverting and one self-destructing.
+func trace(t *testing.T, vmTracing bool) (*Tracer, *state.StateDB, *state.StateDB) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(caller, callCode(storer, reverter, suicider))
+	statedb.SetCode(storer, []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	})
+	statedb.SetCode(reverter, []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)})
+	statedb.SetCode(suicider, []byte{byte(vm.PUSH1), refund[19], byte(vm.SELFDESTRUCT)})
+	statedb.SetBalance(suicider, big.NewInt(100))
+	statedb.Finalise(true)
+	pre := statedb.Copy()
+
+	tracer := NewTracer(vmTracing)
+	_, _, err := runtime.Call(caller, nil, &runtime.Config{
+		Origin:    origin,
+		GasLimit:  1000000,
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	})
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	statedb.Finalise(true)
+	return tracer, pre, statedb
+}
+
+func TestTraces(t *testing.T) {
+	tracer, _, _ := trace(t, false)
+	traces := tracer.Traces()
+	if tracer.VMTrace() != nil {
+		t.Error("VM trace recorded without VM tracing")
+	}
+
+	want := []struct {
+		typ          string
+		from, to     common.Address
+		traceAddress []int
+		subtraces    int
+		err          string
+	}{
+		{TypeCall, origin, caller, []int{}, 3, ""},
+		{TypeCall, caller, storer, []int{0}, 0, ""},
+		{TypeCall, caller, reverter, []int{1}, 0, "Reverted"},
+		{TypeCall, caller, suicider, []int{2}, 1, ""},
+		{TypeSuicide, suicider, refund, []int{2, 0}, 0, ""},
+	}
+	if len(traces) != len(want) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), len(want))
+	}
+	for i, w := range want {
+		trace := traces[i]
+		if trace.Type != w.typ {
+			t.Errorf("trace %d: type mismatch: have %s, want %s", i, trace.Type, w.typ)
+		}
+		if *trace.From() != w.from || *trace.To() != w.to {
+			t.Errorf("trace %d: address mismatch: have %x->%x, want %x->%x", i, *trace.From(), *trace.To(), w.from, w.to)
+		}
+		if !reflect.DeepEqual(trace.TraceAddress, w.traceAddress) {
+			t.Errorf("trace %d: trace address mismatch: have %v, want %v", i, trace.TraceAddress, w.traceAddress)
+		}
+		if trace.Subtraces != w.subtraces {
+			t.Errorf("trace %d: subtraces mismatch: have %d, want %d", i, trace.Subtraces, w.subtraces)
+		}
+		if trace.Error != w.err {
+			t.Errorf("trace %d: error mismatch: have %q, want %q", i, trace.Error, w.err)
+		}
+	}
+	if action := traces[1].Action.(*CallAction); action.CallType != "call" || uint64(action.Gas) != 0xffff {
+		t.Errorf("call action mismatch: have type %s, gas %d", action.CallType, action.Gas)
+	}
+	if result := traces[1].Result.(*CallResult); len(result.Output) != 32 {
+		t.Errorf("call output length mismatch: have %d, want 32", len(result.Output))
+	}
+	if action := traces[4].Action.(*SuicideAction); action.Balance.ToInt().Int64() != 100 {
+		t.Errorf("suicide balance mismatch: have %v, want 100", action.Balance)
+	}
+}
+
+// Tests that calls failing before entering the callee, like transfers exceeding
+// the balance of the caller, are traced.
+func TestTracesFailedCall(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(caller, []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 1,
+		byte(vm.PUSH1), storer[19], byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.STOP),
+	})
+	tracer := NewTracer(false)
+	_, _, err := runtime.Call(caller, nil, &runtime.Config{
+		Origin:    origin,
+		GasLimit:  1000000,
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	})
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	traces := tracer.Traces()
+	if len(traces) != 2 || traces[0].Subtraces != 1 {
+		t.Fatalf("trace mismatch: have %d traces", len(traces))
+	}
+	if trace := traces[1]; *trace.To() != storer || trace.Error != "Insufficient balance for transfer" {
+		t.Errorf("failed call trace mismatch: have %x, error %q", *trace.To(), trace.Error)
+	}
+}
+
+func TestTraceJSON(t *testing.T) {
+	tracer, _, _ := trace(t, false)
+	traces := tracer.Traces()
+
+	hash := common.HexToHash("0x01")
+	position := uint64(3)
+	traces[2].Localize(common.HexToHash("0x02"), 7, &hash, &position)
+
+	var dec map[string]interface{}
+	blob, err := json.Marshal(traces[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(blob, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dec["result"]; ok {
+		t.Errorf("failed trace has a result: %s", blob)
+	}
+	if dec["error"] != "Reverted" || dec["blockNumber"] != float64(7) || dec["transactionPosition"] != float64(3) {
+		t.Errorf("unexpected trace encoding: %s", blob)
+	}
+
+	reward := NewRewardTrace(refund, RewardBlock, big.NewInt(5))
+	reward.Localize(common.HexToHash("0x02"), 7, nil, nil)
+	if blob, err = json.Marshal(reward); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"action":{"author":"0x00000000000000000000000000000000000000ee","rewardType":"block","value":"0x5"},` +
+		`"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000002","blockNumber":7,` +
+		`"result":null,"subtraces":0,"traceAddress":[],"transactionHash":null,"transactionPosition":null,"type":"reward"}`
+	if string(blob) != want {
+		t.Errorf("reward encoding mismatch:\nhave %s\nwant %s", blob, want)
+	}
+}
+
+func TestVMTrace(t *testing.T) {
+	tracer, _, _ := trace(t, true)
+	vmTrace := tracer.VMTrace()
+	if vmTrace == nil {
+		t.Fatal("missing VM trace")
+	}
+	// Each call is 9 operations, followed by a STOP.
+	if len(vmTrace.Ops) != 28 {
+		t.Fatalf("operation count mismatch: have %d, want 28", len(vmTrace.Ops))
+	}
+	call := vmTrace.Ops[7]
+	if call.Sub == nil || len(call.Sub.Ops) != 6 {
+		t.Fatalf("missing or bad subtrace of the first call: %+v", call.Sub)
+	}
+	if call.Ex == nil || len(call.Ex.Push) != 1 || call.Ex.Push[0].ToInt().Int64() != 1 {
+		t.Errorf("call effects mismatch: %+v", call.Ex)
+	}
+	if call.Ex.Mem != nil {
+		t.Errorf("call without return area wrote memory: %+v", call.Ex.Mem)
+	}
+	sstore := call.Sub.Ops[2]
+	if sstore.Ex == nil || sstore.Ex.Store == nil || sstore.Ex.Store.Val.ToInt().Int64() != 0x2a {
+		t.Errorf("storage write mismatch: %+v", sstore.Ex)
+	}
+	if sstore.Ex.Used != call.Sub.Ops[3].Ex.Used+call.Sub.Ops[3].Cost {
+		t.Errorf("gas usage mismatch: sstore left %d, next op left %d", sstore.Ex.Used, call.Sub.Ops[3].Ex.Used)
+	}
+	if push := call.Sub.Ops[0].Ex.Push; len(push) != 1 || push[0].ToInt().Int64() != 0x2a {
+		t.Errorf("push mismatch: %v", push)
+	}
+	if !reflect.DeepEqual([]byte(call.Sub.Code), []byte{0x60, 0x2a, 0x60, 0, 0x55, 0x60, 0x20, 0x60, 0, 0xf3}) {
+		t.Errorf("subtrace code mismatch: %x", call.Sub.Code)
+	}
+}
+
+func TestStateDiff(t *testing.T) {
+	tracer, pre, post := trace(t, false)
+	diff := NewStateDiff(pre, post, tracer.Touched())
+
+	if _, ok := diff[caller]; ok {
+		t.Errorf("unchanged account in diff: %x", caller)
+	}
+	if d := diff[storer]; d == nil || d.Balance != unchanged || len(d.Storage) != 1 {
+		t.Errorf("storer diff mismatch: %+v", d)
+	} else if s := d.Storage[common.Hash{}]; s.From != (common.Hash{}) || s.To != common.BigToHash(big.NewInt(0x2a)) {
+		t.Errorf("storer storage diff mismatch: %+v", s)
+	}
+	if d := diff[suicider]; d == nil || d.Balance.To != nil || d.Balance.From.(*hexutil.Big).ToInt().Int64() != 100 {
+		t.Errorf("suicider diff mismatch: %+v", d)
+	}
+	if d := diff[refund]; d == nil || d.Balance.From != nil || d.Balance.To.(*hexutil.Big).ToInt().Int64() != 100 {
+		t.Errorf("refund diff mismatch: %+v", d)
+	}
+
+	blob, err := json.Marshal(diff[refund])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"balance":{"+":"0x64"},"code":{"+":"0x"},"nonce":{"+":"0x0"},"storage":{}}`
+	if string(blob) != want {
+		t.Errorf("diff encoding mismatch:\nhave %s\nwant %s", blob, want)
+	}
+}
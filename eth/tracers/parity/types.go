@@ -0,0 +1,260 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package parity implements tracing in the formats of the Parity (OpenEthereum)
+// trace_ RPC namespace: flat call traces, VM traces and state diffs.
+package parity
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Trace types.
+const (
+	TypeCall    = "call"
+	TypeCreate  = "create"
+	TypeSuicide = "suicide"
+	TypeReward  = "reward"
+)
+
+// Reward types.
+const (
+	RewardBlock = "block"
+	RewardUncle = "uncle"
+)
+
+// CallAction is the action of a call trace.
+type CallAction struct {
+	CallType string         `json:"callType"`
+	From     common.Address `json:"from"`
+	Gas      hexutil.Uint64 `json:"gas"`
+	Input    hexutil.Bytes  `json:"input"`
+	To       common.Address `json:"to"`
+	Value    *hexutil.Big   `json:"value"`
+}
+
+// CreateAction is the action of a create trace.
+type CreateAction struct {
+	From  common.Address `json:"from"`
+	Gas   hexutil.Uint64 `json:"gas"`
+	Init  hexutil.Bytes  `json:"init"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// SuicideAction is the action of a suicide (self-destruct) trace.
+type SuicideAction struct {
+	Address       common.Address `json:"address"`
+	Balance       *hexutil.Big   `json:"balance"`
+	RefundAddress common.Address `json:"refundAddress"`
+}
+
+// RewardAction is the action of a block or uncle reward trace.
+type RewardAction struct {
+	Author     common.Address `json:"author"`
+	RewardType string         `json:"rewardType"`
+	Value      *hexutil.Big   `json:"value"`
+}
+
+// CallResult is the result of a successful call trace.
+type CallResult struct {
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Output  hexutil.Bytes  `json:"output"`
+}
+
+// CreateResult is the result of a successful create trace.
+type CreateResult struct {
+	Address common.Address `json:"address"`
+	Code    hexutil.Bytes  `json:"code"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+}
+
+// Trace is a flat trace of a single call frame, suicide or reward.
+//
+// The block and transaction fields are only set on traces localized in the chain,
+// as returned by trace_block, trace_transaction and trace_filter.
+type Trace struct {
+	Action       interface{} // One of the *Action types
+	Error        string      // Failure of the frame, if any, in which case Result is omitted
+	Result       interface{} // Nil, *CallResult or *CreateResult
+	Subtraces    int
+	TraceAddress []int
+	Type         string
+
+	BlockHash           *common.Hash
+	BlockNumber         uint64
+	TransactionHash     *common.Hash // Nil for rewards
+	TransactionPosition *uint64      // Nil for rewards
+}
+
+// MarshalJSON implements json.Marshaler, encoding the trace in Parity's format.
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	// Maps are encoded with sorted keys, which is the order used by Parity.
+	enc := map[string]interface{}{
+		"action":       t.Action,
+		"subtraces":    t.Subtraces,
+		"traceAddress": t.TraceAddress,
+		"type":         t.Type,
+	}
+	if t.Error != "" {
+		enc["error"] = t.Error
+	} else {
+		enc["result"] = t.Result
+	}
+	if t.BlockHash != nil {
+		enc["blockHash"] = t.BlockHash
+		enc["blockNumber"] = t.BlockNumber
+		enc["transactionHash"] = t.TransactionHash
+		enc["transactionPosition"] = t.TransactionPosition
+	}
+	return json.Marshal(enc)
+}
+
+// Localize sets the position of the trace in the chain. The transaction hash
+// and position are nil for rewards.
+func (t *Trace) Localize(blockHash common.Hash, blockNumber uint64, txHash *common.Hash, txPosition *uint64) {
+	t.BlockHash = &blockHash
+	t.BlockNumber = blockNumber
+	t.TransactionHash = txHash
+	t.TransactionPosition = txPosition
+}
+
+// From returns the address the trace originates from: the caller of a call or
+// create, the self-destructed contract of a suicide, and nil for rewards.
+func (t *Trace) From() *common.Address {
+	switch action := t.Action.(type) {
+	case *CallAction:
+		return &action.From
+	case *CreateAction:
+		return &action.From
+	case *SuicideAction:
+		return &action.Address
+	}
+	return nil
+}
+
+// To returns the address the trace is directed to: the callee of a call, the
+// created contract of a successful create, the refund address of a suicide,
+// and the author of a reward.
+func (t *Trace) To() *common.Address {
+	switch action := t.Action.(type) {
+	case *CallAction:
+		return &action.To
+	case *CreateAction:
+		if result, ok := t.Result.(*CreateResult); ok && t.Error == "" {
+			return &result.Address
+		}
+	case *SuicideAction:
+		return &action.RefundAddress
+	case *RewardAction:
+		return &action.Author
+	}
+	return nil
+}
+
+// NewRewardTrace creates the trace of a block or uncle reward.
+func NewRewardTrace(author common.Address, rewardType string, value *big.Int) *Trace {
+	return &Trace{
+		Action: &RewardAction{
+			Author:     author,
+			RewardType: rewardType,
+			Value:      (*hexutil.Big)(value),
+		},
+		TraceAddress: []int{},
+		Type:         TypeReward,
+	}
+}
+
+// TraceResults are the results of replaying a transaction with trace_replay*.
+// Results of trace types which weren't requested are empty.
+type TraceResults struct {
+	Output          hexutil.Bytes `json:"output"`
+	StateDiff       StateDiff     `json:"stateDiff"`
+	Trace           []*Trace      `json:"trace"`
+	TransactionHash *common.Hash  `json:"transactionHash,omitempty"`
+	VMTrace         *VMTrace      `json:"vmTrace"`
+}
+
+// VMTrace is the trace of the operations executed in a call frame.
+type VMTrace struct {
+	Code hexutil.Bytes  `json:"code"`
+	Ops  []*VMOperation `json:"ops"`
+}
+
+// VMOperation is a single operation of a VM trace.
+type VMOperation struct {
+	Cost uint64               `json:"cost"`
+	Ex   *VMExecutedOperation `json:"ex"` // Nil if the operation failed
+	PC   uint64               `json:"pc"`
+	Sub  *VMTrace             `json:"sub"` // Trace of the call frame created by the operation
+}
+
+// VMExecutedOperation holds the effects of an operation.
+type VMExecutedOperation struct {
+	Mem   *VMMemoryDiff  `json:"mem"`
+	Push  []*hexutil.Big `json:"push"`
+	Store *VMStorageDiff `json:"store"`
+	Used  uint64         `json:"used"` // Gas remaining after the operation
+}
+
+// VMMemoryDiff is a write to memory by an operation.
+type VMMemoryDiff struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// VMStorageDiff is a write to storage by an operation.
+type VMStorageDiff struct {
+	Key *hexutil.Big `json:"key"`
+	Val *hexutil.Big `json:"val"`
+}
+
+// errorString converts an EVM error to the corresponding Parity error message.
+func errorString(err error) string {
+	switch err.(type) {
+	case *vm.ErrStackUnderflow:
+		return "Stack underflow"
+	case *vm.ErrStackOverflow:
+		return "Out of stack"
+	case *vm.ErrInvalidOpCode:
+		return "Bad instruction"
+	}
+	switch err {
+	case vm.ErrExecutionReverted:
+		return "Reverted"
+	case vm.ErrOutOfGas, vm.ErrCodeStoreOutOfGas, vm.ErrGasUintOverflow:
+		return "Out of gas"
+	case vm.ErrInvalidJump:
+		return "Bad jump destination"
+	case vm.ErrDepth:
+		return "Out of stack"
+	case vm.ErrWriteProtection:
+		return "Mutable Call In Static Context"
+	case vm.ErrReturnDataOutOfBounds:
+		return "Out of bounds"
+	case vm.ErrInsufficientBalance:
+		return "Insufficient balance for transfer"
+	case vm.ErrContractAddressCollision:
+		return "Contract address collision"
+	case vm.ErrMaxCodeSizeExceeded:
+		return "Contract code size limit exceeded"
+	}
+	return err.Error()
+}
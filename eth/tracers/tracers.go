@@ -14,16 +14,54 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript transaction tracers, along with
+// a registry of native Go tracers.
 package tracers
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"unicode"
 
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers/internal/tracers"
 )
 
+// ResultTracer is a vm.Tracer assembling a JSON result, implemented by both the
+// JavaScript tracers and the native tracers.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the result of the trace, or any error encountered.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the trace with the given error, as soon as possible.
+	Stop(err error)
+}
+
+// native contains the constructors of the native tracers by name.
+var native = make(map[string]func() ResultTracer)
+
+// RegisterNative makes a native tracer available by name, taking precedence
+// over any JavaScript tracer of the same name. It is meant to be called from
+// the init function of the package implementing the tracer.
+func RegisterNative(name string, ctor func() ResultTracer) {
+	if _, ok := native[name]; ok {
+		panic(fmt.Sprintf("native tracer %q registered twice", name))
+	}
+	native[name] = ctor
+}
+
+// NewTracer creates the native tracer registered by the given name or, if there
+// is none, a JavaScript tracer from the given name or code.
+func NewTracer(code string) (ResultTracer, error) {
+	if ctor, ok := native[code]; ok {
+		return ctor(), nil
+	}
+	return New(code)
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
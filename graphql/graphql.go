@@ -19,7 +19,10 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -33,22 +36,53 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 var (
 	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+	errDebugDisabled  = errors.New("debugging fields are disabled, enable them with --graphql.debug")
+	errNoTracer       = errors.New("transaction tracing is not supported by this node")
 )
 
+// maxStorageRange is the maximum number of storage slots returned at once by
+// the `storageRange` accessor.
+const maxStorageRange = 1024
+
+// JSON is an arbitrary JSON value, embedded as is in the responses.
+type JSON json.RawMessage
+
+// ImplementsGraphQLType returns true if JSON implements the specified GraphQL type.
+func (JSON) ImplementsGraphQLType(name string) bool { return name == "JSON" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	blob, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	*j = blob
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
 // Account represents an Ethereum account at a particular block.
 type Account struct {
-	backend       ethapi.Backend
+	r             *Resolver
 	address       common.Address
 	blockNrOrHash rpc.BlockNumberOrHash
 }
 
 // getState fetches the StateDB object for an account.
 func (a *Account) getState(ctx context.Context) (*state.StateDB, error) {
-	state, _, err := a.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
+	state, _, err := a.r.backend.StateAndHeaderByNumberOrHash(ctx, a.blockNrOrHash)
 	return state, err
 }
 
@@ -88,9 +122,87 @@ func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash })
 	return state.GetState(a.address, args.Slot), nil
 }
 
+func (a *Account) StorageRange(ctx context.Context, args struct {
+	Start *common.Hash
+	Limit int32
+}) (*StorageRange, error) {
+	if !a.r.debug {
+		return nil, errDebugDisabled
+	}
+	if args.Limit <= 0 || args.Limit > maxStorageRange {
+		return nil, fmt.Errorf("storage range limit must be between 1 and %d", maxStorageRange)
+	}
+	state, err := a.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st := state.StorageTrie(a.address)
+	if st == nil {
+		return &StorageRange{}, nil
+	}
+	var start []byte
+	if args.Start != nil {
+		start = args.Start.Bytes()
+	}
+	it := trie.NewIterator(st.NodeIterator(start))
+	ret := new(StorageRange)
+	for i := int32(0); i < args.Limit && it.Next(); i++ {
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, err
+		}
+		entry := &StorageEntry{hash: common.BytesToHash(it.Key), value: common.BytesToHash(content)}
+		if preimage := st.GetKey(it.Key); preimage != nil {
+			slot := common.BytesToHash(preimage)
+			entry.slot = &slot
+		}
+		ret.entries = append(ret.entries, entry)
+	}
+	// Add the next hash so clients can continue downloading
+	if it.Next() {
+		next := common.BytesToHash(it.Key)
+		ret.nextHash = &next
+	}
+	return ret, nil
+}
+
+// StorageRange represents a range of the storage of an account, as returned by
+// the `storageRange` accessor.
+type StorageRange struct {
+	entries  []*StorageEntry
+	nextHash *common.Hash
+}
+
+func (r *StorageRange) Entries(ctx context.Context) []*StorageEntry {
+	return r.entries
+}
+
+func (r *StorageRange) NextHash(ctx context.Context) *common.Hash {
+	return r.nextHash
+}
+
+// StorageEntry represents a storage slot of an account.
+type StorageEntry struct {
+	hash  common.Hash
+	slot  *common.Hash
+	value common.Hash
+}
+
+func (e *StorageEntry) Hash(ctx context.Context) common.Hash {
+	return e.hash
+}
+
+func (e *StorageEntry) Slot(ctx context.Context) *common.Hash {
+	return e.slot
+}
+
+func (e *StorageEntry) Value(ctx context.Context) common.Hash {
+	return e.value
+}
+
 // Log represents an individual log message. All arguments are mandatory.
 type Log struct {
-	backend     ethapi.Backend
+	r           *Resolver
 	transaction *Transaction
 	log         *types.Log
 }
@@ -101,7 +213,7 @@ func (l *Log) Transaction(ctx context.Context) *Transaction {
 
 func (l *Log) Account(ctx context.Context, args BlockNumberArgs) *Account {
 	return &Account{
-		backend:       l.backend,
+		r:             l.r,
 		address:       l.log.Address,
 		blockNrOrHash: args.NumberOrLatest(),
 	}
@@ -119,30 +231,34 @@ func (l *Log) Data(ctx context.Context) hexutil.Bytes {
 	return hexutil.Bytes(l.log.Data)
 }
 
+func (l *Log) Removed(ctx context.Context) bool {
+	return l.log.Removed
+}
+
 // Transaction represents an Ethereum transaction.
 // backend and hash are mandatory; all others will be fetched when required.
 type Transaction struct {
-	backend ethapi.Backend
-	hash    common.Hash
-	tx      *types.Transaction
-	block   *Block
-	index   uint64
+	r     *Resolver
+	hash  common.Hash
+	tx    *types.Transaction
+	block *Block
+	index uint64
 }
 
 // resolve returns the internal transaction object, fetching it if needed.
 func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, error) {
 	if t.tx == nil {
-		tx, blockHash, _, index := rawdb.ReadTransaction(t.backend.ChainDb(), t.hash)
+		tx, blockHash, _, index := rawdb.ReadTransaction(t.r.backend.ChainDb(), t.hash)
 		if tx != nil {
 			t.tx = tx
 			blockNrOrHash := rpc.BlockNumberOrHashWithHash(blockHash, false)
 			t.block = &Block{
-				backend:      t.backend,
+				r:            t.r,
 				numberOrHash: &blockNrOrHash,
 			}
 			t.index = index
 		} else {
-			t.tx = t.backend.GetPoolTransaction(t.hash)
+			t.tx = t.r.backend.GetPoolTransaction(t.hash)
 		}
 	}
 	return t.tx, nil
@@ -202,7 +318,7 @@ func (t *Transaction) To(ctx context.Context, args BlockNumberArgs) (*Account, e
 		return nil, nil
 	}
 	return &Account{
-		backend:       t.backend,
+		r:             t.r,
 		address:       *to,
 		blockNrOrHash: args.NumberOrLatest(),
 	}, nil
@@ -220,7 +336,7 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	from, _ := types.Sender(signer, tx)
 
 	return &Account{
-		backend:       t.backend,
+		r:             t.r,
 		address:       from,
 		blockNrOrHash: args.NumberOrLatest(),
 	}, nil
@@ -292,7 +408,7 @@ func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs)
 		return nil, err
 	}
 	return &Account{
-		backend:       t.backend,
+		r:             t.r,
 		address:       receipt.ContractAddress,
 		blockNrOrHash: args.NumberOrLatest(),
 	}, nil
@@ -306,7 +422,7 @@ func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
 	ret := make([]*Log, 0, len(receipt.Logs))
 	for _, log := range receipt.Logs {
 		ret = append(ret, &Log{
-			backend:     t.backend,
+			r:           t.r,
 			transaction: t,
 			log:         log,
 		})
@@ -314,6 +430,41 @@ func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
 	return &ret, nil
 }
 
+func (t *Transaction) RawReceipt(ctx context.Context) (*hexutil.Bytes, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	raw, err := receipt.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Bytes)(&raw), nil
+}
+
+func (t *Transaction) Trace(ctx context.Context, args struct{ Tracer *string }) (*JSON, error) {
+	if !t.r.debug {
+		return nil, errDebugDisabled
+	}
+	if t.r.tracer == nil {
+		return nil, errNoTracer
+	}
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	result, err := t.r.tracer.TraceTransaction(ctx, t.hash, args.Tracer)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	ret := JSON(blob)
+	return &ret, nil
+}
+
 func (t *Transaction) R(ctx context.Context) (hexutil.Big, error) {
 	tx, err := t.resolve(ctx)
 	if err != nil || tx == nil {
@@ -347,7 +498,7 @@ type BlockType int
 // backend, and numberOrHash are mandatory. All other fields are lazily fetched
 // when required.
 type Block struct {
-	backend      ethapi.Backend
+	r            *Resolver
 	numberOrHash *rpc.BlockNumberOrHash
 	hash         common.Hash
 	header       *types.Header
@@ -366,7 +517,7 @@ func (b *Block) resolve(ctx context.Context) (*types.Block, error) {
 		b.numberOrHash = &latest
 	}
 	var err error
-	b.block, err = b.backend.BlockByNumberOrHash(ctx, *b.numberOrHash)
+	b.block, err = b.r.backend.BlockByNumberOrHash(ctx, *b.numberOrHash)
 	if b.block != nil && b.header == nil {
 		b.header = b.block.Header()
 		if hash, ok := b.numberOrHash.Hash(); ok {
@@ -386,9 +537,9 @@ func (b *Block) resolveHeader(ctx context.Context) (*types.Header, error) {
 	var err error
 	if b.header == nil {
 		if b.hash != (common.Hash{}) {
-			b.header, err = b.backend.HeaderByHash(ctx, b.hash)
+			b.header, err = b.r.backend.HeaderByHash(ctx, b.hash)
 		} else {
-			b.header, err = b.backend.HeaderByNumberOrHash(ctx, *b.numberOrHash)
+			b.header, err = b.r.backend.HeaderByNumberOrHash(ctx, *b.numberOrHash)
 		}
 	}
 	return b.header, err
@@ -406,7 +557,7 @@ func (b *Block) resolveReceipts(ctx context.Context) ([]*types.Receipt, error) {
 			}
 			hash = header.Hash()
 		}
-		receipts, err := b.backend.GetReceipts(ctx, hash)
+		receipts, err := b.r.backend.GetReceipts(ctx, hash)
 		if err != nil {
 			return nil, err
 		}
@@ -461,7 +612,7 @@ func (b *Block) Parent(ctx context.Context) (*Block, error) {
 	if b.header != nil && b.header.Number.Uint64() > 0 {
 		num := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(b.header.Number.Uint64() - 1))
 		return &Block{
-			backend:      b.backend,
+			r:            b.r,
 			numberOrHash: &num,
 			hash:         b.header.ParentHash,
 		}, nil
@@ -551,7 +702,7 @@ func (b *Block) Ommers(ctx context.Context) (*[]*Block, error) {
 	for _, uncle := range block.Uncles() {
 		blockNumberOrHash := rpc.BlockNumberOrHashWithHash(uncle.Hash(), false)
 		ret = append(ret, &Block{
-			backend:      b.backend,
+			r:            b.r,
 			numberOrHash: &blockNumberOrHash,
 			header:       uncle,
 		})
@@ -584,7 +735,7 @@ func (b *Block) TotalDifficulty(ctx context.Context) (hexutil.Big, error) {
 		}
 		h = header.Hash()
 	}
-	return hexutil.Big(*b.backend.GetTd(ctx, h)), nil
+	return hexutil.Big(*b.r.backend.GetTd(ctx, h)), nil
 }
 
 // BlockNumberArgs encapsulates arguments to accessors that specify a block number.
@@ -617,7 +768,7 @@ func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) (*Account, erro
 		return nil, err
 	}
 	return &Account{
-		backend:       b.backend,
+		r:             b.r,
 		address:       header.Coinbase,
 		blockNrOrHash: args.NumberOrLatest(),
 	}, nil
@@ -640,11 +791,11 @@ func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
 	ret := make([]*Transaction, 0, len(block.Transactions()))
 	for i, tx := range block.Transactions() {
 		ret = append(ret, &Transaction{
-			backend: b.backend,
-			hash:    tx.Hash(),
-			tx:      tx,
-			block:   b,
-			index:   uint64(i),
+			r:     b.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			block: b,
+			index: uint64(i),
 		})
 	}
 	return &ret, nil
@@ -661,14 +812,30 @@ func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (
 	}
 	tx := txs[args.Index]
 	return &Transaction{
-		backend: b.backend,
-		hash:    tx.Hash(),
-		tx:      tx,
-		block:   b,
-		index:   uint64(args.Index),
+		r:     b.r,
+		hash:  tx.Hash(),
+		tx:    tx,
+		block: b,
+		index: uint64(args.Index),
 	}, nil
 }
 
+func (b *Block) RawReceipts(ctx context.Context) (*[]hexutil.Bytes, error) {
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil || receipts == nil {
+		return nil, err
+	}
+	ret := make([]hexutil.Bytes, 0, len(receipts))
+	for _, receipt := range receipts {
+		raw, err := receipt.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, raw)
+	}
+	return &ret, nil
+}
+
 func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block, error) {
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
@@ -681,7 +848,7 @@ func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block
 	uncle := uncles[args.Index]
 	blockNumberOrHash := rpc.BlockNumberOrHashWithHash(uncle.Hash(), false)
 	return &Block{
-		backend:      b.backend,
+		r:            b.r,
 		numberOrHash: &blockNumberOrHash,
 		header:       uncle,
 	}, nil
@@ -708,22 +875,27 @@ type BlockFilterCriteria struct {
 
 // runFilter accepts a filter and executes it, returning all its results as
 // `Log` objects.
-func runFilter(ctx context.Context, be ethapi.Backend, filter *filters.Filter) ([]*Log, error) {
+func runFilter(ctx context.Context, r *Resolver, filter *filters.Filter) ([]*Log, error) {
 	logs, err := filter.Logs(ctx)
 	if err != nil || logs == nil {
 		return nil, err
 	}
 	ret := make([]*Log, 0, len(logs))
 	for _, log := range logs {
-		ret = append(ret, &Log{
-			backend:     be,
-			transaction: &Transaction{backend: be, hash: log.TxHash},
-			log:         log,
-		})
+		ret = append(ret, newLog(r, log))
 	}
 	return ret, nil
 }
 
+// newLog wraps a log entry in a `Log` object.
+func newLog(r *Resolver, log *types.Log) *Log {
+	return &Log{
+		r:           r,
+		transaction: &Transaction{r: r, hash: log.TxHash},
+		log:         log,
+	}
+}
+
 func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
 	var addresses []common.Address
 	if args.Filter.Addresses != nil {
@@ -742,10 +914,10 @@ func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteri
 		hash = header.Hash()
 	}
 	// Construct the range filter
-	filter := filters.NewBlockFilter(b.backend, hash, addresses, topics)
+	filter := filters.NewBlockFilter(b.r.backend, hash, addresses, topics)
 
 	// Run the filter and return all the logs
-	return runFilter(ctx, b.backend, filter)
+	return runFilter(ctx, b.r, filter)
 }
 
 func (b *Block) Account(ctx context.Context, args struct {
@@ -758,7 +930,7 @@ func (b *Block) Account(ctx context.Context, args struct {
 		}
 	}
 	return &Account{
-		backend:       b.backend,
+		r:             b.r,
 		address:       args.Address,
 		blockNrOrHash: *b.numberOrHash,
 	}, nil
@@ -794,16 +966,76 @@ func (c *CallResult) Status() hexutil.Uint64 {
 	return c.status
 }
 
+// StateOverride encapsulates the state overrides of an account for the `call`
+// accessor.
+type StateOverride struct {
+	Address   common.Address
+	Nonce     *hexutil.Uint64
+	Code      *hexutil.Bytes
+	Balance   *hexutil.Big
+	State     *[]StorageOverride
+	StateDiff *[]StorageOverride
+}
+
+// StorageOverride encapsulates the override of a storage slot.
+type StorageOverride struct {
+	Slot  common.Hash
+	Value common.Hash
+}
+
+// toStateOverride converts state overrides into their internal representation.
+func toStateOverride(overrides []StateOverride) (ethapi.StateOverride, error) {
+	storage := func(slots []StorageOverride) *map[common.Hash]common.Hash {
+		ret := make(map[common.Hash]common.Hash, len(slots))
+		for _, slot := range slots {
+			ret[slot.Slot] = slot.Value
+		}
+		return &ret
+	}
+	ret := make(ethapi.StateOverride, len(overrides))
+	for _, override := range overrides {
+		if _, ok := ret[override.Address]; ok {
+			return nil, fmt.Errorf("account %s overridden twice", override.Address.Hex())
+		}
+		account := ethapi.OverrideAccount{
+			Nonce: override.Nonce,
+			Code:  override.Code,
+		}
+		if override.Balance != nil {
+			account.Balance = &override.Balance
+		}
+		if override.State != nil {
+			account.State = storage(*override.State)
+		}
+		if override.StateDiff != nil {
+			account.StateDiff = storage(*override.StateDiff)
+		}
+		ret[override.Address] = account
+	}
+	return ret, nil
+}
+
 func (b *Block) Call(ctx context.Context, args struct {
-	Data ethapi.CallArgs
+	Data      ethapi.CallArgs
+	Overrides *[]StateOverride
 }) (*CallResult, error) {
+	var overrides ethapi.StateOverride
+	if args.Overrides != nil {
+		if !b.r.debug {
+			return nil, errDebugDisabled
+		}
+		var err error
+		if overrides, err = toStateOverride(*args.Overrides); err != nil {
+			return nil, err
+		}
+	}
 	if b.numberOrHash == nil {
 		_, err := b.resolve(ctx)
 		if err != nil {
 			return nil, err
 		}
 	}
-	result, err := ethapi.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, nil, vm.Config{}, 5*time.Second, b.backend.RPCGasCap())
+	result, err := ethapi.DoCall(ctx, b.r.backend, args.Data, *b.numberOrHash, overrides, vm.Config{}, 5*time.Second, b.r.backend.RPCGasCap())
 	if err != nil {
 		return nil, err
 	}
@@ -828,31 +1060,31 @@ func (b *Block) EstimateGas(ctx context.Context, args struct {
 			return hexutil.Uint64(0), err
 		}
 	}
-	gas, err := ethapi.DoEstimateGas(ctx, b.backend, args.Data, *b.numberOrHash, b.backend.RPCGasCap())
+	gas, err := ethapi.DoEstimateGas(ctx, b.r.backend, args.Data, *b.numberOrHash, b.r.backend.RPCGasCap())
 	return gas, err
 }
 
 type Pending struct {
-	backend ethapi.Backend
+	r *Resolver
 }
 
 func (p *Pending) TransactionCount(ctx context.Context) (int32, error) {
-	txs, err := p.backend.GetPoolTransactions()
+	txs, err := p.r.backend.GetPoolTransactions()
 	return int32(len(txs)), err
 }
 
 func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
-	txs, err := p.backend.GetPoolTransactions()
+	txs, err := p.r.backend.GetPoolTransactions()
 	if err != nil {
 		return nil, err
 	}
 	ret := make([]*Transaction, 0, len(txs))
 	for i, tx := range txs {
 		ret = append(ret, &Transaction{
-			backend: p.backend,
-			hash:    tx.Hash(),
-			tx:      tx,
-			index:   uint64(i),
+			r:     p.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			index: uint64(i),
 		})
 	}
 	return &ret, nil
@@ -863,7 +1095,7 @@ func (p *Pending) Account(ctx context.Context, args struct {
 }) *Account {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 	return &Account{
-		backend:       p.backend,
+		r:             p.r,
 		address:       args.Address,
 		blockNrOrHash: pendingBlockNr,
 	}
@@ -873,7 +1105,7 @@ func (p *Pending) Call(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (*CallResult, error) {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-	result, err := ethapi.DoCall(ctx, p.backend, args.Data, pendingBlockNr, nil, vm.Config{}, 5*time.Second, p.backend.RPCGasCap())
+	result, err := ethapi.DoCall(ctx, p.r.backend, args.Data, pendingBlockNr, nil, vm.Config{}, 5*time.Second, p.r.backend.RPCGasCap())
 	if err != nil {
 		return nil, err
 	}
@@ -893,12 +1125,15 @@ func (p *Pending) EstimateGas(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (hexutil.Uint64, error) {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-	return ethapi.DoEstimateGas(ctx, p.backend, args.Data, pendingBlockNr, p.backend.RPCGasCap())
+	return ethapi.DoEstimateGas(ctx, p.r.backend, args.Data, pendingBlockNr, p.r.backend.RPCGasCap())
 }
 
 // Resolver is the top-level object in the GraphQL hierarchy.
 type Resolver struct {
 	backend ethapi.Backend
+	events  *filters.EventSystem // Event system backing the subscriptions
+	debug   bool                 // Whether the debugging fields are enabled
+	tracer  Tracer               // Tracer of the debugging fields, nil if unsupported
 }
 
 func (r *Resolver) Block(ctx context.Context, args struct {
@@ -910,19 +1145,19 @@ func (r *Resolver) Block(ctx context.Context, args struct {
 		number := rpc.BlockNumber(uint64(*args.Number))
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(number)
 		block = &Block{
-			backend:      r.backend,
+			r:            r,
 			numberOrHash: &numberOrHash,
 		}
 	} else if args.Hash != nil {
 		numberOrHash := rpc.BlockNumberOrHashWithHash(*args.Hash, false)
 		block = &Block{
-			backend:      r.backend,
+			r:            r,
 			numberOrHash: &numberOrHash,
 		}
 	} else {
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 		block = &Block{
-			backend:      r.backend,
+			r:            r,
 			numberOrHash: &numberOrHash,
 		}
 	}
@@ -957,7 +1192,7 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	for i := from; i <= to; i++ {
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(i)
 		ret = append(ret, &Block{
-			backend:      r.backend,
+			r:            r,
 			numberOrHash: &numberOrHash,
 		})
 	}
@@ -965,13 +1200,13 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 }
 
 func (r *Resolver) Pending(ctx context.Context) *Pending {
-	return &Pending{r.backend}
+	return &Pending{r}
 }
 
 func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
 	tx := &Transaction{
-		backend: r.backend,
-		hash:    args.Hash,
+		r:    r,
+		hash: args.Hash,
 	}
 	// Resolve the transaction; if it doesn't exist, return nil.
 	t, err := tx.resolve(ctx)
@@ -985,7 +1220,7 @@ func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Has
 
 func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hexutil.Bytes }) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(args.Data, tx); err != nil {
+	if err := tx.UnmarshalBinary(args.Data); err != nil {
 		return common.Hash{}, err
 	}
 	hash, err := ethapi.SubmitTransaction(ctx, r.backend, tx)
@@ -1032,7 +1267,7 @@ func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria
 	}
 	// Construct the range filter
 	filter := filters.NewRangeFilter(filters.Backend(r.backend), begin, end, addresses, topics)
-	return runFilter(ctx, r.backend, filter)
+	return runFilter(ctx, r, filter)
 }
 
 func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
@@ -1088,3 +1323,117 @@ func (r *Resolver) Syncing() (*SyncState, error) {
 	// Otherwise gather the block sync stats
 	return &SyncState{progress}, nil
 }
+
+// subscriptionResolver resolves the fields of subscriptions.
+type subscriptionResolver struct {
+	r *Resolver
+}
+
+// NewBlock streams the blocks added to the canonical chain, until the
+// subscription is cancelled.
+func (s *subscriptionResolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	var (
+		headers = make(chan *types.Header)
+		sub     = s.r.events.SubscribeNewHeads(headers)
+		blocks  = make(chan *Block)
+	)
+	go func() {
+		defer close(blocks)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case header := <-headers:
+				numberOrHash := rpc.BlockNumberOrHashWithHash(header.Hash(), false)
+				block := &Block{
+					r:            s.r,
+					numberOrHash: &numberOrHash,
+					hash:         header.Hash(),
+					header:       header,
+				}
+				select {
+				case blocks <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+// NewPendingTransaction streams the transactions entering the transaction
+// pool, until the subscription is cancelled.
+func (s *subscriptionResolver) NewPendingTransaction(ctx context.Context) (<-chan *Transaction, error) {
+	var (
+		hashes = make(chan []common.Hash)
+		sub    = s.r.events.SubscribePendingTxs(hashes)
+		txs    = make(chan *Transaction)
+	)
+	go func() {
+		defer close(txs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-hashes:
+				for _, hash := range batch {
+					select {
+					case txs <- &Transaction{r: s.r, hash: hash}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return txs, nil
+}
+
+// Logs streams the log entries matching the filter, until the subscription is
+// cancelled.
+func (s *subscriptionResolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) (<-chan *Log, error) {
+	var crit ethereum.FilterQuery
+	if args.Filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*args.Filter.FromBlock))
+	}
+	if args.Filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(uint64(*args.Filter.ToBlock))
+	}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	matches := make(chan []*types.Log)
+	sub, err := s.r.events.SubscribeLogs(crit, matches)
+	if err != nil {
+		return nil, err
+	}
+	logs := make(chan *Log)
+	go func() {
+		defer close(logs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-matches:
+				for _, log := range batch {
+					select {
+					case logs <- newLog(s.r, log):
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return logs, nil
+}
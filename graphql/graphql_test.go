@@ -17,14 +17,29 @@
 package graphql
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,7 +49,7 @@ func TestBuildSchema(t *testing.T) {
 		t.Fatalf("could not create new node: %v", err)
 	}
 	// Make sure the schema can be parsed and matched up to the object model.
-	if err := newHandler(stack, nil, []string{}, []string{}); err != nil {
+	if err := newHandler(stack, nil, false, nil, []string{}, []string{}); err != nil {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
 }
@@ -90,6 +105,228 @@ func TestGraphQLHTTPOnSamePort_GQLRequest_Unsuccessful(t *testing.T) {
 	assert.Equal(t, "404 page not found\n", string(bodyBytes))
 }
 
+// Tests that queries and subscriptions are served over graphql-ws websocket connections
+func TestGraphQLWebsocket(t *testing.T) {
+	stack, err := node.New(&node.Config{HTTPHost: "127.0.0.1", WSHost: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("could not create node: %v", err)
+	}
+	defer stack.Close()
+
+	// The contract emits an empty log when called
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		genesis  = &genesisT.Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc: genesisT.GenesisAlloc{
+				sender:   {Balance: big.NewInt(vars.Ether)},
+				contract: {Balance: new(big.Int), Code: common.FromHex("0x60006000a0")},
+			},
+		}
+	)
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := New(stack, ethBackend.APIBackend, false, nil, []string{}, []string{"localhost"}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	var (
+		dialer   = websocket.Dialer{Subprotocols: []string{wsProtocol}}
+		endpoint = stack.WSEndpoint() + "/graphql"
+	)
+	// Upgrades for unknown virtual hosts are rejected
+	_, resp, err := dialer.Dial(endpoint, http.Header{"Host": {"evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("websocket upgrade for unknown host not rejected: %v", err)
+	}
+	conn, _, err := dialer.Dial(endpoint, http.Header{"Host": {"localhost"}})
+	if err != nil {
+		t.Fatalf("could not dial graphql websocket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	send := func(msg string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("could not send message %s: %v", msg, err)
+		}
+	}
+	expect := func(want string) {
+		var have wsMessage
+		if err := conn.ReadJSON(&have); err != nil {
+			t.Fatalf("could not read message: %v", err)
+		}
+		blob, _ := json.Marshal(have)
+		assert.Equal(t, want, string(blob))
+	}
+	send(`{"type":"connection_init"}`)
+	expect(`{"type":"connection_ack"}`)
+	expect(`{"type":"ka"}`)
+
+	// Queries complete after their result
+	send(`{"id":"1","type":"start","payload":{"query":"{block{number}}"}}`)
+	expect(`{"id":"1","type":"data","payload":{"data":{"block":{"number":"0x0"}}}}`)
+	expect(`{"id":"1","type":"complete"}`)
+
+	// Subscriptions run until stopped
+	send(`{"id":"2","type":"start","payload":{"query":"subscription{newBlock{number}}"}}`)
+	time.Sleep(100 * time.Millisecond) // Let the subscription be installed
+
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := core.GenerateChain(genesis.Config, core.MustCommitGenesis(db, genesis), ethash.NewFaker(), db, 3, func(i int, b *core.BlockGen) {
+		if i == 2 {
+			tx, _ := types.SignTx(types.NewTransaction(0, contract, nil, 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+			b.AddTx(tx)
+		}
+	})
+	if _, err := ethBackend.BlockChain().InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+	expect(`{"id":"2","type":"data","payload":{"data":{"newBlock":{"number":"0x1"}}}}`)
+	expect(`{"id":"2","type":"data","payload":{"data":{"newBlock":{"number":"0x2"}}}}`)
+
+	send(`{"id":"2","type":"stop"}`)
+	expect(`{"id":"2","type":"complete"}`)
+
+	// Logs are subscribed to with the same field as they are queried with
+	send(`{"id":"3","type":"start","payload":{"query":"subscription{logs(filter:{}){index account{address}removed}}"}}`)
+	time.Sleep(100 * time.Millisecond) // Let the subscription be installed
+
+	if _, err := ethBackend.BlockChain().InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+	expect(`{"id":"3","type":"data","payload":{"data":{"logs":{"index":0,"account":{"address":"0x000000000000000000000000000000000000c0de"},"removed":false}}}}`)
+
+	send(`{"id":"3","type":"stop"}`)
+	expect(`{"id":"3","type":"complete"}`)
+}
+
+// testTracer traces transactions with the debug API of a full node.
+type testTracer struct {
+	api *eth.PrivateDebugAPI
+}
+
+func (t *testTracer) TraceTransaction(ctx context.Context, hash common.Hash, tracer *string) (interface{}, error) {
+	return t.api.TraceTransaction(ctx, hash, &eth.TraceConfig{Tracer: tracer})
+}
+
+// Tests the debugging fields, tracing transactions and inspecting state
+func TestGraphQLDebugFields(t *testing.T) {
+	stack := createNode(t, false)
+	defer stack.Close()
+
+	// The contract stores its input in slot 0, and has slot 1 set from genesis
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		genesis  = &genesisT.Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc: genesisT.GenesisAlloc{
+				sender:   {Balance: big.NewInt(vars.Ether)},
+				contract: {Balance: new(big.Int), Code: common.FromHex("0x600035600055"), Storage: map[common.Hash]common.Hash{{1}: {2}}},
+			},
+		}
+	)
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := New(stack, ethBackend.APIBackend, true, &testTracer{eth.NewPrivateDebugAPI(ethBackend)}, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	db := rawdb.NewMemoryDatabase()
+	blocks, receipts := core.GenerateChain(genesis.Config, core.MustCommitGenesis(db, genesis), ethash.NewFaker(), db, 1, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(0, contract, nil, 100000, big.NewInt(1), common.Hash{3}.Bytes()), types.HomesteadSigner{}, key)
+		b.AddTx(tx)
+	})
+	if _, err := ethBackend.BlockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+	txHash := blocks[0].Transactions()[0].Hash()
+	rawReceipt, _ := receipts[0][0].MarshalBinary()
+
+	for i, tt := range []struct {
+		query string
+		want  string
+	}{
+		{
+			query: fmt.Sprintf(`{transaction(hash:"%s"){trace(tracer:"callTracer")}}`, txHash.Hex()),
+			want:  `"type":"CALL","from":"0x71562b71999873db5b286df957af199ec94617f7","to":"0x000000000000000000000000000000000000c0de"`,
+		},
+		{
+			query: `{block(number:1){call(data:{to:"0x000000000000000000000000000000000000c0de"},overrides:[{address:"0x000000000000000000000000000000000000c0de",code:"0x60015460005260206000f3",stateDiff:[{slot:"0x0000000000000000000000000000000000000000000000000000000000000001",value:"0x0000000000000000000000000000000000000000000000000000000000000004"}]}]){data}}}`,
+			want:  `{"data":{"block":{"call":{"data":"0x0000000000000000000000000000000000000000000000000000000000000004"}}}}`,
+		},
+		{
+			query: `{block(number:1){account(address:"0x000000000000000000000000000000000000c0de"){storageRange(limit:2){entries{value}nextHash}}}}`,
+			want:  `"nextHash":null`,
+		},
+		{
+			query: `{block(number:0){account(address:"0x000000000000000000000000000000000000c0de"){storageRange(limit:2){entries{value}}}}}`,
+			want:  `{"data":{"block":{"account":{"storageRange":{"entries":[{"value":"0x0200000000000000000000000000000000000000000000000000000000000000"}]}}}}}`,
+		},
+		{
+			query: `{block(number:1){rawReceipts}}`,
+			want:  fmt.Sprintf(`{"data":{"block":{"rawReceipts":["%s"]}}}`, hexutil.Bytes(rawReceipt)),
+		},
+	} {
+		body := strings.NewReader(fmt.Sprintf(`{"query": %q}`, tt.query))
+		resp, err := http.Post("http://127.0.0.1:9393/graphql", "application/json", body)
+		if err != nil {
+			t.Fatalf("test %d: could not issue graphql request: %v", i, err)
+		}
+		blob, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("test %d: could not read from response body: %v", i, err)
+		}
+		if !strings.Contains(string(blob), tt.want) {
+			t.Errorf("test %d: response mismatch: have %s, want %s", i, blob, tt.want)
+		}
+	}
+}
+
+// Tests that the debugging fields are rejected unless enabled
+func TestGraphQLDebugFieldsDisabled(t *testing.T) {
+	stack := createNode(t, true)
+	defer stack.Close()
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	body := strings.NewReader(`{"query": "{block{account(address:\"0x0000000000000000000000000000000000000000\"){storageRange(limit:1){nextHash}}}}"}`)
+	resp, err := http.Post("http://127.0.0.1:9393/graphql", "application/json", body)
+	if err != nil {
+		t.Fatalf("could not issue graphql request: %v", err)
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("could not read from response body: %v", err)
+	}
+	if !strings.Contains(string(blob), errDebugDisabled.Error()) {
+		t.Errorf("debugging field not rejected: %s", blob)
+	}
+	// Nodes without a tracer, like light clients, support the other fields only
+	tx := &Transaction{r: &Resolver{debug: true}}
+	if _, err := tx.Trace(context.Background(), struct{ Tracer *string }{}); err != errNoTracer {
+		t.Errorf("trace error mismatch: have %v, want %v", err, errNoTracer)
+	}
+}
+
 func createNode(t *testing.T, gqlEnabled bool) *node.Node {
 	stack, err := node.New(&node.Config{
 		HTTPHost: "127.0.0.1",
@@ -117,7 +354,7 @@ func createGQLService(t *testing.T, stack *node.Node, endpoint string) {
 	}
 
 	// create gql service
-	err = New(stack, ethBackend.APIBackend, []string{}, []string{})
+	err = New(stack, ethBackend.APIBackend, false, nil, []string{}, []string{})
 	if err != nil {
 		t.Fatalf("could not create graphql service: %v", err)
 	}
@@ -16,7 +16,39 @@
 
 package graphql
 
+// schema is the schema of the queries and mutations.
 const schema string = `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+` + schemaTypes
+
+// subscriptionSchema is the schema of the subscriptions, served over websocket
+// connections. Their fields are resolved apart from the queries, as both have a
+// logs field, so the subscription type is its query root too.
+const subscriptionSchema string = `
+    schema {
+        query: Subscription
+        subscription: Subscription
+    }
+
+    type Subscription {
+        # NewBlock fires for each new block added to the canonical chain,
+        # including blocks added by a chain reorganisation.
+        newBlock: Block!
+        # NewPendingTransaction fires for each transaction entering the pool of
+        # pending transactions.
+        newPendingTransaction: Transaction!
+        # Logs fires for each log entry matching the provided filter. Logs
+        # reverted by a chain reorganisation are delivered again, flagged as
+        # removed.
+        logs(filter: FilterCriteria!): Log!
+    }
+` + schemaTypes
+
+// schemaTypes holds the types shared by the schemas.
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -30,11 +62,8 @@ const schema string = `
     scalar BigInt
     # Long is a 64 bit unsigned integer.
     scalar Long
-
-    schema {
-        query: Query
-        mutation: Mutation
-    }
+    # JSON is an arbitrary JSON value, such as the result of a tracer.
+    scalar JSON
 
     # Account is an Ethereum account at a particular block.
     type Account {
@@ -52,6 +81,30 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+        # StorageRange returns up to limit storage slots of a contract account,
+        # ordered by the hash of their identifiers, starting at the slot whose
+        # hash is start or the first one after it. Requires the debugging fields
+        # to be enabled.
+        storageRange(start: Bytes32, limit: Int!): StorageRange!
+    }
+
+    # StorageRange is a range of the storage of a contract account.
+    type StorageRange {
+        # Entries are the storage slots of the range, ordered by hash.
+        entries: [StorageEntry!]!
+        # NextHash is the hash of the slot following the range, or null if the
+        # range includes the last slot.
+        nextHash: Bytes32
+    }
+
+    # StorageEntry is a storage slot of a contract account.
+    type StorageEntry {
+        # Hash is the hash of the slot identifier, which orders the storage.
+        hash: Bytes32!
+        # Slot is the 32 byte slot identifier, or null if its preimage is unknown.
+        slot: Bytes32
+        # Value is the value stored in the slot.
+        value: Bytes32!
     }
 
     # Log is an Ethereum event log.
@@ -67,6 +120,9 @@ const schema string = `
         data: Bytes!
         # Transaction is the transaction that generated this log entry.
         transaction: Transaction!
+        # Removed is true if this log was reverted due to a chain reorganisation.
+        # Only logs delivered by subscriptions can be removed.
+        removed: Boolean!
     }
 
     # Transaction is an Ethereum transaction.
@@ -115,6 +171,15 @@ const schema string = `
         # Logs is a list of log entries emitted by this transaction. If the
         # transaction has not yet been mined, this field will be null.
         logs: [Log!]
+        # RawReceipt is the canonical encoding of the receipt of this transaction,
+        # as hashed into the receipts root of its block. If the transaction has
+        # not yet been mined, this field will be null.
+        rawReceipt: Bytes
+        # Trace returns the result of re-executing this transaction with the
+        # given JavaScript or native tracer, or the struct logger if none is
+        # supplied. If the transaction has not yet been mined, this field will be
+        # null. Requires the debugging fields to be enabled.
+        trace(tracer: String): JSON
         r: BigInt!
         s: BigInt!
         v: BigInt!
@@ -200,12 +265,18 @@ const schema string = `
         # transactions are unavailable for this block, or if the index is out of
         # bounds, this field will be null.
         transactionAt(index: Int!): Transaction
+        # RawReceipts is the list of the canonical encodings of the receipts of
+        # this block, as hashed into its receipts root. If receipts are
+        # unavailable for this block, this field will be null.
+        rawReceipts: [Bytes!]
         # Logs returns a filtered set of logs from this block.
         logs(filter: BlockFilterCriteria!): [Log!]!
         # Account fetches an Ethereum account at the current block's state.
         account(address: Address!): Account!
-        # Call executes a local call operation at the current block's state.
-        call(data: CallData!): CallResult
+        # Call executes a local call operation at the current block's state. The
+        # state of some accounts can be overridden for the call, which requires
+        # the debugging fields to be enabled.
+        call(data: CallData!, overrides: [StateOverride!]): CallResult
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
@@ -228,6 +299,32 @@ const schema string = `
         data: Bytes
     }
 
+    # StateOverride overrides the state of an account for a local call. Only
+    # the supplied fields are overridden.
+    input StateOverride {
+        # Address is the address of the overridden account.
+        address: Address!
+        # Nonce replaces the nonce of the account.
+        nonce: Long
+        # Code replaces the code of the account.
+        code: Bytes
+        # Balance replaces the balance of the account, in wei.
+        balance: BigInt
+        # State replaces the whole storage of the account. It can't be supplied
+        # along with stateDiff.
+        state: [StorageOverride!]
+        # StateDiff replaces individual storage slots of the account.
+        stateDiff: [StorageOverride!]
+    }
+
+    # StorageOverride is the value of a storage slot overridden for a local call.
+    input StorageOverride {
+        # Slot is the 32 byte slot identifier.
+        slot: Bytes32!
+        # Value is the value stored in the slot.
+        value: Bytes32!
+    }
+
     # CallResult is the result of a local call operation.
     type CallResult {
         # Data is the return data of the called contract.
@@ -17,32 +17,58 @@
 package graphql
 
 import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 )
 
-// New constructs a new GraphQL service instance.
-func New(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error {
+// Tracer traces transactions for the debugging fields, using the named tracer
+// or the struct logger if nil.
+type Tracer interface {
+	TraceTransaction(ctx context.Context, hash common.Hash, tracer *string) (interface{}, error)
+}
+
+// New constructs a new GraphQL service instance. The debugging fields are only
+// enabled if debug is set, tracing transactions if a tracer is supplied too.
+func New(stack *node.Node, backend ethapi.Backend, debug bool, tracer Tracer, cors, vhosts []string) error {
 	if backend == nil {
 		panic("missing backend")
 	}
 	// check if http server with given endpoint exists and enable graphQL on it
-	return newHandler(stack, backend, cors, vhosts)
+	return newHandler(stack, backend, debug, tracer, cors, vhosts)
+}
+
+// fullNodeBackend is implemented by the backends of full nodes, which announce
+// the logs of new blocks themselves.
+type fullNodeBackend interface {
+	Miner() *miner.Miner
 }
 
 // newHandler returns a new `http.Handler` that will answer GraphQL queries.
+// Subscriptions are served over websocket connections on the same endpoint.
 // It additionally exports an interactive query browser on the / endpoint.
-func newHandler(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error {
-	q := Resolver{backend}
-
+func newHandler(stack *node.Node, backend ethapi.Backend, debug bool, tracer Tracer, cors, vhosts []string) error {
+	q := Resolver{backend: backend, debug: debug, tracer: tracer}
+	if backend != nil {
+		_, full := backend.(fullNodeBackend)
+		q.events = filters.NewEventSystem(backend, !full)
+	}
 	s, err := graphql.ParseSchema(schema, &q)
 	if err != nil {
 		return err
 	}
+	subs, err := graphql.ParseSchema(subscriptionSchema, &subscriptionResolver{r: &q})
+	if err != nil {
+		return err
+	}
 	h := &relay.Handler{Schema: s}
-	handler := node.NewHTTPHandlerStack(h, cors, vhosts)
+	handler := node.NewWSHandlerStack(newWSHandler(s, subs, cors, node.NewHTTPHandlerStack(h, cors, vhosts)), vhosts)
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
 	stack.RegisterHandler("GraphQL", "/graphql", handler)
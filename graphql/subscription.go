@@ -0,0 +1,314 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Message types of the graphql-ws protocol, as defined by the Apollo
+// subscriptions-transport-ws library.
+const (
+	gqlConnectionInit      = "connection_init"      // Client -> Server
+	gqlConnectionAck       = "connection_ack"       // Server -> Client
+	gqlConnectionError     = "connection_error"     // Server -> Client
+	gqlConnectionKeepAlive = "ka"                   // Server -> Client
+	gqlConnectionTerminate = "connection_terminate" // Client -> Server
+	gqlStart               = "start"                // Client -> Server
+	gqlData                = "data"                 // Server -> Client
+	gqlError               = "error"                // Server -> Client
+	gqlComplete            = "complete"             // Server -> Client
+	gqlStop                = "stop"                 // Client -> Server
+)
+
+const (
+	wsProtocol        = "graphql-ws"
+	wsReadLimit       = 1024 * 1024
+	wsKeepAlive       = 30 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+	wsReadBufferSize  = 1024
+	wsWriteBufferSize = 1024
+	wsMaxOperations   = 128 // Maximum number of running operations per connection
+)
+
+// wsMessage is a message of the graphql-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsStartPayload is the payload of a start message, holding the operation to
+// execute.
+type wsStartPayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// wsSubscriptionError is the error reported by a schema executing a subscription.
+const wsSubscriptionError = "graphql-ws protocol header is missing"
+
+// wsHandler serves the GraphQL schemas over websocket connections, using the
+// graphql-ws protocol. Other requests are passed on to the next handler.
+type wsHandler struct {
+	schema        *graphql.Schema // Schema of the queries and mutations
+	subscriptions *graphql.Schema // Schema of the subscriptions
+	upgrader      websocket.Upgrader
+	next          http.Handler
+}
+
+// newWSHandler creates a graphql-ws handler accepting connections from the
+// given origins, besides the ones of the same host.
+func newWSHandler(schema, subscriptions *graphql.Schema, origins []string, next http.Handler) *wsHandler {
+	return &wsHandler{
+		schema:        schema,
+		subscriptions: subscriptions,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  wsReadBufferSize,
+			WriteBufferSize: wsWriteBufferSize,
+			Subprotocols:    []string{wsProtocol},
+			CheckOrigin: func(r *http.Request) bool {
+				return checkOrigin(r, origins)
+			},
+		},
+		next: next,
+	}
+}
+
+// checkOrigin reports whether a websocket connection from the origin of the
+// request is allowed.
+func checkOrigin(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // Not a browser
+	}
+	for _, allowed := range origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	if conn.Subprotocol() != wsProtocol {
+		log.Debug("GraphQL websocket subprotocol not supported", "protocols", websocket.Subprotocols(r))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseProtocolError, "unsupported subprotocol"))
+		conn.Close()
+		return
+	}
+	newWSConn(conn, h.schema, h.subscriptions).serve()
+}
+
+// wsConn is a graphql-ws connection, running the operations started by the
+// client until they complete or are stopped.
+type wsConn struct {
+	conn          *websocket.Conn
+	schema        *graphql.Schema
+	subscriptions *graphql.Schema
+
+	ops   map[string]context.CancelFunc // Running operations by id
+	opsMu sync.Mutex
+	wg    sync.WaitGroup
+
+	writeMu sync.Mutex
+}
+
+func newWSConn(conn *websocket.Conn, schema, subscriptions *graphql.Schema) *wsConn {
+	return &wsConn{
+		conn:          conn,
+		schema:        schema,
+		subscriptions: subscriptions,
+		ops:           make(map[string]context.CancelFunc),
+	}
+}
+
+// serve reads messages from the client until the connection is terminated,
+// then stops all the running operations.
+func (c *wsConn) serve() {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		initialised bool
+	)
+	defer func() {
+		cancel()
+		c.wg.Wait()
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(wsReadLimit)
+
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug("GraphQL websocket read failed", "err", err)
+			}
+			return
+		}
+		switch msg.Type {
+		case gqlConnectionInit:
+			c.write(&wsMessage{Type: gqlConnectionAck})
+			if !initialised {
+				initialised = true
+				c.write(&wsMessage{Type: gqlConnectionKeepAlive})
+				c.wg.Add(1)
+				go c.keepAlive(ctx)
+			}
+
+		case gqlStart:
+			c.start(ctx, msg.ID, msg.Payload)
+
+		case gqlStop:
+			c.opsMu.Lock()
+			if stop, ok := c.ops[msg.ID]; ok {
+				stop()
+			}
+			c.opsMu.Unlock()
+
+		case gqlConnectionTerminate:
+			return
+
+		default:
+			c.writeError(gqlConnectionError, msg.ID, "unknown message type "+msg.Type)
+		}
+	}
+}
+
+// keepAlive periodically notifies the client that the connection is alive.
+func (c *wsConn) keepAlive(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(wsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(&wsMessage{Type: gqlConnectionKeepAlive}); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// start runs an operation, sending its results to the client until it
+// completes or is stopped. Queries and mutations complete after their single
+// result.
+func (c *wsConn) start(ctx context.Context, id string, payload json.RawMessage) {
+	var op wsStartPayload
+	if err := json.Unmarshal(payload, &op); err != nil {
+		c.writeError(gqlError, id, "invalid operation: "+err.Error())
+		return
+	}
+	c.opsMu.Lock()
+	defer c.opsMu.Unlock()
+
+	if _, ok := c.ops[id]; ok {
+		c.writeError(gqlError, id, "operation "+id+" already running")
+		return
+	}
+	if len(c.ops) >= wsMaxOperations {
+		c.writeError(gqlError, id, "too many running operations")
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.ops[id] = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer func() {
+			c.opsMu.Lock()
+			delete(c.ops, id)
+			c.opsMu.Unlock()
+			cancel()
+
+			c.wg.Done()
+		}()
+		responses, err := c.execute(ctx, &op)
+		if err != nil {
+			c.writeError(gqlError, id, err.Error())
+			return
+		}
+		// Drain the responses even once stopped, the schema blocks until they are
+		// delivered.
+		for resp := range responses {
+			if ctx.Err() != nil {
+				continue
+			}
+			blob, err := json.Marshal(resp)
+			if err != nil {
+				log.Warn("Failed to encode GraphQL response", "err", err)
+				continue
+			}
+			c.write(&wsMessage{ID: id, Type: gqlData, Payload: blob})
+		}
+		c.write(&wsMessage{ID: id, Type: gqlComplete})
+	}()
+}
+
+// execute runs an operation, returning its results. Queries and mutations run
+// against the schema, and subscriptions, which it refuses to execute, against
+// the subscription schema.
+func (c *wsConn) execute(ctx context.Context, op *wsStartPayload) (<-chan interface{}, error) {
+	resp := c.schema.Exec(ctx, op.Query, op.OperationName, op.Variables)
+	if len(resp.Errors) == 1 && resp.Errors[0].Message == wsSubscriptionError {
+		return c.subscriptions.Subscribe(ctx, op.Query, op.OperationName, op.Variables)
+	}
+	results := make(chan interface{}, 1)
+	results <- resp
+	close(results)
+	return results, nil
+}
+
+// writeError sends an error message to the client.
+func (c *wsConn) writeError(typ string, id string, message string) error {
+	blob, _ := json.Marshal(map[string]string{"message": message})
+	return c.write(&wsMessage{ID: id, Type: typ, Payload: blob})
+}
+
+// write sends a message to the client.
+func (c *wsConn) write(msg *wsMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(msg)
+}
@@ -28,10 +28,13 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/aleth"
 	"github.com/ethereum/go-ethereum/params/types/chipprgeth"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/ethereum/go-ethereum/params/types/parity"
+	"github.com/ethereum/go-ethereum/params/types/pyethereum"
+	"github.com/ethereum/go-ethereum/params/types/retesteth"
 	"github.com/ethereum/go-ethereum/params/vars"
 	"github.com/ethereum/go-ethereum/tests"
 	"github.com/go-test/deep"
@@ -247,3 +250,114 @@ func TestParityGeneses(t *testing.T) {
 		}
 	}
 }
+
+// TestAlethRetestethGeneses shows that the default configurations survive
+// conversion to and from the Aleth and retesteth formats, including JSON encoding.
+func TestAlethRetestethGeneses(t *testing.T) {
+	defaults := map[string]*genesisT.Genesis{
+		"foundation":  params.DefaultGenesisBlock(),
+		"classic":     params.DefaultClassicGenesisBlock(),
+		"mordor":      params.DefaultMordorGenesisBlock(),
+		"kotti":       params.DefaultKottiGenesisBlock(),
+		"ropsten":     params.DefaultRopstenGenesisBlock(),
+		"rinkeby":     params.DefaultRinkebyGenesisBlock(),
+		"goerli":      params.DefaultGoerliGenesisBlock(),
+		"yolov1":      params.DefaultYoloV1GenesisBlock(),
+		"social":      params.DefaultSocialGenesisBlock(),
+		"ethersocial": params.DefaultEthersocialGenesisBlock(),
+		"mix":         params.DefaultMixGenesisBlock(),
+	}
+	for name, gen := range defaults {
+		for _, format := range []func() ctypes.Configurator{
+			func() ctypes.Configurator { return &aleth.AlethGenesisSpec{} },
+			func() ctypes.Configurator { return &retesteth.RetestethGenesisSpec{} },
+		} {
+			spec := format()
+			if err := confp.Convert(gen, spec); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if err := confp.Equivalent(gen, spec); err != nil {
+				t.Errorf("%s %T: equivalence: %v", name, spec, err)
+			}
+
+			b, err := json.Marshal(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read := format()
+			if err := json.Unmarshal(b, read); err != nil {
+				t.Fatal(err)
+			}
+			if err := confp.Equivalent(gen, read); err != nil {
+				t.Errorf("%s %T: json round trip equivalence: %v", name, spec, err)
+			}
+
+			genc := &genesisT.Genesis{
+				Config: &chipprgeth.ChipprGethChainConfig{},
+			}
+			if err := confp.Convert(read, genc); err != nil {
+				t.Fatal(err)
+			}
+			if err := confp.Equivalent(gen, genc); err != nil {
+				t.Errorf("%s %T: back-conversion equivalence: %v", name, spec, err)
+			}
+			want, got := core.GenesisToBlock(gen, nil), core.GenesisToBlock(genc, nil)
+			if want.Hash() != got.Hash() {
+				t.Errorf("%s %T: mismatch gen hash, want: %s, got: %s", name, spec, want.Hash().Hex(), got.Hash().Hex())
+			}
+		}
+	}
+}
+
+// TestPyethereumGeneses shows that the default configurations survive conversion
+// to and from the pyethereum format, including JSON encoding.
+func TestPyethereumGeneses(t *testing.T) {
+	defaults := map[string]*genesisT.Genesis{
+		"foundation":  params.DefaultGenesisBlock(),
+		"classic":     params.DefaultClassicGenesisBlock(),
+		"mordor":      params.DefaultMordorGenesisBlock(),
+		"kotti":       params.DefaultKottiGenesisBlock(),
+		"ropsten":     params.DefaultRopstenGenesisBlock(),
+		"rinkeby":     params.DefaultRinkebyGenesisBlock(),
+		"goerli":      params.DefaultGoerliGenesisBlock(),
+		"yolov1":      params.DefaultYoloV1GenesisBlock(),
+		"social":      params.DefaultSocialGenesisBlock(),
+		"ethersocial": params.DefaultEthersocialGenesisBlock(),
+		"mix":         params.DefaultMixGenesisBlock(),
+	}
+	for name, gen := range defaults {
+		spec := &pyethereum.PyEthereumGenesisSpec{}
+		if err := confp.Convert(gen, spec); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := confp.Equivalent(gen, spec); err != nil {
+			t.Errorf("%s: equivalence: %v", name, err)
+		}
+
+		b, err := json.Marshal(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read := &pyethereum.PyEthereumGenesisSpec{}
+		if err := json.Unmarshal(b, read); err != nil {
+			t.Fatal(err)
+		}
+		if err := confp.Equivalent(gen, read); err != nil {
+			t.Errorf("%s: json round trip equivalence: %v", name, err)
+		}
+
+		genc := &genesisT.Genesis{
+			Config: &chipprgeth.ChipprGethChainConfig{},
+		}
+		if err := confp.Convert(read, genc); err != nil {
+			t.Fatal(err)
+		}
+		if err := confp.Equivalent(gen, genc); err != nil {
+			t.Errorf("%s: back-conversion equivalence: %v", name, err)
+		}
+		want, got := core.GenesisToBlock(gen, nil), core.GenesisToBlock(genc, nil)
+		if want.Hash() != got.Hash() {
+			t.Errorf("%s: mismatch gen hash, want: %s, got: %s", name, want.Hash().Hex(), got.Hash().Hex())
+		}
+	}
+}
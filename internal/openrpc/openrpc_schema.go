@@ -1066,6 +1066,92 @@ const OpenRPCSchema = `
             "type": "boolean"
           }
         }
+      },
+      {
+        "name": "trace_block",
+        "summary": "Returns the traces of all transactions of a block, along with the traces of its mining rewards.",
+        "params": [
+          {
+            "$ref": "#/components/contentDescriptors/BlockNumber"
+          }
+        ],
+        "result": {
+          "name": "blockTraces",
+          "schema": {
+            "$ref": "#/components/schemas/Traces"
+          }
+        }
+      },
+      {
+        "name": "trace_transaction",
+        "summary": "Returns the traces of a transaction, or null if the transaction is not known.",
+        "params": [
+          {
+            "$ref": "#/components/contentDescriptors/TransactionHash"
+          }
+        ],
+        "result": {
+          "name": "transactionTraces",
+          "schema": {
+            "oneOf": [
+              {
+                "$ref": "#/components/schemas/Traces"
+              },
+              {
+                "$ref": "#/components/schemas/Null"
+              }
+            ]
+          }
+        }
+      },
+      {
+        "name": "trace_filter",
+        "summary": "Returns the traces of a range of blocks matching the given origin and destination addresses.",
+        "params": [
+          {
+            "name": "filter",
+            "required": true,
+            "schema": {
+              "$ref": "#/components/schemas/TraceFilter"
+            }
+          }
+        ],
+        "result": {
+          "name": "filterTraces",
+          "schema": {
+            "$ref": "#/components/schemas/Traces"
+          }
+        }
+      },
+      {
+        "name": "trace_replayBlockTransactions",
+        "summary": "Replays all transactions of a block, returning the requested trace types for each.",
+        "params": [
+          {
+            "$ref": "#/components/contentDescriptors/BlockNumber"
+          },
+          {
+            "name": "traceTypes",
+            "required": true,
+            "schema": {
+              "title": "traceTypes",
+              "type": "array",
+              "items": {
+                "$ref": "#/components/schemas/TraceType"
+              }
+            }
+          }
+        ],
+        "result": {
+          "name": "replayResults",
+          "schema": {
+            "title": "traceResultsSet",
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/TraceResults"
+            }
+          }
+        }
       }
     ],
     "components": {
@@ -1666,6 +1752,162 @@ const OpenRPCSchema = `
           "type": "string",
           "description": "Hex representation of a variable length byte array",
           "pattern": "^0x([a-fA-F0-9]?)+$"
+        },
+        "TraceType": {
+          "title": "traceType",
+          "type": "string",
+          "description": "The kind of trace to produce when replaying a transaction",
+          "enum": [
+            "trace",
+            "vmTrace",
+            "stateDiff"
+          ]
+        },
+        "Trace": {
+          "title": "trace",
+          "type": "object",
+          "description": "A call, create, suicide or reward, in Parity's flat trace format",
+          "properties": {
+            "action": {
+              "title": "traceAction",
+              "type": "object",
+              "description": "The action traced, whose fields depend on the trace type"
+            },
+            "blockHash": {
+              "$ref": "#/components/schemas/BlockHash"
+            },
+            "blockNumber": {
+              "title": "blockNumber",
+              "type": "integer"
+            },
+            "error": {
+              "title": "traceError",
+              "type": "string",
+              "description": "The failure of the traced call, in which case result is omitted"
+            },
+            "result": {
+              "title": "traceResult",
+              "oneOf": [
+                {
+                  "type": "object"
+                },
+                {
+                  "$ref": "#/components/schemas/Null"
+                }
+              ]
+            },
+            "subtraces": {
+              "title": "subtraces",
+              "type": "integer",
+              "description": "The number of calls made by the traced call"
+            },
+            "traceAddress": {
+              "title": "traceAddress",
+              "type": "array",
+              "description": "The position of the trace in the call tree of its transaction",
+              "items": {
+                "type": "integer"
+              }
+            },
+            "transactionHash": {
+              "$ref": "#/components/schemas/KeccakOrPending"
+            },
+            "transactionPosition": {
+              "title": "transactionPosition",
+              "oneOf": [
+                {
+                  "type": "integer"
+                },
+                {
+                  "$ref": "#/components/schemas/Null"
+                }
+              ]
+            },
+            "type": {
+              "title": "traceKind",
+              "type": "string",
+              "enum": [
+                "call",
+                "create",
+                "suicide",
+                "reward"
+              ]
+            }
+          }
+        },
+        "Traces": {
+          "title": "traces",
+          "type": "array",
+          "items": {
+            "$ref": "#/components/schemas/Trace"
+          }
+        },
+        "TraceFilter": {
+          "title": "traceFilter",
+          "type": "object",
+          "description": "A range of blocks and the addresses to match traces against",
+          "properties": {
+            "fromBlock": {
+              "$ref": "#/components/schemas/BlockNumber"
+            },
+            "toBlock": {
+              "$ref": "#/components/schemas/BlockNumber"
+            },
+            "fromAddress": {
+              "$ref": "#/components/schemas/Addresses"
+            },
+            "toAddress": {
+              "$ref": "#/components/schemas/Addresses"
+            },
+            "after": {
+              "title": "after",
+              "type": "integer",
+              "description": "The number of matching traces to skip"
+            },
+            "count": {
+              "title": "count",
+              "type": "integer",
+              "description": "The maximum number of traces to return"
+            }
+          }
+        },
+        "TraceResults": {
+          "title": "traceResults",
+          "type": "object",
+          "description": "The requested traces of a replayed transaction",
+          "properties": {
+            "output": {
+              "$ref": "#/components/schemas/Bytes"
+            },
+            "stateDiff": {
+              "title": "stateDiff",
+              "oneOf": [
+                {
+                  "type": "object"
+                },
+                {
+                  "$ref": "#/components/schemas/Null"
+                }
+              ]
+            },
+            "trace": {
+              "$ref": "#/components/schemas/Traces"
+            },
+            "transactionHash": {
+              "$ref": "#/components/schemas/TransactionHash"
+            },
+            "vmTrace": {
+              "title": "vmTrace",
+              "oneOf": [
+                {
+                  "type": "object"
+                },
+                {
+                  "$ref": "#/components/schemas/Null"
+                }
+              ]
+            }
+          }
         }
       },
       "contentDescriptors": {
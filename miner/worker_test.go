@@ -306,6 +306,83 @@ func testEmptyWork(t *testing.T, chainConfig ctypes.ChainConfigurator, engine co
 	}
 }
 
+// Tests that pending access list and dynamic fee transactions are included in
+// the mined blocks.
+func TestGenerateBlockWithTypedTxs(t *testing.T) {
+	config := *params.AllEthashProtocolChanges
+	config.BerlinBlock = big.NewInt(0)
+	config.LondonBlock = big.NewInt(0)
+
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	backend := newTestWorkerBackend(t, &config, engine, rawdb.NewMemoryDatabase(), 0)
+	w := newWorker(testConfig, &config, engine, backend, new(event.TypeMux), nil, false)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	signer := types.LatestSigner(&config)
+	price := new(big.Int).SetUint64(2 * vars.InitialBaseFee)
+	accessListTx, err := types.SignNewTx(testBankKey, signer, &types.AccessListTx{
+		ChainID:    config.GetChainID(),
+		Nonce:      0,
+		To:         &testUserAddress,
+		Value:      big.NewInt(1000),
+		Gas:        vars.TxGas + 2*vars.TxAccessListStorageKeyGas + vars.TxAccessListAddressGas,
+		GasPrice:   price,
+		AccessList: types.AccessList{{Address: testUserAddress, StorageKeys: []common.Hash{{}, {1}}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign access list transaction: %v", err)
+	}
+	dynamicFeeTx, err := types.SignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+		ChainID:   config.GetChainID(),
+		Nonce:     1,
+		To:        &testUserAddress,
+		Value:     big.NewInt(1000),
+		Gas:       vars.TxGas,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: price,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign dynamic fee transaction: %v", err)
+	}
+	for _, err := range backend.txPool.AddLocals([]*types.Transaction{accessListTx, dynamicFeeTx}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	taskCh := make(chan *task, 1)
+	w.newTaskHook = func(task *task) {
+		if len(task.receipts) == 2 {
+			select {
+			case taskCh <- task:
+			default:
+			}
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	select {
+	case task := <-taskCh:
+		txs := task.block.Transactions()
+		if txs[0].Type() != types.AccessListTxType || txs[1].Type() != types.DynamicFeeTxType {
+			t.Fatalf("transaction types mismatch: have %d and %d", txs[0].Type(), txs[1].Type())
+		}
+		for i, receipt := range task.receipts {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				t.Errorf("transaction %d failed", i)
+			}
+		}
+		if balance := task.state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(2000)) != 0 {
+			t.Errorf("account balance mismatch: have %d, want %d", balance, 2000)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("typed transactions not mined")
+	}
+}
+
 func TestStreamUncleBlock(t *testing.T) {
 	ethash := ethash.NewFaker()
 	defer ethash.Close()
@@ -184,6 +184,8 @@ func (api *privateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		Access:             api.node.config.HTTPAccess,
+		Limits:             api.node.config.RPCLimits,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -243,6 +245,8 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		Access:  api.node.config.WSAccess,
+		Limits:  api.node.config.RPCLimits,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
@@ -105,6 +105,10 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// RPCLimits bounds the batch length, response size and execution time of the
+	// calls served over the IPC, HTTP and WebSocket RPC interfaces.
+	RPCLimits rpc.ServerLimits `toml:",omitempty"`
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -137,6 +141,10 @@ type Config struct {
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
 
+	// HTTPAccess restricts the access to the HTTP RPC interface, requiring
+	// authentication and limiting the methods and call rate of the callers.
+	HTTPAccess RPCAccessConfig `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
@@ -163,6 +171,10 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSAccess restricts the access to the websocket RPC interface, requiring
+	// authentication and limiting the methods and call rate of the callers.
+	WSAccess RPCAccessConfig `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
@@ -177,6 +189,10 @@ type Config struct {
 	// Requests using ip address directly are not affected
 	GraphQLVirtualHosts []string `toml:",omitempty"`
 
+	// GraphQLDebug enables the GraphQL fields tracing transactions and inspecting
+	// historical state. These are expensive, so they are disabled by default.
+	GraphQLDebug bool `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -0,0 +1,304 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+)
+
+// maxRPCLimiters is the maximum number of callers tracked for rate limiting,
+// beyond which the least recently active caller is evicted to bound the memory use.
+const maxRPCLimiters = 16384
+
+// RPCAccessConfig restricts the access to a JSON-RPC listener. If neither a JWT
+// secret nor API keys are configured, requests are not authenticated, but the
+// method and rate limits still apply, keyed by the remote address.
+type RPCAccessConfig struct {
+	// JWTSecret is the hex encoded secret authenticating HS256 signed JSON web
+	// tokens, sent as bearer tokens. The tokens must carry an expiry (exp) claim.
+	JWTSecret string `toml:",omitempty"`
+
+	// APIKeys are static keys accepted either as bearer tokens, or in the
+	// X-API-Key header.
+	APIKeys []string `toml:",omitempty"`
+
+	// AllowMethods is the list of methods which may be called, with '*' matching
+	// any part of the method name (e.g. eth_*). If empty, all the methods of the
+	// exposed modules are allowed.
+	AllowMethods []string `toml:",omitempty"`
+
+	// DenyMethods is the list of methods which may not be called, taking
+	// precedence over AllowMethods.
+	DenyMethods []string `toml:",omitempty"`
+
+	// RateLimit is the number of calls per second allowed for each API key, JWT
+	// subject or unauthenticated remote address. Zero disables rate limiting.
+	RateLimit float64 `toml:",omitempty"`
+
+	// RateBurst is the number of calls which may exceed the rate limit at once.
+	// It defaults to the rate limit, rounded up.
+	RateBurst int `toml:",omitempty"`
+}
+
+// enabled returns whether any access restriction is configured.
+func (c *RPCAccessConfig) enabled() bool {
+	return c.JWTSecret != "" || len(c.APIKeys) > 0 || len(c.AllowMethods) > 0 || len(c.DenyMethods) > 0 || c.RateLimit > 0
+}
+
+var (
+	errMissingCredentials = errors.New("missing credentials")
+	errInvalidCredentials = errors.New("invalid credentials")
+	errInvalidToken       = errors.New("invalid token")
+	errExpiredToken       = errors.New("token expired")
+	errMissingExpiry      = errors.New("token without expiry")
+)
+
+// accessError is a JSON-RPC error denying a method call.
+type accessError struct {
+	code    int
+	message string
+}
+
+func (e *accessError) ErrorCode() int { return e.code }
+
+func (e *accessError) Error() string { return e.message }
+
+// rpcIdentityKey is the context key of the identity of an RPC connection.
+type rpcIdentityKey struct{}
+
+// rpcAccess enforces the access restrictions of a JSON-RPC listener.
+type rpcAccess struct {
+	secret []byte
+	keys   map[string]struct{}
+	allow  []string
+	deny   []string
+
+	limit    rate.Limit
+	burst    int
+	lock     sync.Mutex
+	limiters *lru.Cache // identity -> *rate.Limiter
+}
+
+// newRPCAccess creates the access restrictions of a listener, or nil if the
+// listener is unrestricted.
+func newRPCAccess(config RPCAccessConfig) (*rpcAccess, error) {
+	if !config.enabled() {
+		return nil, nil
+	}
+	access := &rpcAccess{
+		keys:  make(map[string]struct{}),
+		allow: config.AllowMethods,
+		deny:  config.DenyMethods,
+		limit: rate.Inf,
+	}
+	if config.JWTSecret != "" {
+		secret, err := hexutil.Decode(config.JWTSecret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret: %v", err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("JWT secret too short: have %d bytes, want at least 32", len(secret))
+		}
+		access.secret = secret
+	}
+	for _, key := range config.APIKeys {
+		if key == "" {
+			return nil, errors.New("empty API key")
+		}
+		access.keys[key] = struct{}{}
+	}
+	for _, pattern := range append(append([]string{}, config.AllowMethods...), config.DenyMethods...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern %q: %v", pattern, err)
+		}
+	}
+	if config.RateLimit > 0 {
+		access.limit, access.burst = rate.Limit(config.RateLimit), config.RateBurst
+		if access.burst <= 0 {
+			access.burst = int(config.RateLimit)
+			if float64(access.burst) < config.RateLimit {
+				access.burst++
+			}
+		}
+		access.limiters, _ = lru.New(maxRPCLimiters)
+	}
+	return access, nil
+}
+
+// authenticated returns whether the listener requires credentials.
+func (a *rpcAccess) authenticated() bool {
+	return a.secret != nil || len(a.keys) > 0
+}
+
+// handler returns an HTTP handler authenticating the requests before passing
+// them to next, with the identity of the caller in the request context.
+func (a *rpcAccess) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflight requests never carry credentials
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		identity, err := a.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rpcIdentityKey{}, identity)))
+	})
+}
+
+// authenticate checks the credentials of a request, returning the identity of
+// the caller the rate limits are accounted to.
+func (a *rpcAccess) authenticate(r *http.Request) (string, error) {
+	if !a.authenticated() {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return "addr:" + host, nil
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.authenticateKey(key)
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", errMissingCredentials
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	if a.secret != nil && strings.Count(token, ".") == 2 {
+		return a.authenticateJWT(token, time.Now())
+	}
+	return a.authenticateKey(token)
+}
+
+// authenticateKey checks a static API key.
+func (a *rpcAccess) authenticateKey(key string) (string, error) {
+	for known := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(known)) == 1 {
+			return "key:" + known, nil
+		}
+	}
+	return "", errInvalidCredentials
+}
+
+// authenticateJWT checks an HS256 signed JSON web token, along with its expiry
+// and not-before claims.
+func (a *rpcAccess) authenticateJWT(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errInvalidToken
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errInvalidCredentials
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if blob, err := base64.RawURLEncoding.DecodeString(parts[0]); err != nil || json.Unmarshal(blob, &header) != nil || header.Alg != "HS256" {
+		return "", errInvalidToken
+	}
+	var claims struct {
+		Subject   string   `json:"sub"`
+		Expiry    *float64 `json:"exp"`
+		NotBefore *float64 `json:"nbf"`
+	}
+	blob, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errInvalidToken
+	}
+	if err := json.Unmarshal(blob, &claims); err != nil {
+		return "", errInvalidToken
+	}
+	if claims.Expiry == nil {
+		return "", errMissingExpiry
+	}
+	if float64(now.Unix()) >= *claims.Expiry {
+		return "", errExpiredToken
+	}
+	if claims.NotBefore != nil && float64(now.Unix()) < *claims.NotBefore {
+		return "", errInvalidToken
+	}
+	return "jwt:" + claims.Subject, nil
+}
+
+// filter implements rpc.CallFilter, checking the method lists and the rate limit
+// of the caller.
+func (a *rpcAccess) filter(ctx context.Context, method string) error {
+	if !a.allowed(method) {
+		return &accessError{code: -32601, message: fmt.Sprintf("method %s not allowed", method)}
+	}
+	if a.limit == rate.Inf {
+		return nil
+	}
+	identity, _ := ctx.Value(rpcIdentityKey{}).(string)
+
+	a.lock.Lock()
+	var limiter *rate.Limiter
+	if cached, ok := a.limiters.Get(identity); ok {
+		limiter = cached.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(a.limit, a.burst)
+		a.limiters.Add(identity, limiter)
+	}
+	a.lock.Unlock()
+
+	if !limiter.Allow() {
+		return &accessError{code: -32005, message: "rate limit exceeded"}
+	}
+	return nil
+}
+
+// allowed returns whether the method lists permit calling a method.
+func (a *rpcAccess) allowed(method string) bool {
+	for _, pattern := range a.deny {
+		if matched, _ := path.Match(pattern, method); matched {
+			return false
+		}
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	for _, pattern := range a.allow {
+		if matched, _ := path.Match(pattern, method); matched {
+			return true
+		}
+	}
+	return false
+}
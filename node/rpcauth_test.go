@@ -0,0 +1,226 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+)
+
+var testJWTSecret = bytes.Repeat([]byte{0x42}, 32)
+
+// signTestJWT creates an HS256 signed JSON web token with the given claims.
+func signTestJWT(secret []byte, claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	blob, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(blob)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// rpcModulesCall posts an rpc_modules call with the given headers, returning the
+// HTTP status and the response body.
+func rpcModulesCall(t *testing.T, srv *httpServer, headers map[string]string) (int, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest("POST", "http://"+srv.listenAddr(), strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	req.Header.Set("content-type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRPCAccessJWT(t *testing.T) {
+	access, err := newRPCAccess(RPCAccessConfig{JWTSecret: hexutil.Encode(testJWTSecret)})
+	if err != nil {
+		t.Fatalf("failed to create access: %v", err)
+	}
+	now := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		token    string
+		identity string
+		err      error
+	}{
+		{signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice", "exp": now.Unix() + 1}), "jwt:alice", nil},
+		{signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice"}), "", errMissingExpiry},
+		{signTestJWT(testJWTSecret, map[string]interface{}{"sub": "bob", "exp": now.Unix() + 60, "nbf": now.Unix() - 60}), "jwt:bob", nil},
+		{signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice", "exp": now.Unix()}), "", errExpiredToken},
+		{signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice", "exp": now.Unix() + 120, "nbf": now.Unix() + 60}), "", errInvalidToken},
+		{signTestJWT(bytes.Repeat([]byte{0x43}, 32), map[string]interface{}{"sub": "alice", "exp": now.Unix() + 60}), "", errInvalidCredentials},
+		{"a.b.c", "", errInvalidToken},
+	}
+	for i, tt := range tests {
+		identity, err := access.authenticateJWT(tt.token, now)
+		if identity != tt.identity || err != tt.err {
+			t.Errorf("test %d: result mismatch: have %q, %v, want %q, %v", i, identity, err, tt.identity, tt.err)
+		}
+	}
+	if _, err := newRPCAccess(RPCAccessConfig{JWTSecret: "0x4242"}); err == nil {
+		t.Errorf("short JWT secret accepted")
+	}
+}
+
+func TestRPCAccessAuthentication(t *testing.T) {
+	srv := createAndStartServer(t, httpConfig{Access: RPCAccessConfig{
+		JWTSecret: hexutil.Encode(testJWTSecret),
+		APIKeys:   []string{"secret-key"},
+	}}, false, wsConfig{})
+	defer srv.stop()
+
+	tests := []struct {
+		headers map[string]string
+		status  int
+	}{
+		{nil, http.StatusUnauthorized},
+		{map[string]string{"X-API-Key": "secret-key"}, http.StatusOK},
+		{map[string]string{"X-API-Key": "wrong-key"}, http.StatusUnauthorized},
+		{map[string]string{"Authorization": "Bearer secret-key"}, http.StatusOK},
+		{map[string]string{"Authorization": "Basic secret-key"}, http.StatusUnauthorized},
+		{map[string]string{"Authorization": "Bearer " + signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice", "exp": time.Now().Unix() + 60})}, http.StatusOK},
+		{map[string]string{"Authorization": "Bearer " + signTestJWT(testJWTSecret, map[string]interface{}{"sub": "alice"})}, http.StatusUnauthorized},
+		{map[string]string{"Authorization": "Bearer " + signTestJWT(testJWTSecret, map[string]interface{}{"exp": 1})}, http.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		if status, body := rpcModulesCall(t, srv, tt.headers); status != tt.status {
+			t.Errorf("test %d: status mismatch: have %d, want %d (%s)", i, status, tt.status, body)
+		}
+	}
+}
+
+func TestRPCAccessMethods(t *testing.T) {
+	tests := []struct {
+		allow, deny []string
+		allowed     bool
+	}{
+		{[]string{"rpc_*"}, nil, true},
+		{[]string{"eth_*", "rpc_modules"}, nil, true},
+		{[]string{"eth_*"}, nil, false},
+		{nil, []string{"rpc_*"}, false},
+		{[]string{"rpc_*"}, []string{"rpc_modules"}, false},
+	}
+	for i, tt := range tests {
+		srv := createAndStartServer(t, httpConfig{Access: RPCAccessConfig{AllowMethods: tt.allow, DenyMethods: tt.deny}}, false, wsConfig{})
+		_, body := rpcModulesCall(t, srv, nil)
+		srv.stop()
+
+		if denied := strings.Contains(body, "not allowed"); denied == tt.allowed {
+			t.Errorf("test %d: access mismatch: have %s, want allowed %v", i, body, tt.allowed)
+		}
+	}
+	if _, err := newRPCAccess(RPCAccessConfig{AllowMethods: []string{"eth_["}}); err == nil {
+		t.Errorf("invalid method pattern accepted")
+	}
+}
+
+func TestRPCAccessRateLimit(t *testing.T) {
+	srv := createAndStartServer(t, httpConfig{Access: RPCAccessConfig{
+		APIKeys:   []string{"key-a", "key-b"},
+		RateLimit: 0.001,
+		RateBurst: 2,
+	}}, false, wsConfig{})
+	defer srv.stop()
+
+	// Each key has its own burst of calls
+	for _, key := range []string{"key-a", "key-b"} {
+		for i := 0; i < 3; i++ {
+			_, body := rpcModulesCall(t, srv, map[string]string{"X-API-Key": key})
+			if limited := strings.Contains(body, "rate limit exceeded"); limited != (i == 2) {
+				t.Errorf("key %s, call %d: rate limit mismatch: have %s", key, i, body)
+			}
+		}
+	}
+}
+
+func TestRPCAccessRateLimitEviction(t *testing.T) {
+	access, err := newRPCAccess(RPCAccessConfig{RateLimit: 0.001, RateBurst: 1})
+	if err != nil {
+		t.Fatalf("failed to create access: %v", err)
+	}
+	call := func(identity string) error {
+		return access.filter(context.WithValue(context.Background(), rpcIdentityKey{}, identity), "rpc_modules")
+	}
+	// Exhaust the limit of a caller staying active while others churn through
+	if err := call("active"); err != nil {
+		t.Fatalf("first call limited: %v", err)
+	}
+	for i := 0; i < maxRPCLimiters; i++ {
+		call(fmt.Sprintf("caller-%d", i))
+		if i%(maxRPCLimiters/4) == 0 {
+			if err := call("active"); err == nil {
+				t.Fatalf("active caller limit reset after %d callers", i)
+			}
+		}
+	}
+	if err := call("active"); err == nil {
+		t.Fatalf("active caller limit reset")
+	}
+	if access.limiters.Len() != maxRPCLimiters {
+		t.Errorf("tracked callers mismatch: have %d, want %d", access.limiters.Len(), maxRPCLimiters)
+	}
+}
+
+func TestRPCAccessWebsocket(t *testing.T) {
+	srv := createAndStartServer(t, httpConfig{}, true, wsConfig{Origins: []string{"*"}, Access: RPCAccessConfig{
+		APIKeys:     []string{"secret-key"},
+		DenyMethods: []string{"rpc_modules"},
+	}})
+	defer srv.stop()
+
+	if _, _, err := websocket.DefaultDialer.Dial("ws://"+srv.listenAddr(), nil); err == nil {
+		t.Fatalf("unauthenticated websocket connection accepted")
+	}
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+srv.listenAddr(), http.Header{"X-API-Key": []string{"secret-key"}})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`)); err != nil {
+		t.Fatalf("failed to send call: %v", err)
+	}
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("failed to read reply: %v", err)
+	}
+	if !strings.Contains(string(reply), "not allowed") {
+		t.Errorf("denied method called: %s", reply)
+	}
+}
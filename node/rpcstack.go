@@ -39,17 +39,22 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
+	Access             RPCAccessConfig
+	Limits             rpc.ServerLimits
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
 	Origins []string
 	Modules []string
+	Access  RPCAccessConfig
+	Limits  rpc.ServerLimits
 }
 
 type rpcHandler struct {
 	http.Handler
 	server *rpc.Server
+	access *rpcAccess // access restrictions, nil if unrestricted
 }
 
 type httpServer struct {
@@ -185,7 +190,12 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else if rpc != nil {
 		// Requests to a path below root are handled by the mux,
 		// which has all the handlers registered via Node.RegisterHandler.
-		// These are made available when RPC is enabled.
+		// These are made available when RPC is enabled, behind the same
+		// authentication.
+		if rpc.access != nil && rpc.access.authenticated() {
+			rpc.access.handler(&h.mux).ServeHTTP(w, r)
+			return
+		}
 		h.mux.ServeHTTP(w, r)
 		return
 	}
@@ -238,10 +248,22 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetLimits(config.Limits)
+
+	access, err := newRPCAccess(config.Access)
+	if err != nil {
+		return err
+	}
+	var handler http.Handler = srv
+	if access != nil {
+		srv.SetCallFilter(access.filter)
+		handler = access.handler(srv)
+	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
+		Handler: NewHTTPHandlerStack(handler, config.CorsAllowedOrigins, config.Vhosts),
 		server:  srv,
+		access:  access,
 	})
 	return nil
 }
@@ -270,10 +292,22 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetLimits(config.Limits)
+
+	access, err := newRPCAccess(config.Access)
+	if err != nil {
+		return err
+	}
+	handler := srv.WebsocketHandler(config.Origins)
+	if access != nil {
+		srv.SetCallFilter(access.filter)
+		handler = access.handler(handler)
+	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: srv.WebsocketHandler(config.Origins),
+		Handler: handler,
 		server:  srv,
+		access:  access,
 	})
 	return nil
 }
@@ -324,6 +358,12 @@ func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string) http.
 	return newGzipHandler(handler)
 }
 
+// NewWSHandlerStack returns a wrapped websocket handler, rejecting upgrade
+// requests for hosts not listed in vhosts.
+func NewWSHandlerStack(srv http.Handler, vhosts []string) http.Handler {
+	return newVHostHandler(vhosts, srv)
+}
+
 func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
@@ -428,14 +468,15 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	limits   rpc.ServerLimits
 
 	mu       sync.Mutex
 	listener net.Listener
 	srv      *rpc.Server
 }
 
-func newIPCServer(log log.Logger, endpoint string) *ipcServer {
-	return &ipcServer{log: log, endpoint: endpoint}
+func newIPCServer(log log.Logger, endpoint string, limits rpc.ServerLimits) *ipcServer {
+	return &ipcServer{log: log, endpoint: endpoint, limits: limits}
 }
 
 // Start starts the httpServer's http.Server
@@ -450,6 +491,7 @@ func (is *ipcServer) start(apis []rpc.API) error {
 	if err != nil {
 		return err
 	}
+	srv.SetLimits(is.limits)
 	is.log.Info("IPC endpoint opened", "url", is.endpoint)
 	is.listener, is.srv = listener, srv
 	return nil
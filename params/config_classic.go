@@ -73,7 +73,8 @@ var (
 		ECIP1017EraRounds:  big.NewInt(5000000),
 		ECIP1010PauseBlock: big.NewInt(3000000),
 		ECIP1010Length:     big.NewInt(2000000),
-		ECIP1092Transition: big.NewInt(100000000),
+		ECBP1100FBlock:     big.NewInt(11_380_000),
+		ECIP1099FBlock:     big.NewInt(11_700_000), // Etchash (DAG size limit)
 		RequireBlockHashes: map[uint64]common.Hash{
 			1920000: common.HexToHash("0x94365e3a8c0b35089c1d1195081fe7489b528a84b22199c916180db8b28ade7f"),
 			2500000: common.HexToHash("0xca12c63534f565899681965528d536c52cb05b7c48e269c2a6cb77ad864d878a"),
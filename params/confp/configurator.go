@@ -133,6 +133,9 @@ func compatible(head *uint64, a, b ctypes.ChainConfigurator) *ConfigCompatError
 	aFns, aNames := Transitions(a)
 	bFns, _ := Transitions(b)
 	for i, afn := range aFns {
+		if !isForkTransition(aNames[i]) {
+			continue
+		}
 		if err := func(c1, c2, head *uint64) *ConfigCompatError {
 			if isForkIncompatible(c1, c2, head) {
 				return NewCompatError("incompatible fork value: "+aNames[i], c1, c2)
@@ -247,13 +250,23 @@ func Transitions(conf ctypes.ChainConfigurator) (fns []func() *uint64, names []s
 	return fns, names
 }
 
+// isForkTransition reports whether the transition of the given getter name
+// changes the protocol. ECBP1100 (MESS) only affects the local choice of the
+// canonical chain, so it neither forks the chain nor requires a rewind.
+func isForkTransition(name string) bool {
+	return !strings.Contains(name, "ECBP1100")
+}
+
 // Forks returns non-nil, non <maxUin64>, unique sorted forks for a ChainConfigurator.
 func Forks(conf ctypes.ChainConfigurator) []uint64 {
 	var forks []uint64
 	var forksM = make(map[uint64]struct{}) // Will key for uniqueness as fork numbers are appended to slice.
 
-	transitions, _ := Transitions(conf)
-	for _, tr := range transitions {
+	transitions, names := Transitions(conf)
+	for i, tr := range transitions {
+		if !isForkTransition(names[i]) {
+			continue
+		}
 		// Extract the fork rule block number and aggregate it
 		response := tr()
 		if response == nil ||
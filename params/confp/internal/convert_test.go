@@ -33,6 +33,8 @@ import (
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/ethereum/go-ethereum/params/types/goethereum"
 	"github.com/ethereum/go-ethereum/params/types/parity"
+	"github.com/ethereum/go-ethereum/params/types/pyethereum"
+	"github.com/ethereum/go-ethereum/params/types/retesteth"
 )
 
 func mustOpenF(t *testing.T, fabbrev string, into interface{}) {
@@ -124,6 +126,9 @@ func TestIdentical(t *testing.T) {
 func TestConfiguratorImplementationsSatisfied(t *testing.T) {
 	for _, ty := range []interface{}{
 		&parity.ParityChainSpec{},
+		&aleth.AlethGenesisSpec{},
+		&retesteth.RetestethGenesisSpec{},
+		&pyethereum.PyEthereumGenesisSpec{},
 	} {
 		_ = ty.(ctypes.Configurator)
 	}
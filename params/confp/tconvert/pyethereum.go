@@ -17,34 +17,29 @@
 package tconvert
 
 import (
-	"encoding/binary"
-	"errors"
-
-	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params/confp"
 	"github.com/ethereum/go-ethereum/params/types/chipprgeth"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/ethereum/go-ethereum/params/types/pyethereum"
 )
 
-// NewPyEthereumGenesisSpec converts a go-ethereum genesis block into a Parity specific
+// NewPyEthereumGenesisSpec converts a go-ethereum genesis block into a pyethereum specific
 // chain specification format.
 func NewPyEthereumGenesisSpec(network string, genesis *genesisT.Genesis) (*pyethereum.PyEthereumGenesisSpec, error) {
-	// Only ethash is currently supported between go-ethereum and pyethereum
-	if genesis.Config.(*chipprgeth.ChipprGethChainConfig).Ethash == nil {
-		return nil, errors.New("unsupported consensus engine")
-	}
-	spec := &pyethereum.PyEthereumGenesisSpec{
-		Timestamp:  (hexutil.Uint64)(genesis.Timestamp),
-		ExtraData:  genesis.ExtraData,
-		GasLimit:   (hexutil.Uint64)(genesis.GasLimit),
-		Difficulty: (*hexutil.Big)(genesis.Difficulty),
-		Mixhash:    genesis.Mixhash,
-		Coinbase:   genesis.Coinbase,
-		Alloc:      genesis.Alloc,
-		ParentHash: genesis.ParentHash,
+	spec := &pyethereum.PyEthereumGenesisSpec{}
+	if err := confp.Convert(genesis, spec); err != nil {
+		return nil, err
 	}
-	spec.Nonce = (hexutil.Bytes)(make([]byte, 8))
-	binary.LittleEndian.PutUint64(spec.Nonce[:], genesis.Nonce)
-
 	return spec, nil
 }
+
+// PyEthereumConfigTochipprgethGenesis converts a pyethereum chainspec to the corresponding chipprgeth datastructure.
+func PyEthereumConfigTochipprgethGenesis(c *pyethereum.PyEthereumGenesisSpec) (*genesisT.Genesis, error) {
+	mg := &genesisT.Genesis{
+		Config: &chipprgeth.ChipprGethChainConfig{},
+	}
+	if err := confp.Convert(c, mg); err != nil {
+		return nil, err
+	}
+	return mg, nil
+}
@@ -17,9 +17,12 @@
 package aleth
 
 import (
+	"encoding/json"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 )
 
@@ -49,6 +52,60 @@ type AlethGenesisSpec struct {
 		NetworkID                  hexutil.Uint64        `json:"networkID"`
 		ChainID                    hexutil.Uint64        `json:"chainID"`
 		AllowFutureBlocks          bool                  `json:"allowFutureBlocks"`
+		MuirGlacierForkBlock       *hexutil.Big          `json:"muirGlacierForkBlock,omitempty"`
+		MaxCodeSize                *hexutil.Uint64       `json:"maxCodeSize,omitempty"`
+
+		// The following fields are not part of the Aleth format.
+		EIP2Transition             *hexutil.Big `json:"eip2Transition,omitempty"`
+		EIP7Transition             *hexutil.Big `json:"eip7Transition,omitempty"`
+		EIP155Transition           *hexutil.Big `json:"eip155Transition,omitempty"`
+		EIP160Transition           *hexutil.Big `json:"eip160Transition,omitempty"`
+		EIP161abcTransition        *hexutil.Big `json:"eip161abcTransition,omitempty"`
+		EIP161dTransition          *hexutil.Big `json:"eip161dTransition,omitempty"`
+		EIP170Transition           *hexutil.Big `json:"eip170Transition,omitempty"`
+		EIP100BTransition          *hexutil.Big `json:"eip100bTransition,omitempty"`
+		EIP140Transition           *hexutil.Big `json:"eip140Transition,omitempty"`
+		EIP198Transition           *hexutil.Big `json:"eip198Transition,omitempty"`
+		EIP211Transition           *hexutil.Big `json:"eip211Transition,omitempty"`
+		EIP212Transition           *hexutil.Big `json:"eip212Transition,omitempty"`
+		EIP213Transition           *hexutil.Big `json:"eip213Transition,omitempty"`
+		EIP214Transition           *hexutil.Big `json:"eip214Transition,omitempty"`
+		EIP649Transition           *hexutil.Big `json:"eip649Transition,omitempty"`
+		EIP658Transition           *hexutil.Big `json:"eip658Transition,omitempty"`
+		EIP145Transition           *hexutil.Big `json:"eip145Transition,omitempty"`
+		EIP1014Transition          *hexutil.Big `json:"eip1014Transition,omitempty"`
+		EIP1052Transition          *hexutil.Big `json:"eip1052Transition,omitempty"`
+		EIP1234Transition          *hexutil.Big `json:"eip1234Transition,omitempty"`
+		EIP1283Transition          *hexutil.Big `json:"eip1283Transition,omitempty"`
+		EIP152Transition           *hexutil.Big `json:"eip152Transition,omitempty"`
+		EIP1108Transition          *hexutil.Big `json:"eip1108Transition,omitempty"`
+		EIP1344Transition          *hexutil.Big `json:"eip1344Transition,omitempty"`
+		EIP1884Transition          *hexutil.Big `json:"eip1884Transition,omitempty"`
+		EIP2028Transition          *hexutil.Big `json:"eip2028Transition,omitempty"`
+		EIP2200Transition          *hexutil.Big `json:"eip2200Transition,omitempty"`
+		EIP2200DisableTransition   *hexutil.Big `json:"eip2200DisableTransition,omitempty"`
+		EIP1706Transition          *hexutil.Big `json:"eip1706Transition,omitempty"`
+		EIP2537Transition          *hexutil.Big `json:"eip2537Transition,omitempty"`
+		EIP2929Transition          *hexutil.Big `json:"eip2929Transition,omitempty"`
+		EIP2930Transition          *hexutil.Big `json:"eip2930Transition,omitempty"`
+		EIP2718Transition          *hexutil.Big `json:"eip2718Transition,omitempty"`
+		EIP1559Transition          *hexutil.Big `json:"eip1559Transition,omitempty"`
+		ECIP1010PauseTransition    *hexutil.Big `json:"ecip1010PauseTransition,omitempty"`
+		ECIP1010ContinueTransition *hexutil.Big `json:"ecip1010ContinueTransition,omitempty"`
+		ECIP1017Transition         *hexutil.Big `json:"ecip1017Transition,omitempty"`
+		ECIP1017EraRounds          *hexutil.Big `json:"ecip1017EraRounds,omitempty"`
+		ECIP1041Transition         *hexutil.Big `json:"ecip1041Transition,omitempty"`
+		ECIP1080Transition         *hexutil.Big `json:"ecip1080Transition,omitempty"`
+		ECBP1100Transition         *hexutil.Big `json:"ecbp1100Transition,omitempty"`
+		ECIP1099Transition         *hexutil.Big `json:"ecip1099Transition,omitempty"`
+
+		DifficultyBombDelaySchedule ctypes.Uint64BigMapEncodesHex `json:"difficultyBombDelays,omitempty"`
+		BlockRewardSchedule         ctypes.Uint64BigMapEncodesHex `json:"blockRewardSchedule,omitempty"`
+
+		CliquePeriod *hexutil.Uint64 `json:"cliquePeriod,omitempty"`
+		CliqueEpoch  *hexutil.Uint64 `json:"cliqueEpoch,omitempty"`
+
+		RequireBlockHashes map[uint64]common.Hash `json:"requireBlockHashes,omitempty"`
 	} `json:"params"`
 	Genesis struct {
 		Nonce      hexutil.Bytes  `json:"nonce"`
@@ -67,9 +124,11 @@ type AlethGenesisSpec struct {
 // AlethGenesisSpecAccount is the prefunded genesis account and/or precompiled
 // contract definition.
 type AlethGenesisSpecAccount struct {
-	Balance     *math.HexOrDecimal256    `json:"balance,omitempty"`
-	Nonce       uint64                   `json:"nonce,omitempty"`
-	Precompiled *AlethGenesisSpecBuiltin `json:"precompiled,omitempty"`
+	Balance     *math.HexOrDecimal256       `json:"balance,omitempty"`
+	Nonce       uint64                      `json:"nonce,omitempty"`
+	Code        hexutil.Bytes               `json:"code,omitempty"`
+	Storage     map[common.Hash]common.Hash `json:"storage,omitempty"`
+	Precompiled *AlethGenesisSpecBuiltin    `json:"precompiled,omitempty"`
 }
 
 // AlethGenesisSpecBuiltin is the precompiled contract definition.
@@ -109,3 +168,12 @@ func (spec *AlethGenesisSpec) SetAccount(address common.Address, account genesis
 	a.Nonce = account.Nonce
 
 }
+
+// MarshalJSON implements the json.Marshaler interface, omitting granular
+// transitions represented by a fork bundle.
+func (spec AlethGenesisSpec) MarshalJSON() ([]byte, error) {
+	type alethGenesisSpec AlethGenesisSpec
+	enc := spec
+	enc.forkBundles().Omit()
+	return json.Marshal(alethGenesisSpec(enc))
+}
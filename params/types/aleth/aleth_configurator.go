@@ -0,0 +1,1000 @@
+// Copyright 2019 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains logic implementing the Configurator interface for Aleth.
+
+Notes:
+Aleth groups protocol changes into fork bundles, eg. byzantiumForkBlock.
+Granular transitions are implemented on top of them by package forkbundle.
+*/
+
+package aleth
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+	"github.com/ethereum/go-ethereum/params/types/internal/forkbundle"
+	"github.com/ethereum/go-ethereum/params/vars"
+)
+
+func bigNewU64(i *hexutil.Big) *uint64 {
+	if i == nil {
+		return nil
+	}
+	u := i.ToInt().Uint64()
+	return &u
+}
+
+func setBig(u *uint64) *hexutil.Big {
+	if u == nil {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).SetUint64(*u))
+}
+
+// forkBundles returns the fork bundles of the configuration.
+func (spec *AlethGenesisSpec) forkBundles() forkbundle.Bundles {
+	p := &spec.Params
+	f := forkbundle.Big
+	return forkbundle.Bundles{
+		{Fork: f(&p.HomesteadForkBlock), EIPs: []forkbundle.Field{
+			f(&p.EIP2Transition), f(&p.EIP7Transition),
+		}},
+		{Fork: f(&p.EIP158ForkBlock), EIPs: []forkbundle.Field{
+			f(&p.EIP155Transition), f(&p.EIP160Transition), f(&p.EIP161abcTransition), f(&p.EIP161dTransition), f(&p.EIP170Transition),
+		}},
+		{Fork: f(&p.ByzantiumForkBlock), EIPs: []forkbundle.Field{
+			f(&p.EIP100BTransition), f(&p.EIP140Transition), f(&p.EIP198Transition), f(&p.EIP211Transition), f(&p.EIP212Transition),
+			f(&p.EIP213Transition), f(&p.EIP214Transition), f(&p.EIP649Transition), f(&p.EIP658Transition),
+		}},
+		{Fork: f(&p.ConstantinopleForkBlock), EIPs: []forkbundle.Field{
+			f(&p.EIP145Transition), f(&p.EIP1014Transition), f(&p.EIP1052Transition), f(&p.EIP1234Transition), f(&p.EIP1283Transition),
+		}},
+		{Fork: f(&p.IstanbulForkBlock), EIPs: []forkbundle.Field{
+			f(&p.EIP152Transition), f(&p.EIP1108Transition), f(&p.EIP1344Transition), f(&p.EIP1884Transition), f(&p.EIP2028Transition),
+			f(&p.EIP2200Transition),
+		}},
+	}
+}
+
+// transition returns the granular transition value if set, or else the value of its fork bundle.
+func (spec *AlethGenesisSpec) transition(eip **hexutil.Big) *uint64 {
+	return spec.forkBundles().Get(forkbundle.Big(eip))
+}
+
+// setTransition sets a granular transition value and recomputes the fork bundle fields.
+func (spec *AlethGenesisSpec) setTransition(eip **hexutil.Big, n *uint64) error {
+	spec.forkBundles().Set(forkbundle.Big(eip), n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetAccountStartNonce() *uint64 {
+	u := uint64(spec.Params.AccountStartNonce)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetAccountStartNonce(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.AccountStartNonce = math.HexOrDecimal64(*n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetMaximumExtraDataSize() *uint64 {
+	u := uint64(spec.Params.MaximumExtraDataSize)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetMaximumExtraDataSize(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.MaximumExtraDataSize = hexutil.Uint64(*n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetMinGasLimit() *uint64 {
+	u := uint64(spec.Params.MinGasLimit)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetMinGasLimit(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.MinGasLimit = hexutil.Uint64(*n)
+	if spec.Params.MaxGasLimit == 0 {
+		spec.Params.MaxGasLimit = hexutil.Uint64(math.MaxInt64)
+	}
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGasLimitBoundDivisor() *uint64 {
+	u := uint64(spec.Params.GasLimitBoundDivisor)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetGasLimitBoundDivisor(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.GasLimitBoundDivisor = math.HexOrDecimal64(*n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetNetworkID() *uint64 {
+	u := uint64(spec.Params.NetworkID)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetNetworkID(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.NetworkID = hexutil.Uint64(*n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetChainID() *big.Int {
+	if spec.Params.ChainID == 0 {
+		return new(big.Int).SetUint64(uint64(spec.Params.NetworkID))
+	}
+	return new(big.Int).SetUint64(uint64(spec.Params.ChainID))
+}
+
+func (spec *AlethGenesisSpec) SetChainID(i *big.Int) error {
+	if i == nil {
+		return nil
+	}
+	spec.Params.ChainID = hexutil.Uint64(i.Uint64())
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetMaxCodeSize() *uint64 {
+	if spec.Params.MaxCodeSize == nil {
+		return nil
+	}
+	u := uint64(*spec.Params.MaxCodeSize)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetMaxCodeSize(n *uint64) error {
+	if n == nil {
+		spec.Params.MaxCodeSize = nil
+		return nil
+	}
+	u := hexutil.Uint64(*n)
+	spec.Params.MaxCodeSize = &u
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP2Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP2Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP2Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP7Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP7Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP7Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP7Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP150Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP150ForkBlock)
+}
+
+func (spec *AlethGenesisSpec) SetEIP150Transition(n *uint64) error {
+	spec.Params.EIP150ForkBlock = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP152Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP152Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP152Transition(n *uint64) error {
+	if n != nil {
+		spec.SetPrecompile(9, &AlethGenesisSpecBuiltin{
+			Name:          "blake2_compression",
+			StartingBlock: setBig(n),
+		})
+	}
+	return spec.setTransition(&spec.Params.EIP152Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP160Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP160Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP160Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP160Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP161abcTransition() *uint64 {
+	return spec.transition(&spec.Params.EIP161abcTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP161abcTransition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP161abcTransition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP161dTransition() *uint64 {
+	return spec.transition(&spec.Params.EIP161dTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP161dTransition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP161dTransition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP170Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP170Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP170Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP170Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP155Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP155Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP155Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP155Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP140Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP140Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP140Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP140Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP198Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP198Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP198Transition(n *uint64) error {
+	if n != nil {
+		spec.SetPrecompile(5, &AlethGenesisSpecBuiltin{
+			Name:          "modexp",
+			StartingBlock: setBig(n),
+		})
+	}
+	return spec.setTransition(&spec.Params.EIP198Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP211Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP211Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP211Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP211Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP212Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP212Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP212Transition(n *uint64) error {
+	if n != nil {
+		spec.SetPrecompile(8, &AlethGenesisSpecBuiltin{
+			Name:          "alt_bn128_pairing_product",
+			StartingBlock: setBig(n),
+		})
+	}
+	return spec.setTransition(&spec.Params.EIP212Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP213Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP213Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP213Transition(n *uint64) error {
+	if n != nil {
+		add := &AlethGenesisSpecBuiltin{Name: "alt_bn128_G1_add", StartingBlock: setBig(n)}
+		mul := &AlethGenesisSpecBuiltin{Name: "alt_bn128_G1_mul", StartingBlock: setBig(n)}
+		// Aleth hardcodes the gas policy after EIP1108.
+		if spec.GetEIP1108Transition() == nil {
+			add.Linear = &AlethGenesisSpecLinearPricing{Base: 500}
+			mul.Linear = &AlethGenesisSpecLinearPricing{Base: 40000}
+		}
+		spec.SetPrecompile(6, add)
+		spec.SetPrecompile(7, mul)
+	}
+	return spec.setTransition(&spec.Params.EIP213Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP214Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP214Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP214Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP214Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP658Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP658Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP658Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP658Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP145Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP145Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP145Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP145Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP1014Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1014Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1014Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1014Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP1052Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1052Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1052Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1052Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP1283Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1283Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1283Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1283Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP1283DisableTransition() *uint64 {
+	return bigNewU64(spec.Params.ConstantinopleFixForkBlock)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1283DisableTransition(n *uint64) error {
+	spec.Params.ConstantinopleFixForkBlock = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP1108Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1108Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1108Transition(n *uint64) error {
+	if n != nil {
+		// Aleth hardcodes the gas policy after EIP1108.
+		for _, address := range []byte{6, 7} {
+			if acc, ok := spec.Accounts[common.UnprefixedAddress(common.BytesToAddress([]byte{address}))]; ok && acc.Precompiled != nil {
+				acc.Precompiled.Linear = nil
+			}
+		}
+	}
+	return spec.setTransition(&spec.Params.EIP1108Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP2200Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP2200Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2200Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP2200Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP2200DisableTransition() *uint64 {
+	return bigNewU64(spec.Params.EIP2200DisableTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2200DisableTransition(n *uint64) error {
+	spec.Params.EIP2200DisableTransition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP1344Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1344Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1344Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1344Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP1884Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP1884Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1884Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1884Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEIP2028Transition() *uint64 {
+	return spec.transition(&spec.Params.EIP2028Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2028Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP2028Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetECIP1080Transition() *uint64 {
+	return bigNewU64(spec.Params.ECIP1080Transition)
+}
+
+func (spec *AlethGenesisSpec) SetECIP1080Transition(n *uint64) error {
+	spec.Params.ECIP1080Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP1706Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP1706Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1706Transition(n *uint64) error {
+	spec.Params.EIP1706Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP2537Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP2537Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2537Transition(n *uint64) error {
+	spec.Params.EIP2537Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP2929Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP2929Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2929Transition(n *uint64) error {
+	spec.Params.EIP2929Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP2930Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP2930Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2930Transition(n *uint64) error {
+	spec.Params.EIP2930Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP2718Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP2718Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP2718Transition(n *uint64) error {
+	spec.Params.EIP2718Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEIP1559Transition() *uint64 {
+	return bigNewU64(spec.Params.EIP1559Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEIP1559Transition(n *uint64) error {
+	spec.Params.EIP1559Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetECBP1100Transition() *uint64 {
+	return bigNewU64(spec.Params.ECBP1100Transition)
+}
+
+func (spec *AlethGenesisSpec) SetECBP1100Transition(n *uint64) error {
+	spec.Params.ECBP1100Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) IsEnabled(fn func() *uint64, n *big.Int) bool {
+	f := fn()
+	if f == nil || n == nil {
+		return false
+	}
+	return new(big.Int).SetUint64(*f).Cmp(n) <= 0
+}
+
+func (spec *AlethGenesisSpec) GetForkCanonHash(n uint64) common.Hash {
+	if spec.Params.RequireBlockHashes == nil {
+		return common.Hash{}
+	}
+	return spec.Params.RequireBlockHashes[n]
+}
+
+func (spec *AlethGenesisSpec) SetForkCanonHash(n uint64, h common.Hash) error {
+	if spec.Params.RequireBlockHashes == nil {
+		spec.Params.RequireBlockHashes = make(map[uint64]common.Hash)
+	}
+	spec.Params.RequireBlockHashes[n] = h
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetForkCanonHashes() map[uint64]common.Hash {
+	return spec.Params.RequireBlockHashes
+}
+
+// GetConsensusEngineType maps Aleth's seal engine names onto the supported consensus engines.
+// Clique is not supported by Aleth, and is only recognized for configurations written by this package.
+func (spec *AlethGenesisSpec) GetConsensusEngineType() ctypes.ConsensusEngineT {
+	switch spec.SealEngine {
+	case "Ethash", "NoProof", "NoReward":
+		return ctypes.ConsensusEngineT_Ethash
+	case "Clique":
+		return ctypes.ConsensusEngineT_Clique
+	}
+	return ctypes.ConsensusEngineT_Unknown
+}
+
+func (spec *AlethGenesisSpec) MustSetConsensusEngineType(t ctypes.ConsensusEngineT) error {
+	spec.setFrontierPrecompiles()
+	switch t {
+	case ctypes.ConsensusEngineT_Ethash:
+		if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+			spec.SealEngine = "Ethash"
+		}
+		if spec.Params.MinimumDifficulty == nil {
+			spec.Params.MinimumDifficulty = (*hexutil.Big)(new(big.Int).Set(vars.MinimumDifficulty))
+		}
+		spec.Params.CliquePeriod = nil
+		spec.Params.CliqueEpoch = nil
+		return nil
+	case ctypes.ConsensusEngineT_Clique:
+		spec.SealEngine = "Clique"
+		if spec.Params.CliquePeriod == nil {
+			spec.Params.CliquePeriod = new(hexutil.Uint64)
+		}
+		if spec.Params.CliqueEpoch == nil {
+			epoch := hexutil.Uint64(30000)
+			spec.Params.CliqueEpoch = &epoch
+		}
+		spec.Params.MinimumDifficulty = nil
+		return nil
+	default:
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+}
+
+func (spec *AlethGenesisSpec) GetEthashMinimumDifficulty() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.Params.MinimumDifficulty.ToInt()
+}
+
+func (spec *AlethGenesisSpec) SetEthashMinimumDifficulty(i *big.Int) error {
+	if i == nil {
+		spec.Params.MinimumDifficulty = nil
+		return nil
+	}
+	spec.Params.MinimumDifficulty = (*hexutil.Big)(new(big.Int).Set(i))
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashDifficultyBoundDivisor() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.Params.DifficultyBoundDivisor.ToInt()
+}
+
+func (spec *AlethGenesisSpec) SetEthashDifficultyBoundDivisor(i *big.Int) error {
+	if i == nil {
+		return nil
+	}
+	spec.Params.DifficultyBoundDivisor = (*math.HexOrDecimal256)(new(big.Int).Set(i))
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashDurationLimit() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.Params.DurationLimit.ToInt()
+}
+
+func (spec *AlethGenesisSpec) SetEthashDurationLimit(i *big.Int) error {
+	if i == nil {
+		return nil
+	}
+	spec.Params.DurationLimit = (*math.HexOrDecimal256)(new(big.Int).Set(i))
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashHomesteadTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	x, y := spec.GetEIP2Transition(), spec.GetEIP7Transition()
+	if x == nil || y == nil {
+		return nil
+	}
+	if *x > *y {
+		return x
+	}
+	return y
+}
+
+func (spec *AlethGenesisSpec) SetEthashHomesteadTransition(n *uint64) error {
+	if err := spec.SetEIP2Transition(n); err != nil {
+		return err
+	}
+	return spec.SetEIP7Transition(n)
+}
+
+// GetEthashEIP779Transition returns the DAO hard fork block.
+// Aleth uses 0 (the zero value) to signal that the DAO fork is not supported.
+func (spec *AlethGenesisSpec) GetEthashEIP779Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	if spec.Params.DaoHardforkBlock == 0 {
+		return nil
+	}
+	u := uint64(spec.Params.DaoHardforkBlock)
+	return &u
+}
+
+func (spec *AlethGenesisSpec) SetEthashEIP779Transition(n *uint64) error {
+	if n == nil {
+		spec.Params.DaoHardforkBlock = 0
+		return nil
+	}
+	if *n == 0 {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Params.DaoHardforkBlock = math.HexOrDecimal64(*n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashEIP649Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Params.EIP649Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashEIP649Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP649Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEthashEIP1234Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Params.EIP1234Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashEIP1234Transition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP1234Transition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEthashEIP2384Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.MuirGlacierForkBlock)
+}
+
+func (spec *AlethGenesisSpec) SetEthashEIP2384Transition(n *uint64) error {
+	spec.Params.MuirGlacierForkBlock = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1010PauseTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1010PauseTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1010PauseTransition(n *uint64) error {
+	spec.Params.ECIP1010PauseTransition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1010ContinueTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1010ContinueTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1010ContinueTransition(n *uint64) error {
+	spec.Params.ECIP1010ContinueTransition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1017Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1017Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1017Transition(n *uint64) error {
+	spec.Params.ECIP1017Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1017EraRounds() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1017EraRounds)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1017EraRounds(n *uint64) error {
+	spec.Params.ECIP1017EraRounds = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashEIP100BTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Params.EIP100BTransition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashEIP100BTransition(n *uint64) error {
+	return spec.setTransition(&spec.Params.EIP100BTransition, n)
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1041Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1041Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1041Transition(n *uint64) error {
+	spec.Params.ECIP1041Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashECIP1099Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(spec.Params.ECIP1099Transition)
+}
+
+func (spec *AlethGenesisSpec) SetEthashECIP1099Transition(n *uint64) error {
+	spec.Params.ECIP1099Transition = setBig(n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetEthashDifficultyBombDelaySchedule() ctypes.Uint64BigMapEncodesHex {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.Params.DifficultyBombDelaySchedule
+}
+
+func (spec *AlethGenesisSpec) SetEthashDifficultyBombDelaySchedule(m ctypes.Uint64BigMapEncodesHex) error {
+	spec.Params.DifficultyBombDelaySchedule = m
+	return nil
+}
+
+// GetEthashBlockRewardSchedule returns the configured block reward schedule.
+// A native Aleth blockReward value is only reported if it differs from the Frontier block reward.
+func (spec *AlethGenesisSpec) GetEthashBlockRewardSchedule() ctypes.Uint64BigMapEncodesHex {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	if spec.Params.BlockRewardSchedule != nil {
+		return spec.Params.BlockRewardSchedule
+	}
+	if r := spec.Params.BlockReward.ToInt(); r != nil && r.Cmp(vars.FrontierBlockReward) != 0 {
+		return ctypes.Uint64BigMapEncodesHex{0: r}
+	}
+	return nil
+}
+
+func (spec *AlethGenesisSpec) SetEthashBlockRewardSchedule(m ctypes.Uint64BigMapEncodesHex) error {
+	spec.Params.BlockRewardSchedule = m
+	if r, ok := m[0]; ok {
+		spec.Params.BlockReward = (*hexutil.Big)(new(big.Int).Set(r))
+	} else {
+		spec.Params.BlockReward = (*hexutil.Big)(new(big.Int).Set(vars.FrontierBlockReward))
+	}
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetCliquePeriod() uint64 {
+	if spec.Params.CliquePeriod == nil {
+		return 0
+	}
+	return uint64(*spec.Params.CliquePeriod)
+}
+
+func (spec *AlethGenesisSpec) SetCliquePeriod(n uint64) error {
+	p := hexutil.Uint64(n)
+	spec.Params.CliquePeriod = &p
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetCliqueEpoch() uint64 {
+	if spec.Params.CliqueEpoch == nil {
+		return 0
+	}
+	return uint64(*spec.Params.CliqueEpoch)
+}
+
+func (spec *AlethGenesisSpec) SetCliqueEpoch(n uint64) error {
+	e := hexutil.Uint64(n)
+	spec.Params.CliqueEpoch = &e
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetSealingType() ctypes.BlockSealingT {
+	return ctypes.BlockSealing_Ethereum
+}
+
+func (spec *AlethGenesisSpec) SetSealingType(t ctypes.BlockSealingT) error {
+	if t != ctypes.BlockSealing_Ethereum {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	return nil
+}
+
+// GetGenesisSealerEthereumNonce returns the genesis nonce, which follows the
+// little-endian encoding used by NewAlethGenesisSpec.
+func (spec *AlethGenesisSpec) GetGenesisSealerEthereumNonce() uint64 {
+	var nonce [8]byte
+	copy(nonce[:], spec.Genesis.Nonce)
+	return binary.LittleEndian.Uint64(nonce[:])
+}
+
+func (spec *AlethGenesisSpec) SetGenesisSealerEthereumNonce(n uint64) error {
+	spec.Genesis.Nonce = make(hexutil.Bytes, 8)
+	binary.LittleEndian.PutUint64(spec.Genesis.Nonce, n)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisSealerEthereumMixHash() common.Hash {
+	return spec.Genesis.MixHash
+}
+
+func (spec *AlethGenesisSpec) SetGenesisSealerEthereumMixHash(h common.Hash) error {
+	spec.Genesis.MixHash = h
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisDifficulty() *big.Int {
+	return spec.Genesis.Difficulty.ToInt()
+}
+
+func (spec *AlethGenesisSpec) SetGenesisDifficulty(i *big.Int) error {
+	if i == nil {
+		spec.Genesis.Difficulty = nil
+		return nil
+	}
+	spec.Genesis.Difficulty = (*hexutil.Big)(new(big.Int).Set(i))
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisAuthor() common.Address {
+	return spec.Genesis.Author
+}
+
+func (spec *AlethGenesisSpec) SetGenesisAuthor(a common.Address) error {
+	spec.Genesis.Author = a
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisTimestamp() uint64 {
+	return uint64(spec.Genesis.Timestamp)
+}
+
+func (spec *AlethGenesisSpec) SetGenesisTimestamp(u uint64) error {
+	spec.Genesis.Timestamp = hexutil.Uint64(u)
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisParentHash() common.Hash {
+	return spec.Genesis.ParentHash
+}
+
+func (spec *AlethGenesisSpec) SetGenesisParentHash(h common.Hash) error {
+	spec.Genesis.ParentHash = h
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisExtraData() []byte {
+	return spec.Genesis.ExtraData
+}
+
+func (spec *AlethGenesisSpec) SetGenesisExtraData(b []byte) error {
+	spec.Genesis.ExtraData = b
+	return nil
+}
+
+func (spec *AlethGenesisSpec) GetGenesisGasLimit() uint64 {
+	return uint64(spec.Genesis.GasLimit)
+}
+
+func (spec *AlethGenesisSpec) SetGenesisGasLimit(u uint64) error {
+	spec.Genesis.GasLimit = hexutil.Uint64(u)
+	return nil
+}
+
+// ForEachAccount iterates the genesis accounts, skipping precompile definitions
+// which have no balance.
+func (spec *AlethGenesisSpec) ForEachAccount(fn func(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error) error {
+	for k, v := range spec.Accounts {
+		bal := v.Balance.ToInt()
+		if v.Precompiled != nil && (bal == nil || bal.Sign() == 0) {
+			continue
+		}
+		if bal == nil {
+			bal = new(big.Int)
+		}
+		if err := fn(common.Address(k), bal, v.Nonce, v.Code, v.Storage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (spec *AlethGenesisSpec) UpdateAccount(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error {
+	if spec.Accounts == nil {
+		spec.Accounts = make(map[common.UnprefixedAddress]*AlethGenesisSpecAccount)
+	}
+	a, ok := spec.Accounts[common.UnprefixedAddress(address)]
+	if !ok {
+		a = &AlethGenesisSpecAccount{}
+		spec.Accounts[common.UnprefixedAddress(address)] = a
+	}
+	if bal != nil {
+		a.Balance = (*math.HexOrDecimal256)(new(big.Int).Set(bal))
+	}
+	a.Nonce = nonce
+	a.Code = code
+	a.Storage = storage
+
+	return nil
+}
+
+// setFrontierPrecompiles defines the precompiled contracts available since genesis,
+// which Aleth expects to be listed with the accounts.
+func (spec *AlethGenesisSpec) setFrontierPrecompiles() {
+	for address, builtin := range map[byte]AlethGenesisSpecBuiltin{
+		1: {Name: "ecrecover", Linear: &AlethGenesisSpecLinearPricing{Base: 3000}},
+		2: {Name: "sha256", Linear: &AlethGenesisSpecLinearPricing{Base: 60, Word: 12}},
+		3: {Name: "ripemd160", Linear: &AlethGenesisSpecLinearPricing{Base: 600, Word: 120}},
+		4: {Name: "identity", Linear: &AlethGenesisSpecLinearPricing{Base: 15, Word: 3}},
+	} {
+		builtin := builtin
+		spec.SetPrecompile(address, &builtin)
+	}
+}
@@ -381,6 +381,51 @@ func (c *ChipprGethChainConfig) SetEIP2537Transition(n *uint64) error {
 	return nil
 }
 
+func (c *ChipprGethChainConfig) GetEIP2929Transition() *uint64 {
+	return bigNewU64(c.EIP2929FBlock)
+}
+
+func (c *ChipprGethChainConfig) SetEIP2929Transition(n *uint64) error {
+	c.EIP2929FBlock = setBig(c.EIP2929FBlock, n)
+	return nil
+}
+
+func (c *ChipprGethChainConfig) GetEIP2930Transition() *uint64 {
+	return bigNewU64(c.EIP2930FBlock)
+}
+
+func (c *ChipprGethChainConfig) SetEIP2930Transition(n *uint64) error {
+	c.EIP2930FBlock = setBig(c.EIP2930FBlock, n)
+	return nil
+}
+
+func (c *ChipprGethChainConfig) GetEIP2718Transition() *uint64 {
+	return bigNewU64(c.EIP2718FBlock)
+}
+
+func (c *ChipprGethChainConfig) SetEIP2718Transition(n *uint64) error {
+	c.EIP2718FBlock = setBig(c.EIP2718FBlock, n)
+	return nil
+}
+
+func (c *ChipprGethChainConfig) GetEIP1559Transition() *uint64 {
+	return bigNewU64(c.EIP1559FBlock)
+}
+
+func (c *ChipprGethChainConfig) SetEIP1559Transition(n *uint64) error {
+	c.EIP1559FBlock = setBig(c.EIP1559FBlock, n)
+	return nil
+}
+
+func (c *ChipprGethChainConfig) GetECBP1100Transition() *uint64 {
+	return bigNewU64(c.ECBP1100FBlock)
+}
+
+func (c *ChipprGethChainConfig) SetECBP1100Transition(n *uint64) error {
+	c.ECBP1100FBlock = setBig(c.ECBP1100FBlock, n)
+	return nil
+}
+
 func (c *ChipprGethChainConfig) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
@@ -748,19 +793,18 @@ func (c *ChipprGethChainConfig) SetEthashECIP1041Transition(n *uint64) error {
 	return nil
 }
 
-func (c *ChipprGethChainConfig) GetECIP1092Transition() *uint64 {
+func (c *ChipprGethChainConfig) GetEthashECIP1099Transition() *uint64 {
 	if c.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
 		return nil
 	}
-
-	return bigNewU64(c.ECIP1092Transition)
+	return bigNewU64(c.ECIP1099FBlock)
 }
 
-func (c *ChipprGethChainConfig) SetECIP1092Transition(n *uint64) error {
+func (c *ChipprGethChainConfig) SetEthashECIP1099Transition(n *uint64) error {
 	if c.Ethash == nil {
 		return ctypes.ErrUnsupportedConfigFatal
 	}
-	c.ECIP1092Transition = setBig(c.ECIP1092Transition, n)
+	c.ECIP1099FBlock = setBig(c.ECIP1099FBlock, n)
 	return nil
 }
 
@@ -130,6 +130,19 @@ type ProtocolSpecifier interface {
 	SetEIP1706Transition(n *uint64) error
 	GetEIP2537Transition() *uint64
 	SetEIP2537Transition(n *uint64) error
+	GetEIP2929Transition() *uint64
+	SetEIP2929Transition(n *uint64) error
+	GetEIP2930Transition() *uint64
+	SetEIP2930Transition(n *uint64) error
+	GetEIP2718Transition() *uint64
+	SetEIP2718Transition(n *uint64) error
+	GetEIP1559Transition() *uint64
+	SetEIP1559Transition(n *uint64) error
+
+	// ECBP1100 (MESS) isn't a hard fork, it only changes how the canonical chain
+	// is chosen locally between competing segments.
+	GetECBP1100Transition() *uint64
+	SetECBP1100Transition(n *uint64) error
 }
 
 type Forker interface {
@@ -185,8 +198,8 @@ type EthashConfigurator interface {
 	SetEthashEIP100BTransition(n *uint64) error
 	GetEthashECIP1041Transition() *uint64
 	SetEthashECIP1041Transition(n *uint64) error
-	GetECIP1092Transition() *uint64
-	SetECIP1092Transition(n *uint64) error
+	GetEthashECIP1099Transition() *uint64
+	SetEthashECIP1099Transition(n *uint64) error
 	GetEthashDifficultyBombDelaySchedule() Uint64BigMapEncodesHex
 	SetEthashDifficultyBombDelaySchedule(m Uint64BigMapEncodesHex) error
 	GetEthashBlockRewardSchedule() Uint64BigMapEncodesHex
@@ -285,6 +285,27 @@ func (c ConsensusEngineT) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (c ConsensusEngineT) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the engine
+// names returned by String.
+func (c *ConsensusEngineT) UnmarshalText(input []byte) error {
+	switch string(input) {
+	case "ethash":
+		*c = ConsensusEngineT_Ethash
+	case "clique":
+		*c = ConsensusEngineT_Clique
+	case "unknown":
+		*c = ConsensusEngineT_Unknown
+	default:
+		return fmt.Errorf("unknown consensus engine %q", input)
+	}
+	return nil
+}
+
 func (c ConsensusEngineT) IsEthash() bool {
 	return c == ConsensusEngineT_Ethash
 }
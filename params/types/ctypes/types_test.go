@@ -247,3 +247,23 @@ func TestUint64BigMapEncodesHex_SetValueTotalForHeight(t *testing.T) {
 	mgTestlike.SetValueTotalForHeight(&five, vars.EIP1234DifficultyBombDelay)
 	check(mgTestlike, mgTestlike.SumValues(&zero), vars.EIP649DifficultyBombDelay.Uint64())
 }
+
+func TestConsensusEngineT_UnmarshalText(t *testing.T) {
+	for _, want := range []ConsensusEngineT{ConsensusEngineT_Unknown, ConsensusEngineT_Ethash, ConsensusEngineT_Clique} {
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ConsensusEngineT
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	}
+	var c ConsensusEngineT
+	if err := c.UnmarshalText([]byte("aura")); err == nil {
+		t.Error("expected error for unknown engine")
+	}
+}
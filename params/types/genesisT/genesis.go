@@ -52,6 +52,7 @@ type Genesis struct {
 	Number     uint64      `json:"number"`
 	GasUsed    uint64      `json:"gasUsed"`
 	ParentHash common.Hash `json:"parentHash"`
+	BaseFee    *big.Int    `json:"baseFeePerGas"`
 }
 
 func (g *Genesis) ForEachAccount(fn func(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error) error {
@@ -115,6 +116,7 @@ type genesisSpecMarshaling struct {
 	GasUsed    math.HexOrDecimal64
 	Number     math.HexOrDecimal64
 	Difficulty *math.HexOrDecimal256
+	BaseFee    *math.HexOrDecimal256
 	Alloc      map[common.UnprefixedAddress]GenesisAccount
 }
 
@@ -544,6 +546,46 @@ func (g Genesis) SetEIP2537Transition(n *uint64) error {
 	return g.Config.SetEIP2537Transition(n)
 }
 
+func (g *Genesis) GetEIP2929Transition() *uint64 {
+	return g.Config.GetEIP2929Transition()
+}
+
+func (g *Genesis) SetEIP2929Transition(n *uint64) error {
+	return g.Config.SetEIP2929Transition(n)
+}
+
+func (g *Genesis) GetEIP2930Transition() *uint64 {
+	return g.Config.GetEIP2930Transition()
+}
+
+func (g *Genesis) SetEIP2930Transition(n *uint64) error {
+	return g.Config.SetEIP2930Transition(n)
+}
+
+func (g *Genesis) GetEIP2718Transition() *uint64 {
+	return g.Config.GetEIP2718Transition()
+}
+
+func (g *Genesis) SetEIP2718Transition(n *uint64) error {
+	return g.Config.SetEIP2718Transition(n)
+}
+
+func (g *Genesis) GetEIP1559Transition() *uint64 {
+	return g.Config.GetEIP1559Transition()
+}
+
+func (g *Genesis) SetEIP1559Transition(n *uint64) error {
+	return g.Config.SetEIP1559Transition(n)
+}
+
+func (g *Genesis) GetECBP1100Transition() *uint64 {
+	return g.Config.GetECBP1100Transition()
+}
+
+func (g *Genesis) SetECBP1100Transition(n *uint64) error {
+	return g.Config.SetECBP1100Transition(n)
+}
+
 func (g *Genesis) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	return g.Config.IsEnabled(fn, n)
 }
@@ -688,12 +730,12 @@ func (g *Genesis) SetEthashECIP1041Transition(n *uint64) error {
 	return g.Config.SetEthashECIP1041Transition(n)
 }
 
-func (g *Genesis) GetECIP1092Transition() *uint64 {
-	return g.Config.GetECIP1092Transition()
+func (g *Genesis) GetEthashECIP1099Transition() *uint64 {
+	return g.Config.GetEthashECIP1099Transition()
 }
 
-func (g *Genesis) SetECIP1092Transition(n *uint64) error {
-	return g.Config.SetECIP1092Transition(n)
+func (g *Genesis) SetEthashECIP1099Transition(n *uint64) error {
+	return g.Config.SetEthashECIP1099Transition(n)
 }
 
 func (g *Genesis) GetEthashDifficultyBombDelaySchedule() ctypes.Uint64BigMapEncodesHex {
@@ -391,6 +391,51 @@ func (c *ChainConfig) SetEIP2537Transition(n *uint64) error {
 	return nil
 }
 
+func (c *ChainConfig) GetEIP2929Transition() *uint64 {
+	return bigNewU64(c.BerlinBlock)
+}
+
+func (c *ChainConfig) SetEIP2929Transition(n *uint64) error {
+	c.BerlinBlock = setBig(c.BerlinBlock, n)
+	return nil
+}
+
+func (c *ChainConfig) GetEIP2930Transition() *uint64 {
+	return bigNewU64(c.BerlinBlock)
+}
+
+func (c *ChainConfig) SetEIP2930Transition(n *uint64) error {
+	c.BerlinBlock = setBig(c.BerlinBlock, n)
+	return nil
+}
+
+func (c *ChainConfig) GetEIP2718Transition() *uint64 {
+	return bigNewU64(c.BerlinBlock)
+}
+
+func (c *ChainConfig) SetEIP2718Transition(n *uint64) error {
+	c.BerlinBlock = setBig(c.BerlinBlock, n)
+	return nil
+}
+
+func (c *ChainConfig) GetEIP1559Transition() *uint64 {
+	return bigNewU64(c.LondonBlock)
+}
+
+func (c *ChainConfig) SetEIP1559Transition(n *uint64) error {
+	c.LondonBlock = setBig(c.LondonBlock, n)
+	return nil
+}
+
+func (c *ChainConfig) GetECBP1100Transition() *uint64 {
+	return bigNewU64(c.ECBP1100Transition)
+}
+
+func (c *ChainConfig) SetECBP1100Transition(n *uint64) error {
+	c.ECBP1100Transition = setBig(c.ECBP1100Transition, n)
+	return nil
+}
+
 func (c *ChainConfig) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
@@ -662,14 +707,11 @@ func (c *ChainConfig) SetEthashECIP1041Transition(i *uint64) error {
 	return ctypes.ErrUnsupportedConfigFatal
 }
 
-func (c *ChainConfig) GetECIP1092Transition() *uint64 {
-	if c.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
-		return nil
-	}
+func (c *ChainConfig) GetEthashECIP1099Transition() *uint64 {
 	return nil
 }
 
-func (c *ChainConfig) SetECIP1092Transition(i *uint64) error {
+func (c *ChainConfig) SetEthashECIP1099Transition(i *uint64) error {
 	if i == nil {
 		return nil
 	}
@@ -0,0 +1,162 @@
+// Copyright 2021 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package forkbundle implements granular transitions on top of configuration
+formats which group protocol changes into fork bundles, eg. Byzantium.
+
+Chains like Ethereum Classic activate the features of a bundle at different
+blocks, or not at all, and have features of their own, so formats using this
+package carry granular (extension) fields next to their bundle fields.
+A granular transition is read from its own field when set, and otherwise falls
+back to the bundle it belongs to. Setting a granular transition recomputes the
+bundle fields; a bundle field is set only when all of its transitions are set
+and equal. When encoding, granular fields represented by a set bundle are
+omitted, so that configurations describable by the format are written without
+extension fields.
+*/
+package forkbundle
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Field is a transition field of a configuration format.
+type Field interface {
+	Get() *uint64
+	Set(n *uint64)
+}
+
+type uint64Field struct {
+	p **uint64
+}
+
+// Uint64 returns the Field stored at p.
+func Uint64(p **uint64) Field {
+	return uint64Field{p}
+}
+
+func (f uint64Field) Get() *uint64 {
+	if *f.p == nil {
+		return nil
+	}
+	n := **f.p
+	return &n
+}
+
+func (f uint64Field) Set(n *uint64) {
+	if n == nil {
+		*f.p = nil
+		return
+	}
+	u := *n
+	*f.p = &u
+}
+
+type bigField struct {
+	p **hexutil.Big
+}
+
+// Big returns the Field stored at p.
+func Big(p **hexutil.Big) Field {
+	return bigField{p}
+}
+
+func (f bigField) Get() *uint64 {
+	if *f.p == nil {
+		return nil
+	}
+	n := (*f.p).ToInt().Uint64()
+	return &n
+}
+
+func (f bigField) Set(n *uint64) {
+	if n == nil {
+		*f.p = nil
+		return
+	}
+	*f.p = (*hexutil.Big)(new(big.Int).SetUint64(*n))
+}
+
+// Bundle pairs a fork bundle field with the granular transitions it represents.
+type Bundle struct {
+	Fork Field
+	EIPs []Field
+}
+
+// Bundles is the set of fork bundles of a configuration.
+type Bundles []Bundle
+
+// Get returns the value of a granular transition if set, or else the value of
+// its fork bundle.
+func (bundles Bundles) Get(eip Field) *uint64 {
+	if n := eip.Get(); n != nil {
+		return n
+	}
+	for _, bundle := range bundles {
+		for _, e := range bundle.EIPs {
+			if e == eip {
+				return bundle.Fork.Get()
+			}
+		}
+	}
+	return nil
+}
+
+// Set sets a granular transition value and recomputes the fork bundle fields.
+func (bundles Bundles) Set(eip Field, n *uint64) {
+	// Fork bundles are expanded before the new value is set so that transitions
+	// inheriting the bundle value don't lose it when the bundle is unset.
+	for _, bundle := range bundles {
+		fork := bundle.Fork.Get()
+		if fork == nil {
+			continue
+		}
+		for _, e := range bundle.EIPs {
+			if e.Get() == nil {
+				e.Set(fork)
+			}
+		}
+	}
+	eip.Set(n)
+	for _, bundle := range bundles {
+		var fork *uint64
+		for i, e := range bundle.EIPs {
+			v := e.Get()
+			if v == nil || (i > 0 && (fork == nil || *fork != *v)) {
+				fork = nil
+				break
+			}
+			fork = v
+		}
+		bundle.Fork.Set(fork)
+	}
+}
+
+// Omit clears the granular transitions represented by a set fork bundle field.
+// It is used on a copy of the configuration before encoding it.
+func (bundles Bundles) Omit() {
+	for _, bundle := range bundles {
+		if bundle.Fork.Get() == nil {
+			continue
+		}
+		for _, eip := range bundle.EIPs {
+			eip.Set(nil)
+		}
+	}
+}
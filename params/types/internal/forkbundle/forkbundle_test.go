@@ -0,0 +1,82 @@
+// Copyright 2021 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkbundle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type testConfig struct {
+	Fork       *hexutil.Big
+	EIPA, EIPB *hexutil.Big
+	Other      *uint64
+	OtherEIP   *uint64
+}
+
+func (c *testConfig) bundles() Bundles {
+	return Bundles{
+		{Fork: Big(&c.Fork), EIPs: []Field{Big(&c.EIPA), Big(&c.EIPB)}},
+		{Fork: Uint64(&c.Other), EIPs: []Field{Uint64(&c.OtherEIP)}},
+	}
+}
+
+func TestBundles(t *testing.T) {
+	var (
+		c        = new(testConfig)
+		n1, n2   = uint64(1), uint64(2)
+		get      = func(f Field) *uint64 { return c.bundles().Get(f) }
+		eipA     = Big(&c.EIPA)
+		eipB     = Big(&c.EIPB)
+		otherEIP = Uint64(&c.OtherEIP)
+	)
+	c.Fork = (*hexutil.Big)(hexutil.MustDecodeBig("0x1"))
+	if v := get(eipA); v == nil || *v != n1 {
+		t.Fatalf("transition not inherited from bundle: %v", v)
+	}
+	// Splitting the bundle keeps the inherited values
+	c.bundles().Set(eipB, &n2)
+	if c.Fork != nil {
+		t.Errorf("bundle set for unequal transitions: %v", c.Fork)
+	}
+	if v := get(eipA); v == nil || *v != n1 {
+		t.Errorf("transition lost bundle value: %v", v)
+	}
+	// Rejoining it sets the bundle
+	c.bundles().Set(eipA, &n2)
+	if c.Fork == nil || c.Fork.ToInt().Uint64() != n2 {
+		t.Errorf("bundle not set: %v", c.Fork)
+	}
+	c.bundles().Set(otherEIP, &n1)
+	if c.Other == nil || *c.Other != n1 {
+		t.Errorf("bundle not set: %v", c.Other)
+	}
+	// Granular fields are omitted for set bundles
+	c.bundles().Omit()
+	if c.EIPA != nil || c.EIPB != nil || c.OtherEIP != nil {
+		t.Errorf("granular transitions not omitted: %+v", c)
+	}
+	if v := get(eipB); v == nil || *v != n2 {
+		t.Errorf("transition not inherited from bundle: %v", v)
+	}
+	// Unsetting a transition unsets its bundle
+	c.bundles().Set(otherEIP, nil)
+	if c.Other != nil || get(otherEIP) != nil {
+		t.Errorf("bundle not unset: %v", c.Other)
+	}
+}
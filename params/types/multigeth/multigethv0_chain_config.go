@@ -51,7 +51,8 @@ type ChainConfig struct {
 	ECIP1010Length      *big.Int `json:"ecip1010Length,omitempty"`     // ECIP1010 length
 	ECIP1017EraBlock    *big.Int `json:"ecip1017EraBlock,omitempty"`   // ECIP1017 era rounds
 	DisposalBlock       *big.Int `json:"disposalBlock,omitempty"`      // Bomb disposal HF block
-	ECIP1092Block       *big.Int `json:"ecip1092Block,omitempty"`      // ECIP1092 pirlguard activation
+	ECBP1100Block       *big.Int `json:"ecbp1100Block,omitempty"`      // ECBP1100 (MESS) artificial finality
+	ECIP1099Block       *big.Int `json:"ecip1099Block,omitempty"`      // ECIP1099 etchash epoch length doubling
 
 	MCIP0Block *big.Int `json:"mcip0Block,omitempty"` // Musicoin default block; no MCIP, just denotes chain pref
 	MCIP3Block *big.Int `json:"mcip3Block,omitempty"` // Musicoin 'UBI Fork' block
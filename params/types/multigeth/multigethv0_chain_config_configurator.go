@@ -416,6 +416,59 @@ func (c *ChainConfig) SetEIP2537Transition(n *uint64) error {
 	return ctypes.ErrUnsupportedConfigFatal
 }
 
+func (c *ChainConfig) GetEIP2929Transition() *uint64 {
+	return nil
+}
+
+func (c *ChainConfig) SetEIP2929Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
+func (c *ChainConfig) GetEIP2930Transition() *uint64 {
+	return nil
+}
+
+func (c *ChainConfig) SetEIP2930Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
+func (c *ChainConfig) GetEIP2718Transition() *uint64 {
+	return nil
+}
+
+func (c *ChainConfig) SetEIP2718Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
+func (c *ChainConfig) GetEIP1559Transition() *uint64 {
+	return nil
+}
+
+func (c *ChainConfig) SetEIP1559Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
+func (c *ChainConfig) GetECBP1100Transition() *uint64 {
+	return bigNewU64(c.ECBP1100Block)
+}
+
+func (c *ChainConfig) SetECBP1100Transition(n *uint64) error {
+	c.ECBP1100Block = setBig(c.ECBP1100Block, n)
+	return nil
+}
+
 func (c *ChainConfig) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
@@ -750,18 +803,18 @@ func (c *ChainConfig) SetEthashECIP1041Transition(n *uint64) error {
 	return nil
 }
 
-func (c *ChainConfig) GetECIP1092Transition() *uint64 {
+func (c *ChainConfig) GetEthashECIP1099Transition() *uint64 {
 	if c.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
 		return nil
 	}
-	return bigNewU64(c.ECIP1092Block)
+	return bigNewU64(c.ECIP1099Block)
 }
 
-func (c *ChainConfig) SetECIP1092Transition(n *uint64) error {
+func (c *ChainConfig) SetEthashECIP1099Transition(n *uint64) error {
 	if c.Ethash == nil {
 		return ctypes.ErrUnsupportedConfigFatal
 	}
-	c.ECIP1092Block = setBig(c.ECIP1092Block, n)
+	c.ECIP1099Block = setBig(c.ECIP1099Block, n)
 	return nil
 }
 
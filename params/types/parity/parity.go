@@ -19,6 +19,7 @@ package parity
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"reflect"
 	"sort"
@@ -30,6 +31,10 @@ import (
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 )
 
+// ErrUnsupportedAuthorityRound is returned when decoding a chain specification
+// using the authority round engine, which cannot be run.
+var ErrUnsupportedAuthorityRound = errors.New("unsupported consensus engine: authority round")
+
 // ParityChainSpec is the chain specification format used by Parity.
 type ParityChainSpec struct {
 	Name    string `json:"name"`
@@ -68,7 +73,7 @@ type ParityChainSpec struct {
 				ECIP1010PauseTransition    *ParityU64 `json:"ecip1010PauseTransition,omitempty"`
 				ECIP1010ContinueTransition *ParityU64 `json:"ecip1010ContinueTransition,omitempty"`
 				ECIP1017EraRounds          *ParityU64 `json:"ecip1017EraRounds,omitempty"`
-				ECIP1092Transition         *ParityU64 `json:"ECIP1092Transition,omitempty"` //may not be needed but here it is
+				ECIP1099Transition         *ParityU64 `json:"ecip1099Transition,omitempty"`
 			} `json:"params"`
 		} `json:"Ethash,omitempty"`
 		Clique struct {
@@ -77,6 +82,10 @@ type ParityChainSpec struct {
 				Epoch  *ParityU64 `json:"epoch,omitempty"`
 			} `json:"params,omitempty"`
 		} `json:"Clique,omitempty"`
+
+		// AuthorityRound is only decoded for specifications sealed by Parity's
+		// authority round engine to be rejected, as it is not supported.
+		AuthorityRound json.RawMessage `json:"authorityRound,omitempty"`
 	} `json:"engine"`
 
 	Params struct {
@@ -108,8 +117,13 @@ type ParityChainSpec struct {
 		EIP1884Transition         *ParityU64 `json:"eip1884Transition,omitempty"`
 		EIP2028Transition         *ParityU64 `json:"eip2028Transition,omitempty"`
 		EIP2537Transition         *ParityU64 `json:"eip2537Transition,omitempty"`
+		EIP2929Transition         *ParityU64 `json:"eip2929Transition,omitempty"`
+		EIP2930Transition         *ParityU64 `json:"eip2930Transition,omitempty"`
+		EIP2718Transition         *ParityU64 `json:"eip2718Transition,omitempty"`
+		EIP1559Transition         *ParityU64 `json:"eip1559Transition,omitempty"`
 		EIP1706Transition         *ParityU64 `json:"-"` // FIXME, when and if i'm implemented in Parity
 		ECIP1080Transition        *ParityU64 `json:"-"` // FIXME, when and if i'm implemented in Parity
+		ECBP1100Transition        *ParityU64 `json:"ecbp1100Transition,omitempty"`
 
 		ForkBlock     *ParityU64   `json:"forkBlock,omitempty"`
 		ForkCanonHash *common.Hash `json:"forkCanonHash,omitempty"`
@@ -360,6 +374,21 @@ type ParityChainSpecBLS12PairingPricing struct {
 	Pair uint64 `json:"pair"`
 }
 
+// UnmarshalJSON decodes the chain specification, rejecting the ones sealed by
+// an engine which cannot be run.
+func (spec *ParityChainSpec) UnmarshalJSON(input []byte) error {
+	type parityChainSpec ParityChainSpec
+	var dec parityChainSpec
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Engine.AuthorityRound != nil {
+		return ErrUnsupportedAuthorityRound
+	}
+	*spec = ParityChainSpec(dec)
+	return nil
+}
+
 func (spec *ParityChainSpec) GetPrecompile(address common.Address, pricing ParityChainSpecPricing) *ParityU64 {
 	if spec.Accounts == nil {
 		return nil
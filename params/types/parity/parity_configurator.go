@@ -624,6 +624,51 @@ func (spec *ParityChainSpec) SetEIP2537Transition(n *uint64) error {
 	return nil
 }
 
+func (spec *ParityChainSpec) GetEIP2929Transition() *uint64 {
+	return spec.Params.EIP2929Transition.Uint64P()
+}
+
+func (spec *ParityChainSpec) SetEIP2929Transition(n *uint64) error {
+	spec.Params.EIP2929Transition = new(ParityU64).SetUint64(n)
+	return nil
+}
+
+func (spec *ParityChainSpec) GetEIP2930Transition() *uint64 {
+	return spec.Params.EIP2930Transition.Uint64P()
+}
+
+func (spec *ParityChainSpec) SetEIP2930Transition(n *uint64) error {
+	spec.Params.EIP2930Transition = new(ParityU64).SetUint64(n)
+	return nil
+}
+
+func (spec *ParityChainSpec) GetEIP2718Transition() *uint64 {
+	return spec.Params.EIP2718Transition.Uint64P()
+}
+
+func (spec *ParityChainSpec) SetEIP2718Transition(n *uint64) error {
+	spec.Params.EIP2718Transition = new(ParityU64).SetUint64(n)
+	return nil
+}
+
+func (spec *ParityChainSpec) GetEIP1559Transition() *uint64 {
+	return spec.Params.EIP1559Transition.Uint64P()
+}
+
+func (spec *ParityChainSpec) SetEIP1559Transition(n *uint64) error {
+	spec.Params.EIP1559Transition = new(ParityU64).SetUint64(n)
+	return nil
+}
+
+func (spec *ParityChainSpec) GetECBP1100Transition() *uint64 {
+	return spec.Params.ECBP1100Transition.Uint64P()
+}
+
+func (spec *ParityChainSpec) SetECBP1100Transition(n *uint64) error {
+	spec.Params.ECBP1100Transition = new(ParityU64).SetUint64(n)
+	return nil
+}
+
 func (spec *ParityChainSpec) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
@@ -963,15 +1008,15 @@ func (spec *ParityChainSpec) SetEthashECIP1041Transition(n *uint64) error {
 	return nil
 }
 
-func (spec *ParityChainSpec) GetECIP1092Transition() *uint64 {
+func (spec *ParityChainSpec) GetEthashECIP1099Transition() *uint64 {
 	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
 		return nil
 	}
-	return spec.Engine.Ethash.Params.ECIP1092Transition.Uint64P()
+	return spec.Engine.Ethash.Params.ECIP1099Transition.Uint64P()
 }
 
-func (spec *ParityChainSpec) SetECIP1092Transition(n *uint64) error {
-	spec.Engine.Ethash.Params.ECIP1092Transition = new(ParityU64).SetUint64(n)
+func (spec *ParityChainSpec) SetEthashECIP1099Transition(n *uint64) error {
+	spec.Engine.Ethash.Params.ECIP1099Transition = new(ParityU64).SetUint64(n)
 	return nil
 }
 
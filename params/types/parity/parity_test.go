@@ -116,6 +116,33 @@ func TestParityChainSpec_UnmarshalJSON(t *testing.T) {
 	}
 }
 
+// TestParityChainSpec_UnmarshalJSON_AuthorityRound shows that specs sealed
+// by the unsupported authority round engine are rejected.
+func TestParityChainSpec_UnmarshalJSON_AuthorityRound(t *testing.T) {
+	spec := ParityChainSpec{}
+	err := json.Unmarshal([]byte(`
+{
+  "name": "AuthorityRound (Test)",
+  "engine": {
+    "authorityRound": {
+      "params": {
+        "stepDuration": 5,
+        "validators": {
+          "list": ["0x0000000000000000000000000000000000000001"]
+        }
+      }
+    }
+  },
+  "params": {
+    "chainID": "0x2a"
+  }
+}
+`), &spec)
+	if err != ErrUnsupportedAuthorityRound {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrUnsupportedAuthorityRound)
+	}
+}
+
 // TestParityChainSpec_GetPrecompile checks lexographical unmarshaling for maps which can
 // have duplicate keys when unmarshaling builtin pricing.
 func TestParityChainSpec_GetPrecompile(t *testing.T) {
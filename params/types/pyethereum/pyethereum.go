@@ -17,8 +17,12 @@
 package pyethereum
 
 import (
+	"encoding/json"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 )
 
@@ -34,4 +38,108 @@ type PyEthereumGenesisSpec struct {
 	Coinbase   common.Address        `json:"coinbase"`
 	Alloc      genesisT.GenesisAlloc `json:"alloc"`
 	ParentHash common.Hash           `json:"parentHash"`
+
+	Config PyEthereumConfig `json:"config"`
+}
+
+// PyEthereumConfig holds the chain parameters of a pyethereum chain.
+// Keys follow the names used by pyethereum's config.default_config.
+type PyEthereumConfig struct {
+	NetworkID             uint64   `json:"NETWORK_ID"`
+	ChainID               *big.Int `json:"CHAIN_ID,omitempty"`
+	ConsensusStrategy     string   `json:"CONSENSUS_STRATEGY"`
+	AccountInitialNonce   uint64   `json:"ACCOUNT_INITIAL_NONCE"`
+	MaxExtraDataLength    uint64   `json:"MAX_EXTRADATA_LENGTH"`
+	MinGasLimit           uint64   `json:"MIN_GAS_LIMIT"`
+	GasLimitAdjmaxFactor  uint64   `json:"GASLIMIT_ADJMAX_FACTOR"`
+	ContractCodeSizeLimit *uint64  `json:"CONTRACT_CODE_SIZE_LIMIT,omitempty"`
+
+	MinDiff              *big.Int `json:"MIN_DIFF,omitempty"`
+	BlockDiffFactor      *big.Int `json:"BLOCK_DIFF_FACTOR,omitempty"`
+	DiffAdjustmentCutoff *big.Int `json:"DIFF_ADJUSTMENT_CUTOFF,omitempty"`
+	BlockReward          *big.Int `json:"BLOCK_REWARD,omitempty"`
+
+	HomesteadForkBlknum      *uint64 `json:"HOMESTEAD_FORK_BLKNUM,omitempty"`
+	DAOForkBlknum            *uint64 `json:"DAO_FORK_BLKNUM,omitempty"`
+	AntiDOSForkBlknum        *uint64 `json:"ANTI_DOS_FORK_BLKNUM,omitempty"`
+	SpuriousDragonForkBlknum *uint64 `json:"SPURIOUS_DRAGON_FORK_BLKNUM,omitempty"`
+	MetropolisForkBlknum     *uint64 `json:"METROPOLIS_FORK_BLKNUM,omitempty"`
+	ConstantinopleForkBlknum *uint64 `json:"CONSTANTINOPLE_FORK_BLKNUM,omitempty"`
+	PetersburgForkBlknum     *uint64 `json:"PETERSBURG_FORK_BLKNUM,omitempty"`
+	IstanbulForkBlknum       *uint64 `json:"ISTANBUL_FORK_BLKNUM,omitempty"`
+	MuirGlacierForkBlknum    *uint64 `json:"MUIR_GLACIER_FORK_BLKNUM,omitempty"`
+
+	// The following fields are not part of the pyethereum configuration.
+	EIP2ForkBlknum             *uint64 `json:"EIP2_FORK_BLKNUM,omitempty"`
+	EIP7ForkBlknum             *uint64 `json:"EIP7_FORK_BLKNUM,omitempty"`
+	EIP155ForkBlknum           *uint64 `json:"EIP155_FORK_BLKNUM,omitempty"`
+	EIP160ForkBlknum           *uint64 `json:"EIP160_FORK_BLKNUM,omitempty"`
+	EIP161abcForkBlknum        *uint64 `json:"EIP161ABC_FORK_BLKNUM,omitempty"`
+	EIP161dForkBlknum          *uint64 `json:"EIP161D_FORK_BLKNUM,omitempty"`
+	EIP170ForkBlknum           *uint64 `json:"EIP170_FORK_BLKNUM,omitempty"`
+	EIP100BForkBlknum          *uint64 `json:"EIP100B_FORK_BLKNUM,omitempty"`
+	EIP140ForkBlknum           *uint64 `json:"EIP140_FORK_BLKNUM,omitempty"`
+	EIP198ForkBlknum           *uint64 `json:"EIP198_FORK_BLKNUM,omitempty"`
+	EIP211ForkBlknum           *uint64 `json:"EIP211_FORK_BLKNUM,omitempty"`
+	EIP212ForkBlknum           *uint64 `json:"EIP212_FORK_BLKNUM,omitempty"`
+	EIP213ForkBlknum           *uint64 `json:"EIP213_FORK_BLKNUM,omitempty"`
+	EIP214ForkBlknum           *uint64 `json:"EIP214_FORK_BLKNUM,omitempty"`
+	EIP649ForkBlknum           *uint64 `json:"EIP649_FORK_BLKNUM,omitempty"`
+	EIP658ForkBlknum           *uint64 `json:"EIP658_FORK_BLKNUM,omitempty"`
+	EIP145ForkBlknum           *uint64 `json:"EIP145_FORK_BLKNUM,omitempty"`
+	EIP1014ForkBlknum          *uint64 `json:"EIP1014_FORK_BLKNUM,omitempty"`
+	EIP1052ForkBlknum          *uint64 `json:"EIP1052_FORK_BLKNUM,omitempty"`
+	EIP1234ForkBlknum          *uint64 `json:"EIP1234_FORK_BLKNUM,omitempty"`
+	EIP1283ForkBlknum          *uint64 `json:"EIP1283_FORK_BLKNUM,omitempty"`
+	EIP152ForkBlknum           *uint64 `json:"EIP152_FORK_BLKNUM,omitempty"`
+	EIP1108ForkBlknum          *uint64 `json:"EIP1108_FORK_BLKNUM,omitempty"`
+	EIP1344ForkBlknum          *uint64 `json:"EIP1344_FORK_BLKNUM,omitempty"`
+	EIP1884ForkBlknum          *uint64 `json:"EIP1884_FORK_BLKNUM,omitempty"`
+	EIP2028ForkBlknum          *uint64 `json:"EIP2028_FORK_BLKNUM,omitempty"`
+	EIP2200ForkBlknum          *uint64 `json:"EIP2200_FORK_BLKNUM,omitempty"`
+	EIP2200DisableForkBlknum   *uint64 `json:"EIP2200_DISABLE_FORK_BLKNUM,omitempty"`
+	EIP1706ForkBlknum          *uint64 `json:"EIP1706_FORK_BLKNUM,omitempty"`
+	EIP2537ForkBlknum          *uint64 `json:"EIP2537_FORK_BLKNUM,omitempty"`
+	EIP2929ForkBlknum          *uint64 `json:"EIP2929_FORK_BLKNUM,omitempty"`
+	EIP2930ForkBlknum          *uint64 `json:"EIP2930_FORK_BLKNUM,omitempty"`
+	EIP2718ForkBlknum          *uint64 `json:"EIP2718_FORK_BLKNUM,omitempty"`
+	EIP1559ForkBlknum          *uint64 `json:"EIP1559_FORK_BLKNUM,omitempty"`
+	ECIP1010PauseForkBlknum    *uint64 `json:"ECIP1010_PAUSE_FORK_BLKNUM,omitempty"`
+	ECIP1010ContinueForkBlknum *uint64 `json:"ECIP1010_CONTINUE_FORK_BLKNUM,omitempty"`
+	ECIP1017ForkBlknum         *uint64 `json:"ECIP1017_FORK_BLKNUM,omitempty"`
+	ECIP1017EraRounds          *uint64 `json:"ECIP1017_ERA_ROUNDS,omitempty"`
+	ECIP1041ForkBlknum         *uint64 `json:"ECIP1041_FORK_BLKNUM,omitempty"`
+	ECIP1080ForkBlknum         *uint64 `json:"ECIP1080_FORK_BLKNUM,omitempty"`
+	ECBP1100ForkBlknum         *uint64 `json:"ECBP1100_FORK_BLKNUM,omitempty"`
+	ECIP1099ForkBlknum         *uint64 `json:"ECIP1099_FORK_BLKNUM,omitempty"`
+
+	DifficultyBombDelays ctypes.Uint64BigMapEncodesHex `json:"DIFFICULTY_BOMB_DELAYS,omitempty"`
+	BlockRewardSchedule  ctypes.Uint64BigMapEncodesHex `json:"BLOCK_REWARD_SCHEDULE,omitempty"`
+
+	CliquePeriod *uint64 `json:"CLIQUE_PERIOD,omitempty"`
+	CliqueEpoch  *uint64 `json:"CLIQUE_EPOCH,omitempty"`
+
+	RequireBlockHashes map[uint64]common.Hash `json:"REQUIRE_BLOCK_HASHES,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface, omitting granular
+// transitions represented by a fork bundle.
+func (spec PyEthereumGenesisSpec) MarshalJSON() ([]byte, error) {
+	type pyEthereumGenesisSpec PyEthereumGenesisSpec
+	enc := spec
+	enc.forkBundles().Omit()
+	return json.Marshal(pyEthereumGenesisSpec(enc))
+}
+
+// IsPyEthereumGenesisSpec reports whether the JSON input describes a pyethereum
+// chain configuration, as opposed to a go-ethereum style genesis.
+func IsPyEthereumGenesisSpec(input []byte) bool {
+	var dec struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return false
+	}
+	_, ok := dec.Config["NETWORK_ID"]
+	return ok
 }
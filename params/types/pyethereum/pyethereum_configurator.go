@@ -0,0 +1,893 @@
+// Copyright 2019 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains logic implementing the Configurator interface for pyethereum.
+
+Notes:
+Pyethereum groups protocol changes into fork bundles, eg. METROPOLIS_FORK_BLKNUM.
+Granular transitions are implemented on top of them by package forkbundle.
+*/
+
+package pyethereum
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/types/internal/forkbundle"
+	"github.com/ethereum/go-ethereum/params/vars"
+)
+
+const (
+	consensusStrategyPoW    = "pow"
+	consensusStrategyClique = "clique"
+)
+
+func newU64(u *uint64) *uint64 {
+	if u == nil {
+		return nil
+	}
+	n := *u
+	return &n
+}
+
+func newBig(i *big.Int) *big.Int {
+	if i == nil {
+		return nil
+	}
+	return new(big.Int).Set(i)
+}
+
+// forkBundles returns the fork bundles of the configuration.
+func (spec *PyEthereumGenesisSpec) forkBundles() forkbundle.Bundles {
+	c := &spec.Config
+	f := forkbundle.Uint64
+	return forkbundle.Bundles{
+		{Fork: f(&c.HomesteadForkBlknum), EIPs: []forkbundle.Field{
+			f(&c.EIP2ForkBlknum), f(&c.EIP7ForkBlknum),
+		}},
+		{Fork: f(&c.SpuriousDragonForkBlknum), EIPs: []forkbundle.Field{
+			f(&c.EIP155ForkBlknum), f(&c.EIP160ForkBlknum), f(&c.EIP161abcForkBlknum), f(&c.EIP161dForkBlknum), f(&c.EIP170ForkBlknum),
+		}},
+		{Fork: f(&c.MetropolisForkBlknum), EIPs: []forkbundle.Field{
+			f(&c.EIP100BForkBlknum), f(&c.EIP140ForkBlknum), f(&c.EIP198ForkBlknum), f(&c.EIP211ForkBlknum), f(&c.EIP212ForkBlknum),
+			f(&c.EIP213ForkBlknum), f(&c.EIP214ForkBlknum), f(&c.EIP649ForkBlknum), f(&c.EIP658ForkBlknum),
+		}},
+		{Fork: f(&c.ConstantinopleForkBlknum), EIPs: []forkbundle.Field{
+			f(&c.EIP145ForkBlknum), f(&c.EIP1014ForkBlknum), f(&c.EIP1052ForkBlknum), f(&c.EIP1234ForkBlknum), f(&c.EIP1283ForkBlknum),
+		}},
+		{Fork: f(&c.IstanbulForkBlknum), EIPs: []forkbundle.Field{
+			f(&c.EIP152ForkBlknum), f(&c.EIP1108ForkBlknum), f(&c.EIP1344ForkBlknum), f(&c.EIP1884ForkBlknum), f(&c.EIP2028ForkBlknum),
+			f(&c.EIP2200ForkBlknum),
+		}},
+	}
+}
+
+// transition returns the granular transition value if set, or else the value of its fork bundle.
+func (spec *PyEthereumGenesisSpec) transition(eip **uint64) *uint64 {
+	return spec.forkBundles().Get(forkbundle.Uint64(eip))
+}
+
+// setTransition sets a granular transition value and recomputes the fork bundle fields.
+func (spec *PyEthereumGenesisSpec) setTransition(eip **uint64, n *uint64) error {
+	spec.forkBundles().Set(forkbundle.Uint64(eip), n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetAccountStartNonce() *uint64 {
+	return newU64(&spec.Config.AccountInitialNonce)
+}
+
+func (spec *PyEthereumGenesisSpec) SetAccountStartNonce(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Config.AccountInitialNonce = *n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetMaximumExtraDataSize() *uint64 {
+	return newU64(&spec.Config.MaxExtraDataLength)
+}
+
+func (spec *PyEthereumGenesisSpec) SetMaximumExtraDataSize(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Config.MaxExtraDataLength = *n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetMinGasLimit() *uint64 {
+	return newU64(&spec.Config.MinGasLimit)
+}
+
+func (spec *PyEthereumGenesisSpec) SetMinGasLimit(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Config.MinGasLimit = *n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGasLimitBoundDivisor() *uint64 {
+	return newU64(&spec.Config.GasLimitAdjmaxFactor)
+}
+
+func (spec *PyEthereumGenesisSpec) SetGasLimitBoundDivisor(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Config.GasLimitAdjmaxFactor = *n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetNetworkID() *uint64 {
+	return newU64(&spec.Config.NetworkID)
+}
+
+func (spec *PyEthereumGenesisSpec) SetNetworkID(n *uint64) error {
+	if n == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	spec.Config.NetworkID = *n
+	return nil
+}
+
+// GetChainID returns the chain ID, which pyethereum assumes to be the network ID
+// unless configured otherwise.
+func (spec *PyEthereumGenesisSpec) GetChainID() *big.Int {
+	if spec.Config.ChainID == nil {
+		return new(big.Int).SetUint64(spec.Config.NetworkID)
+	}
+	return newBig(spec.Config.ChainID)
+}
+
+func (spec *PyEthereumGenesisSpec) SetChainID(i *big.Int) error {
+	spec.Config.ChainID = newBig(i)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetMaxCodeSize() *uint64 {
+	return newU64(spec.Config.ContractCodeSizeLimit)
+}
+
+func (spec *PyEthereumGenesisSpec) SetMaxCodeSize(n *uint64) error {
+	spec.Config.ContractCodeSizeLimit = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP2ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP2ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP7Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP7ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP7Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP7ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP150Transition() *uint64 {
+	return newU64(spec.Config.AntiDOSForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP150Transition(n *uint64) error {
+	spec.Config.AntiDOSForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP152Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP152ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP152Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP152ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP160Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP160ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP160Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP160ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP161abcTransition() *uint64 {
+	return spec.transition(&spec.Config.EIP161abcForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP161abcTransition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP161abcForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP161dTransition() *uint64 {
+	return spec.transition(&spec.Config.EIP161dForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP161dTransition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP161dForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP170Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP170ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP170Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP170ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP155Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP155ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP155Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP155ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP140Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP140ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP140Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP140ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP198Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP198ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP198Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP198ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP211Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP211ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP211Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP211ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP212Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP212ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP212Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP212ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP213Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP213ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP213Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP213ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP214Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP214ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP214Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP214ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP658Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP658ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP658Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP658ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP145Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP145ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP145Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP145ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1014Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1014ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1014Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1014ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1052Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1052ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1052Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1052ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1283Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1283ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1283Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1283ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1283DisableTransition() *uint64 {
+	return newU64(spec.Config.PetersburgForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1283DisableTransition(n *uint64) error {
+	spec.Config.PetersburgForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1108Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1108ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1108Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1108ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2200Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP2200ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2200Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP2200ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2200DisableTransition() *uint64 {
+	return newU64(spec.Config.EIP2200DisableForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2200DisableTransition(n *uint64) error {
+	spec.Config.EIP2200DisableForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1344Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1344ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1344Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1344ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1884Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP1884ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1884Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1884ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2028Transition() *uint64 {
+	return spec.transition(&spec.Config.EIP2028ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2028Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP2028ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetECIP1080Transition() *uint64 {
+	return newU64(spec.Config.ECIP1080ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetECIP1080Transition(n *uint64) error {
+	spec.Config.ECIP1080ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1706Transition() *uint64 {
+	return newU64(spec.Config.EIP1706ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1706Transition(n *uint64) error {
+	spec.Config.EIP1706ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2537Transition() *uint64 {
+	return newU64(spec.Config.EIP2537ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2537Transition(n *uint64) error {
+	spec.Config.EIP2537ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2929Transition() *uint64 {
+	return newU64(spec.Config.EIP2929ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2929Transition(n *uint64) error {
+	spec.Config.EIP2929ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2930Transition() *uint64 {
+	return newU64(spec.Config.EIP2930ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2930Transition(n *uint64) error {
+	spec.Config.EIP2930ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP2718Transition() *uint64 {
+	return newU64(spec.Config.EIP2718ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP2718Transition(n *uint64) error {
+	spec.Config.EIP2718ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEIP1559Transition() *uint64 {
+	return newU64(spec.Config.EIP1559ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEIP1559Transition(n *uint64) error {
+	spec.Config.EIP1559ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetECBP1100Transition() *uint64 {
+	return newU64(spec.Config.ECBP1100ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetECBP1100Transition(n *uint64) error {
+	spec.Config.ECBP1100ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) IsEnabled(fn func() *uint64, n *big.Int) bool {
+	f := fn()
+	if f == nil || n == nil {
+		return false
+	}
+	return new(big.Int).SetUint64(*f).Cmp(n) <= 0
+}
+
+func (spec *PyEthereumGenesisSpec) GetForkCanonHash(n uint64) common.Hash {
+	if spec.Config.RequireBlockHashes == nil {
+		return common.Hash{}
+	}
+	return spec.Config.RequireBlockHashes[n]
+}
+
+func (spec *PyEthereumGenesisSpec) SetForkCanonHash(n uint64, h common.Hash) error {
+	if spec.Config.RequireBlockHashes == nil {
+		spec.Config.RequireBlockHashes = make(map[uint64]common.Hash)
+	}
+	spec.Config.RequireBlockHashes[n] = h
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetForkCanonHashes() map[uint64]common.Hash {
+	return spec.Config.RequireBlockHashes
+}
+
+// GetConsensusEngineType maps the consensus strategy onto the supported consensus engines.
+// Clique is not supported by pyethereum, and is only recognized for configurations written by this package.
+func (spec *PyEthereumGenesisSpec) GetConsensusEngineType() ctypes.ConsensusEngineT {
+	switch spec.Config.ConsensusStrategy {
+	case consensusStrategyPoW:
+		return ctypes.ConsensusEngineT_Ethash
+	case consensusStrategyClique:
+		return ctypes.ConsensusEngineT_Clique
+	}
+	return ctypes.ConsensusEngineT_Unknown
+}
+
+func (spec *PyEthereumGenesisSpec) MustSetConsensusEngineType(t ctypes.ConsensusEngineT) error {
+	switch t {
+	case ctypes.ConsensusEngineT_Ethash:
+		spec.Config.ConsensusStrategy = consensusStrategyPoW
+		if spec.Config.MinDiff == nil {
+			spec.Config.MinDiff = newBig(vars.MinimumDifficulty)
+		}
+		spec.Config.CliquePeriod = nil
+		spec.Config.CliqueEpoch = nil
+		return nil
+	case ctypes.ConsensusEngineT_Clique:
+		spec.Config.ConsensusStrategy = consensusStrategyClique
+		if spec.Config.CliquePeriod == nil {
+			spec.Config.CliquePeriod = new(uint64)
+		}
+		if spec.Config.CliqueEpoch == nil {
+			epoch := uint64(30000)
+			spec.Config.CliqueEpoch = &epoch
+		}
+		spec.Config.MinDiff = nil
+		return nil
+	default:
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashMinimumDifficulty() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newBig(spec.Config.MinDiff)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashMinimumDifficulty(i *big.Int) error {
+	spec.Config.MinDiff = newBig(i)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashDifficultyBoundDivisor() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newBig(spec.Config.BlockDiffFactor)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashDifficultyBoundDivisor(i *big.Int) error {
+	spec.Config.BlockDiffFactor = newBig(i)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashDurationLimit() *big.Int {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newBig(spec.Config.DiffAdjustmentCutoff)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashDurationLimit(i *big.Int) error {
+	spec.Config.DiffAdjustmentCutoff = newBig(i)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashHomesteadTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	x, y := spec.GetEIP2Transition(), spec.GetEIP7Transition()
+	if x == nil || y == nil {
+		return nil
+	}
+	if *x > *y {
+		return x
+	}
+	return y
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashHomesteadTransition(n *uint64) error {
+	if err := spec.SetEIP2Transition(n); err != nil {
+		return err
+	}
+	return spec.SetEIP7Transition(n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashEIP779Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.DAOForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashEIP779Transition(n *uint64) error {
+	spec.Config.DAOForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashEIP649Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Config.EIP649ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashEIP649Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP649ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashEIP1234Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Config.EIP1234ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashEIP1234Transition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP1234ForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashEIP2384Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.MuirGlacierForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashEIP2384Transition(n *uint64) error {
+	spec.Config.MuirGlacierForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1010PauseTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1010PauseForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1010PauseTransition(n *uint64) error {
+	spec.Config.ECIP1010PauseForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1010ContinueTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1010ContinueForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1010ContinueTransition(n *uint64) error {
+	spec.Config.ECIP1010ContinueForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1017Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1017ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1017Transition(n *uint64) error {
+	spec.Config.ECIP1017ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1017EraRounds() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1017EraRounds)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1017EraRounds(n *uint64) error {
+	spec.Config.ECIP1017EraRounds = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashEIP100BTransition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.transition(&spec.Config.EIP100BForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashEIP100BTransition(n *uint64) error {
+	return spec.setTransition(&spec.Config.EIP100BForkBlknum, n)
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1041Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1041ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1041Transition(n *uint64) error {
+	spec.Config.ECIP1041ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashECIP1099Transition() *uint64 {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return newU64(spec.Config.ECIP1099ForkBlknum)
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashECIP1099Transition(n *uint64) error {
+	spec.Config.ECIP1099ForkBlknum = newU64(n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetEthashDifficultyBombDelaySchedule() ctypes.Uint64BigMapEncodesHex {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return spec.Config.DifficultyBombDelays
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashDifficultyBombDelaySchedule(m ctypes.Uint64BigMapEncodesHex) error {
+	spec.Config.DifficultyBombDelays = m
+	return nil
+}
+
+// GetEthashBlockRewardSchedule returns the configured block reward schedule.
+// A native BLOCK_REWARD value is only reported if it differs from the Frontier block reward.
+func (spec *PyEthereumGenesisSpec) GetEthashBlockRewardSchedule() ctypes.Uint64BigMapEncodesHex {
+	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	if spec.Config.BlockRewardSchedule != nil {
+		return spec.Config.BlockRewardSchedule
+	}
+	if r := spec.Config.BlockReward; r != nil && r.Cmp(vars.FrontierBlockReward) != 0 {
+		return ctypes.Uint64BigMapEncodesHex{0: newBig(r)}
+	}
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) SetEthashBlockRewardSchedule(m ctypes.Uint64BigMapEncodesHex) error {
+	spec.Config.BlockRewardSchedule = m
+	if r, ok := m[0]; ok {
+		spec.Config.BlockReward = newBig(r)
+	} else {
+		spec.Config.BlockReward = newBig(vars.FrontierBlockReward)
+	}
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetCliquePeriod() uint64 {
+	if spec.Config.CliquePeriod == nil {
+		return 0
+	}
+	return *spec.Config.CliquePeriod
+}
+
+func (spec *PyEthereumGenesisSpec) SetCliquePeriod(n uint64) error {
+	spec.Config.CliquePeriod = &n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetCliqueEpoch() uint64 {
+	if spec.Config.CliqueEpoch == nil {
+		return 0
+	}
+	return *spec.Config.CliqueEpoch
+}
+
+func (spec *PyEthereumGenesisSpec) SetCliqueEpoch(n uint64) error {
+	spec.Config.CliqueEpoch = &n
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetSealingType() ctypes.BlockSealingT {
+	return ctypes.BlockSealing_Ethereum
+}
+
+func (spec *PyEthereumGenesisSpec) SetSealingType(t ctypes.BlockSealingT) error {
+	if t != ctypes.BlockSealing_Ethereum {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	return nil
+}
+
+// GetGenesisSealerEthereumNonce returns the genesis nonce, which follows the
+// little-endian encoding used by NewPyEthereumGenesisSpec.
+func (spec *PyEthereumGenesisSpec) GetGenesisSealerEthereumNonce() uint64 {
+	var nonce [8]byte
+	copy(nonce[:], spec.Nonce)
+	return binary.LittleEndian.Uint64(nonce[:])
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisSealerEthereumNonce(n uint64) error {
+	spec.Nonce = make(hexutil.Bytes, 8)
+	binary.LittleEndian.PutUint64(spec.Nonce, n)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisSealerEthereumMixHash() common.Hash {
+	return spec.Mixhash
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisSealerEthereumMixHash(h common.Hash) error {
+	spec.Mixhash = h
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisDifficulty() *big.Int {
+	return spec.Difficulty.ToInt()
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisDifficulty(i *big.Int) error {
+	spec.Difficulty = (*hexutil.Big)(newBig(i))
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisAuthor() common.Address {
+	return spec.Coinbase
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisAuthor(a common.Address) error {
+	spec.Coinbase = a
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisTimestamp() uint64 {
+	return uint64(spec.Timestamp)
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisTimestamp(u uint64) error {
+	spec.Timestamp = hexutil.Uint64(u)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisParentHash() common.Hash {
+	return spec.ParentHash
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisParentHash(h common.Hash) error {
+	spec.ParentHash = h
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisExtraData() []byte {
+	return spec.ExtraData
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisExtraData(b []byte) error {
+	spec.ExtraData = b
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) GetGenesisGasLimit() uint64 {
+	return uint64(spec.GasLimit)
+}
+
+func (spec *PyEthereumGenesisSpec) SetGenesisGasLimit(u uint64) error {
+	spec.GasLimit = hexutil.Uint64(u)
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) ForEachAccount(fn func(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error) error {
+	for k, v := range spec.Alloc {
+		bal := v.Balance
+		if bal == nil {
+			bal = new(big.Int)
+		}
+		if err := fn(k, bal, v.Nonce, v.Code, v.Storage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (spec *PyEthereumGenesisSpec) UpdateAccount(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error {
+	if spec.Alloc == nil {
+		spec.Alloc = make(genesisT.GenesisAlloc)
+	}
+	spec.Alloc[address] = genesisT.GenesisAccount{
+		Balance: newBig(bal),
+		Nonce:   nonce,
+		Code:    code,
+		Storage: storage,
+	}
+	return nil
+}
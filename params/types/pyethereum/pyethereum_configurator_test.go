@@ -0,0 +1,88 @@
+// Copyright 2019 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+package pyethereum
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+// This file contains a few unit tests for the pyethereum-specific configuration interface.
+// Conversion of complete configurations is covered by the integration tests.
+
+func TestPyEthereumGenesisSpec_GetConsensusEngineType(t *testing.T) {
+	spec := new(PyEthereumGenesisSpec)
+	if engine := spec.GetConsensusEngineType(); engine != ctypes.ConsensusEngineT_Unknown {
+		t.Error("unwanted engine type", engine)
+	}
+	if err := spec.MustSetConsensusEngineType(ctypes.ConsensusEngineT_Ethash); err != nil {
+		t.Fatal(err)
+	}
+	if engine := spec.GetConsensusEngineType(); engine != ctypes.ConsensusEngineT_Ethash {
+		t.Error("mismatch engine", engine)
+	}
+}
+
+func TestPyEthereumGenesisSpec_ForkBundles(t *testing.T) {
+	spec := new(PyEthereumGenesisSpec)
+	spec.MustSetConsensusEngineType(ctypes.ConsensusEngineT_Ethash)
+
+	n42, n43 := uint64(42), uint64(43)
+	spec.SetEthashHomesteadTransition(&n42)
+	if spec.Config.HomesteadForkBlknum == nil || *spec.Config.HomesteadForkBlknum != n42 {
+		t.Fatal("homestead bundle not set")
+	}
+
+	// Split the bundle.
+	spec.SetEIP7Transition(&n43)
+	if spec.Config.HomesteadForkBlknum != nil {
+		t.Error("homestead bundle set for unequal transitions")
+	}
+	if v := spec.GetEIP2Transition(); v == nil || *v != n42 {
+		t.Error("eip2 lost bundle value", v)
+	}
+	if v := spec.GetEthashHomesteadTransition(); v == nil || *v != n43 {
+		t.Error("wrong homestead transition", v)
+	}
+
+	// And rejoin it.
+	spec.SetEIP2Transition(&n43)
+	if spec.Config.HomesteadForkBlknum == nil || *spec.Config.HomesteadForkBlknum != n43 {
+		t.Error("homestead bundle not set")
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec struct {
+		Config map[string]interface{} `json:"config"`
+	}
+	if err := json.Unmarshal(b, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dec.Config["EIP2_FORK_BLKNUM"]; ok {
+		t.Error("granular transition written for set bundle")
+	}
+	if !IsPyEthereumGenesisSpec(b) {
+		t.Error("not recognized as pyethereum")
+	}
+	if IsPyEthereumGenesisSpec([]byte(`{"config":{"chainId":1}}`)) {
+		t.Error("recognized geth config as pyethereum")
+	}
+}
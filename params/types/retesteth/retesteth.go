@@ -0,0 +1,175 @@
+// Copyright 2019 The multi-geth Authors
+// This file is part of the multi-geth library.
+//
+// The multi-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The multi-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the multi-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package retesteth implements the chain configuration format used by retesteth,
+ie. the parameter of its test_setChainParams method.
+
+The format is derived from Aleth's, so the Configurator implementation is
+inherited from the aleth package. The differences are in encoding only:
+the genesis nonce is a quantity, and accounts use 0x-prefixed addresses.
+*/
+package retesteth
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/types/aleth"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+// RetestethGenesisSpec represents the chain configuration format used by retesteth.
+type RetestethGenesisSpec struct {
+	aleth.AlethGenesisSpec
+}
+
+type retestethGenesis struct {
+	Nonce      math.HexOrDecimal64 `json:"nonce"`
+	Difficulty *hexutil.Big        `json:"difficulty"`
+	MixHash    common.Hash         `json:"mixHash"`
+	Author     common.Address      `json:"author"`
+	Timestamp  hexutil.Uint64      `json:"timestamp"`
+	ParentHash common.Hash         `json:"parentHash"`
+	ExtraData  hexutil.Bytes       `json:"extraData"`
+	GasLimit   hexutil.Uint64      `json:"gasLimit"`
+}
+
+type retestethAccount struct {
+	Balance     *math.HexOrDecimal256          `json:"balance,omitempty"`
+	Nonce       math.HexOrDecimal64            `json:"nonce"`
+	Code        hexutil.Bytes                  `json:"code"`
+	Storage     map[common.Hash]common.Hash    `json:"storage"`
+	Precompiled *aleth.AlethGenesisSpecBuiltin `json:"precompiled,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (spec RetestethGenesisSpec) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(spec.AlethGenesisSpec)
+	if err != nil {
+		return nil, err
+	}
+	var enc map[string]json.RawMessage
+	if err := json.Unmarshal(b, &enc); err != nil {
+		return nil, err
+	}
+	g := spec.AlethGenesisSpec.Genesis
+	enc["genesis"], err = json.Marshal(retestethGenesis{
+		Nonce:      math.HexOrDecimal64(spec.GetGenesisSealerEthereumNonce()),
+		Difficulty: g.Difficulty,
+		MixHash:    g.MixHash,
+		Author:     g.Author,
+		Timestamp:  g.Timestamp,
+		ParentHash: g.ParentHash,
+		ExtraData:  g.ExtraData,
+		GasLimit:   g.GasLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[common.Address]*retestethAccount, len(spec.Accounts))
+	for k, v := range spec.Accounts {
+		storage := v.Storage
+		if storage == nil {
+			storage = make(map[common.Hash]common.Hash)
+		}
+		accounts[common.Address(k)] = &retestethAccount{
+			Balance:     v.Balance,
+			Nonce:       math.HexOrDecimal64(v.Nonce),
+			Code:        v.Code,
+			Storage:     storage,
+			Precompiled: v.Precompiled,
+		}
+	}
+	enc["accounts"], err = json.Marshal(accounts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (spec *RetestethGenesisSpec) UnmarshalJSON(input []byte) error {
+	// The seal engine and params are shared with the Aleth format.
+	var shared struct {
+		SealEngine string          `json:"sealEngine"`
+		Params     json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(input, &shared); err != nil {
+		return err
+	}
+	b, err := json.Marshal(shared)
+	if err != nil {
+		return err
+	}
+	var a aleth.AlethGenesisSpec
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+
+	var dec struct {
+		Genesis  retestethGenesis                     `json:"genesis"`
+		Accounts map[common.Address]*retestethAccount `json:"accounts"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	a.Genesis.Nonce = make(hexutil.Bytes, 8)
+	binary.LittleEndian.PutUint64(a.Genesis.Nonce, uint64(dec.Genesis.Nonce))
+	a.Genesis.Difficulty = dec.Genesis.Difficulty
+	a.Genesis.MixHash = dec.Genesis.MixHash
+	a.Genesis.Author = dec.Genesis.Author
+	a.Genesis.Timestamp = dec.Genesis.Timestamp
+	a.Genesis.ParentHash = dec.Genesis.ParentHash
+	a.Genesis.ExtraData = dec.Genesis.ExtraData
+	a.Genesis.GasLimit = dec.Genesis.GasLimit
+
+	if dec.Accounts != nil {
+		a.Accounts = make(map[common.UnprefixedAddress]*aleth.AlethGenesisSpecAccount, len(dec.Accounts))
+	}
+	for k, v := range dec.Accounts {
+		var storage map[common.Hash]common.Hash
+		if len(v.Storage) > 0 {
+			storage = v.Storage
+		}
+		a.Accounts[common.UnprefixedAddress(k)] = &aleth.AlethGenesisSpecAccount{
+			Balance:     v.Balance,
+			Nonce:       uint64(v.Nonce),
+			Code:        v.Code,
+			Storage:     storage,
+			Precompiled: v.Precompiled,
+		}
+	}
+	spec.AlethGenesisSpec = a
+	return nil
+}
+
+// MustSetConsensusEngineType sets the consensus engine type.
+// Retesteth configurations which are not already using an Ethash-type seal
+// engine use NoProof, skipping proof-of-work verification.
+func (spec *RetestethGenesisSpec) MustSetConsensusEngineType(t ctypes.ConsensusEngineT) error {
+	wasEthash := spec.GetConsensusEngineType() == ctypes.ConsensusEngineT_Ethash
+	if err := spec.AlethGenesisSpec.MustSetConsensusEngineType(t); err != nil {
+		return err
+	}
+	if t == ctypes.ConsensusEngineT_Ethash && !wasEthash {
+		spec.SealEngine = "NoProof"
+	}
+	return nil
+}
@@ -102,7 +102,8 @@ Signed integers, floating point numbers, maps, channels and functions cannot be
 
 Struct Tags
 
-Package rlp honours certain struct tags: "-", "tail", "nil", "nilList" and "nilString".
+Package rlp honours certain struct tags: "-", "tail", "nil", "nilList", "nilString" and
+"optional".
 
 The "-" tag ignores fields.
 
@@ -126,5 +127,16 @@ an empty RLP list.
 The choice of null value can be made explicit with the "nilList" and "nilString" struct
 tags. Using these tags encodes/decodes a Go nil pointer value as the kind of empty
 RLP value defined by the tag.
+
+The "optional" tag allows a trailing struct field to be missing from the input list.
+When decoding, missing optional fields are set to their zero value. When encoding,
+optional fields holding the zero value are omitted from the end of the output list.
+If a field is tagged "optional", all subsequent exported fields must also be tagged
+"optional" or "tail".
+
+    type StructWithOptionalTail struct {
+        A uint
+        B uint `rlp:"optional"`
+    }
 */
 package rlp
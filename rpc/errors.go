@@ -16,7 +16,10 @@
 
 package rpc
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 var (
 	_ Error = new(methodNotFoundError)
@@ -25,6 +28,8 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(callTimeoutError)
+	_ Error = new(responseTooLargeError)
 )
 
 const defaultErrorCode = -32000
@@ -72,3 +77,24 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// method call ran past its execution timeout
+type callTimeoutError struct {
+	method  string
+	timeout time.Duration
+}
+
+func (e *callTimeoutError) ErrorCode() int { return -32002 }
+
+func (e *callTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v", e.method, e.timeout)
+}
+
+// results of a call or batch exceed the response size limit
+type responseTooLargeError struct{ limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large, exceeds %d bytes", e.limit)
+}
@@ -19,6 +19,8 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -28,6 +30,14 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
+// maxDetachedCalls is the maximum number of calls with an execution timeout a
+// connection may have running, counting the ones which timed out but haven't
+// returned yet.
+const maxDetachedCalls = 16
+
+// errDetachedLimit is returned by runDetached when maxDetachedCalls are running.
+var errDetachedLimit = errors.New("too many running calls")
+
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
 // handler is not safe for concurrent use. Message handling never blocks indefinitely
 // because RPCs are processed on background goroutines launched by handler.
@@ -56,6 +66,7 @@ type handler struct {
 	respWait       map[string]*requestOp          // active client requests
 	clientSubs     map[string]*ClientSubscription // active client subscriptions
 	callWG         sync.WaitGroup                 // pending call goroutines
+	detached       chan struct{}                  // semaphore of the running calls with a timeout
 	rootCtx        context.Context                // canceled by close()
 	cancelRoot     func()                         // cancel function for rootCtx
 	conn           jsonWriter                     // where responses will be sent
@@ -69,6 +80,7 @@ type handler struct {
 type callProc struct {
 	ctx       context.Context
 	notifiers []*Notifier
+	respSize  int // size of the results answered so far, checked against the response limit
 }
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
@@ -79,6 +91,7 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		conn:           conn,
 		respWait:       make(map[string]*requestOp),
 		clientSubs:     make(map[string]*ClientSubscription),
+		detached:       make(chan struct{}, maxDetachedCalls),
 		rootCtx:        rootCtx,
 		cancelRoot:     cancelRoot,
 		allowSubscribe: true,
@@ -101,6 +114,24 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	limits := h.reg.serverLimits()
+	if limits.BatchItems > 0 && len(msgs) > limits.BatchItems {
+		batchLimitMeter.Mark(1)
+		h.startCallProc(func(cp *callProc) {
+			err := &invalidRequestError{fmt.Sprintf("batch too large, exceeds %d requests", limits.BatchItems)}
+			answers := make([]*jsonrpcMessage, 0, len(msgs))
+			for _, msg := range msgs {
+				if msg.hasValidID() {
+					answers = append(answers, msg.errorResponse(err))
+				}
+			}
+			if len(answers) == 0 {
+				answers = append(answers, errorMessage(err))
+			}
+			h.conn.writeJSON(cp.ctx, answers)
+		})
+		return
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -317,6 +348,11 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if !msg.isUnsubscribe() {
+		if err := h.reg.filterCall(cp.ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -333,8 +369,53 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
-	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	// Bound the execution time and the response size of method calls, leaving
+	// unsubscriptions alone. The results of a batch share the response limit.
+	var (
+		limits  = h.reg.serverLimits()
+		ctx     = cp.ctx
+		timeout time.Duration
+		maxSize int
+	)
+	if callb != h.unsubscribeCb {
+		if limits.ResponseSize > 0 {
+			if maxSize = limits.ResponseSize - cp.respSize; maxSize <= 0 {
+				responseLimitMeter.Mark(1)
+				return msg.errorResponse(&responseTooLargeError{limits.ResponseSize})
+			}
+		}
+		if timeout = limits.callTimeout(msg.Method); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+	var (
+		start  = time.Now()
+		result interface{}
+		answer *jsonrpcMessage
+	)
+	if timeout > 0 {
+		result, err = h.runDetached(ctx, msg, callb, args)
+	} else {
+		result, err = callb.call(ctx, msg.Method, args)
+	}
+	switch {
+	case timeout > 0 && (ctx.Err() == context.DeadlineExceeded || err == errDetachedLimit):
+		callTimeoutMeter.Mark(1)
+		answer = msg.errorResponse(&callTimeoutError{msg.Method, timeout})
+	case err != nil:
+		answer = msg.errorResponse(err)
+	case maxSize > 0:
+		if answer, err = msg.limitedResponse(result, maxSize); err == errResponseLimit {
+			responseLimitMeter.Mark(1)
+			h.log.Debug("Dropped oversized RPC response", "method", msg.Method, "reqid", idForLog{msg.ID}, "limit", limits.ResponseSize)
+			answer = msg.errorResponse(&responseTooLargeError{limits.ResponseSize})
+		}
+		cp.respSize += len(answer.Result)
+	default:
+		answer = msg.response(result)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -347,6 +428,10 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		}
 		rpcServingTimer.UpdateSince(start)
 		newRPCServingTimer(msg.Method, answer.Error == nil).UpdateSince(start)
+
+		if elapsed := time.Since(start); limits.SlowCallThreshold > 0 && elapsed >= limits.SlowCallThreshold {
+			logSlowCall(h.log, msg, elapsed)
+		}
 	}
 	return answer
 }
@@ -384,6 +469,38 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	return h.runMethod(ctx, msg, callb, args)
 }
 
+// runDetached runs the Go callback for an RPC method in the background, returning
+// as soon as the context is done. The callback is then left to finish on its own,
+// with its context canceled, and its result is discarded. The callbacks running
+// in the background are bounded by maxDetachedCalls, further calls are rejected.
+func (h *handler) runDetached(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) (interface{}, error) {
+	select {
+	case h.detached <- struct{}{}:
+	default:
+		return nil, errDetachedLimit
+	}
+	type callResult struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan callResult, 1)
+	h.callWG.Add(1)
+	go func() {
+		defer func() {
+			<-h.detached
+			h.callWG.Done()
+		}()
+		result, err := callb.call(ctx, msg.Method, args)
+		done <- callResult{result, err}
+	}()
+	select {
+	case res := <-done:
+		return res.result, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
 	result, err := callb.call(ctx, msg.Method, args)
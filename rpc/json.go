@@ -19,11 +19,13 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -118,6 +120,135 @@ func (msg *jsonrpcMessage) response(result interface{}) *jsonrpcMessage {
 	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc}
 }
 
+// limitedResponse is like response, but fails with errResponseLimit as soon as
+// the encoded result exceeds the given size.
+func (msg *jsonrpcMessage) limitedResponse(result interface{}, limit int) (*jsonrpcMessage, error) {
+	enc := &limitedEncoder{limit: limit}
+	if err := enc.encode(reflect.ValueOf(result)); err != nil {
+		if err == errResponseLimit {
+			return nil, err
+		}
+		return msg.errorResponse(err), nil
+	}
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc.buf.Bytes()}, nil
+}
+
+var (
+	errResponseLimit = errors.New("response size limit exceeded")
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// limitedEncoder produces the same encoding as json.Marshal, checking the size
+// of the output as it grows. Lists and maps are encoded element by element, so
+// large results are rejected without being encoded as a whole.
+type limitedEncoder struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (e *limitedEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return e.write([]byte("null"))
+	}
+	if isMarshaler(v) {
+		return e.marshal(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		return e.encode(v.Elem())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.marshal(v) // base64 encoded
+		}
+		return e.encodeList(v)
+
+	case reflect.Array:
+		return e.encodeList(v)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return e.marshal(v)
+		}
+		if v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		e.buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(key.String())
+			e.buf.Write(name)
+			e.buf.WriteByte(':')
+			if err := e.encode(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return e.write([]byte{'}'})
+
+	default:
+		return e.marshal(v)
+	}
+}
+
+func (e *limitedEncoder) encodeList(v reflect.Value) error {
+	e.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return e.write([]byte{']'})
+}
+
+// marshal encodes a value with json.Marshal, using the methods of its pointer
+// receiver if addressable as the json package does.
+func (e *limitedEncoder) marshal(v reflect.Value) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	enc, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	return e.write(enc)
+}
+
+func (e *limitedEncoder) write(b []byte) error {
+	if e.buf.Write(b); e.buf.Len() > e.limit {
+		return errResponseLimit
+	}
+	return nil
+}
+
+// isMarshaler reports whether a value encodes itself.
+func isMarshaler(v reflect.Value) bool {
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		pt := reflect.PtrTo(t)
+		return pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType)
+	}
+	return false
+}
+
 func errorMessage(err error) *jsonrpcMessage {
 	msg := &jsonrpcMessage{Version: vsn, ID: null, Error: &jsonError{
 		Code:    defaultErrorCode,
@@ -17,8 +17,11 @@
 package rpc
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"time"
 
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 )
 
@@ -27,6 +30,11 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	slowCallMeter      = metrics.NewRegisteredMeter("rpc/slow/all", nil)
+	callTimeoutMeter   = metrics.NewRegisteredMeter("rpc/limit/timeout", nil)
+	batchLimitMeter    = metrics.NewRegisteredMeter("rpc/limit/batch", nil)
+	responseLimitMeter = metrics.NewRegisteredMeter("rpc/limit/response", nil)
 )
 
 func newRPCServingTimer(method string, valid bool) metrics.Timer {
@@ -37,3 +45,14 @@ func newRPCServingTimer(method string, valid bool) metrics.Timer {
 	m := fmt.Sprintf("rpc/duration/%s/%s", method, flag)
 	return metrics.GetOrRegisterTimer(m, nil)
 }
+
+// logSlowCall reports a call running longer than the slow call threshold. The
+// parameters are identified by their hash, keeping the logs compact while
+// allowing repeated calls to be correlated.
+func logSlowCall(logger log.Logger, msg *jsonrpcMessage, elapsed time.Duration) {
+	slowCallMeter.Mark(1)
+	metrics.GetOrRegisterMeter(fmt.Sprintf("rpc/slow/%s", msg.Method), nil).Mark(1)
+
+	hash := sha256.Sum256(msg.Params)
+	logger.Warn("Slow RPC call", "method", msg.Method, "reqid", idForLog{msg.ID}, "params", fmt.Sprintf("%x", hash[:8]), "t", elapsed)
+}
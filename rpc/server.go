@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/log"
@@ -114,12 +115,71 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// CallFilter decides whether a method may be called, given the context of the
+// connection the call arrived on. A non-nil error is returned to the caller
+// instead of running the method.
+type CallFilter func(ctx context.Context, method string) error
+
+// SetCallFilter installs a filter checking all the method calls and subscriptions
+// served by the server.
+func (s *Server) SetCallFilter(filter CallFilter) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+
+	s.services.filter = filter
+}
+
+// ServerLimits bounds the resources used to serve the calls of a server. Zero
+// values disable the corresponding limits.
+type ServerLimits struct {
+	// BatchItems is the maximum number of requests in a batch.
+	BatchItems int
+
+	// ResponseSize is the maximum size in bytes of the results of a call, or of
+	// all the calls of a batch.
+	ResponseSize int
+
+	// CallTimeout is the execution timeout of the method calls, propagated to
+	// the methods through their context.
+	CallTimeout time.Duration
+
+	// MethodTimeouts overrides the execution timeout of individual methods, with
+	// zero disabling the timeout of a method.
+	MethodTimeouts map[string]time.Duration
+
+	// SlowCallThreshold is the execution time beyond which calls are logged and
+	// counted as slow.
+	SlowCallThreshold time.Duration
+}
+
+// callTimeout returns the execution timeout of a method.
+func (l *ServerLimits) callTimeout(method string) time.Duration {
+	if timeout, ok := l.MethodTimeouts[method]; ok {
+		return timeout
+	}
+	return l.CallTimeout
+}
+
+// SetLimits sets the resource limits of the calls served by the server.
+func (s *Server) SetLimits(limits ServerLimits) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+
+	s.services.limits = limits
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec)
+}
+
+// serveCodec serves the requests read from codec, with handlers derived from the
+// given connection context.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -131,7 +191,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(ctx, codec, s.idgen, &s.services)
 	<-codec.closed()
 	c.Close()
 }
@@ -19,11 +19,15 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -150,3 +154,147 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+func TestServerLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetLimits(ServerLimits{
+		BatchItems:     3,
+		ResponseSize:   100,
+		CallTimeout:    50 * time.Millisecond,
+		MethodTimeouts: map[string]time.Duration{"test_sleep": 0},
+	})
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Calls are canceled past their timeout, unless overridden for the method
+	err := client.Call(nil, "test_block")
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32002 {
+		t.Errorf("timeout error mismatch: have %v", err)
+	}
+	if err := client.Call(nil, "test_sleep", 100*time.Millisecond); err != nil {
+		t.Errorf("call without timeout failed: %v", err)
+	}
+	// Oversized responses are replaced by errors
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "small", 1, nil); err != nil {
+		t.Errorf("small response failed: %v", err)
+	}
+	err = client.Call(&result, "test_echo", strings.Repeat("x", 100), 1, nil)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32003 {
+		t.Errorf("response size error mismatch: have %v", err)
+	}
+	// Batches fail as a whole if too long, or from the call exceeding the size
+	batch := []BatchElem{
+		{Method: "test_echo", Args: []interface{}{strings.Repeat("x", 40), 1, nil}, Result: new(echoResult)},
+		{Method: "test_echo", Args: []interface{}{strings.Repeat("x", 40), 2, nil}, Result: new(echoResult)},
+		{Method: "test_echo", Args: []interface{}{strings.Repeat("x", 40), 3, nil}, Result: new(echoResult)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	for i, elem := range batch {
+		if (elem.Error != nil) != (i > 0) {
+			t.Errorf("batch element %d: error mismatch: have %v", i, elem.Error)
+		}
+	}
+	batch = append(batch, BatchElem{Method: "test_echo", Args: []interface{}{"", 4, nil}, Result: new(echoResult)})
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	for i, elem := range batch {
+		if rpcErr, ok := elem.Error.(Error); !ok || rpcErr.ErrorCode() != -32600 {
+			t.Errorf("batch element %d: error mismatch: have %v", i, elem.Error)
+		}
+	}
+}
+
+// Tests that calls exceeding their timeout are answered right away, even if the
+// method doesn't return.
+func TestServerCallTimeout(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetLimits(ServerLimits{CallTimeout: 50 * time.Millisecond})
+	client := DialInProc(server)
+	defer client.Close()
+
+	start := time.Now()
+	err := client.Call(nil, "test_sleep", time.Second)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32002 {
+		t.Errorf("timeout error mismatch: have %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timeout answered after %v", elapsed)
+	}
+}
+
+func TestServerDetachedCallLimit(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetLimits(ServerLimits{CallTimeout: 20 * time.Millisecond})
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Fill the connection with calls running past their timeout
+	var wg sync.WaitGroup
+	for i := 0; i < maxDetachedCalls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Call(nil, "test_sleep", 500*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	// Further calls are rejected until they return
+	err := client.Call(nil, "test_sleep", time.Duration(0))
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32002 {
+		t.Errorf("limit error mismatch: have %v", err)
+	}
+	time.Sleep(time.Second)
+	if err := client.Call(nil, "test_sleep", time.Duration(0)); err != nil {
+		t.Errorf("call failed after the running calls returned: %v", err)
+	}
+}
+
+func TestLimitedEncoder(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Value *big.Int
+	}
+	values := []interface{}{
+		nil,
+		"<a&b>",
+		[]byte{1, 2, 3},
+		[]int(nil),
+		[]big.Int{*big.NewInt(1), *big.NewInt(2)},
+		[]*item{{"a", big.NewInt(1)}, nil},
+		map[string]interface{}{"b": []interface{}{1, "x", nil}, "a": &item{Name: "<>"}, "c": json.RawMessage(`{"x":1}`)},
+		map[int]string{2: "b", 1: "a"},
+		[2][]string{{"a"}, nil},
+	}
+	for i, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("value %d: failed to marshal: %v", i, err)
+		}
+		enc := &limitedEncoder{limit: len(want)}
+		if err := enc.encode(reflect.ValueOf(v)); err != nil {
+			t.Errorf("value %d: failed to encode: %v", i, err)
+		} else if !bytes.Equal(enc.buf.Bytes(), want) {
+			t.Errorf("value %d: encoding mismatch: have %s, want %s", i, enc.buf.Bytes(), want)
+		}
+		enc = &limitedEncoder{limit: len(want) - 1}
+		if err := enc.encode(reflect.ValueOf(v)); err != errResponseLimit {
+			t.Errorf("value %d: limit error mismatch: have %v", i, err)
+		}
+	}
+	// Lists are rejected once their encoding exceeds the limit
+	enc := &limitedEncoder{limit: 100}
+	if err := enc.encode(reflect.ValueOf(make([]int, 1000000))); err != errResponseLimit {
+		t.Errorf("limit error mismatch: have %v", err)
+	}
+	if enc.buf.Len() > 102 {
+		t.Errorf("encoding continued past the limit: %d bytes", enc.buf.Len())
+	}
+}
@@ -38,6 +38,8 @@ var (
 type serviceRegistry struct {
 	mu       sync.Mutex
 	services map[string]service
+	filter   CallFilter
+	limits   ServerLimits
 }
 
 // service represents a registered object.
@@ -102,6 +104,27 @@ func (r *serviceRegistry) callback(method string) *callback {
 	return r.services[module].callbacks[mthd]
 }
 
+// serverLimits returns the resource limits of the calls.
+func (r *serviceRegistry) serverLimits() ServerLimits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.limits
+}
+
+// filterCall checks whether the given method may be called on the connection
+// of the context.
+func (r *serviceRegistry) filterCall(ctx context.Context, method string) error {
+	r.mu.Lock()
+	filter := r.filter
+	r.mu.Unlock()
+
+	if filter == nil {
+		return nil
+	}
+	return filter(ctx, method)
+}
+
 // subscription returns a subscription callback in the given service.
 func (r *serviceRegistry) subscription(service, name string) *callback {
 	r.mu.Lock()
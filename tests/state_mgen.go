@@ -28,6 +28,7 @@ var writeStateTestsReferencePairs = map[string]string{
 	"Byzantium":         "ETC_Atlantis",
 	"ConstantinopleFix": "ETC_Agharta",
 	"Istanbul":          "ETC_Phoenix",
+	"Berlin":            "ETC_Magneto",
 }
 
 // RunSetPost runs the state subtest for a given config, and writes the resulting
@@ -56,6 +56,8 @@ func TestState(t *testing.T) {
 		st.skipFork("Constantinople") // Only support
 		st.skipFork("Istanbul")
 		st.skipFork("ETC_Phoenix")
+		st.skipFork("Berlin")
+		st.skipFork("ETC_Magneto")
 
 		// These tests are noted as SLOW above, and they fail against the EVMOne.so
 		// So (get it?), skip 'em.
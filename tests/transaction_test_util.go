@@ -24,7 +24,6 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
-	"github.com/ethereum/go-ethereum/rlp"
 )
 
 // TransactionTest checks RLP decoding and sender derivation of transactions.
@@ -48,7 +47,7 @@ func (tt *TransactionTest) Run(config ctypes.ChainConfigurator) error {
 
 	validateTx := func(rlpData hexutil.Bytes, signer types.Signer, isEIP2F bool, isEIP2028F bool) (*common.Address, *common.Hash, error) {
 		tx := new(types.Transaction)
-		if err := rlp.DecodeBytes(rlpData, tx); err != nil {
+		if err := tx.UnmarshalBinary(rlpData); err != nil {
 			return nil, nil, err
 		}
 		sender, err := types.Sender(signer, tx)
@@ -56,7 +55,7 @@ func (tt *TransactionTest) Run(config ctypes.ChainConfigurator) error {
 			return nil, nil, err
 		}
 		// Intrinsic gas
-		requiredGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, isEIP2F, isEIP2028F)
+		requiredGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, isEIP2F, isEIP2028F)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -393,7 +393,7 @@ func hasRightElement(node node, key []byte) bool {
 // (unless firstProof is an existent proof).
 //
 // Expect the normal case, this function can also be used to verify the following
-// range proofs(note this function doesn't accept zero element proof):
+// range proofs:
 //
 // - All elements proof. In this case the left and right proof can be nil, but the
 //   range should be all the leaves in the trie.
@@ -401,21 +401,37 @@ func hasRightElement(node node, key []byte) bool {
 // - One element proof. In this case no matter the left edge proof is a non-existent
 //   proof or not, we can always verify the correctness of the proof.
 //
+// - Zero element proof. In this case a single non-existent proof is given for the
+//   first key, proving that no more leaves exist at or after it.
+//
 // Except returning the error to indicate the proof is valid or not, the function will
 // also return a flag to indicate whether there exists more accounts/slots in the trie.
 func VerifyRangeProof(rootHash common.Hash, firstKey []byte, keys [][]byte, values [][]byte, firstProof ethdb.KeyValueReader, lastProof ethdb.KeyValueReader) (error, bool) {
 	if len(keys) != len(values) {
 		return fmt.Errorf("inconsistent proof data, keys: %d, values: %d", len(keys), len(values)), false
 	}
-	if len(keys) == 0 {
-		return errors.New("empty proof"), false
-	}
 	// Ensure the received batch is monotonic increasing.
 	for i := 0; i < len(keys)-1; i++ {
 		if bytes.Compare(keys[i], keys[i+1]) >= 0 {
 			return errors.New("range is not monotonically increasing"), false
 		}
 	}
+	// Special case, there is a provided edge proof but zero key/value
+	// pairs, ensure there are no more entries in the trie after the
+	// first key.
+	if len(keys) == 0 {
+		if firstProof == nil {
+			return errors.New("empty proof"), false
+		}
+		root, val, err := proofToPath(rootHash, nil, firstKey, firstProof, true)
+		if err != nil {
+			return err, false
+		}
+		if val != nil || hasRightElement(root, firstKey) {
+			return errors.New("more entries available"), false
+		}
+		return nil, false
+	}
 	// Special case, there is no edge proof at all. The given range is expected
 	// to be the whole leaf-set in the trie.
 	if firstProof == nil && lastProof == nil {
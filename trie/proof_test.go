@@ -230,6 +230,43 @@ func TestRangeProofWithNonExistentProof(t *testing.T) {
 	}
 }
 
+// TestEmptyRangeProof tests the range proof with "no" element. The first edge
+// proof is a non-existent proof, which is only valid if no more elements exist
+// after it.
+func TestEmptyRangeProof(t *testing.T) {
+	trie, vals := randomTrie(4096)
+	var entries entrySlice
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(entries)
+
+	var cases = []struct {
+		pos int
+		err bool
+	}{
+		{len(entries) - 1, false},
+		{500, true},
+	}
+	for _, c := range cases {
+		proof := memorydb.New()
+		first := increseKey(common.CopyBytes(entries[c.pos].k))
+		if err := trie.Prove(first, 0, proof); err != nil {
+			t.Fatalf("Failed to prove the first node %v", err)
+		}
+		err, cont := VerifyRangeProof(trie.Hash(), first, nil, nil, proof, proof)
+		if c.err && err == nil {
+			t.Fatalf("Expected error, got nil")
+		}
+		if !c.err && err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cont {
+			t.Fatalf("Expected no more elements")
+		}
+	}
+}
+
 // TestRangeProofWithInvalidNonExistentProof tests such scenarios:
 // - The last edge proof is an non-existent proof
 // - There exists a gap between the first element and the left edge proof
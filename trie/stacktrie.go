@@ -0,0 +1,238 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrStackTrieOrder is returned by StackTrie.TryUpdate if the keys are not
+// inserted in strictly increasing order.
+var ErrStackTrieOrder = errors.New("stack trie keys not in increasing order")
+
+const (
+	stEmpty  = iota // Node without any content, only the root may be empty
+	stBranch        // Full node, only the right-most child may still be unhashed
+	stExt           // Short node with a child branch
+	stLeaf          // Short node with a value
+	stHashed        // Node already hashed (and committed), only its reference is kept
+)
+
+// StackTrie is a trie implementation that expects keys to be inserted in order.
+// Once it determines that a subtree will no longer be inserted into, it hashes
+// it, writes its nodes into the optional database and frees up the memory they
+// used. It can thus generate the nodes of arbitrarily large tries while only
+// keeping the right-most path in memory.
+type StackTrie struct {
+	nodeType uint8
+	key      []byte         // Nibbles covered by the node (short nodes only)
+	val      []byte         // Value of the node (leaves only)
+	children [16]*StackTrie // Children of the node (ext nodes only use the first)
+	ref      node           // Hash or embedded collapsed node, once hashed
+
+	db   ethdb.KeyValueWriter // Database to write the hashed nodes into, may be nil
+	last []byte               // Last inserted key, to enforce the ordering (root only)
+}
+
+// NewStackTrie creates a new, empty stack trie, writing its nodes into the given
+// database as they are hashed. If db is nil, the nodes are only hashed.
+func NewStackTrie(db ethdb.KeyValueWriter) *StackTrie {
+	return &StackTrie{db: db}
+}
+
+// Update inserts the given key and value into the trie, logging any error.
+func (st *StackTrie) Update(key, value []byte) {
+	if err := st.TryUpdate(key, value); err != nil {
+		log.Error(fmt.Sprintf("Unhandled trie error: %v", err))
+	}
+}
+
+// TryUpdate inserts the given key and value into the trie. Keys must be inserted
+// in strictly increasing order and all have the same length, and deletions (by
+// means of empty values) are not supported.
+func (st *StackTrie) TryUpdate(key, value []byte) error {
+	if len(value) == 0 {
+		return errors.New("stack trie deletions not supported")
+	}
+	if st.nodeType == stHashed {
+		return errors.New("stack trie already hashed")
+	}
+	if st.last != nil && (len(key) != len(st.last) || string(key) <= string(st.last)) {
+		return ErrStackTrieOrder
+	}
+	st.last = common.CopyBytes(key)
+
+	hex := keybytesToHex(key)
+	st.insert(hex[:len(hex)-1], common.CopyBytes(value))
+	return nil
+}
+
+// Reset empties the trie, so it can be reused for another one.
+func (st *StackTrie) Reset() {
+	db := st.db
+	*st = StackTrie{db: db}
+}
+
+// insert adds the value at the given key, relative to the node, into the subtrie.
+func (st *StackTrie) insert(key, value []byte) {
+	switch st.nodeType {
+	case stEmpty:
+		st.nodeType, st.key, st.val = stLeaf, key, value
+
+	case stBranch:
+		idx := key[0]
+
+		// Keys are inserted in order, the previous child can't be touched anymore
+		for i := int(idx) - 1; i >= 0; i-- {
+			if child := st.children[i]; child != nil {
+				child.hash(false)
+				break
+			}
+		}
+		if st.children[idx] == nil {
+			st.children[idx] = &StackTrie{db: st.db}
+		}
+		st.children[idx].insert(key[1:], value)
+
+	case stExt:
+		diff := prefixLen(st.key, key)
+		if diff == len(st.key) {
+			st.children[0].insert(key[diff:], value)
+			return
+		}
+		// The key diverges within the extension, so it has to be split into an
+		// optional extension for the common prefix, a branch where the keys
+		// diverge, and the original and new subtries below it.
+		orig := st.children[0]
+		if diff < len(st.key)-1 {
+			orig = &StackTrie{nodeType: stExt, key: st.key[diff+1:], db: st.db}
+			orig.children[0] = st.children[0]
+		}
+		orig.hash(false)
+
+		branch := st.split(diff)
+		branch.children[st.key[diff]] = orig
+		branch.children[key[diff]] = &StackTrie{nodeType: stLeaf, key: key[diff+1:], val: value, db: st.db}
+		st.key = st.key[:diff]
+
+	case stLeaf:
+		diff := prefixLen(st.key, key)
+		if diff == len(st.key) {
+			panic("stack trie key already present")
+		}
+		// The keys diverge within the leaf, so it has to be split into an optional
+		// extension for the common prefix, a branch where the keys diverge, and
+		// one leaf for each key below it.
+		orig := &StackTrie{nodeType: stLeaf, key: st.key[diff+1:], val: st.val, db: st.db}
+		orig.hash(false)
+
+		branch := st.split(diff)
+		branch.children[st.key[diff]] = orig
+		branch.children[key[diff]] = &StackTrie{nodeType: stLeaf, key: key[diff+1:], val: value, db: st.db}
+		st.key, st.val = st.key[:diff], nil
+
+	default:
+		panic(fmt.Sprintf("stack trie insert into node type %d", st.nodeType))
+	}
+}
+
+// split turns the node into the branch where keys diverge after the given
+// number of nibbles, or into an extension to that branch if there's a common
+// prefix, returning the branch.
+func (st *StackTrie) split(diff int) *StackTrie {
+	st.children = [16]*StackTrie{}
+	if diff == 0 {
+		st.nodeType = stBranch
+		return st
+	}
+	st.nodeType = stExt
+	st.children[0] = &StackTrie{nodeType: stBranch, db: st.db}
+	return st.children[0]
+}
+
+// hash collapses the subtrie into the reference to be embedded in its parent,
+// writing all nodes large enough to be referenced by hash into the database. If
+// force is set, the node itself is always hashed (as needed for the root).
+func (st *StackTrie) hash(force bool) {
+	if st.nodeType == stHashed {
+		return
+	}
+	var collapsed node
+	switch st.nodeType {
+	case stEmpty:
+		st.ref = hashNode(emptyRoot.Bytes())
+		st.nodeType = stHashed
+		return
+
+	case stLeaf:
+		key := make([]byte, len(st.key)+1)
+		copy(key, st.key)
+		key[len(st.key)] = 16 // Terminator flag of leaves
+		collapsed = &shortNode{Key: hexToCompact(key), Val: valueNode(st.val)}
+
+	case stExt:
+		st.children[0].hash(false)
+		collapsed = &shortNode{Key: hexToCompact(st.key), Val: st.children[0].ref}
+
+	case stBranch:
+		full := new(fullNode)
+		for i, child := range st.children {
+			if child != nil {
+				child.hash(false)
+				full.Children[i] = child.ref
+			} else {
+				full.Children[i] = nilValueNode
+			}
+		}
+		full.Children[16] = nilValueNode
+		collapsed = full
+	}
+	h := newHasher(false)
+	defer returnHasherToPool(h)
+
+	if sn, ok := collapsed.(*shortNode); ok {
+		st.ref = h.shortnodeToHash(sn, force)
+	} else {
+		st.ref = h.fullnodeToHash(collapsed.(*fullNode), force)
+	}
+	if hash, ok := st.ref.(hashNode); ok && st.db != nil {
+		// The hasher left the encoding of the node in its buffer
+		st.db.Put(hash, common.CopyBytes(h.tmp))
+	}
+	st.nodeType, st.key, st.val, st.children = stHashed, nil, nil, [16]*StackTrie{}
+}
+
+// Hash returns the root hash of the trie, hashing and writing out all the nodes
+// not yet hashed. The trie can't be inserted into afterwards, until reset.
+func (st *StackTrie) Hash() common.Hash {
+	st.hash(true)
+	return common.BytesToHash(st.ref.(hashNode))
+}
+
+// Commit hashes the trie like Hash, failing if there's no database to write the
+// nodes into.
+func (st *StackTrie) Commit() (common.Hash, error) {
+	if st.db == nil {
+		return common.Hash{}, errors.New("stack trie without database")
+	}
+	return st.Hash(), nil
+}
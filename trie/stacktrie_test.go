@@ -0,0 +1,131 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// Tests that the stack trie produces the same root hash and the same set of
+// nodes as the regular trie, both for tries referencing all their children by
+// hash and for tries with small, embedded nodes.
+func TestStackTrieConsistency(t *testing.T) {
+	tests := []struct {
+		keys    int
+		keySize int
+		valSize int
+	}{
+		{0, 32, 32},
+		{1, 32, 32},
+		{2, 32, 1},
+		{16, 1, 1},
+		{100, 2, 1},
+		{1000, 32, 32},
+		{1000, 32, 1},
+		{5000, 3, 2},
+	}
+	for i, tt := range tests {
+		var (
+			rnd  = rand.New(rand.NewSource(int64(i)))
+			keys = make(map[string][]byte)
+		)
+		for len(keys) < tt.keys {
+			key, val := make([]byte, tt.keySize), make([]byte, 1+rnd.Intn(tt.valSize))
+			rnd.Read(key)
+			rnd.Read(val)
+			keys[string(key)] = val
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		// Create the reference trie and commit it into a clean database
+		var (
+			trieDisk  = memorydb.New()
+			stackDisk = memorydb.New()
+		)
+		trie, _ := New(common.Hash{}, NewDatabase(trieDisk))
+		stack := NewStackTrie(stackDisk)
+		for _, key := range sorted {
+			trie.Update([]byte(key), keys[key])
+			if err := stack.TryUpdate([]byte(key), keys[key]); err != nil {
+				t.Fatalf("test %d: failed to insert into stack trie: %v", i, err)
+			}
+		}
+		root, err := trie.Commit(nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to commit trie: %v", i, err)
+		}
+		if err := trie.db.Commit(root, false, nil); err != nil {
+			t.Fatalf("test %d: failed to commit trie database: %v", i, err)
+		}
+		if have, err := stack.Commit(); err != nil || have != root {
+			t.Fatalf("test %d: root mismatch: have %x, want %x (err %v)", i, have, root, err)
+		}
+		// Ensure both tries wrote out the very same nodes
+		if have, want := stackDisk.Len(), trieDisk.Len(); have != want {
+			t.Errorf("test %d: node count mismatch: have %d, want %d", i, have, want)
+		}
+		it := trieDisk.NewIterator(nil, nil)
+		for it.Next() {
+			if blob, err := stackDisk.Get(it.Key()); err != nil || !bytes.Equal(blob, it.Value()) {
+				t.Errorf("test %d: node %x mismatch: have %x, want %x", i, it.Key(), blob, it.Value())
+			}
+		}
+		it.Release()
+	}
+}
+
+// Tests that the stack trie rejects keys not inserted in increasing order, along
+// with deletions and insertions after hashing.
+func TestStackTrieInvalidUpdates(t *testing.T) {
+	stack := NewStackTrie(nil)
+	if err := stack.TryUpdate([]byte{0x02}, []byte{0x01}); err != nil {
+		t.Fatalf("failed to insert first key: %v", err)
+	}
+	if err := stack.TryUpdate([]byte{0x01}, []byte{0x01}); err != ErrStackTrieOrder {
+		t.Errorf("lower key error mismatch: have %v, want %v", err, ErrStackTrieOrder)
+	}
+	if err := stack.TryUpdate([]byte{0x02}, []byte{0x01}); err != ErrStackTrieOrder {
+		t.Errorf("duplicate key error mismatch: have %v, want %v", err, ErrStackTrieOrder)
+	}
+	if err := stack.TryUpdate([]byte{0x03, 0x00}, []byte{0x01}); err != ErrStackTrieOrder {
+		t.Errorf("longer key error mismatch: have %v, want %v", err, ErrStackTrieOrder)
+	}
+	if err := stack.TryUpdate([]byte{0x03}, nil); err == nil {
+		t.Errorf("deletion accepted")
+	}
+	if _, err := stack.Commit(); err == nil {
+		t.Errorf("commit without database accepted")
+	}
+	stack.Hash()
+	if err := stack.TryUpdate([]byte{0x04}, []byte{0x01}); err == nil {
+		t.Errorf("insertion after hashing accepted")
+	}
+	stack.Reset()
+	if err := stack.TryUpdate([]byte{0x01}, []byte{0x01}); err != nil {
+		t.Errorf("failed to insert after reset: %v", err)
+	}
+}